@@ -19,6 +19,15 @@ const (
 
 type jwtAuthUserContextKey string
 type jwtUserIdMapKey string
+type apiKeyContextKey string
+type AuthThrottlePolicy struct {
+	FreeAttempts    int           // failures allowed before any backoff kicks in
+	MaxAttempts     int           // failures after which the key is locked out
+	BaseDelay       time.Duration // first backoff delay, doubled on every further failure
+	MaxDelay        time.Duration
+	LockoutDuration time.Duration
+	Window          time.Duration // failures older than this no longer count
+}
 type PaystackTransactionStatus struct {
 	Abandoned string `json:"abandoned"`
 	Success string `json:"success"`
@@ -29,16 +38,337 @@ var (
 		Success: "success",
 	}
 )
+type SellerOnboardingStatus struct {
+	Draft     string `json:"draft"`
+	Submitted string `json:"submitted"`
+	Approved  string `json:"approved"`
+	Rejected  string `json:"rejected"`
+}
+var (
+	SellerOnboardingStatuses = SellerOnboardingStatus{
+		Draft:     "draft",
+		Submitted: "submitted",
+		Approved:  "approved",
+		Rejected:  "rejected",
+	}
+)
+type OrderStatus struct {
+	Placed    string `json:"placed"`
+	Cancelled string `json:"cancelled"` // every sub-order of the order has been cancelled
+}
+// sub-orders move forward one status at a time, as the seller fulfils their part of the order.
+// They can be cancelled until something has been shipped
+type SubOrderStatus struct {
+	Pending    string `json:"pending"`
+	Processing string `json:"processing"`
+	Shipped    string `json:"shipped"`
+	Delivered  string `json:"delivered"`
+	Cancelled  string `json:"cancelled"`
+}
+type CancelledBy struct {
+	Customer string `json:"customer"`
+	Seller   string `json:"seller"`
+	System   string `json:"system"` // unpaid for too long
+}
+type RefundStatus struct {
+	Pending    string `json:"pending"`
+	Processing string `json:"processing"` // sent to the payment gateway
+	Processed  string `json:"processed"`
+	Failed     string `json:"failed"`
+}
+var (
+	OrderStatuses = OrderStatus{
+		Placed:    "placed",
+		Cancelled: "cancelled",
+	}
+	SubOrderStatuses = SubOrderStatus{
+		Pending:    "pending",
+		Processing: "processing",
+		Shipped:    "shipped",
+		Delivered:  "delivered",
+		Cancelled:  "cancelled",
+	}
+	CancelledByValues = CancelledBy{
+		Customer: "customer",
+		Seller:   "seller",
+		System:   "system",
+	}
+	RefundStatuses = RefundStatus{
+		Pending:    "pending",
+		Processing: "processing",
+		Processed:  "processed",
+		Failed:     "failed",
+	}
+	// the status a sub-order can be moved to from its current status
+	SubOrderNextStatus = map[string]string{
+		SubOrderStatuses.Pending:    SubOrderStatuses.Processing,
+		SubOrderStatuses.Processing: SubOrderStatuses.Shipped,
+		SubOrderStatuses.Shipped:    SubOrderStatuses.Delivered,
+	}
+)
+type ShipmentStatus struct {
+	Shipped   string `json:"shipped"`
+	Delivered string `json:"delivered"`
+}
+var (
+	ShipmentStatuses = ShipmentStatus{
+		Shipped:   "shipped",
+		Delivered: "delivered",
+	}
+)
+// a return is approved or rejected by the seller, then inspected once it is received and resolved.
+// It is also rejected when the item fails inspection
+type ReturnStatus struct {
+	Requested string `json:"requested"`
+	Approved  string `json:"approved"`
+	Rejected  string `json:"rejected"`
+	Received  string `json:"received"`
+	Resolved  string `json:"resolved"`
+}
+type ReturnResolution struct {
+	Refund      string `json:"refund"`
+	Replacement string `json:"replacement"` // sent as a new order that has already been paid for
+	StoreCredit string `json:"storeCredit"`
+}
+var (
+	ReturnStatuses = ReturnStatus{
+		Requested: "requested",
+		Approved:  "approved",
+		Rejected:  "rejected",
+		Received:  "received",
+		Resolved:  "resolved",
+	}
+	ReturnResolutions = ReturnResolution{
+		Refund:      "refund",
+		Replacement: "replacement",
+		StoreCredit: "store_credit",
+	}
+)
+// drafts are only seen by their seller, active products are for sale and archived ones are taken off sale but still show in the orders they are part of
+type ProductStatus struct {
+	Draft    string `json:"draft"`
+	Active   string `json:"active"`
+	Archived string `json:"archived"`
+}
+var (
+	ProductStatuses = ProductStatus{
+		Draft:    "draft",
+		Active:   "active",
+		Archived: "archived",
+	}
+)
+// a sale lowers the price of a product between two times, the price it had goes back when the sale ends or is cancelled
+type ProductSaleStatus struct {
+	Scheduled string `json:"scheduled"`
+	Active    string `json:"active"`
+	Ended     string `json:"ended"`
+	Cancelled string `json:"cancelled"`
+}
+type PriceChangeReason struct {
+	Manual       string `json:"manual"`       // the seller edited the price
+	SaleStart    string `json:"saleStart"`
+	SaleEnd      string `json:"saleEnd"`      // the sale ran its course or was cancelled while running
+	RegularPrice string `json:"regularPrice"` // the seller edited the price during a sale, so only the price it goes back to changed
+}
+var (
+	ProductSaleStatuses = ProductSaleStatus{
+		Scheduled: "scheduled",
+		Active:    "active",
+		Ended:     "ended",
+		Cancelled: "cancelled",
+	}
+	PriceChangeReasons = PriceChangeReason{
+		Manual:       "manual",
+		SaleStart:    "sale_start",
+		SaleEnd:      "sale_end",
+		RegularPrice: "regular_price",
+	}
+)
+// every change to the quantity of a product is recorded as a stock movement, with why it happened and who made it happen
+type StockMovementReason struct {
+	Initial      string `json:"initial"`      // the stock the product was added with
+	Import       string `json:"import"`       // the stock the product was imported with
+	Adjustment   string `json:"adjustment"`   // the seller edited the quantity, or their account was deleted
+	Sale         string `json:"sale"`
+	Cancellation string `json:"cancellation"` // the order was cancelled
+	Return       string `json:"return"`       // a returned item was put back in stock
+	Replacement  string `json:"replacement"`  // a returned item was replaced
+}
+type StockMovementActor struct {
+	Seller   string `json:"seller"`
+	Customer string `json:"customer"`
+	System   string `json:"system"`
+}
+var (
+	StockMovementReasons = StockMovementReason{
+		Initial:      "initial",
+		Import:       "import",
+		Adjustment:   "adjustment",
+		Sale:         "sale",
+		Cancellation: "cancellation",
+		Return:       "return",
+		Replacement:  "replacement",
+	}
+	StockMovementActors = StockMovementActor{
+		Seller:   "seller",
+		Customer: "customer",
+		System:   "system",
+	}
+)
+// reviews are shown once written, a flagged review stays up until an admin has looked at it and a hidden one is taken down.
+// Hidden reviews don't count towards the rating of the product
+type ReviewStatus struct {
+	Published string `json:"published"`
+	Flagged   string `json:"flagged"`
+	Hidden    string `json:"hidden"`
+}
+type ReviewSort struct {
+	Newest  string `json:"newest"`
+	Helpful string `json:"helpful"`
+}
+var (
+	ReviewStatuses = ReviewStatus{
+		Published: "published",
+		Flagged:   "flagged",
+		Hidden:    "hidden",
+	}
+	ReviewSorts = ReviewSort{
+		Newest:  "newest",
+		Helpful: "helpful",
+	}
+	// questions and answers are moderated the same way as reviews
+	QuestionStatuses = ReviewStatuses
+	AnswerAuthorRoles = AnswerAuthorRole{
+		Seller:        "seller",
+		VerifiedBuyer: "verified_buyer",
+	}
+)
+type AnswerAuthorRole struct {
+	Seller        string `json:"seller"`
+	VerifiedBuyer string `json:"verifiedBuyer"`
+}
+// ledger accounts, amounts posted to them are in kobo, debits are positive and credits negative
+type LedgerAccount struct {
+	GatewayClearing     string `json:"gatewayClearing"`     // money held by the payment gateway
+	SellerPending       string `json:"sellerPending"`       // owed to a seller, still within the hold period
+	SellerAvailable     string `json:"sellerAvailable"`     // owed to a seller, can be paid out
+	PayoutsInTransit    string `json:"payoutsInTransit"`    // requested by a seller, not yet in their bank
+	PlatformCommission  string `json:"platformCommission"`  // earned by the marketplace
+	CustomerStoreCredit string `json:"customerStoreCredit"` // owed to customers for returned items, funded by the seller
+}
+type LedgerTransactionType struct {
+	Sale         string `json:"sale"`
+	Release      string `json:"release"`
+	Payout       string `json:"payout"`
+	PayoutPaid   string `json:"payoutPaid"`
+	PayoutFailed string `json:"payoutFailed"`
+	Refund       string `json:"refund"` // reverses the sale of a cancelled or returned order item
+}
+type PayoutStatus struct {
+	Requested  string `json:"requested"`
+	Processing string `json:"processing"`
+	Paid       string `json:"paid"`
+	Failed     string `json:"failed"`
+}
+type PayoutMethod struct {
+	Gateway string `json:"gateway"`
+	Manual  string `json:"manual"` // exported as csv and paid by bank transfer
+}
+var (
+	LedgerAccounts = LedgerAccount{
+		GatewayClearing:     "gateway_clearing",
+		SellerPending:       "seller_pending",
+		SellerAvailable:     "seller_available",
+		PayoutsInTransit:    "payouts_in_transit",
+		PlatformCommission:  "platform_commission",
+		CustomerStoreCredit: "customer_store_credit",
+	}
+	LedgerTransactionTypes = LedgerTransactionType{
+		Sale:         "sale",
+		Release:      "release",
+		Payout:       "payout",
+		PayoutPaid:   "payout_paid",
+		PayoutFailed: "payout_failed",
+		Refund:       "refund",
+	}
+	PayoutStatuses = PayoutStatus{
+		Requested:  "requested",
+		Processing: "processing",
+		Paid:       "paid",
+		Failed:     "failed",
+	}
+	PayoutMethods = PayoutMethod{
+		Gateway: "gateway",
+		Manual:  "manual",
+	}
+)
+type OIDCProviderConfig struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
 // default values
 const (
 	DefaultPageSize 		= 20
 	DefaultContextTimeOut 	= time.Second * 5
 	FrontendUrl = "http://localhost:3000"
+	ApiUrl = "http://localhost:8000"
+	UploadDir = "uploads"
+	PrivateUploadDir = "private_uploads" // never served directly
 	JWTSecret	= "secret"
 	JWTExpirationTime = time.Hour * 24
 	PaystackSecretKey = "sk_test_dc0078426d6a4b0cf15b370c15a61de841a23f78"
 	PaystackPublicKey = "pk_test_8ad0429e25af1f59ecf24104442f56ee4bbb39fe"
 	AppVersion = "1.0.0"
+	VerificationTokenTTL = time.Hour * 24
+	PasswordResetTokenTTL = time.Hour * 4
+	MagicLinkTokenTTL = time.Minute * 15
+	MaxOutstandingTokensPerEmail = 3
+	ExpiredTokenCleanupInterval = time.Hour
+	IdempotencyKeyHeader = "Idempotency-Key"
+	IdempotencyKeyTTL = time.Hour * 24 // how long a response is replayed for
+	IdempotencyKeyLockTimeout = time.Minute * 15 // a request still in progress after this is assumed to have died and its key can be used again, so it is kept far above the slowest request (a checkout is a handful of calls that each give up after DefaultContextTimeOut)
+	MaxIdempotencyKeyLength = 255
+	IdempotencyKeyCleanupInterval = time.Hour
+	OIDCLoginStateTTL = time.Minute * 10
+	OIDCHTTPTimeout = time.Second * 10
+	OIDCJwksRefetchInterval = time.Minute // the least time between fetches of a provider's keys for a kid it doesn't know
+	APIKeyPrefix = "eck_"
+	MaxActiveAPIKeysPerUser = 10
+	APIKeyLastUsedResolution = time.Minute
+	EmailChangeTokenTTL = time.Hour * 24
+	MaxAvatarSize = 2 << 20 // 2MB
+	AccountDeletionGracePeriod = time.Hour * 24 * 30
+	AccountDeletionCheckInterval = time.Hour
+	AnonymisedUserName = "Deleted User"
+	AnonymisedStreetAddress = "[removed]"
+	AdminRole = "admin" // only given out directly in the database
+	MaxSellerDocumentSize = 5 << 20 // 5MB
+	MaxStorefrontImageSize = 5 << 20 // 5MB
+	DefaultCommissionRateBps = 1000 // 10%, used when there is no global rate, categories and sellers can be given their own rate
+	SellerFundsHoldPeriod = time.Hour * 24 * 7 // time for returns and disputes before a sale can be paid out
+	SellerFundsReleaseInterval = time.Hour
+	MinPayoutAmount = 1000 * 100 // in kobo
+	UnpaidOrderTimeout = time.Hour * 24 // unpaid orders are cancelled after this, giving their stock back
+	UnpaidOrderCheckInterval = time.Hour
+	RefundProcessInterval = time.Minute * 5
+	ReturnWindow = time.Hour * 24 * 30 // after delivery
+	MaxReturnPhotos = 5
+	MaxReturnPhotoSize = 5 << 20 // 5MB
+	ReplacementPaymentMethod = "replacement" // replacement orders are paid for by the return they replace
+	VATRateBps = 750 // 7.5%, prices include vat
+	OrderNumberPrefix = "ORD"
+	InvoiceNumberPrefix = "INV"
+	CreditNoteNumberPrefix = "CN"
+	MaxWishlistsPerCustomer = 20
+	WishlistAlertCheckInterval = time.Minute * 15
+	ProductScheduleCheckInterval = time.Minute // how late a scheduled publish or unpublish can be
+	ProductSaleCheckInterval = time.Minute // how late a sale can start or end
+	ProductPageQuestionCount = 5 // the latest questions shown with a product, the rest are listed on their own
+	LowStockDigestPeriod = time.Hour * 24 // sellers get at most one low stock digest in this time
+	LowStockDigestCheckInterval = time.Hour
 	
 	
 	
@@ -53,21 +383,162 @@ const (
 	MsgCategoriesRetrieved = "Categories Retrieved Successfully"
 	MsgCategoryRetrieved = "Category Retrieved Successfully"
 	MsgInternalServerError = "Internal Server Error"
+	MsgTooManyRequests = "Too Many Requests"
+	MsgAuthorizationError = "Authorization Error"
 )
 // errors
 var (
 	ErrPageSizeNotValid = errors.New("page size must be an integer")
 	ErrCategoryNotFound = errors.New("category not found")
 	ErrInvalidUserRole = errors.New("user role should be either customer, or seller")
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	ErrInvalidOrExpiredToken = errors.New("invalid or expired token")
+	ErrTooManyAuthAttempts = errors.New("too many attempts, please try again later")
+	ErrCustomerProfileRequired = errors.New("this action requires a customer account")
+	ErrSellerProfileRequired = errors.New("this action requires a seller account")
+	ErrUnknownOIDCProvider = errors.New("unknown identity provider")
+	ErrInvalidOIDCState = errors.New("invalid or expired login state")
+	ErrOIDCEmailNotVerified = errors.New("the identity provider has not verified this email, please sign in with your password and link the provider from your account")
+	ErrTooManyOutstandingTokens = errors.New("too many unused tokens have been issued for this email, please use one of them or wait for them to expire")
+	ErrInvalidAPIKey = errors.New("invalid, expired or revoked api key")
+	ErrInvalidAPIKeyScope = errors.New("api key scopes should be one or more of products:read, products:write, orders:read")
+	ErrAPIKeyScopeMissing = errors.New("this api key does not have the scope needed for this action")
+	ErrTooManyAPIKeys = errors.New("the maximum number of active api keys has been reached, please revoke one first")
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	ErrEmailAlreadyInUse = errors.New("this email is already in use")
+	ErrSameEmail = errors.New("the new email is the same as the current one")
+	ErrInvalidAvatar = errors.New("avatar should be a jpeg, png, gif or webp image of at most 2MB")
+	ErrAccountDeletionAlreadyScheduled = errors.New("the deletion of this account has already been scheduled")
+	ErrAccountDeletionNotScheduled = errors.New("no deletion has been scheduled for this account")
+	ErrUserRoleAlreadyAdded = errors.New("the user already has this role")
+	ErrAdminRoleRequired = errors.New("this action requires an admin account")
+	ErrSellerNotApproved = errors.New("your seller account has to be approved before you can list products, please complete your seller onboarding")
+	ErrSellerOnboardingNotEditable = errors.New("seller onboarding can only be changed while it is a draft or after it has been rejected")
+	ErrSellerOnboardingIncomplete = errors.New("business details, bank account and a government issued id are required before submitting")
+	ErrSellerOnboardingNotSubmitted = errors.New("only submitted seller onboardings can be reviewed")
+	ErrSellerOnboardingNotFound = errors.New("seller onboarding not found")
+	ErrSellerDocumentNotFound = errors.New("seller document not found")
+	ErrInvalidSellerDocument = errors.New("documents should be a pdf, jpeg or png file of at most 5MB")
+	ErrReviewCommentRequired = errors.New("a comment is required when rejecting a seller")
+	ErrStorefrontNotFound = errors.New("storefront not found, please set up your storefront first")
+	ErrShopNotFound = errors.New("shop not found")
+	ErrShopSlugTaken = errors.New("this shop url is already taken")
+	ErrInvalidShopSlug = errors.New("shop url should be 3 to 60 letters, numbers or dashes")
+	ErrInvalidStorefrontImage = errors.New("logos and banners should be a jpeg, png, gif or webp image of at most 5MB")
+	ErrSubOrderNotFound = errors.New("order not found")
+	ErrInvalidSubOrderStatus = errors.New("orders move from pending to processing, shipped and then delivered, one step at a time")
+	ErrShipmentNotFound = errors.New("shipment not found")
+	ErrSubOrderNotShippable = errors.New("this order can no longer be shipped")
+	ErrShipmentItemNotInOrder = errors.New("shipment items must be items of the order")
+	ErrShipmentQuantityExceeded = errors.New("shipment quantity is more than what is left to ship of the item")
+	ErrShipmentAlreadyDelivered = errors.New("shipment has already been delivered")
+	ErrCommissionRateNotFound = errors.New("commission rate not found")
+	ErrCommissionScopeNotFound = errors.New("the category or seller of the commission rate doesn't exist")
+	ErrUnbalancedLedgerTransaction = errors.New("ledger transaction entries don't balance")
+	ErrInsufficientBalance = errors.New("the amount is more than your available balance")
+	ErrPayoutBelowMinimum = errors.New("the amount is below the minimum payout of 1,000 naira")
+	ErrPayoutNotFound = errors.New("payout not found")
+	ErrPayoutNotProcessing = errors.New("only payouts that are being processed can be completed")
+	ErrPayoutBatchNotFound = errors.New("payout batch not found")
+	ErrNoPayoutsToBatch = errors.New("there are no payout requests waiting to be batched")
+	ErrInvalidStatementPeriod = errors.New("from and to should be dates in the format 2006-01-02, with from before to")
+	ErrOrderNotFound = errors.New("order not found")
+	ErrOrderNotCancellable = errors.New("orders can only be cancelled before anything has been shipped")
+	ErrOrderAlreadyCancelled = errors.New("order has already been cancelled")
+	ErrInsufficientStock = errors.New("some items in your cart are no longer in stock")
+	ErrCartItemNotFound = errors.New("item is not in the cart")
+	ErrSavedItemNotFound = errors.New("item is not saved for later")
+	ErrRefundNotFound = errors.New("refund not found")
+	ErrRefundNotFailed = errors.New("only failed refunds can be retried")
+	ErrOrderItemNotFound = errors.New("order item not found")
+	ErrNotReturnable = errors.New("items can only be returned within 30 days of being delivered")
+	ErrReturnQuantityExceeded = errors.New("return quantity is more than what is left to return of the item")
+	ErrReturnNotFound = errors.New("return not found")
+	ErrInvalidReturnStatus = errors.New("returns are approved or rejected, then received and then resolved, one step at a time")
+	ErrTooManyReturnPhotos = errors.New("a return can have at most 5 photos")
+	ErrInvalidReturnPhoto = errors.New("photos should be a jpeg, png, gif or webp image of at most 5MB")
+	ErrReturnPhotoNotFound = errors.New("return photo not found")
+	ErrOrderNotPaid = errors.New("invoices are issued once the order has been paid for")
+	ErrInvoiceNotFound = errors.New("invoice not found")
+	ErrInvalidIdempotencyKey = errors.New("the Idempotency-Key header should be at most 255 characters")
+	ErrIdempotencyKeyInProgress = errors.New("a request with this Idempotency-Key is still being processed, retry once it has finished")
+	ErrIdempotencyKeyReused = errors.New("this Idempotency-Key was already used for a different request")
+	ErrReplacementOutOfStock = errors.New("the product is out of stock, the return can be refunded or given as store credit instead")
+	ErrProductNotFound = errors.New("product not found")
+	ErrWishlistNotFound = errors.New("wishlist not found")
+	ErrWishlistItemNotFound = errors.New("product is not in the wishlist")
+	ErrWishlistNameTaken = errors.New("you already have a wishlist with this name")
+	ErrTooManyWishlists = errors.New("you can have at most 20 wishlists")
+	ErrReviewNotFound = errors.New("review not found")
+	ErrNotVerifiedBuyer = errors.New("only customers who have paid for the product can review it")
+	ErrAlreadyReviewed = errors.New("you have already reviewed this product, edit your review instead")
+	ErrOwnReviewVote = errors.New("you can't vote on your own review")
+	ErrQuestionNotFound = errors.New("question not found")
+	ErrAnswerNotFound = errors.New("answer not found")
+	ErrNotAllowedToAnswer = errors.New("only the seller and customers who have bought the product can answer questions about it")
+	ErrProductNotForSale = errors.New("some of the products are no longer for sale")
+	ErrPublishAtNotValid = errors.New("publishAt must be in the future and can only be set on a draft")
+	ErrUnpublishAtNotValid = errors.New("unpublishAt must be in the future, after publishAt, and can't be set on an archived product")
+	ErrSaleNotFound = errors.New("sale not found")
+	ErrSaleWindowNotValid = errors.New("endsAt must be in the future and after startsAt")
+	ErrSalePriceNotLower = errors.New("the sale price must be lower than the price of the product")
+	ErrSaleOverlaps = errors.New("the product already has a sale at that time")
+	ErrSaleOver = errors.New("the sale has already ended")
 )
 // expirations & general
 var (
-	VerificationTokenExpiresAt = time.Now().Add(time.Hour * 24)
-	PasswordResetTokenExpiresAt = time.Now().Add(time.Hour * 4)
 	ValidUserRoles = []string{"customer", "seller"}
 	JWTAuthUserContextKey jwtAuthUserContextKey  = "user"
 	JWTUserIdMapKey jwtUserIdMapKey = "userID"
-
+	APIKeyContextKey apiKeyContextKey = "apiKey"
+	// scopes an api key can be given, requests authenticated with a jwt are not limited by scopes
+	APIKeyScopeProductsRead = "products:read"
+	APIKeyScopeProductsWrite = "products:write"
+	APIKeyScopeOrdersRead = "orders:read"
+	ValidAPIKeyScopes = []string{APIKeyScopeProductsRead, APIKeyScopeProductsWrite, APIKeyScopeOrdersRead}
+	SellerDocumentTypes = []string{"government_id", "proof_of_address", "business_registration"}
+	ReturnReasons = []string{"damaged", "defective", "wrong_item", "not_as_described", "no_longer_needed", "other"}
+	SellerDocumentContentTypes = map[string]string{
+		"application/pdf": ".pdf",
+		"image/jpeg":      ".jpg",
+		"image/png":       ".png",
+	}
+	// avatar, logo and banner content types mapped to the extension the file is saved with
+	AvatarContentTypes = map[string]string{
+		"image/jpeg": ".jpg",
+		"image/png":  ".png",
+		"image/gif":  ".gif",
+		"image/webp": ".webp",
+	}
+	// per account (email) limits are strict, per IP limits are looser as many users can share an IP
+	AccountAuthThrottlePolicy = AuthThrottlePolicy{
+		FreeAttempts:    3,
+		MaxAttempts:     10,
+		BaseDelay:       time.Second * 2,
+		MaxDelay:        time.Minute * 5,
+		LockoutDuration: time.Minute * 30,
+		Window:          time.Hour,
+	}
+	IPAuthThrottlePolicy = AuthThrottlePolicy{
+		FreeAttempts:    10,
+		MaxAttempts:     50,
+		BaseDelay:       time.Second,
+		MaxDelay:        time.Minute * 5,
+		LockoutDuration: time.Minute * 30,
+		Window:          time.Hour,
+	}
+	// identity providers that users can sign in with, keyed by the name used in the url
+	OIDCProviders = map[string]OIDCProviderConfig{
+		"google": {
+			Issuer:       "https://accounts.google.com",
+			ClientID:     "google-client-id",
+			ClientSecret: "google-client-secret",
+			RedirectURL:  FrontendUrl + "/auth/oidc/google/callback",
+			Scopes:       []string{"openid", "email", "profile"},
+		},
+	}
+	// compared against when a login email is unknown so that both paths take the same time
+	DummyPasswordHash = "$2a$10$HuFWCQt15YMS8Sy7p8GQJeDt6ps4o2N0yIUdm/WePw7oPIEucvyLq"
 
 )
 
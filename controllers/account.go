@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/types"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+type AccountController struct {
+	accountRepo types.AccountRepository
+}
+
+func NewAccountController(accountRepo types.AccountRepository) *AccountController {
+	return &AccountController{
+		accountRepo: accountRepo,
+	}
+}
+
+// Export account data, responds with a zip archive holding a json file per kind of data
+func (c *AccountController) ExportAccountDataHandler(w http.ResponseWriter, r *http.Request)  {
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	data, err := c.accountRepo.RetrieveAccountData(user)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	files := []struct {
+		name string
+		content interface{}
+	}{
+		{"profile.json", data.Profile},
+		{"addresses.json", data.Addresses},
+		{"orders.json", data.Orders},
+		{"payments.json", data.Payments},
+		{"returns.json", data.Returns},
+		{"store-credit.json", data.StoreCredit},
+		{"wishlists.json", data.Wishlists},
+		{"reviews.json", data.Reviews},
+		{"questions.json", data.Questions},
+		{"products.json", data.Products},
+		{"seller-onboarding.json", data.SellerOnboarding},
+		{"storefront.json", data.Storefront},
+		{"audit-log.json", data.AuditLogs},
+	}
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="account-export-%s.zip"`, time.Now().Format("2006-01-02")))
+	w.WriteHeader(http.StatusOK)
+	// the status has been sent by now, so a failure can only be logged
+	archive := zip.NewWriter(w)
+	for _, file := range files {
+		f, err := archive.Create(file.name)
+		if err != nil {
+			log.Println("unable to write account export of user", user.ID, err)
+			return
+		}
+		encoder := json.NewEncoder(f)
+		encoder.SetIndent("", "  ")
+		if err = encoder.Encode(file.content); err != nil {
+			log.Println("unable to write account export of user", user.ID, err)
+			return
+		}
+	}
+	if err = archive.Close(); err != nil {
+		log.Println("unable to write account export of user", user.ID, err)
+	}
+
+}
+
+// Delete account, the account is anonymised once the grace period is over and can be restored until then
+func (c *AccountController) DeleteAccountHandler(w http.ResponseWriter, r *http.Request)  {
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	request, err := c.accountRepo.ScheduleAccountDeletion(user.ID, time.Now().Add(constants.AccountDeletionGracePeriod))
+	if err == constants.ErrAccountDeletionAlreadyScheduled {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	if err = sendAccountDeletionScheduledEmail(user.Email, request.ScheduledFor); err != nil {
+		log.Println("unable to send account deletion email to user", user.ID, err)
+	}
+	utils.WriteJson(w, http.StatusOK, "Your account will be deleted at the end of the grace period, sign in and restore it before then if you change your mind", request)
+
+}
+
+func (c *AccountController) RestoreAccountHandler(w http.ResponseWriter, r *http.Request)  {
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	err = c.accountRepo.CancelAccountDeletion(user.ID)
+	if err == constants.ErrAccountDeletionNotScheduled {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Account restored successfully!", nil)
+
+}
+
+func sendAccountDeletionScheduledEmail(userEmail string, scheduledFor time.Time) error {
+	err := utils.SendMail([]string{userEmail}, "Your account will be deleted", fmt.Sprintf("We received a request to delete your account. Your personal data will be removed on %s, sign in and restore your account before then if you didn't mean to do this: %s/me/restore", scheduledFor.Format("2 January 2006"), constants.FrontendUrl))
+	return err
+}
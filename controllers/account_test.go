@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/models"
+	"github.com/kaasikodes/e-commerce-go/types"
+)
+
+type fakeAccountRepository struct {
+	types.AccountRepository
+	data types.AccountDataExport
+}
+
+func (r *fakeAccountRepository) RetrieveAccountData(user models.User) (types.AccountDataExport, error) {
+	r.data.Profile = user
+	return r.data, nil
+}
+
+func TestAccountController_ExportAccountData(t *testing.T) {
+	repo := &fakeAccountRepository{data: types.AccountDataExport{
+		Orders: []models.Order{{ID: "order-1", CustomerID: "customer-1", CreatedAt: time.Now()}},
+	}}
+	controller := NewAccountController(repo)
+
+	user := models.User{ID: "user-1", Email: "jane@example.com", Password: "secret-hash"}
+	req := httptest.NewRequest(http.MethodGet, "/me/export", nil)
+	req = req.WithContext(context.WithValue(req.Context(), constants.JWTAuthUserContextKey, user))
+	w := httptest.NewRecorder()
+	controller.ExportAccountDataHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", w.Code, http.StatusOK)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatalf("response is not a zip archive: %v", err)
+	}
+	files := map[string]string{}
+	for _, f := range archive.File {
+		rc, _ := f.Open()
+		content, _ := io.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(content)
+	}
+	for _, name := range []string{"profile.json", "addresses.json", "orders.json", "payments.json", "products.json", "audit-log.json"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("export is missing %s", name)
+		}
+	}
+	if !strings.Contains(files["orders.json"], "order-1") {
+		t.Errorf("orders.json doesn't hold the user's orders: %s", files["orders.json"])
+	}
+	if strings.Contains(files["profile.json"], "secret-hash") {
+		t.Errorf("profile.json must not include the password hash")
+	}
+}
@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/types"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+type APIKeyController struct {
+	apiKeyRepo types.APIKeyRepository
+}
+
+func NewAPIKeyController(apiKeyRepo types.APIKeyRepository) *APIKeyController {
+	return &APIKeyController{
+		apiKeyRepo: apiKeyRepo,
+	}
+}
+
+// create api key, the plain key is only ever returned in this response
+func (c *APIKeyController) CreateAPIKeyHandler(w http.ResponseWriter, r *http.Request)  {
+	var payload types.CreateAPIKeyInput
+	if err:= utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	errParsed := utils.ValidatePayload(payload)
+	if len(errParsed) > 0{
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, errParsed)
+		return
+	}
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	apiKey, err := c.apiKeyRepo.CreateAPIKey(user.ID, payload)
+	if err == constants.ErrTooManyAPIKeys {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "API key created successfully, copy it now as it won't be shown again!", apiKey)
+
+}
+
+func (c *APIKeyController) GetAPIKeysHandler(w http.ResponseWriter, r *http.Request)  {
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	apiKeys, err := c.apiKeyRepo.RetrieveAPIKeys(user.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "API keys retrieved successfully!", apiKeys)
+
+}
+
+func (c *APIKeyController) RevokeAPIKeyHandler(w http.ResponseWriter, r *http.Request)  {
+	id := mux.Vars(r)["id"]
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	apiKey, err := c.apiKeyRepo.RevokeAPIKey(user.ID, id)
+	if err == constants.ErrAPIKeyNotFound {
+		utils.WriteError(w, http.StatusNotFound, constants.MsgValidationError, []error{err})
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "API key revoked successfully!", apiKey)
+
+}
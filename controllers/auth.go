@@ -2,7 +2,11 @@ package controllers
 
 import (
 	"fmt"
+	"log"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/kaasikodes/e-commerce-go/constants"
@@ -14,12 +18,66 @@ import (
 type AuthController struct {
 	userRepo types.UserRepository
 	tokenRepo types.TokenRepository
+	throttleRepo types.AuthThrottleRepository
 }
 
-func NewAuthController(userRepo types.UserRepository, tokenRepo types.TokenRepository) *AuthController{
+func NewAuthController(userRepo types.UserRepository, tokenRepo types.TokenRepository, throttleRepo types.AuthThrottleRepository) *AuthController{
 	return &AuthController{
 		userRepo: userRepo,
 		tokenRepo: tokenRepo,
+		throttleRepo: throttleRepo,
+	}
+}
+
+// throttle actions
+const (
+	throttleActionLogin = "login"
+	throttleActionForgotPwd = "forgot-password"
+	throttleActionVerifyUser = "verify-user"
+	throttleActionMagicLink = "magic-link"
+	throttleActionVerifyMagicLink = "verify-magic-link"
+)
+
+// authThrottleKeys returns the per account and per ip throttle keys for an action
+func authThrottleKeys(action, email string, r *http.Request) (accountKey, ipKey string) {
+	accountKey = fmt.Sprintf("%s:account:%s", action, strings.ToLower(strings.TrimSpace(email)))
+	ipKey = fmt.Sprintf("%s:ip:%s", action, utils.GetClientIP(r))
+	return accountKey, ipKey
+}
+
+// ensureNotThrottled writes a 429 and returns false if either key is currently backing off or locked out
+func (h *AuthController) ensureNotThrottled(w http.ResponseWriter, accountKey, ipKey string) bool {
+	var retryAfter time.Duration
+	for _, key := range []string{accountKey, ipKey} {
+		throttle, err := h.throttleRepo.RetrieveThrottle(key)
+		if err != nil { // no record means no recent failures
+			continue
+		}
+		if wait := time.Until(throttle.LockedUntil); wait > retryAfter {
+			retryAfter = wait
+		}
+	}
+	if retryAfter <= 0 {
+		return true
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	utils.WriteError(w, http.StatusTooManyRequests, constants.MsgTooManyRequests, []error{constants.ErrTooManyAuthAttempts})
+	return false
+}
+
+// recordFailedAuthAttempt counts a failure against both keys, and lets the account owner know once when the account gets locked
+func (h *AuthController) recordFailedAuthAttempt(accountKey, ipKey string, user *models.User) {
+	policy := constants.AccountAuthThrottlePolicy
+	throttle, err := h.throttleRepo.RecordFailedAttempt(accountKey, policy)
+	if err != nil {
+		log.Println("unable to record failed attempt", err)
+	} else if user != nil && throttle.FailedAttempts >= policy.MaxAttempts && throttle.NotifyLockout {
+		if err = sendAccountLockedEmail(user.Email, throttle.LockedUntil); err != nil {
+			log.Println("unable to send account locked email", err)
+		}
+	}
+	if _, err = h.throttleRepo.RecordFailedAttempt(ipKey, constants.IPAuthThrottlePolicy); err != nil {
+		log.Println("unable to record failed attempt", err)
 	}
 }
 
@@ -40,10 +98,17 @@ func (h *AuthController) ForgotPwdHandler(w http.ResponseWriter, r *http.Request
 		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, errParsed)
 		return
 	}
+	accountKey, ipKey := authThrottleKeys(throttleActionForgotPwd, payload.Email, r)
+	if !h.ensureNotThrottled(w, accountKey, ipKey) {
+		return
+	}
+	// every request counts, so that the endpoint can't be used to flood an inbox
+	h.recordFailedAuthAttempt(accountKey, ipKey, nil)
+	// the same response is sent whether or not the email exists, so the endpoint can't be used to find users
+	successMsg := "If an account exists for this email, a password reset link has been sent to it"
 	user, err := repo.RetrieveUserByEmail(payload.Email)
 	if err != nil {
-		err  = fmt.Errorf("user with email %s not found", payload.Email)
-		utils.WriteError(w, http.StatusBadRequest, "User Error", []error{err})
+		utils.WriteJson(w, http.StatusOK, successMsg,  nil)
 		return
 	}
 
@@ -51,7 +116,10 @@ func (h *AuthController) ForgotPwdHandler(w http.ResponseWriter, r *http.Request
 	token, err := h.tokenRepo.CreatePasswordResetToken(types.CreateTokenInput{
 		Email: user.Email,
 	})
-
+	if err == constants.ErrTooManyOutstandingTokens { // the user already has links they can use
+		utils.WriteJson(w, http.StatusOK, successMsg,  nil)
+		return
+	}
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
 		return
@@ -63,7 +131,7 @@ func (h *AuthController) ForgotPwdHandler(w http.ResponseWriter, r *http.Request
 		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
 		return
 	}
-	utils.WriteJson(w, http.StatusOK, "Hello, a password reset link has been sent to your email",  nil)
+	utils.WriteJson(w, http.StatusOK, successMsg,  nil)
 		
 }
 // Register User
@@ -122,6 +190,14 @@ func sendVerificationEmail(userEmail string, token string)error {
 	err := utils.SendMail([]string{userEmail}, "Verify your account", fmt.Sprintf("Please verify your email by clicking on the link: %s/auth/verify?token=%s&email=%s", constants.FrontendUrl, token, userEmail))
 	return err
 }
+func sendMagicLinkEmail(userEmail string, token string)error {
+	err := utils.SendMail([]string{userEmail}, "Your sign in link", fmt.Sprintf("Sign in by clicking on the link below, it expires in %d minutes and only works on the device you requested it from: %s/auth/magic-link?token=%s&email=%s", int(constants.MagicLinkTokenTTL.Minutes()), constants.FrontendUrl, token, userEmail))
+	return err
+}
+func sendAccountLockedEmail(userEmail string, lockedUntil time.Time)error {
+	err := utils.SendMail([]string{userEmail}, "Your account has been temporarily locked", fmt.Sprintf("We noticed several failed attempts to access your account, so it has been locked until %s. If this wasn't you, please reset your password at %s/auth/forgot-password", lockedUntil.Format(time.RFC1123), constants.FrontendUrl))
+	return err
+}
 // Reset Pwd
 func (h *AuthController) ResetPwdrHandler(w http.ResponseWriter, r *http.Request)  {
 	t := h.tokenRepo
@@ -140,43 +216,32 @@ func (h *AuthController) ResetPwdrHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 	
-	user, err := u.RetrieveUserByEmail(payload.Email)
+	// a missing, wrong, expired or already used token all get the same error
+	err := t.ConsumePasswordResetToken(payload.Email, payload.Token)
 	if err != nil {
-		utils.WriteError(w, http.StatusBadRequest, "User not found!", []error{err})
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{constants.ErrInvalidOrExpiredToken})
 		return
 	}
-	token, err := t.RetrievePasswordResetToken(payload.Email)
+	user, err := u.RetrieveUserByEmail(payload.Email)
 	if err != nil {
-		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
-		return
-	}
-	// verify token
-	if payload.Token != token.Token {
-		err = fmt.Errorf("invalid token")
-		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{constants.ErrInvalidOrExpiredToken})
 		return
 	}
-	// check token expiration
-	if time.Now().After(token.ExpiresAt) {
-		err = fmt.Errorf("token expired")
-		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+	// encrypt password
+	encryptedPassword, err := utils.EncryptPassword(payload.Password)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
 		return
 	}
 	// update password
 	user, err = u.UpdateUserPassword(user.ID, types.UpdateUserPwdInput{ 
-		Password: payload.Password,
+		Password: encryptedPassword,
 
 	})
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
 		return
 	}
-	// delete token
-	err = t.DeletePasswordResetToken(payload.Email)
-	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
-		return
-	}
 	
 	
 	utils.WriteJson(w, http.StatusOK, "Password reset successful!",  user)
@@ -199,22 +264,16 @@ func (h *AuthController) VerifyUserHandler(w http.ResponseWriter, r *http.Reques
 		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, errParsed)
 		return
 	}
-	
-	token, err := t.RetrieveVerificationToken(payload.Email)
-	if err != nil {
-		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
-		return
-	}
-	// verify token
-	if payload.Token != token.Token {
-		err = fmt.Errorf("invalid token")
-		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+	accountKey, ipKey := authThrottleKeys(throttleActionVerifyUser, payload.Email, r)
+	if !h.ensureNotThrottled(w, accountKey, ipKey) {
 		return
 	}
-	// check token expiration
-	if time.Now().After(token.ExpiresAt) {
-		err = fmt.Errorf("token expired")
-		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+	
+	// a missing, wrong, expired or already used token all get the same error
+	err := t.ConsumeVerificationToken(payload.Email, payload.Token)
+	if err != nil {
+		h.recordFailedAuthAttempt(accountKey, ipKey, nil)
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{constants.ErrInvalidOrExpiredToken})
 		return
 	}
 	// verify user
@@ -225,49 +284,31 @@ func (h *AuthController) VerifyUserHandler(w http.ResponseWriter, r *http.Reques
 		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
 		return
 	}
-	// delete token
-	err = t.DeleteVerificationToken(payload.Email)
-	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
-		return
-	}
+	h.throttleRepo.ClearThrottle(accountKey)
 	
 	
 	utils.WriteJson(w, http.StatusOK, "User verified!",  user)
 		
 }
 
-func  (h *AuthController) ensureUserEmailIsVerified(user models.User, payloadEmail string) (bool, error) {
+func  (h *AuthController) ensureUserEmailIsVerified(user models.User) (bool, error) {
 	emailVerified := true
 	if !user.EmailVerified {
-		
-		token, err := h.tokenRepo.RetrieveVerificationToken(payloadEmail)
+		emailVerified = false
+		// tokens are stored hashed so an existing one can't be resent, a new one is issued instead
+		token, err := h.tokenRepo.CreateVerificationToken(types.CreateTokenInput{
+			Email: user.Email,
+		})
+		if err == constants.ErrTooManyOutstandingTokens {
+			return emailVerified, fmt.Errorf("user not verified, please use one of the verification links already sent to your email")
+		}
 		if err != nil {
-			emailVerified = false
 			return emailVerified, err
 		}
-		if  time.Now().After(token.ExpiresAt) {
-			// delete token
-			err = h.tokenRepo.DeleteVerificationToken(payloadEmail)
-			if err != nil {
-				emailVerified = false
-				return emailVerified, err
-			}
-			// create new token
-			token, err = h.tokenRepo.CreateVerificationToken(types.CreateTokenInput{
-				Email: user.Email,
-			})
-			if err != nil {
-				emailVerified = false
-				return emailVerified, err
-			}
-			
-		}
 		
 		err = sendVerificationEmail(user.Email, token.Token)
 	
 		if err != nil {
-			emailVerified = false
 			return emailVerified, err
 		}
 		err = fmt.Errorf("user not verified, please check your email, a verification link has been sent to your email")
@@ -290,29 +331,134 @@ func  (h *AuthController) LoginUser(w http.ResponseWriter, r *http.Request) {
 		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, errParsed)
 		return
 	}
-	// check if user exists
+	accountKey, ipKey := authThrottleKeys(throttleActionLogin, payload.Email, r)
+	if !h.ensureNotThrottled(w, accountKey, ipKey) {
+		return
+	}
+	// check if user exists, an unknown email and a wrong password get the same error
 	user, err := h.userRepo.RetrieveUserByEmail(payload.Email)
 	if err != nil {
-		err = fmt.Errorf("user not found")
-		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		// compare anyway so that both failures take the same time
+		utils.CheckPasswordHash(payload.Password, constants.DummyPasswordHash)
+		h.recordFailedAuthAttempt(accountKey, ipKey, nil)
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{constants.ErrInvalidCredentials})
 		return
 	}
 	// check if password is correct
 	isValidPassword := utils.CheckPasswordHash(payload.Password,user.Password)
 	
 	if !isValidPassword {
-		err = fmt.Errorf("invalid password")
-		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		h.recordFailedAuthAttempt(accountKey, ipKey, &user)
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{constants.ErrInvalidCredentials})
 		return
 	}
+	// the ip counter is left alone, otherwise an attacker could reset it with an account of their own
+	h.throttleRepo.ClearThrottle(accountKey)
 	// check if the user has been 
-	isEmailVerified, err := h.ensureUserEmailIsVerified(user, payload.Email)
+	isEmailVerified, err := h.ensureUserEmailIsVerified(user)
 	if err != nil || !isEmailVerified {
 		utils.WriteError(w, http.StatusBadRequest, "User not verified!", []error{err})
 		return
 	}
 
 	
+	token, err := utils.CreateJWT(user.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	authData := createAuthResponseData(user, token)
+	utils.WriteJson(w, http.StatusOK, "User logged in successfully!", authData)
+
+}
+// Magic link, emails a one time sign in link. The device token in the response must be sent along with the link's token to sign in
+func (h *AuthController) MagicLinkHandler(w http.ResponseWriter, r *http.Request)  {
+	var payload types.MagicLinkInput
+	if err:= utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	errParsed := utils.ValidatePayload(payload)
+	if len(errParsed) > 0{
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, errParsed)
+		return
+	}
+	accountKey, ipKey := authThrottleKeys(throttleActionMagicLink, payload.Email, r)
+	if !h.ensureNotThrottled(w, accountKey, ipKey) {
+		return
+	}
+	// every request counts, so that the endpoint can't be used to flood an inbox
+	h.recordFailedAuthAttempt(accountKey, ipKey, nil)
+	// a device token is returned whether or not the email exists, so the endpoint can't be used to find users
+	deviceToken, err := utils.GenerateSecureToken()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	successMsg := "If an account exists for this email, a sign in link has been sent to it"
+	successData := map[string]string{"deviceToken": deviceToken}
+	user, err := h.userRepo.RetrieveUserByEmail(payload.Email)
+	if err != nil {
+		utils.WriteJson(w, http.StatusOK, successMsg, successData)
+		return
+	}
+	token, err := h.tokenRepo.CreateMagicLinkToken(types.CreateMagicLinkTokenInput{
+		Email: user.Email,
+		DeviceToken: deviceToken,
+	})
+	if err == constants.ErrTooManyOutstandingTokens {
+		utils.WriteJson(w, http.StatusOK, successMsg, successData)
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	if err = sendMagicLinkEmail(user.Email, token.Token); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, successMsg, successData)
+
+}
+
+// Verify magic link, exchanges the link's token for the same auth payload a password login returns
+func (h *AuthController) VerifyMagicLinkHandler(w http.ResponseWriter, r *http.Request)  {
+	var payload types.VerifyMagicLinkInput
+	if err:= utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	errParsed := utils.ValidatePayload(payload)
+	if len(errParsed) > 0{
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, errParsed)
+		return
+	}
+	accountKey, ipKey := authThrottleKeys(throttleActionVerifyMagicLink, payload.Email, r)
+	if !h.ensureNotThrottled(w, accountKey, ipKey) {
+		return
+	}
+	// a missing, wrong, expired or used token, or one requested from another device, all get the same error
+	// the link is single use, using it up is what checks it so the same link opened twice at once only logs in once
+	if err := h.tokenRepo.ConsumeMagicLinkToken(payload.Email, payload.Token, payload.DeviceToken); err != nil {
+		h.recordFailedAuthAttempt(accountKey, ipKey, nil)
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{constants.ErrInvalidOrExpiredToken})
+		return
+	}
+	h.throttleRepo.ClearThrottle(accountKey)
+	user, err := h.userRepo.RetrieveUserByEmail(payload.Email)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{constants.ErrInvalidOrExpiredToken})
+		return
+	}
+	// opening the link proves the user owns the email
+	if !user.EmailVerified {
+		if user, err = h.userRepo.VerifyUser(user.Email); err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+			return
+		}
+	}
+
 	token, err := utils.CreateJWT(user.ID)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
@@ -361,8 +507,14 @@ func (h *AuthController) ChangePassword(w http.ResponseWriter, r *http.Request)
 		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, errParsed)
 		return
 	}
+	// encrypt password
+	encryptedPassword, err := utils.EncryptPassword(payload.Password)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
 	_, err = u.UpdateUserPassword(user.ID, types.UpdateUserPwdInput{
-		Password: payload.Password,
+		Password: encryptedPassword,
 	})
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
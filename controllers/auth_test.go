@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/models"
+	"github.com/kaasikodes/e-commerce-go/types"
+)
+
+// fakeTokenRepository has a single magic link that, like the database, can only be consumed once
+type fakeTokenRepository struct {
+	types.TokenRepository
+	mu       sync.Mutex
+	consumed bool
+}
+
+func (r *fakeTokenRepository) ConsumeMagicLinkToken(email string, token string, deviceToken string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.consumed || token != "plain-token" || deviceToken != "device-token" {
+		return constants.ErrInvalidOrExpiredToken
+	}
+	r.consumed = true
+	return nil
+}
+
+type fakeAuthUserRepository struct {
+	types.UserRepository
+}
+
+func (r *fakeAuthUserRepository) RetrieveUserByEmail(email string) (models.User, error) {
+	return models.User{ID: "user-1", Email: email, EmailVerified: true}, nil
+}
+
+// fakeAuthThrottleRepository has no recent failures and counts the ones recorded
+type fakeAuthThrottleRepository struct {
+	types.AuthThrottleRepository
+	mu       sync.Mutex
+	failures int
+}
+
+func (r *fakeAuthThrottleRepository) RetrieveThrottle(key string) (models.AuthThrottle, error) {
+	return models.AuthThrottle{}, sql.ErrNoRows
+}
+func (r *fakeAuthThrottleRepository) RecordFailedAttempt(key string, policy constants.AuthThrottlePolicy) (models.AuthThrottle, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failures++
+	return models.AuthThrottle{Key: key, FailedAttempts: 1}, nil
+}
+func (r *fakeAuthThrottleRepository) ClearThrottle(key string) error {
+	return nil
+}
+
+func verifyMagicLink(controller *AuthController, deviceToken string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(types.VerifyMagicLinkInput{Email: "ada@example.com", Token: "plain-token", DeviceToken: deviceToken})
+	req := httptest.NewRequest(http.MethodPost, "/auth/magic-link/verify", bytes.NewReader(body))
+	rr := httptest.NewRecorder()
+	controller.VerifyMagicLinkHandler(rr, req)
+	return rr
+}
+
+func TestAuthController_VerifyMagicLinkHandler_SingleUse(t *testing.T) {
+	throttleRepo := &fakeAuthThrottleRepository{}
+	controller := NewAuthController(&fakeAuthUserRepository{}, &fakeTokenRepository{}, throttleRepo)
+
+	// the same link opened twice at once
+	codes := make([]int, 2)
+	var wg sync.WaitGroup
+	for i := range codes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			codes[i] = verifyMagicLink(controller, "device-token").Code
+		}(i)
+	}
+	wg.Wait()
+
+	loggedIn := 0
+	for _, code := range codes {
+		if code == http.StatusOK {
+			loggedIn++
+		} else if code != http.StatusBadRequest {
+			t.Errorf("status = %d, want %d or %d", code, http.StatusOK, http.StatusBadRequest)
+		}
+	}
+	if loggedIn != 1 {
+		t.Errorf("logged in %d times, want %d", loggedIn, 1)
+	}
+	if throttleRepo.failures == 0 {
+		t.Errorf("the reused link was not counted as a failed attempt")
+	}
+}
+
+func TestAuthController_VerifyMagicLinkHandler_OtherDevice(t *testing.T) {
+	controller := NewAuthController(&fakeAuthUserRepository{}, &fakeTokenRepository{}, &fakeAuthThrottleRepository{})
+
+	if rr := verifyMagicLink(controller, "other-device"); rr.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", rr.Code, http.StatusBadRequest)
+	}
+	// the link still works on the device it was requested from
+	if rr := verifyMagicLink(controller, "device-token"); rr.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", rr.Code, http.StatusOK)
+	}
+}
@@ -1,6 +1,8 @@
 package controllers
 
 import (
+	"fmt"
+	"log"
 	"net/http"
 
 	"github.com/gorilla/mux"
@@ -14,14 +16,20 @@ type CartController struct {
 	orderRepo types.OrderRepository
 	paymentRepo types.PaymentRepository
 	addressRepo types.AddressRepository
+	ledgerRepo types.LedgerRepository
+	refundRepo types.RefundRepository
+	invoiceRepo types.InvoiceRepository
 }
 
-func NewCartController(cartRepo types.CartRepository, orderRepo types.OrderRepository, paymentRepo types.PaymentRepository, addressRepo types.AddressRepository) *CartController {
+func NewCartController(cartRepo types.CartRepository, orderRepo types.OrderRepository, paymentRepo types.PaymentRepository, addressRepo types.AddressRepository, ledgerRepo types.LedgerRepository, refundRepo types.RefundRepository, invoiceRepo types.InvoiceRepository) *CartController {
 	return &CartController{
 		cartRepo: cartRepo,
 		orderRepo: orderRepo,
 		paymentRepo: paymentRepo,
 		addressRepo: addressRepo,
+		ledgerRepo: ledgerRepo,
+		refundRepo: refundRepo,
+		invoiceRepo: invoiceRepo,
 	}
 }
 
@@ -65,15 +73,39 @@ func (c *CartController) VerifyPaymentHandler(w http.ResponseWriter, r *http.Req
 	var finalRes = make(map[string]string)
 	switch verfifyResponse.Data.Status {
 		case constants.PaystackTransactionStatuses.Success:
-			// update the payment status of order in database
-			err = paymentRepo.UpdatePayment(types.UpdatePaymentInput{
-				Paid: true,
-				PaidAt: verfifyResponse.Data.PaidAt,
-			}, reference)
+			payment, err := paymentRepo.RetrievePayment(reference)
 			if err != nil {
 				utils.WriteError(w, http.StatusInternalServerError, "Error while verifying payment!", []error{err})
 				return
 			}
+			// update the payment status of order in database, only the verification that marks it paid sends the confirmation
+			markedPaid, err := paymentRepo.MarkPaymentPaid(reference, verfifyResponse.Data.PaidAt)
+			if err != nil {
+				utils.WriteError(w, http.StatusInternalServerError, "Error while verifying payment!", []error{err})
+				return
+			}
+			// credit the sellers, posting is idempotent so verifying the same payment again is harmless
+			err = c.ledgerRepo.PostPaymentSales(reference)
+			if err != nil {
+				utils.WriteError(w, http.StatusInternalServerError, "Error while verifying payment!", []error{err})
+				return
+			}
+			// the order may have been cancelled before the payment came in
+			err = c.refundRepo.RefundCancelledPayment(reference)
+			if err != nil {
+				utils.WriteError(w, http.StatusInternalServerError, "Error while verifying payment!", []error{err})
+				return
+			}
+			if markedPaid {
+				// the confirmation goes to the customer of the order, whoever asked for the payment to be verified
+				customerEmail, err := paymentRepo.RetrievePaymentCustomerEmail(reference)
+				if err == nil {
+					err = c.sendPaymentConfirmationEmail(customerEmail, payment.OrderID)
+				}
+				if err != nil {
+					log.Println("Error sending payment confirmation email:", err)
+				}
+			}
 			finalRes["paid"] = "true"
 		case constants.PaystackTransactionStatuses.Abandoned:
 			finalRes["paid"] = "false"
@@ -90,7 +122,6 @@ func (c *CartController) CheckoutCartHandler(w http.ResponseWriter, r *http.Requ
 	// TODO: Consider making a db transaction for this, since multple tables are involved
 	cartRepo := c.cartRepo
 	orderRepo := c.orderRepo
-	paymentRepo := c.paymentRepo
 	addressRepo := c.addressRepo
 
 	var payload types.CartCheckoutInput
@@ -128,10 +159,16 @@ func (c *CartController) CheckoutCartHandler(w http.ResponseWriter, r *http.Requ
 	// create order in db
 	var createOrderInput types.CreateOrderInput
 	createOrderInput.TotalAmount = virtualOrder.TotalAmount
+	createOrderInput.Payment = types.CreatePaymentInput{
+		Reference: virtualOrder.Payment.ID,
+		Amount: virtualOrder.TotalAmount,
+	}
 	createOrderInput.OrderItems = []types.OrderItemInput{}
 	for _, item := range virtualOrder.Items {
 		createOrderInput.OrderItems = append(createOrderInput.OrderItems, types.OrderItemInput{
 			ProductId: item.ProductID,
+			SellerId: item.Product.SellerID,
+			UnitPrice: item.UnitPrice,
 			TotalPrice: item.TotalPrice,
 			Quantity: item.Quantity,
 		})
@@ -141,23 +178,23 @@ func (c *CartController) CheckoutCartHandler(w http.ResponseWriter, r *http.Requ
 		utils.WriteError(w, http.StatusInternalServerError, "Error while creating address for cart!", []error{err})
 		return
 	}
-	orderId, err := orderRepo.CreateOrder(createOrderInput, customerId, virtualOrder.DeliveryAddressID)
-	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, "Error while creating order for cart!", []error{err})
+	orderId, orderNumber, err := orderRepo.CreateOrder(createOrderInput, customerId, virtualOrder.DeliveryAddressID)
+	if err == constants.ErrInsufficientStock || err == constants.ErrProductNotForSale {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
 		return
 	}
-
-	// create payment in db
-	err = paymentRepo.CreatePayment(types.CreatePaymentInput{
-		Reference: virtualOrder.Payment.ID,
-		Amount: virtualOrder.TotalAmount,
-		
-
-	}, orderId)
 	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, "Error while saving payment for cart!", []error{err})
+		utils.WriteError(w, http.StatusInternalServerError, "Error while creating order for cart!", []error{err})
 		return
 	}
+	// the customer is shown the order that was saved, not the one worked out from the cart
+	virtualOrder.ID = orderId
+	virtualOrder.Number = orderNumber
+	virtualOrder.Payment.OrderID = orderId
+	for i := range virtualOrder.Items {
+		virtualOrder.Items[i].OrderID = orderId
+	}
+
 	// delete cart
 	err = cartRepo.DeleteCart(customerId)
 	if err != nil {
@@ -200,6 +237,10 @@ func (c *CartController) SaveCartHandler(w http.ResponseWriter, r *http.Request)
 	}
 	// create cart
 	cart, err := repo.CreateCart(payload, customerId)
+	if err == constants.ErrProductNotForSale {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
 		return
@@ -227,4 +268,114 @@ func (c *CartController) GetCartHandler(w http.ResponseWriter, r *http.Request)
 		
 }
 
+// add the items of a past order to the cart again, the customer is told what couldn't be added or costs something else now
+func (c *CartController) ReorderHandler(w http.ResponseWriter, r *http.Request)  {
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	output, err := c.cartRepo.Reorder(mux.Vars(r)["id"], user.Customer.ID)
+	if err == constants.ErrOrderNotFound {
+		utils.WriteError(w, http.StatusNotFound, constants.MsgValidationError, []error{err})
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Order items added to cart successfully!",  output)
+}
+
+func (c *CartController) GetSavedItemsHandler(w http.ResponseWriter, r *http.Request)  {
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	items, err := c.cartRepo.RetrieveSavedItems(user.Customer.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Saved items retrieved successfully!",  items)
+}
+
+func (c *CartController) SaveForLaterHandler(w http.ResponseWriter, r *http.Request)  {
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	cart, err := c.cartRepo.SaveForLater(mux.Vars(r)["productId"], user.Customer.ID)
+	if err == constants.ErrCartItemNotFound {
+		utils.WriteError(w, http.StatusNotFound, constants.MsgValidationError, []error{err})
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Item saved for later successfully!",  cart)
+}
 
+func (c *CartController) MoveToCartHandler(w http.ResponseWriter, r *http.Request)  {
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	cart, err := c.cartRepo.MoveToCart(mux.Vars(r)["productId"], user.Customer.ID)
+	if err == constants.ErrSavedItemNotFound {
+		utils.WriteError(w, http.StatusNotFound, constants.MsgValidationError, []error{err})
+		return
+	}
+	if err == constants.ErrProductNotForSale {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Item moved to cart successfully!",  cart)
+}
+
+func (c *CartController) DeleteSavedItemHandler(w http.ResponseWriter, r *http.Request)  {
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	err = c.cartRepo.DeleteSavedItem(mux.Vars(r)["productId"], user.Customer.ID)
+	if err == constants.ErrSavedItemNotFound {
+		utils.WriteError(w, http.StatusNotFound, constants.MsgValidationError, []error{err})
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Saved item removed successfully!",  nil)
+}
+
+// private
+// sendPaymentConfirmationEmail lets the customer know their payment came in, with the order's invoices attached
+func (c *CartController) sendPaymentConfirmationEmail(userEmail string, orderId string) error {
+	invoices, err := c.invoiceRepo.IssueOrderInvoices(orderId)
+	if err != nil {
+		return err
+	}
+	if len(invoices) == 0 {
+		return utils.SendMail([]string{userEmail}, "Payment received", fmt.Sprintf("We have received your payment for order %s, thank you! You can follow your order at %s/orders/%s", orderId, constants.FrontendUrl, orderId))
+	}
+	reference := orderReference(orderId, invoices[0].OrderNumber)
+	body := fmt.Sprintf("We have received your payment for order %s, thank you! You can follow your order at %s/orders/%s", reference, constants.FrontendUrl, orderId)
+	content, err := renderInvoicesPDF(invoices)
+	if err != nil {
+		return err
+	}
+	return utils.SendMailWithAttachments([]string{userEmail}, "Payment received", body+"\n\nYour invoice is attached.", []utils.MailAttachment{
+		{Filename: "invoice-" + reference + ".pdf", ContentType: "application/pdf", Content: content},
+	})
+}
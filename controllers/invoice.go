@@ -0,0 +1,206 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/models"
+	"github.com/kaasikodes/e-commerce-go/types"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+type InvoiceController struct {
+	invoiceRepo types.InvoiceRepository
+	orderRepo types.OrderRepository
+}
+
+func NewInvoiceController(invoiceRepo types.InvoiceRepository, orderRepo types.OrderRepository) *InvoiceController {
+	return &InvoiceController{
+		invoiceRepo: invoiceRepo,
+		orderRepo: orderRepo,
+	}
+}
+
+// GetOrderInvoiceHandler sends the invoices of every seller in a paid order, with their credit notes, as one pdf
+func (c *InvoiceController) GetOrderInvoiceHandler(w http.ResponseWriter, r *http.Request)  {
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	id := mux.Vars(r)["id"]
+	order, err := c.orderRepo.RetrieveOrder(id)
+	if err == constants.ErrOrderNotFound || (err == nil && order.CustomerID != user.Customer.ID) {
+		utils.WriteError(w, http.StatusNotFound, constants.MsgValidationError, []error{constants.ErrOrderNotFound})
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	invoices, err := c.invoiceRepo.IssueOrderInvoices(order.ID)
+	if err == constants.ErrOrderNotPaid {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	if err == nil && len(invoices) == 0 {
+		err = constants.ErrInvoiceNotFound
+	}
+	if err == constants.ErrOrderNotFound || err == constants.ErrInvoiceNotFound {
+		utils.WriteError(w, http.StatusNotFound, constants.MsgValidationError, []error{err})
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	writeInvoicesPDF(w, "invoice-"+orderReference(order.ID, order.Number)+".pdf", invoices)
+
+}
+
+// seller
+func (c *InvoiceController) GetSellerInvoiceHandler(w http.ResponseWriter, r *http.Request)  {
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	id := mux.Vars(r)["id"]
+	invoice, err := c.invoiceRepo.RetrieveSellerInvoice(id, user.Seller.ID)
+	if err == constants.ErrOrderNotPaid {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	if err == constants.ErrSubOrderNotFound || err == constants.ErrOrderNotFound || err == constants.ErrInvoiceNotFound {
+		utils.WriteError(w, http.StatusNotFound, constants.MsgValidationError, []error{err})
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	writeInvoicesPDF(w, invoice.Number+".pdf", []models.Invoice{invoice})
+
+}
+
+// private
+func writeInvoicesPDF(w http.ResponseWriter, filename string, invoices []models.Invoice) {
+	content, err := renderInvoicesPDF(invoices)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	w.Write(content)
+}
+
+// renderInvoicesPDF puts each invoice on its own page, followed by a page for each of its credit notes
+func renderInvoicesPDF(invoices []models.Invoice) ([]byte, error) {
+	pdf := utils.NewPDF()
+	for _, invoice := range invoices {
+		pdf.AddPage()
+		y := renderDocumentHeader(pdf, "INVOICE", invoice.Number, invoice.IssuedAt, invoice)
+		pdf.Text(50, y, 10, false, fmt.Sprintf("Order: %s", orderReference(invoice.OrderID, invoice.OrderNumber)))
+		y += 30
+
+		// the items, continued on a new page when they don't fit
+		columns := []float64{50, 330, 380, 470}
+		tableHeader := func() {
+			for i, heading := range []string{"Description", "Qty", "Unit price", "Amount"} {
+				pdf.Text(columns[i], y, 10, true, heading)
+			}
+			pdf.Line(50, y + 6, utils.PDFPageWidth - 50, y + 6)
+			y += 22
+		}
+		tableHeader()
+		for _, line := range invoice.Lines {
+			if y > utils.PDFPageHeight - 160 {
+				pdf.AddPage()
+				y = 60
+				tableHeader()
+			}
+			pdf.Text(columns[0], y, 10, false, truncate(line.Description, 50))
+			pdf.Text(columns[1], y, 10, false, strconv.Itoa(line.Quantity))
+			pdf.Text(columns[2], y, 10, false, formatKobo(line.UnitPrice))
+			pdf.Text(columns[3], y, 10, false, formatKobo(line.Total))
+			y += 18
+		}
+		pdf.Line(50, y - 6, utils.PDFPageWidth - 50, y - 6)
+		y = renderDocumentTotals(pdf, y + 12, invoice.Subtotal, invoice.TaxRateBps, invoice.TaxAmount, invoice.Total)
+
+		y += 20
+		pdf.Text(50, y, 10, true, "Payment")
+		pdf.Text(50, y + 16, 10, false, fmt.Sprintf("Paid by %s on %s, reference %s", invoice.PaymentMethod, invoice.PaidAt.Format("2 January 2006"), invoice.PaymentID))
+
+		for _, note := range invoice.CreditNotes {
+			pdf.AddPage()
+			y := renderDocumentHeader(pdf, "CREDIT NOTE", note.Number, note.IssuedAt, invoice)
+			pdf.Text(50, y, 10, false, fmt.Sprintf("Credits invoice %s issued on %s", invoice.Number, invoice.IssuedAt.Format("2 January 2006")))
+			pdf.Text(50, y + 16, 10, false, "Reason: " + truncate(note.Reason, 80))
+			y = renderDocumentTotals(pdf, y + 54, note.Subtotal, invoice.TaxRateBps, note.TaxAmount, note.Total)
+		}
+	}
+	return pdf.Bytes()
+}
+
+// renderDocumentHeader draws the title, the seller and who the document is for, and returns where the page continues
+func renderDocumentHeader(pdf *utils.PDF, title string, number string, date time.Time, invoice models.Invoice) float64 {
+	pdf.Text(50, 70, 20, true, title)
+	pdf.Text(350, 60, 10, true, number)
+	pdf.Text(350, 76, 10, false, "Date: " + date.Format("2 January 2006"))
+	pdf.Text(50, 110, 12, true, truncate(invoice.SellerName, 60))
+	pdf.Text(50, 150, 10, true, "Bill to")
+	pdf.Text(50, 166, 10, false, truncate(invoice.BillToName, 80))
+	pdf.Text(50, 182, 10, false, truncate(invoice.BillToAddress, 90))
+	return 210
+}
+
+func renderDocumentTotals(pdf *utils.PDF, y float64, subtotal int64, taxRateBps int64, taxAmount int64, total int64) float64 {
+	pdf.Text(330, y, 10, false, "Subtotal")
+	pdf.Text(470, y, 10, false, formatKobo(subtotal))
+	pdf.Text(330, y + 16, 10, false, fmt.Sprintf("VAT (%d.%02d%%)", taxRateBps / 100, taxRateBps % 100))
+	pdf.Text(470, y + 16, 10, false, formatKobo(taxAmount))
+	pdf.Text(330, y + 36, 11, true, "Total")
+	pdf.Text(470, y + 36, 11, true, formatKobo(total))
+	return y + 52
+}
+
+// formatKobo formats an amount in kobo as naira with thousands separators, e.g. NGN 1,234.50
+func formatKobo(amount int64) string {
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	naira := strconv.FormatInt(amount / 100, 10)
+	grouped := []string{}
+	for len(naira) > 3 {
+		grouped = append([]string{naira[len(naira)-3:]}, grouped...)
+		naira = naira[:len(naira)-3]
+	}
+	grouped = append([]string{naira}, grouped...)
+	return fmt.Sprintf("%sNGN %s.%02d", sign, strings.Join(grouped, ","), amount % 100)
+}
+
+// orderReference is how an order is referred to, orders placed before order numbers existed only have their id
+func orderReference(id string, number string) string {
+	if number == "" {
+		return id
+	}
+	return number
+}
+
+func truncate(text string, max int) string {
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	return string(runes[:max-3]) + "..."
+}
@@ -0,0 +1,209 @@
+package controllers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/models"
+	"github.com/kaasikodes/e-commerce-go/types"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+type OIDCController struct {
+	userRepo types.UserRepository
+	identityRepo types.UserIdentityRepository
+	providers map[string]types.OIDCClient
+}
+
+func NewOIDCController(userRepo types.UserRepository, identityRepo types.UserIdentityRepository, providers map[string]types.OIDCClient) *OIDCController {
+	return &OIDCController{
+		userRepo: userRepo,
+		identityRepo: identityRepo,
+		providers: providers,
+	}
+}
+
+// Start OIDC login, returns the url the frontend should send the user to
+func (h *OIDCController) StartOIDCLoginHandler(w http.ResponseWriter, r *http.Request)  {
+	providerName := mux.Vars(r)["provider"]
+	client, ok := h.providers[providerName]
+	if !ok {
+		utils.WriteError(w, http.StatusNotFound, constants.MsgValidationError, []error{constants.ErrUnknownOIDCProvider})
+		return
+	}
+	// state protects against csrf, the nonce against id token replay and the code verifier (pkce) against code interception
+	state, err := utils.GenerateSecureToken()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	nonce, err := utils.GenerateSecureToken()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	codeVerifier, err := utils.GenerateSecureToken()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	authorizationUrl, err := client.AuthCodeURL(state, nonce, utils.PKCECodeChallenge(codeVerifier))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadGateway, "Identity Provider Error", []error{err})
+		return
+	}
+	err = h.identityRepo.CreateOIDCLoginState(models.OIDCLoginState{
+		State: state,
+		Provider: providerName,
+		Nonce: nonce,
+		CodeVerifier: codeVerifier,
+		ExpiresAt: time.Now().Add(constants.OIDCLoginStateTTL),
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Login started, please continue at the identity provider", map[string]string{"authorizationUrl": authorizationUrl})
+
+}
+
+// OIDC callback, exchanges the code the provider sent back for the same auth payload a password login returns
+func (h *OIDCController) OIDCCallbackHandler(w http.ResponseWriter, r *http.Request)  {
+	providerName := mux.Vars(r)["provider"]
+	client, ok := h.providers[providerName]
+	if !ok {
+		utils.WriteError(w, http.StatusNotFound, constants.MsgValidationError, []error{constants.ErrUnknownOIDCProvider})
+		return
+	}
+	var payload types.OIDCCallbackInput
+	if err:= utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	errParsed := utils.ValidatePayload(payload)
+	if len(errParsed) > 0{
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, errParsed)
+		return
+	}
+	state, err := h.identityRepo.ConsumeOIDCLoginState(payload.State)
+	if err != nil || state.Provider != providerName {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{constants.ErrInvalidOIDCState})
+		return
+	}
+	tokens, err := client.Exchange(payload.Code, state.CodeVerifier)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Identity Provider Error", []error{err})
+		return
+	}
+	claims, err := client.VerifyIDToken(tokens.IDToken, state.Nonce)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Identity Provider Error", []error{err})
+		return
+	}
+	user, err := h.resolveOIDCUser(providerName, claims)
+	if err == constants.ErrOIDCEmailNotVerified {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+
+	token, err := utils.CreateJWT(user.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	authData := createAuthResponseData(user, token)
+	utils.WriteJson(w, http.StatusOK, "User logged in successfully!", authData)
+
+}
+
+// resolveOIDCUser finds the user an external identity belongs to, linking it to the account with the same email or creating a new customer when there is none
+func (h *OIDCController) resolveOIDCUser(providerName string, claims types.OIDCClaims) (models.User, error) {
+	identity, err := h.identityRepo.RetrieveUserIdentity(providerName, claims.Subject)
+	if err == nil {
+		return h.userRepo.RetrieveUserByID(identity.UserID)
+	}
+	if err != sql.ErrNoRows {
+		return models.User{}, err
+	}
+	// an email is only trusted for linking or sign up once the provider has verified it
+	if claims.Email == "" || !claims.EmailVerified {
+		return models.User{}, constants.ErrOIDCEmailNotVerified
+	}
+	user, err := h.userRepo.RetrieveUserByEmail(claims.Email)
+	isNewUser := err == sql.ErrNoRows
+	if err != nil && !isNewUser {
+		return user, err
+	}
+	if isNewUser {
+		encryptedPassword, err := randomPasswordHash()
+		if err != nil {
+			return user, err
+		}
+		name := claims.Name
+		if name == "" {
+			name = strings.Split(claims.Email, "@")[0]
+		}
+		user, err = h.userRepo.AddUser(types.AddUserInput{
+			Name: name,
+			Email: claims.Email,
+			Password: encryptedPassword,
+			Image: claims.Picture,
+			UserRoles: []string{"customer"},
+		})
+		if err != nil {
+			return user, err
+		}
+	}
+	switch {
+	case !isNewUser && !user.EmailVerified:
+		// anyone could have signed up with the email before its owner, so the password they chose must not keep working
+		encryptedPassword, err := randomPasswordHash()
+		if err != nil {
+			return user, err
+		}
+		if user, err = h.userRepo.ClaimUnverifiedUser(user.ID, encryptedPassword); err != nil {
+			return user, err
+		}
+	case !user.EmailVerified:
+		if _, err = h.userRepo.VerifyUser(claims.Email); err != nil {
+			return user, err
+		}
+	}
+	if _, err = h.identityRepo.AddUserIdentity(types.AddUserIdentityInput{
+		UserID: user.ID,
+		Provider: providerName,
+		Subject: claims.Subject,
+		Email: claims.Email,
+	}); err != nil {
+		return user, err
+	}
+	if !isNewUser {
+		if err = sendIdentityLinkedEmail(user.Email, providerName); err != nil {
+			fmt.Println("unable to send identity linked email", err)
+		}
+	}
+	return h.userRepo.RetrieveUserByID(user.ID)
+}
+
+// randomPasswordHash is the password of an account that can only be signed into through a provider, until the user sets one with forgot password
+func randomPasswordHash() (string, error) {
+	randomPassword, err := utils.GenerateSecureToken()
+	if err != nil {
+		return "", err
+	}
+	return utils.EncryptPassword(randomPassword)
+}
+
+func sendIdentityLinkedEmail(userEmail string, providerName string)error {
+	err := utils.SendMail([]string{userEmail}, "A new sign in method was added to your account", fmt.Sprintf("Your %s account was just linked to your account and can now be used to sign in. If this wasn't you, please reset your password at %s/auth/forgot-password", providerName, constants.FrontendUrl))
+	return err
+}
@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/kaasikodes/e-commerce-go/services"
+	"github.com/kaasikodes/e-commerce-go/types"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+// fakeOIDCClient stands in for a provider that has already authenticated the user
+type fakeOIDCClient struct {
+	claims types.OIDCClaims
+}
+
+func (c *fakeOIDCClient) AuthCodeURL(state, nonce, codeChallenge string) (string, error) {
+	return "https://provider.example.com/authorize?state=" + state, nil
+}
+func (c *fakeOIDCClient) Exchange(code, codeVerifier string) (types.OIDCTokenResponse, error) {
+	return types.OIDCTokenResponse{IDToken: "id-token"}, nil
+}
+func (c *fakeOIDCClient) VerifyIDToken(rawIDToken, nonce string) (types.OIDCClaims, error) {
+	return c.claims, nil
+}
+
+func newOIDCCallbackRequest() *http.Request {
+	body, _ := json.Marshal(types.OIDCCallbackInput{Code: "code", State: "state-1"})
+	req := httptest.NewRequest(http.MethodPost, "/auth/oidc/google/callback", bytes.NewReader(body))
+	return mux.SetURLVars(req, map[string]string{"provider": "google"})
+}
+
+func expectOIDCLoginState(mock sqlmock.Sqlmock) {
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM OIDCLoginState").WithArgs("state-1").
+		WillReturnRows(sqlmock.NewRows([]string{"State", "Provider", "Nonce", "CodeVerifier", "CreatedAt", "ExpiresAt"}).
+			AddRow("state-1", "google", "nonce-1", "verifier", time.Now(), time.Now().Add(time.Minute)))
+	mock.ExpectExec("DELETE FROM OIDCLoginState").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+}
+
+func TestOIDCController_CallbackLogsInLinkedIdentity(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	expectOIDCLoginState(mock)
+	mock.ExpectPrepare("SELECT (.+) FROM UserIdentity").ExpectQuery().WithArgs("google", "subject-1").
+		WillReturnRows(sqlmock.NewRows([]string{"ID", "UserID", "Provider", "Subject", "Email", "CreatedAt"}).
+			AddRow("identity-1", "user-1", "google", "subject-1", "jane@example.com", time.Now()))
+	mock.ExpectPrepare("SELECT (.+) FROM(.+)User u").ExpectQuery().WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "user_name", "user_email", "user_image", "user_password", "user_email_verified", "user_created_at", "user_updated_at", "customer_id", "customer_user_id", "customer_created_at", "customer_updated_at", "seller_id", "seller_user_id", "seller_created_at", "seller_updated_at", "user_roles"}).
+			AddRow("user-1", "Jane", "jane@example.com", nil, "hash", true, time.Now(), time.Now(), "customer-1", "user-1", time.Now(), time.Now(), nil, nil, nil, nil, "customer"))
+
+	client := &fakeOIDCClient{claims: types.OIDCClaims{Subject: "subject-1", Email: "jane@example.com", EmailVerified: true}}
+	controller := NewOIDCController(services.NewUserRepository(db), services.NewUserIdentityRepository(db), map[string]types.OIDCClient{"google": client})
+
+	w := httptest.NewRecorder()
+	controller.OIDCCallbackHandler(w, newOIDCCallbackRequest())
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v, body %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	var response struct {
+		Data struct {
+			AccessToken string `json:"accessToken"`
+			User        struct {
+				ID string `json:"id"`
+			} `json:"user"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("error parsing response body: %v", err)
+	}
+	if response.Data.AccessToken == "" || response.Data.User.ID != "user-1" {
+		t.Errorf("handler returned unexpected auth data: %s", w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
+
+func TestOIDCController_CallbackRejectsUnverifiedEmail(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	expectOIDCLoginState(mock)
+	mock.ExpectPrepare("SELECT (.+) FROM UserIdentity").ExpectQuery().WithArgs("google", "subject-2").
+		WillReturnRows(sqlmock.NewRows([]string{"ID", "UserID", "Provider", "Subject", "Email", "CreatedAt"}))
+
+	// an unverified email must never be linked to the existing account that uses it
+	client := &fakeOIDCClient{claims: types.OIDCClaims{Subject: "subject-2", Email: "jane@example.com", EmailVerified: false}}
+	controller := NewOIDCController(services.NewUserRepository(db), services.NewUserIdentityRepository(db), map[string]types.OIDCClient{"google": client})
+
+	w := httptest.NewRecorder()
+	controller.OIDCCallbackHandler(w, newOIDCCallbackRequest())
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusBadRequest)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,275 @@
+package controllers
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/types"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+type SellerOnboardingController struct {
+	onboardingRepo types.SellerOnboardingRepository
+	userRepo types.UserRepository
+	storage types.FileStorage // private storage, documents are only served through the admin endpoint
+}
+
+func NewSellerOnboardingController(onboardingRepo types.SellerOnboardingRepository, userRepo types.UserRepository, storage types.FileStorage) *SellerOnboardingController {
+	return &SellerOnboardingController{
+		onboardingRepo: onboardingRepo,
+		userRepo: userRepo,
+		storage: storage,
+	}
+}
+
+func (c *SellerOnboardingController) GetOnboardingHandler(w http.ResponseWriter, r *http.Request)  {
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	onboarding, err := c.onboardingRepo.RetrieveSellerOnboarding(user.Seller.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Seller onboarding retrieved successfully!", onboarding)
+
+}
+
+func (c *SellerOnboardingController) SaveOnboardingHandler(w http.ResponseWriter, r *http.Request)  {
+	var payload types.SaveSellerOnboardingInput
+	if err:= utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	errParsed := utils.ValidatePayload(payload)
+	if len(errParsed) > 0{
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, errParsed)
+		return
+	}
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	onboarding, err := c.onboardingRepo.SaveSellerOnboarding(user.Seller.ID, payload)
+	if err == constants.ErrSellerOnboardingNotEditable {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Seller onboarding saved successfully!", onboarding)
+
+}
+
+// Upload document, expects a multipart form with the document type in the type field and the file in the document field
+func (c *SellerOnboardingController) UploadDocumentHandler(w http.ResponseWriter, r *http.Request)  {
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	// leave some room for the rest of the multipart body
+	r.Body = http.MaxBytesReader(w, r.Body, constants.MaxSellerDocumentSize + 1 << 10)
+	if err = r.ParseMultipartForm(constants.MaxSellerDocumentSize); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{constants.ErrInvalidSellerDocument})
+		return
+	}
+	documentType := r.FormValue("type")
+	if err = utils.Validate.Var(documentType, "required,oneof=government_id proof_of_address business_registration"); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	file, header, err := r.FormFile("document")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	defer file.Close()
+	if header.Size > constants.MaxSellerDocumentSize {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{constants.ErrInvalidSellerDocument})
+		return
+	}
+	// the content type is worked out from the file itself, not from what the client says it is
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{constants.ErrInvalidSellerDocument})
+		return
+	}
+	contentType := http.DetectContentType(head[:n])
+	ext, ok := constants.SellerDocumentContentTypes[contentType]
+	if !ok {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{constants.ErrInvalidSellerDocument})
+		return
+	}
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	name, _ := utils.GenerateRandomID(20)
+	fileUrl, err := c.storage.Save("seller-documents", name + ext, file)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	document, err := c.onboardingRepo.AddSellerDocument(user.Seller.ID, types.AddSellerDocumentInput{
+		Type: documentType,
+		FileUrl: fileUrl,
+		ContentType: contentType,
+	})
+	if err != nil {
+		c.storage.Delete(fileUrl)
+		status, msg := http.StatusInternalServerError, constants.MsgInternalServerError
+		if err == constants.ErrSellerOnboardingNotEditable {
+			status, msg = http.StatusBadRequest, constants.MsgValidationError
+		}
+		utils.WriteError(w, status, msg, []error{err})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Document uploaded successfully!", document)
+
+}
+
+func (c *SellerOnboardingController) SubmitOnboardingHandler(w http.ResponseWriter, r *http.Request)  {
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	onboarding, err := c.onboardingRepo.SubmitSellerOnboarding(user.Seller.ID)
+	if err == constants.ErrSellerOnboardingNotEditable || err == constants.ErrSellerOnboardingIncomplete {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Seller onboarding submitted for review!", onboarding)
+
+}
+
+// admin
+func (c *SellerOnboardingController) GetOnboardingsHandler(w http.ResponseWriter, r *http.Request)  {
+	// get query params
+	pageSizeStr := r.URL.Query().Get(constants.QueryPageSize)
+	pageSize, err := strconv.Atoi(pageSizeStr)
+	if err != nil && pageSizeStr != "" {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{constants.ErrPageSizeNotValid})
+		return
+	}
+	status := r.URL.Query().Get("status")
+	if err = utils.Validate.Var(status, "omitempty,oneof=draft submitted approved rejected"); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	data, err := c.onboardingRepo.RetrieveSellerOnboardings(types.RetrieveSellerOnboardingsInput{
+		Pagination: types.Pagination{
+			PageSize: pageSize,
+			NextCursor: r.URL.Query().Get("nextCursor"),
+		},
+		Status: status,
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Seller onboardings retrieved successfully!", data)
+
+}
+
+func (c *SellerOnboardingController) GetSellerOnboardingHandler(w http.ResponseWriter, r *http.Request)  {
+	sellerId := mux.Vars(r)["sellerId"]
+	onboarding, err := c.onboardingRepo.RetrieveSellerOnboarding(sellerId)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Seller onboarding retrieved successfully!", onboarding)
+
+}
+
+func (c *SellerOnboardingController) GetSellerDocumentHandler(w http.ResponseWriter, r *http.Request)  {
+	vars := mux.Vars(r)
+	document, err := c.onboardingRepo.RetrieveSellerDocument(vars["sellerId"], vars["documentId"])
+	if err == constants.ErrSellerDocumentNotFound {
+		utils.WriteError(w, http.StatusNotFound, constants.MsgValidationError, []error{err})
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	file, err := c.storage.Open(document.FileUrl)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	defer file.Close()
+	w.Header().Set("Content-Type", document.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%s%s"`, document.Type, document.ID, constants.SellerDocumentContentTypes[document.ContentType]))
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, file)
+
+}
+
+func (c *SellerOnboardingController) ReviewOnboardingHandler(w http.ResponseWriter, r *http.Request)  {
+	sellerId := mux.Vars(r)["sellerId"]
+	var payload types.ReviewSellerOnboardingInput
+	if err:= utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	errParsed := utils.ValidatePayload(payload)
+	if len(errParsed) > 0{
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, errParsed)
+		return
+	}
+	// the seller needs to know what to fix
+	if payload.Status == constants.SellerOnboardingStatuses.Rejected && payload.Comment == "" {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{constants.ErrReviewCommentRequired})
+		return
+	}
+	reviewer, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	onboarding, err := c.onboardingRepo.ReviewSellerOnboarding(sellerId, reviewer.ID, payload)
+	if err == constants.ErrSellerOnboardingNotSubmitted {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	if err = c.sendOnboardingReviewedEmail(sellerId, onboarding.Status, onboarding.ReviewerComment); err != nil {
+		log.Println("unable to send onboarding review email to seller", sellerId, err)
+	}
+	utils.WriteJson(w, http.StatusOK, "Seller onboarding reviewed successfully!", onboarding)
+
+}
+
+// private
+func (c *SellerOnboardingController) sendOnboardingReviewedEmail(sellerId string, status string, comment string) error {
+	user, err := c.userRepo.RetrieveUserBySellerID(sellerId)
+	if err != nil {
+		return err
+	}
+	if status == constants.SellerOnboardingStatuses.Approved {
+		return utils.SendMail([]string{user.Email}, "Your seller account has been approved", fmt.Sprintf("Your seller onboarding has been approved, you can now list products: %s/seller/products", constants.FrontendUrl))
+	}
+	return utils.SendMail([]string{user.Email}, "Your seller onboarding needs changes", fmt.Sprintf("Your seller onboarding was not approved for the following reason: %s\n\nPlease update your details and submit again: %s/seller/onboarding", comment, constants.FrontendUrl))
+}
@@ -50,29 +50,58 @@ func (c *OrderController) GetOrdersHandler(w http.ResponseWriter, r *http.Reques
 		
 }
 
-func (c *OrderController) DeleteOrderHandler(w http.ResponseWriter, r *http.Request)  {
-	repo := c.orderRepo
-	id := mux.Vars(r)["id"]
-
-	
-
-	// delete product
-	 err := repo.DeleteOrder(id)
+func (c *OrderController) CancelOrderHandler(w http.ResponseWriter, r *http.Request)  {
+	var payload types.CancelOrderInput
+	// a reason is optional for customers
+	if r.ContentLength != 0 {
+		if err:= utils.ParseJSON(r, &payload); err != nil {
+			utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+			return
+		}
+	}
+	errParsed := utils.ValidatePayload(payload)
+	if len(errParsed) > 0{
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, errParsed)
+		return
+	}
+	user, err := utils.RetrieveUserFromRequestContext(r)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
 		return
 	}
-	utils.WriteJson(w, http.StatusOK, "Order deleted successfully!",  nil)
-		
+	order, err := c.orderRepo.CancelOrder(mux.Vars(r)["id"], user.Customer.ID, payload)
+	if err == constants.ErrOrderNotFound {
+		utils.WriteError(w, http.StatusNotFound, constants.MsgValidationError, []error{err})
+		return
+	}
+	if err == constants.ErrOrderNotCancellable || err == constants.ErrOrderAlreadyCancelled {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Order cancelled successfully!",  order)
+
 }
 
 func (c *OrderController) GetOrderHandler(w http.ResponseWriter, r *http.Request)  {
 	repo := c.orderRepo
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
 	// get query params
 	id := mux.Vars(r)["id"]
 
-	
+	// order numbers are sequential, so an order of another customer is not found rather than forbidden
 	order, err := repo.RetrieveOrder(id)
+	if err == constants.ErrOrderNotFound || (err == nil && order.CustomerID != user.Customer.ID) {
+		utils.WriteError(w, http.StatusNotFound, constants.MsgValidationError, []error{constants.ErrOrderNotFound})
+		return
+	}
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
 		return
@@ -80,3 +109,122 @@ func (c *OrderController) GetOrderHandler(w http.ResponseWriter, r *http.Request
 	utils.WriteJson(w, http.StatusOK, "Order retrieved successfully!",  order)
 		
 }
+
+// seller
+func (c *OrderController) GetSellerOrdersHandler(w http.ResponseWriter, r *http.Request)  {
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	// get query params
+	pageSizeStr := r.URL.Query().Get(constants.QueryPageSize)
+	pageSize, err := strconv.Atoi(pageSizeStr)
+	if err != nil && pageSizeStr != "" {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{constants.ErrPageSizeNotValid})
+		return
+	}
+	status := r.URL.Query().Get("status")
+	if err = utils.Validate.Var(status, "omitempty,oneof=pending processing shipped delivered cancelled"); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	orders, err := c.orderRepo.RetrieveSellerOrders(types.RetrieveSellerOrdersInput{
+		Pagination: types.Pagination{
+			PageSize: pageSize,
+			NextCursor: r.URL.Query().Get("nextCursor"),
+		},
+		Status: status,
+	}, user.Seller.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Orders retrieved successfully!",  orders)
+
+}
+
+func (c *OrderController) GetSellerOrderHandler(w http.ResponseWriter, r *http.Request)  {
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	order, err := c.orderRepo.RetrieveSellerOrder(mux.Vars(r)["id"], user.Seller.ID)
+	if err == constants.ErrSubOrderNotFound {
+		utils.WriteError(w, http.StatusNotFound, constants.MsgValidationError, []error{err})
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Order retrieved successfully!",  order)
+
+}
+
+func (c *OrderController) UpdateSellerOrderStatusHandler(w http.ResponseWriter, r *http.Request)  {
+	var payload types.UpdateSubOrderStatusInput
+	if err:= utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	errParsed := utils.ValidatePayload(payload)
+	if len(errParsed) > 0{
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, errParsed)
+		return
+	}
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	order, err := c.orderRepo.UpdateSubOrderStatus(mux.Vars(r)["id"], user.Seller.ID, payload)
+	if err == constants.ErrSubOrderNotFound {
+		utils.WriteError(w, http.StatusNotFound, constants.MsgValidationError, []error{err})
+		return
+	}
+	if err == constants.ErrInvalidSubOrderStatus {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Order status updated successfully!",  order)
+
+}
+
+func (c *OrderController) CancelSellerOrderHandler(w http.ResponseWriter, r *http.Request)  {
+	var payload types.CancelSubOrderInput
+	if err:= utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	errParsed := utils.ValidatePayload(payload)
+	if len(errParsed) > 0{
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, errParsed)
+		return
+	}
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	order, err := c.orderRepo.CancelSubOrder(mux.Vars(r)["id"], user.Seller.ID, payload)
+	if err == constants.ErrSubOrderNotFound {
+		utils.WriteError(w, http.StatusNotFound, constants.MsgValidationError, []error{err})
+		return
+	}
+	if err == constants.ErrOrderNotCancellable || err == constants.ErrOrderAlreadyCancelled {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Order cancelled successfully!",  order)
+
+}
@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/models"
+	"github.com/kaasikodes/e-commerce-go/types"
+)
+
+type fakeOrderRepository struct {
+	types.OrderRepository
+	order models.Order
+}
+
+func (r *fakeOrderRepository) RetrieveOrder(id string) (models.Order, error) {
+	if id != r.order.ID && id != r.order.Number {
+		return models.Order{}, constants.ErrOrderNotFound
+	}
+	return r.order, nil
+}
+
+func getOrder(controller *OrderController, customerID string, id string) *httptest.ResponseRecorder {
+	user := models.User{ID: "user-1", Customer: &models.Customer{ID: customerID}}
+	req := httptest.NewRequest(http.MethodGet, "/orders/"+id, nil)
+	req = req.WithContext(context.WithValue(req.Context(), constants.JWTAuthUserContextKey, user))
+	req = mux.SetURLVars(req, map[string]string{"id": id})
+	w := httptest.NewRecorder()
+	controller.GetOrderHandler(w, req)
+	return w
+}
+
+func TestOrderController_GetOrderHandler_ForeignOrder(t *testing.T) {
+	repo := &fakeOrderRepository{order: models.Order{ID: "order-1", Number: "ORD-2024-000001", CustomerID: "customer-1"}}
+	controller := NewOrderController(repo, nil)
+
+	// order numbers are sequential, another customer can't read an order by guessing its number
+	w := getOrder(controller, "customer-2", "ORD-2024-000001")
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %v, want %v", w.Code, http.StatusNotFound)
+	}
+	w = getOrder(controller, "customer-1", "ORD-2024-000001")
+	if w.Code != http.StatusOK {
+		t.Errorf("status = %v, want %v", w.Code, http.StatusOK)
+	}
+}
+
+func TestOrderController_GetOrderHandler_ShipmentsOnlyForOwner(t *testing.T) {
+	repo := &fakeOrderRepository{order: models.Order{ID: "order-1", Number: "ORD-2024-000001", CustomerID: "customer-1",
+		SubOrders: []models.SubOrder{{ID: "sub-order-1", Shipments: []models.Shipment{{ID: "shipment-1", Carrier: "GIG", TrackingNumber: "TRACK-123"}}}}}}
+	controller := NewOrderController(repo, nil)
+
+	w := getOrder(controller, "customer-2", "order-1")
+	if strings.Contains(w.Body.String(), "TRACK-123") {
+		t.Errorf("the tracking number of another customer's order was sent: %s", w.Body.String())
+	}
+	w = getOrder(controller, "customer-1", "order-1")
+	if !strings.Contains(w.Body.String(), "TRACK-123") {
+		t.Errorf("the tracking number is missing from the customer's order: %s", w.Body.String())
+	}
+}
@@ -0,0 +1,328 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/types"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+type PayoutController struct {
+	ledgerRepo types.LedgerRepository
+	payoutRepo types.PayoutRepository
+	gateway types.PayoutGateway
+}
+
+func NewPayoutController(ledgerRepo types.LedgerRepository, payoutRepo types.PayoutRepository, gateway types.PayoutGateway) *PayoutController {
+	return &PayoutController{
+		ledgerRepo: ledgerRepo,
+		payoutRepo: payoutRepo,
+		gateway: gateway,
+	}
+}
+
+// seller
+func (c *PayoutController) GetBalanceHandler(w http.ResponseWriter, r *http.Request)  {
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	balance, err := c.ledgerRepo.RetrieveSellerBalance(user.Seller.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Balance retrieved successfully!", balance)
+
+}
+
+// Statement for a period, from and to are dates (2006-01-02) and both days are included. It defaults to the current month
+func (c *PayoutController) GetStatementHandler(w http.ResponseWriter, r *http.Request)  {
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if fromStr := r.URL.Query().Get("from"); fromStr != "" {
+		if from, err = time.ParseInLocation("2006-01-02", fromStr, now.Location()); err != nil {
+			utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{constants.ErrInvalidStatementPeriod})
+			return
+		}
+	}
+	if toStr := r.URL.Query().Get("to"); toStr != "" {
+		if to, err = time.ParseInLocation("2006-01-02", toStr, now.Location()); err != nil {
+			utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{constants.ErrInvalidStatementPeriod})
+			return
+		}
+	}
+	if to.Before(from) {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{constants.ErrInvalidStatementPeriod})
+		return
+	}
+	statement, err := c.ledgerRepo.RetrieveSellerStatement(user.Seller.ID, from, to.AddDate(0, 0, 1))
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Statement retrieved successfully!", statement)
+
+}
+
+func (c *PayoutController) RequestPayoutHandler(w http.ResponseWriter, r *http.Request)  {
+	var payload types.RequestPayoutInput
+	if err:= utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	errParsed := utils.ValidatePayload(payload)
+	if len(errParsed) > 0{
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, errParsed)
+		return
+	}
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	payout, err := c.payoutRepo.RequestPayout(user.Seller.ID, payload)
+	if err == constants.ErrPayoutBelowMinimum || err == constants.ErrInsufficientBalance || err == constants.ErrSellerNotApproved {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	utils.WriteJson(w, http.StatusCreated, "Payout requested successfully!", payout)
+
+}
+
+func (c *PayoutController) GetPayoutsHandler(w http.ResponseWriter, r *http.Request)  {
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	input, err := payoutsInputFromRequest(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	payouts, err := c.payoutRepo.RetrieveSellerPayouts(user.Seller.ID, input)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Payouts retrieved successfully!", payouts)
+
+}
+
+// admin
+func (c *PayoutController) GetCommissionRatesHandler(w http.ResponseWriter, r *http.Request)  {
+	rates, err := c.ledgerRepo.RetrieveCommissionRates()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Commission rates retrieved successfully!", map[string]interface{}{
+		"defaultRateBps": constants.DefaultCommissionRateBps,
+		"rates": rates,
+	})
+
+}
+
+func (c *PayoutController) SaveCommissionRateHandler(w http.ResponseWriter, r *http.Request)  {
+	var payload types.SaveCommissionRateInput
+	if err:= utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	errParsed := utils.ValidatePayload(payload)
+	if len(errParsed) > 0{
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, errParsed)
+		return
+	}
+	rate, err := c.ledgerRepo.SaveCommissionRate(payload)
+	if err == constants.ErrCommissionScopeNotFound {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Commission rate saved successfully!", rate)
+
+}
+
+func (c *PayoutController) DeleteCommissionRateHandler(w http.ResponseWriter, r *http.Request)  {
+	vars := mux.Vars(r)
+	err := c.ledgerRepo.DeleteCommissionRate(vars["scope"], vars["scopeId"])
+	if err == constants.ErrCommissionRateNotFound {
+		utils.WriteError(w, http.StatusNotFound, constants.MsgValidationError, []error{err})
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Commission rate removed!", nil)
+
+}
+
+func (c *PayoutController) GetAllPayoutsHandler(w http.ResponseWriter, r *http.Request)  {
+	input, err := payoutsInputFromRequest(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	payouts, err := c.payoutRepo.RetrievePayouts(input)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Payouts retrieved successfully!", payouts)
+
+}
+
+// Create payout batch, gateway batches are sent straight away while manual ones are exported and completed one by one once transferred
+func (c *PayoutController) CreatePayoutBatchHandler(w http.ResponseWriter, r *http.Request)  {
+	var payload types.CreatePayoutBatchInput
+	if err:= utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	errParsed := utils.ValidatePayload(payload)
+	if len(errParsed) > 0{
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, errParsed)
+		return
+	}
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	batch, err := c.payoutRepo.CreatePayoutBatch(user.ID, payload)
+	if err == constants.ErrNoPayoutsToBatch {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	if batch.Method == constants.PayoutMethods.Gateway {
+		// one failed transfer doesn't stop the rest, it is returned to the seller's balance
+		for _, payout := range batch.Payouts {
+			input := types.CompletePayoutInput{Status: constants.PayoutStatuses.Paid}
+			input.GatewayReference, err = c.gateway.Transfer(payout)
+			if err != nil {
+				input.Status, input.FailureReason = constants.PayoutStatuses.Failed, err.Error()
+			}
+			if _, err = c.payoutRepo.CompletePayout(payout.ID, input); err != nil {
+				log.Println("unable to complete payout", payout.ID, "of batch", batch.ID, err)
+			}
+		}
+		if batch, err = c.payoutRepo.RetrievePayoutBatch(batch.ID); err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+			return
+		}
+	}
+	utils.WriteJson(w, http.StatusCreated, "Payout batch created successfully!", batch)
+
+}
+
+func (c *PayoutController) GetPayoutBatchHandler(w http.ResponseWriter, r *http.Request)  {
+	batch, err := c.payoutRepo.RetrievePayoutBatch(mux.Vars(r)["id"])
+	if err == constants.ErrPayoutBatchNotFound {
+		utils.WriteError(w, http.StatusNotFound, constants.MsgValidationError, []error{err})
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Payout batch retrieved successfully!", batch)
+
+}
+
+// Export payout batch as a csv for manual bank transfers, amounts are in naira
+func (c *PayoutController) ExportPayoutBatchHandler(w http.ResponseWriter, r *http.Request)  {
+	batch, err := c.payoutRepo.RetrievePayoutBatch(mux.Vars(r)["id"])
+	if err == constants.ErrPayoutBatchNotFound {
+		utils.WriteError(w, http.StatusNotFound, constants.MsgValidationError, []error{err})
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="payout-batch-%s.csv"`, batch.ID))
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"payout_id", "seller_id", "bank_name", "bank_code", "account_number", "account_name", "amount", "status"})
+	for _, payout := range batch.Payouts {
+		writer.Write([]string{payout.ID, payout.SellerID, payout.BankName, payout.BankCode, payout.BankAccountNumber, payout.BankAccountName, fmt.Sprintf("%d.%02d", payout.Amount / 100, payout.Amount % 100), payout.Status})
+	}
+	writer.Flush()
+
+}
+
+func (c *PayoutController) CompletePayoutHandler(w http.ResponseWriter, r *http.Request)  {
+	var payload types.CompletePayoutInput
+	if err:= utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	errParsed := utils.ValidatePayload(payload)
+	if len(errParsed) > 0{
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, errParsed)
+		return
+	}
+	payout, err := c.payoutRepo.CompletePayout(mux.Vars(r)["id"], payload)
+	if err == constants.ErrPayoutNotFound {
+		utils.WriteError(w, http.StatusNotFound, constants.MsgValidationError, []error{err})
+		return
+	}
+	if err == constants.ErrPayoutNotProcessing {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Payout completed successfully!", payout)
+
+}
+
+func payoutsInputFromRequest(r *http.Request) (types.RetrievePayoutsInput, error) {
+	input := types.RetrievePayoutsInput{}
+	pageSizeStr := r.URL.Query().Get(constants.QueryPageSize)
+	pageSize, err := strconv.Atoi(pageSizeStr)
+	if err != nil && pageSizeStr != "" {
+		return input, constants.ErrPageSizeNotValid
+	}
+	input.Status = r.URL.Query().Get("status")
+	if err = utils.Validate.Var(input.Status, "omitempty,oneof=requested processing paid failed"); err != nil {
+		return input, err
+	}
+	input.Pagination = types.Pagination{
+		PageSize: pageSize,
+		NextCursor: r.URL.Query().Get("nextCursor"),
+	}
+	return input, nil
+}
@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/types"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+type PriceController struct {
+	priceRepo types.PriceRepository
+}
+
+func NewPriceController(priceRepo types.PriceRepository) *PriceController {
+	return &PriceController{
+		priceRepo: priceRepo,
+	}
+}
+
+func (c *PriceController) GetPriceHistoryHandler(w http.ResponseWriter, r *http.Request)  {
+	pageSizeStr := r.URL.Query().Get(constants.QueryPageSize)
+	pageSize, err := strconv.Atoi(pageSizeStr)
+	if err != nil && pageSizeStr != "" {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{constants.ErrPageSizeNotValid})
+		return
+	}
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	history, err := c.priceRepo.RetrievePriceHistory(mux.Vars(r)["id"], user.Seller.ID, types.Pagination{
+		PageSize: pageSize,
+		NextCursor: r.URL.Query().Get("nextCursor"),
+	})
+	if err != nil {
+		writePriceError(w, err)
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Price history retrieved successfully!", history)
+}
+
+func (c *PriceController) GetSalesHandler(w http.ResponseWriter, r *http.Request)  {
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	sales, err := c.priceRepo.RetrieveSales(mux.Vars(r)["id"], user.Seller.ID)
+	if err != nil {
+		writePriceError(w, err)
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Sales retrieved successfully!", sales)
+}
+
+// the product is sold at the sale price between the two times, then goes back to its regular price
+func (c *PriceController) ScheduleSaleHandler(w http.ResponseWriter, r *http.Request)  {
+	var payload types.ScheduleSaleInput
+	if err:= utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	errParsed := utils.ValidatePayload(payload)
+	if len(errParsed) > 0{
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, errParsed)
+		return
+	}
+	if !payload.EndsAt.After(payload.StartsAt) || !payload.EndsAt.After(time.Now()) {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{constants.ErrSaleWindowNotValid})
+		return
+	}
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	sale, err := c.priceRepo.ScheduleSale(mux.Vars(r)["id"], user.Seller.ID, payload)
+	if err != nil {
+		writePriceError(w, err)
+		return
+	}
+	utils.WriteJson(w, http.StatusCreated, "Sale scheduled successfully!", sale)
+}
+
+// a sale that is running is ended straight away
+func (c *PriceController) CancelSaleHandler(w http.ResponseWriter, r *http.Request)  {
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	sale, err := c.priceRepo.CancelSale(mux.Vars(r)["saleId"], mux.Vars(r)["id"], user.Seller.ID)
+	if err != nil {
+		writePriceError(w, err)
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Sale cancelled successfully!", sale)
+}
+
+// private
+func writePriceError(w http.ResponseWriter, err error) {
+	switch err {
+	case constants.ErrProductNotFound, constants.ErrSaleNotFound:
+		utils.WriteError(w, http.StatusNotFound, constants.MsgValidationError, []error{err})
+	case constants.ErrSalePriceNotLower, constants.ErrSaleOverlaps, constants.ErrSaleOver:
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+	default:
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+	}
+}
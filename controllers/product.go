@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/kaasikodes/e-commerce-go/constants"
@@ -17,12 +18,14 @@ import (
 type ProductController struct {
 	productRepo types.ProductRepository
 	categoryRepo types.CategoryRepository
+	questionRepo types.QuestionRepository
 }
 
-func NewProductController(productRepo types.ProductRepository , categoryRepo types.CategoryRepository) *ProductController {
+func NewProductController(productRepo types.ProductRepository , categoryRepo types.CategoryRepository, questionRepo types.QuestionRepository) *ProductController {
 	return &ProductController{
 		productRepo: productRepo,
 		categoryRepo: categoryRepo,
+		questionRepo: questionRepo,
 	}
 }
 
@@ -63,12 +66,16 @@ func (c *ProductController) DeleteProductHandler(w http.ResponseWriter, r *http.
 	repo := c.productRepo
 	id := mux.Vars(r)["id"]
 
-	
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
 
 	// delete product
-	product, err := repo.DeleteProduct(id)
+	product, err := repo.DeleteProduct(id, user.Seller.ID)
 	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		writeProductError(w, err)
 		return
 	}
 	utils.WriteJson(w, http.StatusOK, "Product deleted successfully!",  product)
@@ -93,11 +100,16 @@ func (c *ProductController) EditProductHandler(w http.ResponseWriter, r *http.Re
 		return
 	}
 	
-	product, err := repo.UpdateProduct(id, payload)
+	user, err := utils.RetrieveUserFromRequestContext(r)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
 		return
 	}
+	product, err := repo.UpdateProduct(id, user.Seller.ID, payload)
+	if err != nil {
+		writeProductError(w, err)
+		return
+	}
 	utils.WriteJson(w, http.StatusOK, "Product updated successfully!",  product)
 		
 }
@@ -109,10 +121,27 @@ func (c *ProductController) GetProductHandler(w http.ResponseWriter, r *http.Req
 
 	
 	product, err := repo.RetrieveProductByID(id,)
+	if err != nil {
+		writeProductError(w, err)
+		return
+	}
+	// products that are not for sale are only shown to their seller
+	user, err := utils.RetrieveUserFromRequestContext(r)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
 		return
 	}
+	if product.Status != constants.ProductStatuses.Active && (user.Seller == nil || user.Seller.ID != product.SellerID) {
+		writeProductError(w, constants.ErrProductNotFound)
+		return
+	}
+	// the latest questions are shown with the product, the rest are paged through /products/{id}/questions
+	questions, err := c.questionRepo.RetrieveQuestions(types.RetrieveQuestionsInput{ProductID: id, Pagination: types.Pagination{PageSize: constants.ProductPageQuestionCount}})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	product.Questions = questions.Data.([]models.ProductQuestion)
 	utils.WriteJson(w, http.StatusOK, "Product retrieved successfully!",  product)
 		
 }
@@ -132,10 +161,15 @@ func (c *ProductController) GetProductsHandler(w http.ResponseWriter, r *http.Re
 		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{constants.ErrPageSizeNotValid})
 		return
 	}
+	status := r.URL.Query().Get("status")
+	if err = utils.Validate.Var(status, "omitempty,oneof=draft active archived"); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
 	// get products
 	products, err := repo.RetrieveProducts( types.RetrievProductsInput{Pagination: types.Pagination{
 		PageSize: pageSize,
-	}}, sellerId)
+	}, Status: status}, sellerId)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
 		return
@@ -143,6 +177,40 @@ func (c *ProductController) GetProductsHandler(w http.ResponseWriter, r *http.Re
 	utils.WriteJson(w, http.StatusOK, "Products retrieved successfully!",  products)
 		
 }
+// publishes, unpublishes or archives one of the seller's products, now or at the times given
+func (c *ProductController) UpdateProductStatusHandler(w http.ResponseWriter, r *http.Request)  {
+	var payload types.UpdateProductStatusInput
+	if err:= utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	errParsed := utils.ValidatePayload(payload)
+	if len(errParsed) > 0{
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, errParsed)
+		return
+	}
+	now := time.Now()
+	if payload.PublishAt != nil && (payload.Status != constants.ProductStatuses.Draft || !payload.PublishAt.After(now)) {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{constants.ErrPublishAtNotValid})
+		return
+	}
+	if payload.UnpublishAt != nil && (payload.Status == constants.ProductStatuses.Archived || !payload.UnpublishAt.After(now) || (payload.PublishAt != nil && !payload.UnpublishAt.After(*payload.PublishAt))) {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{constants.ErrUnpublishAtNotValid})
+		return
+	}
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	product, err := c.productRepo.UpdateProductStatus(mux.Vars(r)["id"], user.Seller.ID, payload)
+	if err != nil {
+		writeProductError(w, err)
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Product status updated successfully!", product)
+}
+
 const (
 	productName int = iota 
 	productDescription
@@ -270,3 +338,13 @@ func (c *ProductController) GetImportProductTemplateHandler(w http.ResponseWrite
 	}
 		
 }
+
+// private
+func writeProductError(w http.ResponseWriter, err error) {
+	switch err {
+	case constants.ErrProductNotFound:
+		utils.WriteError(w, http.StatusNotFound, constants.MsgValidationError, []error{err})
+	default:
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+	}
+}
@@ -0,0 +1,296 @@
+package controllers
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/models"
+	"github.com/kaasikodes/e-commerce-go/types"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+type ProfileController struct {
+	userRepo types.UserRepository
+	tokenRepo types.TokenRepository
+	auditRepo types.UserAuditLogRepository
+	storage types.FileStorage
+}
+
+func NewProfileController(userRepo types.UserRepository, tokenRepo types.TokenRepository, auditRepo types.UserAuditLogRepository, storage types.FileStorage) *ProfileController {
+	return &ProfileController{
+		userRepo: userRepo,
+		tokenRepo: tokenRepo,
+		auditRepo: auditRepo,
+		storage: storage,
+	}
+}
+
+// audit actions
+const (
+	auditActionProfileUpdated = "profile.updated"
+	auditActionEmailChangeRequested = "email.change_requested"
+	auditActionEmailChanged = "email.changed"
+	auditActionAvatarUpdated = "avatar.updated"
+	auditActionRoleAdded = "role.added"
+)
+
+func (c *ProfileController) UpdateProfileHandler(w http.ResponseWriter, r *http.Request)  {
+	var payload types.UpdateUserProfileInput
+	if err:= utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	errParsed := utils.ValidatePayload(payload)
+	if len(errParsed) > 0{
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, errParsed)
+		return
+	}
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	updatedUser, err := c.userRepo.UpdateUserProfile(user.ID, payload)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	if user.Name != updatedUser.Name {
+		c.addAuditLog(r, user.ID, auditActionProfileUpdated, user.Name, updatedUser.Name)
+	}
+	utils.WriteJson(w, http.StatusOK, "Profile updated successfully!", updatedUser)
+
+}
+
+// Change email, the email is only changed once the link sent to the new email is used
+func (c *ProfileController) ChangeEmailHandler(w http.ResponseWriter, r *http.Request)  {
+	var payload types.ChangeEmailInput
+	if err:= utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	errParsed := utils.ValidatePayload(payload)
+	if len(errParsed) > 0{
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, errParsed)
+		return
+	}
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	if payload.Email == user.Email {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{constants.ErrSameEmail})
+		return
+	}
+	if _, err = c.userRepo.RetrieveUserByEmail(payload.Email); err == nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{constants.ErrEmailAlreadyInUse})
+		return
+	}
+	token, err := c.tokenRepo.CreateEmailChangeToken(types.CreateEmailChangeTokenInput{
+		UserID: user.ID,
+		NewEmail: payload.Email,
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	if err = sendEmailChangeConfirmationEmail(payload.Email, token.Token); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	c.addAuditLog(r, user.ID, auditActionEmailChangeRequested, user.Email, payload.Email)
+	utils.WriteJson(w, http.StatusOK, "A confirmation link has been sent to the new email, your email will be changed once it is used", nil)
+
+}
+
+// Confirm email change, the token proves the user owns the new email so no auth is needed
+func (c *ProfileController) ConfirmEmailChangeHandler(w http.ResponseWriter, r *http.Request)  {
+	var payload types.ConfirmEmailChangeInput
+	if err:= utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	errParsed := utils.ValidatePayload(payload)
+	if len(errParsed) > 0{
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, errParsed)
+		return
+	}
+	// the email may have been taken since the change was requested, checked first so the link isn't used up for nothing
+	if _, err := c.userRepo.RetrieveUserByEmail(payload.Email); err == nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{constants.ErrEmailAlreadyInUse})
+		return
+	}
+	// the link is used up as it is checked, so it can only change the email once
+	token, err := c.tokenRepo.ConsumeEmailChangeToken(payload.Email, payload.Token)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{constants.ErrInvalidOrExpiredToken})
+		return
+	}
+	user, err := c.userRepo.RetrieveUserByID(token.UserID)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{constants.ErrInvalidOrExpiredToken})
+		return
+	}
+	// tokens issued to the old email must not outlive it
+	for _, deleteTokens := range []func(string) error{c.tokenRepo.DeleteVerificationToken, c.tokenRepo.DeletePasswordResetToken, c.tokenRepo.DeleteMagicLinkToken} {
+		if err = deleteTokens(user.Email); err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+			return
+		}
+	}
+	updatedUser, err := c.userRepo.UpdateUserEmail(user.ID, token.NewEmail)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	c.addAuditLog(r, user.ID, auditActionEmailChanged, user.Email, updatedUser.Email)
+	// let the old email know, in case the change wasn't made by its owner
+	if err = sendEmailChangedEmail(user.Email, updatedUser.Email); err != nil {
+		log.Println("unable to notify old email of email change", user.ID, err)
+	}
+	utils.WriteJson(w, http.StatusOK, "Email changed successfully!", updatedUser)
+
+}
+
+// Upload avatar, expects a multipart form with the image in the avatar field
+func (c *ProfileController) UploadAvatarHandler(w http.ResponseWriter, r *http.Request)  {
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	// leave some room for the rest of the multipart body
+	r.Body = http.MaxBytesReader(w, r.Body, constants.MaxAvatarSize + 1 << 10)
+	if err = r.ParseMultipartForm(constants.MaxAvatarSize); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{constants.ErrInvalidAvatar})
+		return
+	}
+	file, header, err := r.FormFile("avatar")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	defer file.Close()
+	if header.Size > constants.MaxAvatarSize {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{constants.ErrInvalidAvatar})
+		return
+	}
+	// the content type is worked out from the file itself, not from what the client says it is
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{constants.ErrInvalidAvatar})
+		return
+	}
+	ext, ok := constants.AvatarContentTypes[http.DetectContentType(head[:n])]
+	if !ok {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{constants.ErrInvalidAvatar})
+		return
+	}
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	name, _ := utils.GenerateRandomID(20)
+	imageUrl, err := c.storage.Save("avatars", name + ext, file)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	updatedUser, err := c.userRepo.UpdateUserImage(user.ID, imageUrl)
+	if err != nil {
+		c.storage.Delete(imageUrl)
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	if err = c.storage.Delete(user.Image); err != nil {
+		log.Println("unable to delete previous avatar of user", user.ID, err)
+	}
+	c.addAuditLog(r, user.ID, auditActionAvatarUpdated, user.Image, imageUrl)
+	utils.WriteJson(w, http.StatusOK, "Avatar updated successfully!", updatedUser)
+
+}
+
+// Add customer role, lets a seller buy with the same account
+func (c *ProfileController) AddCustomerRoleHandler(w http.ResponseWriter, r *http.Request)  {
+	c.addRole(w, r, "customer")
+}
+
+// Add seller role, lets a customer start selling with the same account
+func (c *ProfileController) AddSellerRoleHandler(w http.ResponseWriter, r *http.Request)  {
+	c.addRole(w, r, "seller")
+}
+
+func (c *ProfileController) GetAuditLogsHandler(w http.ResponseWriter, r *http.Request)  {
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	logs, err := c.auditRepo.RetrieveUserAuditLogs(user.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Audit logs retrieved successfully!", logs)
+
+}
+
+// private
+func (c *ProfileController) addRole(w http.ResponseWriter, r *http.Request, role string) {
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	updatedUser, err := c.userRepo.AddUserRole(user.ID, role)
+	if err == constants.ErrUserRoleAlreadyAdded {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	c.addAuditLog(r, user.ID, auditActionRoleAdded, "", role)
+	// new sellers have to complete onboarding before they can sell
+	if role == "seller" {
+		if err = sendSellerOnboardingEmail(updatedUser.Email); err != nil {
+			log.Println("unable to send seller onboarding email to user", user.ID, err)
+		}
+	}
+	utils.WriteJson(w, http.StatusOK, fmt.Sprintf("The %s role has been added to your account!", role), updatedUser)
+}
+
+// the change has already been made when this is called, so a failure to log it is reported but doesn't fail the request
+func (c *ProfileController) addAuditLog(r *http.Request, userID string, action string, oldValue string, newValue string) (models.UserAuditLog, error) {
+	auditLog, err := c.auditRepo.AddUserAuditLog(types.AddUserAuditLogInput{
+		UserID: userID,
+		Action: action,
+		OldValue: oldValue,
+		NewValue: newValue,
+		IPAddress: utils.GetClientIP(r),
+		UserAgent: r.UserAgent(),
+	})
+	if err != nil {
+		log.Println("unable to add audit log", action, userID, err)
+	}
+	return auditLog, err
+}
+
+func sendEmailChangeConfirmationEmail(newEmail string, token string) error {
+	err := utils.SendMail([]string{newEmail}, "Confirm your new email", fmt.Sprintf("Confirm this is your new email by clicking on the link below, it expires in %d hours: %s/me/email-change/confirm?token=%s&email=%s", int(constants.EmailChangeTokenTTL.Hours()), constants.FrontendUrl, token, newEmail))
+	return err
+}
+func sendEmailChangedEmail(oldEmail string, newEmail string) error {
+	err := utils.SendMail([]string{oldEmail}, "Your email has been changed", fmt.Sprintf("The email of your account has been changed to %s. If you didn't make this change, please contact support immediately.", newEmail))
+	return err
+}
+func sendSellerOnboardingEmail(userEmail string) error {
+	err := utils.SendMail([]string{userEmail}, "Welcome, seller", fmt.Sprintf("Your seller account is ready. Complete your seller onboarding to start listing products: %s/seller/onboarding", constants.FrontendUrl))
+	return err
+}
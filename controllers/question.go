@@ -0,0 +1,222 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/models"
+	"github.com/kaasikodes/e-commerce-go/types"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+type QuestionController struct {
+	questionRepo types.QuestionRepository
+}
+
+func NewQuestionController(questionRepo types.QuestionRepository) *QuestionController {
+	return &QuestionController{
+		questionRepo: questionRepo,
+	}
+}
+
+// public, lists the questions of a product that are shown, the newest first
+func (c *QuestionController) GetProductQuestionsHandler(w http.ResponseWriter, r *http.Request)  {
+	pagination, err := parseQuestionPagination(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	questions, err := c.questionRepo.RetrieveQuestions(types.RetrieveQuestionsInput{Pagination: pagination, ProductID: mux.Vars(r)["id"]})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Questions retrieved successfully!", questions)
+}
+
+func (c *QuestionController) AskQuestionHandler(w http.ResponseWriter, r *http.Request)  {
+	var payload types.AskQuestionInput
+	if err:= utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	errParsed := utils.ValidatePayload(payload)
+	if len(errParsed) > 0{
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, errParsed)
+		return
+	}
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	question, err := c.questionRepo.AskQuestion(mux.Vars(r)["id"], user.Customer.ID, payload)
+	if err != nil {
+		writeQuestionError(w, err)
+		return
+	}
+	utils.WriteJson(w, http.StatusCreated, "Question asked successfully!", question)
+}
+
+// the seller of the product or customers who have bought it can answer, the asker is emailed the answer
+func (c *QuestionController) AnswerQuestionHandler(w http.ResponseWriter, r *http.Request)  {
+	var payload types.AnswerQuestionInput
+	if err:= utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	errParsed := utils.ValidatePayload(payload)
+	if len(errParsed) > 0{
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, errParsed)
+		return
+	}
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	answer, err := c.questionRepo.AnswerQuestion(mux.Vars(r)["id"], user, payload)
+	if err == constants.ErrNotAllowedToAnswer {
+		utils.WriteError(w, http.StatusForbidden, constants.MsgValidationError, []error{err})
+		return
+	}
+	if err != nil {
+		writeQuestionError(w, err)
+		return
+	}
+	// the answer has been saved by now, so a failure to notify the asker is only logged
+	question, err := c.questionRepo.RetrieveQuestion(answer.QuestionID)
+	if err != nil {
+		log.Println("unable to retrieve question", answer.QuestionID, "to notify the asker", err)
+	} else if question.AskerUserID != user.ID {
+		if err = sendQuestionAnsweredEmail(question, answer); err != nil {
+			log.Println("unable to send question answered email to user", question.AskerUserID, err)
+		}
+	}
+	utils.WriteJson(w, http.StatusCreated, "Answer added successfully!", answer)
+}
+
+// flags the question for an admin to look at, it stays up until then
+func (c *QuestionController) ReportQuestionHandler(w http.ResponseWriter, r *http.Request)  {
+	if err := c.questionRepo.ReportQuestion(mux.Vars(r)["id"]); err != nil {
+		writeQuestionError(w, err)
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Question reported successfully!", nil)
+}
+
+func (c *QuestionController) ReportAnswerHandler(w http.ResponseWriter, r *http.Request)  {
+	if err := c.questionRepo.ReportAnswer(mux.Vars(r)["id"]); err != nil {
+		writeQuestionError(w, err)
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Answer reported successfully!", nil)
+}
+
+// admins can list the questions of any status, the ones shown on product pages when no status is asked for
+func (c *QuestionController) GetAdminQuestionsHandler(w http.ResponseWriter, r *http.Request)  {
+	status := r.URL.Query().Get("status")
+	if err := utils.Validate.Var(status, "omitempty,oneof=published flagged hidden"); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	pagination, err := parseQuestionPagination(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	questions, err := c.questionRepo.RetrieveQuestions(types.RetrieveQuestionsInput{Pagination: pagination, Status: status})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Questions retrieved successfully!", questions)
+}
+
+func (c *QuestionController) GetAdminAnswersHandler(w http.ResponseWriter, r *http.Request)  {
+	status := r.URL.Query().Get("status")
+	if err := utils.Validate.Var(status, "omitempty,oneof=published flagged hidden"); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	pagination, err := parseQuestionPagination(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	answers, err := c.questionRepo.RetrieveAnswers(types.RetrieveAnswersInput{Pagination: pagination, Status: status})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Answers retrieved successfully!", answers)
+}
+
+func (c *QuestionController) ModerateQuestionHandler(w http.ResponseWriter, r *http.Request)  {
+	var payload types.ModerateQuestionInput
+	if err:= utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	errParsed := utils.ValidatePayload(payload)
+	if len(errParsed) > 0{
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, errParsed)
+		return
+	}
+	question, err := c.questionRepo.ModerateQuestion(mux.Vars(r)["id"], payload)
+	if err != nil {
+		writeQuestionError(w, err)
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Question moderated successfully!", question)
+}
+
+func (c *QuestionController) ModerateAnswerHandler(w http.ResponseWriter, r *http.Request)  {
+	var payload types.ModerateQuestionInput
+	if err:= utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	errParsed := utils.ValidatePayload(payload)
+	if len(errParsed) > 0{
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, errParsed)
+		return
+	}
+	answer, err := c.questionRepo.ModerateAnswer(mux.Vars(r)["id"], payload)
+	if err != nil {
+		writeQuestionError(w, err)
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Answer moderated successfully!", answer)
+}
+
+// private
+func parseQuestionPagination(r *http.Request) (types.Pagination, error) {
+	pageSizeStr := r.URL.Query().Get(constants.QueryPageSize)
+	pageSize, err := strconv.Atoi(pageSizeStr)
+	if err != nil && pageSizeStr != "" {
+		return types.Pagination{}, constants.ErrPageSizeNotValid
+	}
+	return types.Pagination{
+		PageSize: pageSize,
+		NextCursor: r.URL.Query().Get("nextCursor"),
+	}, nil
+}
+
+func writeQuestionError(w http.ResponseWriter, err error) {
+	switch err {
+	case constants.ErrQuestionNotFound, constants.ErrAnswerNotFound, constants.ErrProductNotFound:
+		utils.WriteError(w, http.StatusNotFound, constants.MsgValidationError, []error{err})
+	default:
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+	}
+}
+
+func sendQuestionAnsweredEmail(question models.ProductQuestion, answer models.ProductAnswer) error {
+	err := utils.SendMail([]string{question.AskerEmail}, "Your question has been answered", fmt.Sprintf("%s answered your question \"%s\": %s\n\nSee all the answers at %s/products/%s", answer.AuthorName, question.Question, answer.Answer, constants.FrontendUrl, question.ProductID))
+	return err
+}
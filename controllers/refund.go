@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/types"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+type RefundController struct {
+	refundRepo types.RefundRepository
+}
+
+func NewRefundController(refundRepo types.RefundRepository) *RefundController {
+	return &RefundController{
+		refundRepo: refundRepo,
+	}
+}
+
+func (c *RefundController) GetRefundsHandler(w http.ResponseWriter, r *http.Request)  {
+	pageSizeStr := r.URL.Query().Get(constants.QueryPageSize)
+	pageSize, err := strconv.Atoi(pageSizeStr)
+	if err != nil && pageSizeStr != "" {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{constants.ErrPageSizeNotValid})
+		return
+	}
+	status := r.URL.Query().Get("status")
+	if err = utils.Validate.Var(status, "omitempty,oneof=pending processing processed failed"); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	refunds, err := c.refundRepo.RetrieveRefunds(types.RetrieveRefundsInput{
+		Pagination: types.Pagination{
+			PageSize: pageSize,
+			NextCursor: r.URL.Query().Get("nextCursor"),
+		},
+		Status: status,
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Refunds retrieved successfully!", refunds)
+
+}
+
+func (c *RefundController) RetryRefundHandler(w http.ResponseWriter, r *http.Request)  {
+	refund, err := c.refundRepo.RetryRefund(mux.Vars(r)["id"])
+	if err == constants.ErrRefundNotFound {
+		utils.WriteError(w, http.StatusNotFound, constants.MsgValidationError, []error{err})
+		return
+	}
+	if err == constants.ErrRefundNotFailed {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Refund will be retried shortly!", refund)
+
+}
@@ -0,0 +1,370 @@
+package controllers
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/models"
+	"github.com/kaasikodes/e-commerce-go/types"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+type ReturnController struct {
+	returnRepo types.ReturnRepository
+	userRepo types.UserRepository
+	storage types.FileStorage // private storage, photos are only served to the customer and the seller of the return
+}
+
+func NewReturnController(returnRepo types.ReturnRepository, userRepo types.UserRepository, storage types.FileStorage) *ReturnController {
+	return &ReturnController{
+		returnRepo: returnRepo,
+		userRepo: userRepo,
+		storage: storage,
+	}
+}
+
+func (c *ReturnController) CreateReturnHandler(w http.ResponseWriter, r *http.Request)  {
+	var payload types.CreateReturnInput
+	if err:= utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	errParsed := utils.ValidatePayload(payload)
+	if len(errParsed) > 0{
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, errParsed)
+		return
+	}
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	ret, err := c.returnRepo.CreateReturn(user.Customer.ID, payload)
+	if err == constants.ErrOrderItemNotFound {
+		utils.WriteError(w, http.StatusNotFound, constants.MsgValidationError, []error{err})
+		return
+	}
+	if err == constants.ErrNotReturnable || err == constants.ErrReturnQuantityExceeded {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	utils.WriteJson(w, http.StatusCreated, "Return requested successfully!", ret)
+
+}
+
+func (c *ReturnController) UploadReturnPhotoHandler(w http.ResponseWriter, r *http.Request)  {
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	// leave some room for the rest of the multipart body
+	r.Body = http.MaxBytesReader(w, r.Body, constants.MaxReturnPhotoSize + 1 << 10)
+	if err = r.ParseMultipartForm(constants.MaxReturnPhotoSize); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{constants.ErrInvalidReturnPhoto})
+		return
+	}
+	file, header, err := r.FormFile("photo")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	defer file.Close()
+	if header.Size > constants.MaxReturnPhotoSize {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{constants.ErrInvalidReturnPhoto})
+		return
+	}
+	// the content type is worked out from the file itself, not from what the client says it is
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{constants.ErrInvalidReturnPhoto})
+		return
+	}
+	contentType := http.DetectContentType(head[:n])
+	ext, ok := constants.AvatarContentTypes[contentType]
+	if !ok {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{constants.ErrInvalidReturnPhoto})
+		return
+	}
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	name, _ := utils.GenerateRandomID(20)
+	fileUrl, err := c.storage.Save("return-photos", name + ext, file)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	photo, err := c.returnRepo.AddReturnPhoto(mux.Vars(r)["id"], user.Customer.ID, types.AddReturnPhotoInput{
+		FileUrl: fileUrl,
+		ContentType: contentType,
+	})
+	if err != nil {
+		c.storage.Delete(fileUrl)
+		status, msg := http.StatusInternalServerError, constants.MsgInternalServerError
+		if err == constants.ErrReturnNotFound {
+			status, msg = http.StatusNotFound, constants.MsgValidationError
+		}
+		if err == constants.ErrInvalidReturnStatus || err == constants.ErrTooManyReturnPhotos {
+			status, msg = http.StatusBadRequest, constants.MsgValidationError
+		}
+		utils.WriteError(w, status, msg, []error{err})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Photo uploaded successfully!", photo)
+
+}
+
+func (c *ReturnController) GetReturnsHandler(w http.ResponseWriter, r *http.Request)  {
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	c.writeReturns(w, r, types.RetrieveReturnsInput{CustomerID: user.Customer.ID})
+
+}
+
+func (c *ReturnController) GetReturnHandler(w http.ResponseWriter, r *http.Request)  {
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	ret, ok := c.retrieveReturn(w, mux.Vars(r)["id"], func(ret models.ReturnRequest) bool { return ret.CustomerID == user.Customer.ID })
+	if !ok {
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Return retrieved successfully!", ret)
+
+}
+
+func (c *ReturnController) GetReturnPhotoHandler(w http.ResponseWriter, r *http.Request)  {
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	if _, ok := c.retrieveReturn(w, mux.Vars(r)["id"], func(ret models.ReturnRequest) bool { return ret.CustomerID == user.Customer.ID }); ok {
+		c.writeReturnPhoto(w, r)
+	}
+
+}
+
+func (c *ReturnController) GetStoreCreditHandler(w http.ResponseWriter, r *http.Request)  {
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	credit, err := c.returnRepo.RetrieveStoreCredit(user.Customer.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Store credit retrieved successfully!", credit)
+
+}
+
+func (c *ReturnController) GetSellerReturnsHandler(w http.ResponseWriter, r *http.Request)  {
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	c.writeReturns(w, r, types.RetrieveReturnsInput{SellerID: user.Seller.ID})
+
+}
+
+func (c *ReturnController) GetSellerReturnHandler(w http.ResponseWriter, r *http.Request)  {
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	ret, ok := c.retrieveReturn(w, mux.Vars(r)["id"], func(ret models.ReturnRequest) bool { return ret.SellerID == user.Seller.ID })
+	if !ok {
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Return retrieved successfully!", ret)
+
+}
+
+func (c *ReturnController) GetSellerReturnPhotoHandler(w http.ResponseWriter, r *http.Request)  {
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	if _, ok := c.retrieveReturn(w, mux.Vars(r)["id"], func(ret models.ReturnRequest) bool { return ret.SellerID == user.Seller.ID }); ok {
+		c.writeReturnPhoto(w, r)
+	}
+
+}
+
+func (c *ReturnController) ApproveReturnHandler(w http.ResponseWriter, r *http.Request)  {
+	var payload types.ApproveReturnInput
+	c.updateReturn(w, r, &payload, "Return approved successfully!", func(id string, sellerID string) (models.ReturnRequest, error) {
+		return c.returnRepo.ApproveReturn(id, sellerID, payload)
+	})
+
+}
+
+func (c *ReturnController) RejectReturnHandler(w http.ResponseWriter, r *http.Request)  {
+	var payload types.RejectReturnInput
+	c.updateReturn(w, r, &payload, "Return rejected successfully!", func(id string, sellerID string) (models.ReturnRequest, error) {
+		return c.returnRepo.RejectReturn(id, sellerID, payload)
+	})
+
+}
+
+func (c *ReturnController) ReceiveReturnHandler(w http.ResponseWriter, r *http.Request)  {
+	var payload types.ReceiveReturnInput
+	c.updateReturn(w, r, &payload, "Return inspected successfully!", func(id string, sellerID string) (models.ReturnRequest, error) {
+		return c.returnRepo.ReceiveReturn(id, sellerID, payload)
+	})
+
+}
+
+func (c *ReturnController) ResolveReturnHandler(w http.ResponseWriter, r *http.Request)  {
+	var payload types.ResolveReturnInput
+	c.updateReturn(w, r, &payload, "Return resolved successfully!", func(id string, sellerID string) (models.ReturnRequest, error) {
+		return c.returnRepo.ResolveReturn(id, sellerID, payload)
+	})
+
+}
+
+// private
+// updateReturn parses and validates the payload, moves the seller's return on with update and lets the customer know
+func (c *ReturnController) updateReturn(w http.ResponseWriter, r *http.Request, payload interface{}, message string, update func(id string, sellerID string) (models.ReturnRequest, error)) {
+	if err:= utils.ParseJSON(r, payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	errParsed := utils.ValidatePayload(payload)
+	if len(errParsed) > 0{
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, errParsed)
+		return
+	}
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	ret, err := update(mux.Vars(r)["id"], user.Seller.ID)
+	if err == constants.ErrReturnNotFound {
+		utils.WriteError(w, http.StatusNotFound, constants.MsgValidationError, []error{err})
+		return
+	}
+	if err == constants.ErrInvalidReturnStatus || err == constants.ErrReplacementOutOfStock {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	// the return has been updated, so failing to send the email shouldn't fail the request
+	if err = c.sendReturnUpdatedEmail(ret); err != nil {
+		log.Println("unable to send return update email to customer", ret.CustomerID, err)
+	}
+	utils.WriteJson(w, http.StatusOK, message, ret)
+
+}
+
+// retrieveReturn writes a not found error when the return doesn't exist or isn't visible to the user
+func (c *ReturnController) retrieveReturn(w http.ResponseWriter, id string, visible func(ret models.ReturnRequest) bool) (models.ReturnRequest, bool) {
+	ret, err := c.returnRepo.RetrieveReturn(id)
+	if err == nil && !visible(ret) {
+		err = constants.ErrReturnNotFound
+	}
+	if err == constants.ErrReturnNotFound {
+		utils.WriteError(w, http.StatusNotFound, constants.MsgValidationError, []error{err})
+		return ret, false
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return ret, false
+	}
+	return ret, true
+}
+
+func (c *ReturnController) writeReturns(w http.ResponseWriter, r *http.Request, input types.RetrieveReturnsInput) {
+	pageSizeStr := r.URL.Query().Get(constants.QueryPageSize)
+	pageSize, err := strconv.Atoi(pageSizeStr)
+	if err != nil && pageSizeStr != "" {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{constants.ErrPageSizeNotValid})
+		return
+	}
+	status := r.URL.Query().Get("status")
+	if err = utils.Validate.Var(status, "omitempty,oneof=requested approved rejected received resolved"); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	input.Pagination = types.Pagination{
+		PageSize: pageSize,
+		NextCursor: r.URL.Query().Get("nextCursor"),
+	}
+	input.Status = status
+	returns, err := c.returnRepo.RetrieveReturns(input)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Returns retrieved successfully!", returns)
+}
+
+func (c *ReturnController) writeReturnPhoto(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	photo, err := c.returnRepo.RetrieveReturnPhoto(vars["id"], vars["photoId"])
+	if err == constants.ErrReturnPhotoNotFound {
+		utils.WriteError(w, http.StatusNotFound, constants.MsgValidationError, []error{err})
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	file, err := c.storage.Open(photo.FileUrl)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	defer file.Close()
+	w.Header().Set("Content-Type", photo.ContentType)
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, file)
+}
+
+func (c *ReturnController) sendReturnUpdatedEmail(ret models.ReturnRequest) error {
+	user, err := c.userRepo.RetrieveUserByCustomerID(ret.CustomerID)
+	if err != nil {
+		return err
+	}
+	link := fmt.Sprintf("%s/returns/%s", constants.FrontendUrl, ret.ID)
+	switch ret.Status {
+	case constants.ReturnStatuses.Approved:
+		body := fmt.Sprintf("Your return has been approved, please send the item back as follows:\n\n%s\n\n", ret.Instructions)
+		if ret.LabelUrl != "" {
+			body += fmt.Sprintf("Your return shipping label: %s\n\n", ret.LabelUrl)
+		}
+		return utils.SendMail([]string{user.Email}, "Your return has been approved", body + "Track your return: " + link)
+	case constants.ReturnStatuses.Rejected:
+		return utils.SendMail([]string{user.Email}, "Your return was not accepted", fmt.Sprintf("Your return was not accepted for the following reason: %s\n\nView your return: %s", ret.RejectionReason, link))
+	case constants.ReturnStatuses.Received:
+		return utils.SendMail([]string{user.Email}, "Your returned item has been received", fmt.Sprintf("The seller has received and inspected your returned item, it will be resolved shortly: %s", link))
+	}
+	return utils.SendMail([]string{user.Email}, "Your return has been resolved", fmt.Sprintf("Your return has been resolved with a %s: %s", utils.Ternary(ret.Resolution == constants.ReturnResolutions.StoreCredit, "store credit", ret.Resolution), link))
+}
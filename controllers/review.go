@@ -0,0 +1,228 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/types"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+type ReviewController struct {
+	reviewRepo types.ReviewRepository
+}
+
+func NewReviewController(reviewRepo types.ReviewRepository) *ReviewController {
+	return &ReviewController{
+		reviewRepo: reviewRepo,
+	}
+}
+
+// public, lists the reviews of a product that are shown, the newest or most helpful first
+func (c *ReviewController) GetProductReviewsHandler(w http.ResponseWriter, r *http.Request)  {
+	c.writeReviews(w, r, types.RetrieveReviewsInput{ProductID: mux.Vars(r)["id"]})
+}
+
+// only customers who have paid for the product can review it
+func (c *ReviewController) CreateReviewHandler(w http.ResponseWriter, r *http.Request)  {
+	var payload types.SaveReviewInput
+	if err:= utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	errParsed := utils.ValidatePayload(payload)
+	if len(errParsed) > 0{
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, errParsed)
+		return
+	}
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	review, err := c.reviewRepo.CreateReview(mux.Vars(r)["id"], user.Customer.ID, payload)
+	if err == constants.ErrNotVerifiedBuyer {
+		utils.WriteError(w, http.StatusForbidden, constants.MsgValidationError, []error{err})
+		return
+	}
+	if err != nil {
+		writeReviewError(w, err)
+		return
+	}
+	utils.WriteJson(w, http.StatusCreated, "Review created successfully!", review)
+}
+
+func (c *ReviewController) UpdateReviewHandler(w http.ResponseWriter, r *http.Request)  {
+	var payload types.SaveReviewInput
+	if err:= utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	errParsed := utils.ValidatePayload(payload)
+	if len(errParsed) > 0{
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, errParsed)
+		return
+	}
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	review, err := c.reviewRepo.UpdateReview(mux.Vars(r)["id"], user.Customer.ID, payload)
+	if err != nil {
+		writeReviewError(w, err)
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Review updated successfully!", review)
+}
+
+func (c *ReviewController) DeleteReviewHandler(w http.ResponseWriter, r *http.Request)  {
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	if err = c.reviewRepo.DeleteReview(mux.Vars(r)["id"], user.Customer.ID); err != nil {
+		writeReviewError(w, err)
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Review deleted successfully!", nil)
+}
+
+func (c *ReviewController) VoteReviewHelpfulHandler(w http.ResponseWriter, r *http.Request)  {
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	review, err := c.reviewRepo.VoteReviewHelpful(mux.Vars(r)["id"], user.ID)
+	if err != nil {
+		writeReviewError(w, err)
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Review marked as helpful!", review)
+}
+
+func (c *ReviewController) RemoveReviewHelpfulVoteHandler(w http.ResponseWriter, r *http.Request)  {
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	review, err := c.reviewRepo.RemoveReviewHelpfulVote(mux.Vars(r)["id"], user.ID)
+	if err != nil {
+		writeReviewError(w, err)
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Helpful vote removed successfully!", review)
+}
+
+// flags the review for an admin to look at, it stays up until then
+func (c *ReviewController) ReportReviewHandler(w http.ResponseWriter, r *http.Request)  {
+	if err := c.reviewRepo.ReportReview(mux.Vars(r)["id"]); err != nil {
+		writeReviewError(w, err)
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Review reported successfully!", nil)
+}
+
+func (c *ReviewController) GetSellerReviewsHandler(w http.ResponseWriter, r *http.Request)  {
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	c.writeReviews(w, r, types.RetrieveReviewsInput{SellerID: user.Seller.ID})
+}
+
+func (c *ReviewController) RespondToReviewHandler(w http.ResponseWriter, r *http.Request)  {
+	var payload types.RespondToReviewInput
+	if err:= utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	errParsed := utils.ValidatePayload(payload)
+	if len(errParsed) > 0{
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, errParsed)
+		return
+	}
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	review, err := c.reviewRepo.RespondToReview(mux.Vars(r)["id"], user.Seller.ID, payload)
+	if err != nil {
+		writeReviewError(w, err)
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Response saved successfully!", review)
+}
+
+// admins can list the reviews of any status, the ones shown on product pages when no status is asked for
+func (c *ReviewController) GetAdminReviewsHandler(w http.ResponseWriter, r *http.Request)  {
+	status := r.URL.Query().Get("status")
+	if err := utils.Validate.Var(status, "omitempty,oneof=published flagged hidden"); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	c.writeReviews(w, r, types.RetrieveReviewsInput{Status: status})
+}
+
+func (c *ReviewController) ModerateReviewHandler(w http.ResponseWriter, r *http.Request)  {
+	var payload types.ModerateReviewInput
+	if err:= utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	errParsed := utils.ValidatePayload(payload)
+	if len(errParsed) > 0{
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, errParsed)
+		return
+	}
+	review, err := c.reviewRepo.ModerateReview(mux.Vars(r)["id"], payload)
+	if err != nil {
+		writeReviewError(w, err)
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Review moderated successfully!", review)
+}
+
+// private
+func (c *ReviewController) writeReviews(w http.ResponseWriter, r *http.Request, input types.RetrieveReviewsInput) {
+	pageSizeStr := r.URL.Query().Get(constants.QueryPageSize)
+	pageSize, err := strconv.Atoi(pageSizeStr)
+	if err != nil && pageSizeStr != "" {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{constants.ErrPageSizeNotValid})
+		return
+	}
+	sort := r.URL.Query().Get("sort")
+	if err = utils.Validate.Var(sort, "omitempty,oneof=newest helpful"); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	input.Pagination = types.Pagination{
+		PageSize: pageSize,
+		NextCursor: r.URL.Query().Get("nextCursor"),
+	}
+	input.Sort = sort
+	reviews, err := c.reviewRepo.RetrieveReviews(input)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Reviews retrieved successfully!", reviews)
+}
+
+func writeReviewError(w http.ResponseWriter, err error) {
+	switch err {
+	case constants.ErrReviewNotFound, constants.ErrProductNotFound:
+		utils.WriteError(w, http.StatusNotFound, constants.MsgValidationError, []error{err})
+	case constants.ErrAlreadyReviewed, constants.ErrOwnReviewVote:
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+	default:
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+	}
+}
@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/types"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+type ShipmentController struct {
+	shipmentRepo types.ShipmentRepository
+}
+
+func NewShipmentController(shipmentRepo types.ShipmentRepository) *ShipmentController {
+	return &ShipmentController{
+		shipmentRepo: shipmentRepo,
+	}
+}
+
+func (c *ShipmentController) CreateShipmentHandler(w http.ResponseWriter, r *http.Request)  {
+	var payload types.CreateShipmentInput
+	if err:= utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	errParsed := utils.ValidatePayload(payload)
+	if len(errParsed) > 0{
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, errParsed)
+		return
+	}
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	shipment, err := c.shipmentRepo.CreateShipment(mux.Vars(r)["id"], user.Seller.ID, payload)
+	if err == constants.ErrSubOrderNotFound {
+		utils.WriteError(w, http.StatusNotFound, constants.MsgValidationError, []error{err})
+		return
+	}
+	if err == constants.ErrSubOrderNotShippable || err == constants.ErrShipmentItemNotInOrder || err == constants.ErrShipmentQuantityExceeded {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	utils.WriteJson(w, http.StatusCreated, "Shipment created successfully!", shipment)
+
+}
+
+func (c *ShipmentController) MarkShipmentDeliveredHandler(w http.ResponseWriter, r *http.Request)  {
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	shipment, err := c.shipmentRepo.MarkShipmentDelivered(mux.Vars(r)["id"], user.Seller.ID)
+	if err == constants.ErrShipmentNotFound {
+		utils.WriteError(w, http.StatusNotFound, constants.MsgValidationError, []error{err})
+		return
+	}
+	if err == constants.ErrShipmentAlreadyDelivered {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Shipment marked as delivered!", shipment)
+
+}
@@ -0,0 +1,44 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/types"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+type StockController struct {
+	stockRepo types.StockRepository
+}
+
+func NewStockController(stockRepo types.StockRepository) *StockController {
+	return &StockController{
+		stockRepo: stockRepo,
+	}
+}
+
+func (c *StockController) GetStockMovementsHandler(w http.ResponseWriter, r *http.Request)  {
+	pageSizeStr := r.URL.Query().Get(constants.QueryPageSize)
+	pageSize, err := strconv.Atoi(pageSizeStr)
+	if err != nil && pageSizeStr != "" {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{constants.ErrPageSizeNotValid})
+		return
+	}
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	movements, err := c.stockRepo.RetrieveStockMovements(mux.Vars(r)["id"], user.Seller.ID, types.Pagination{
+		PageSize: pageSize,
+		NextCursor: r.URL.Query().Get("nextCursor"),
+	})
+	if err != nil {
+		writeProductError(w, err)
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Stock movements retrieved successfully!", movements)
+}
@@ -0,0 +1,185 @@
+package controllers
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/models"
+	"github.com/kaasikodes/e-commerce-go/types"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+type StorefrontController struct {
+	storefrontRepo types.StorefrontRepository
+	storage types.FileStorage
+}
+
+func NewStorefrontController(storefrontRepo types.StorefrontRepository, storage types.FileStorage) *StorefrontController {
+	return &StorefrontController{
+		storefrontRepo: storefrontRepo,
+		storage: storage,
+	}
+}
+
+// public shop page, lists the seller's products that are in stock
+func (c *StorefrontController) GetShopHandler(w http.ResponseWriter, r *http.Request)  {
+	slug := mux.Vars(r)["slug"]
+	// get query params
+	pageSizeStr := r.URL.Query().Get(constants.QueryPageSize)
+	pageSize, err := strconv.Atoi(pageSizeStr)
+	if err != nil && pageSizeStr != "" {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{constants.ErrPageSizeNotValid})
+		return
+	}
+	shop, err := c.storefrontRepo.RetrieveShop(slug, types.RetrieveShopInput{
+		Pagination: types.Pagination{
+			PageSize: pageSize,
+			NextCursor: r.URL.Query().Get("nextCursor"),
+		},
+	})
+	if err == constants.ErrShopNotFound {
+		utils.WriteError(w, http.StatusNotFound, constants.MsgValidationError, []error{err})
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Shop retrieved successfully!", shop)
+
+}
+
+func (c *StorefrontController) GetStorefrontHandler(w http.ResponseWriter, r *http.Request)  {
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	storefront, err := c.storefrontRepo.RetrieveStorefront(user.Seller.ID)
+	if err == constants.ErrStorefrontNotFound {
+		utils.WriteError(w, http.StatusNotFound, constants.MsgValidationError, []error{err})
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Storefront retrieved successfully!", storefront)
+
+}
+
+func (c *StorefrontController) SaveStorefrontHandler(w http.ResponseWriter, r *http.Request)  {
+	var payload types.SaveStorefrontInput
+	if err:= utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	errParsed := utils.ValidatePayload(payload)
+	if len(errParsed) > 0{
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, errParsed)
+		return
+	}
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	storefront, err := c.storefrontRepo.SaveStorefront(user.Seller.ID, payload)
+	if err == constants.ErrInvalidShopSlug || err == constants.ErrShopSlugTaken {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Storefront saved successfully!", storefront)
+
+}
+
+// Upload logo, expects a multipart form with the image in the image field
+func (c *StorefrontController) UploadLogoHandler(w http.ResponseWriter, r *http.Request)  {
+	c.uploadImage(w, r, "logo")
+}
+
+// Upload banner, expects a multipart form with the image in the image field
+func (c *StorefrontController) UploadBannerHandler(w http.ResponseWriter, r *http.Request)  {
+	c.uploadImage(w, r, "banner")
+}
+
+func (c *StorefrontController) uploadImage(w http.ResponseWriter, r *http.Request, kind string)  {
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	// the storefront has to exist before images can be added to it
+	storefront, err := c.storefrontRepo.RetrieveStorefront(user.Seller.ID)
+	if err == constants.ErrStorefrontNotFound {
+		utils.WriteError(w, http.StatusNotFound, constants.MsgValidationError, []error{err})
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	// leave some room for the rest of the multipart body
+	r.Body = http.MaxBytesReader(w, r.Body, constants.MaxStorefrontImageSize + 1 << 10)
+	if err = r.ParseMultipartForm(constants.MaxStorefrontImageSize); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{constants.ErrInvalidStorefrontImage})
+		return
+	}
+	file, header, err := r.FormFile("image")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	defer file.Close()
+	if header.Size > constants.MaxStorefrontImageSize {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{constants.ErrInvalidStorefrontImage})
+		return
+	}
+	// the content type is worked out from the file itself, not from what the client says it is
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{constants.ErrInvalidStorefrontImage})
+		return
+	}
+	ext, ok := constants.AvatarContentTypes[http.DetectContentType(head[:n])]
+	if !ok {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{constants.ErrInvalidStorefrontImage})
+		return
+	}
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	name, _ := utils.GenerateRandomID(20)
+	imageUrl, err := c.storage.Save("storefronts", name + ext, file)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	previousUrl := storefront.LogoUrl
+	update := c.storefrontRepo.UpdateStorefrontLogo
+	if kind == "banner" {
+		previousUrl = storefront.BannerUrl
+		update = c.storefrontRepo.UpdateStorefrontBanner
+	}
+	var updated models.Storefront
+	if updated, err = update(user.Seller.ID, imageUrl); err != nil {
+		c.storage.Delete(imageUrl)
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	if err = c.storage.Delete(previousUrl); err != nil {
+		log.Println("unable to delete previous storefront", kind, "of seller", user.Seller.ID, err)
+	}
+	utils.WriteJson(w, http.StatusOK, "Storefront " + kind + " updated successfully!", updated)
+
+}
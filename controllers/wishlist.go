@@ -0,0 +1,224 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/types"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+type WishlistController struct {
+	wishlistRepo types.WishlistRepository
+}
+
+func NewWishlistController(wishlistRepo types.WishlistRepository) *WishlistController {
+	return &WishlistController{
+		wishlistRepo: wishlistRepo,
+	}
+}
+
+func (c *WishlistController) GetWishlistsHandler(w http.ResponseWriter, r *http.Request)  {
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	wishlists, err := c.wishlistRepo.RetrieveWishlists(user.Customer.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Wishlists retrieved successfully!", wishlists)
+}
+
+func (c *WishlistController) GetWishlistHandler(w http.ResponseWriter, r *http.Request)  {
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	wishlist, err := c.wishlistRepo.RetrieveWishlist(mux.Vars(r)["id"], user.Customer.ID)
+	if err != nil {
+		writeWishlistError(w, err)
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Wishlist retrieved successfully!", wishlist)
+}
+
+func (c *WishlistController) CreateWishlistHandler(w http.ResponseWriter, r *http.Request)  {
+	var payload types.SaveWishlistInput
+	if err:= utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	errParsed := utils.ValidatePayload(payload)
+	if len(errParsed) > 0{
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, errParsed)
+		return
+	}
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	wishlist, err := c.wishlistRepo.CreateWishlist(user.Customer.ID, payload)
+	if err != nil {
+		writeWishlistError(w, err)
+		return
+	}
+	utils.WriteJson(w, http.StatusCreated, "Wishlist created successfully!", wishlist)
+}
+
+func (c *WishlistController) RenameWishlistHandler(w http.ResponseWriter, r *http.Request)  {
+	var payload types.SaveWishlistInput
+	if err:= utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	errParsed := utils.ValidatePayload(payload)
+	if len(errParsed) > 0{
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, errParsed)
+		return
+	}
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	wishlist, err := c.wishlistRepo.RenameWishlist(mux.Vars(r)["id"], user.Customer.ID, payload)
+	if err != nil {
+		writeWishlistError(w, err)
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Wishlist renamed successfully!", wishlist)
+}
+
+func (c *WishlistController) DeleteWishlistHandler(w http.ResponseWriter, r *http.Request)  {
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	if err = c.wishlistRepo.DeleteWishlist(mux.Vars(r)["id"], user.Customer.ID); err != nil {
+		writeWishlistError(w, err)
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Wishlist deleted successfully!", nil)
+}
+
+// adding a product that is already on the wishlist updates its alerts
+func (c *WishlistController) AddWishlistItemHandler(w http.ResponseWriter, r *http.Request)  {
+	var payload types.AddWishlistItemInput
+	if err:= utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	errParsed := utils.ValidatePayload(payload)
+	if len(errParsed) > 0{
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, errParsed)
+		return
+	}
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	wishlist, err := c.wishlistRepo.AddWishlistItem(mux.Vars(r)["id"], user.Customer.ID, payload)
+	if err != nil {
+		writeWishlistError(w, err)
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Product added to wishlist successfully!", wishlist)
+}
+
+// turn the back in stock and price drop alerts of a wishlisted product on or off
+func (c *WishlistController) UpdateWishlistItemHandler(w http.ResponseWriter, r *http.Request)  {
+	var payload types.UpdateWishlistItemInput
+	if err:= utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+		return
+	}
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	vars := mux.Vars(r)
+	wishlist, err := c.wishlistRepo.UpdateWishlistItem(vars["id"], vars["productId"], user.Customer.ID, payload)
+	if err != nil {
+		writeWishlistError(w, err)
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Wishlist alerts updated successfully!", wishlist)
+}
+
+func (c *WishlistController) RemoveWishlistItemHandler(w http.ResponseWriter, r *http.Request)  {
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	vars := mux.Vars(r)
+	wishlist, err := c.wishlistRepo.RemoveWishlistItem(vars["id"], vars["productId"], user.Customer.ID)
+	if err != nil {
+		writeWishlistError(w, err)
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Product removed from wishlist successfully!", wishlist)
+}
+
+// sharing again gives the wishlist a new link, the old one stops working
+func (c *WishlistController) ShareWishlistHandler(w http.ResponseWriter, r *http.Request)  {
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	wishlist, err := c.wishlistRepo.ShareWishlist(mux.Vars(r)["id"], user.Customer.ID)
+	if err != nil {
+		writeWishlistError(w, err)
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Wishlist shared successfully!", map[string]interface{}{
+		"wishlist": wishlist,
+		"shareUrl": constants.FrontendUrl + "/wishlists/shared/" + wishlist.ShareToken,
+	})
+}
+
+func (c *WishlistController) UnshareWishlistHandler(w http.ResponseWriter, r *http.Request)  {
+	user, err := utils.RetrieveUserFromRequestContext(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+		return
+	}
+	wishlist, err := c.wishlistRepo.UnshareWishlist(mux.Vars(r)["id"], user.Customer.ID)
+	if err != nil {
+		writeWishlistError(w, err)
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Wishlist is no longer shared!", wishlist)
+}
+
+// public, anyone with the link can see the wishlist
+func (c *WishlistController) GetSharedWishlistHandler(w http.ResponseWriter, r *http.Request)  {
+	wishlist, err := c.wishlistRepo.RetrieveSharedWishlist(mux.Vars(r)["token"])
+	if err != nil {
+		writeWishlistError(w, err)
+		return
+	}
+	utils.WriteJson(w, http.StatusOK, "Wishlist retrieved successfully!", wishlist)
+}
+
+// private
+func writeWishlistError(w http.ResponseWriter, err error) {
+	switch err {
+	case constants.ErrWishlistNotFound, constants.ErrWishlistItemNotFound, constants.ErrProductNotFound:
+		utils.WriteError(w, http.StatusNotFound, constants.MsgValidationError, []error{err})
+	case constants.ErrWishlistNameTaken, constants.ErrTooManyWishlists:
+		utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+	default:
+		utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+	}
+}
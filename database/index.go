@@ -111,12 +111,20 @@ func  CreateTables(db *sql.DB)  {
 	utils.ErrHandler(err)
 	err = migrations.CreateVerificationTokenTable(db)
 	utils.ErrHandler(err)
+	err = migrations.AlterTokenTables(db)
+	utils.ErrHandler(err)
+	err = migrations.CreateMagicLinkTokenTable(db)
+	utils.ErrHandler(err)
+	err = migrations.CreateEmailChangeTokenTable(db)
+	utils.ErrHandler(err)
 	err = migrations.CreateProductTable(db)
 	utils.ErrHandler(err)
 	err = migrations.CreateCartTable(db)
 	utils.ErrHandler(err)
 	err = migrations.CreateCartItemTable(db)
 	utils.ErrHandler(err)
+	err = migrations.CreateSavedForLaterItemTable(db)
+	utils.ErrHandler(err)
 	err = migrations.CreateCountryTable(db)
 	utils.ErrHandler(err)
 	err = migrations.CreateStateTable(db)
@@ -127,10 +135,86 @@ func  CreateTables(db *sql.DB)  {
 	utils.ErrHandler(err)
 	err = migrations.CreateOrderTable(db)
 	utils.ErrHandler(err)
+	err = migrations.CreateOrderNumberSequenceTable(db)
+	utils.ErrHandler(err)
 	err = migrations.CreateOrderItemTable(db)
 	utils.ErrHandler(err)
+	err = migrations.CreateSubOrderTable(db)
+	utils.ErrHandler(err)
 	err = migrations.CreatePaymentTable(db)
 	utils.ErrHandler(err)
+	err = migrations.CreateAuthThrottleTable(db)
+	utils.ErrHandler(err)
+	err = migrations.AlterAuthThrottleTable(db)
+	utils.ErrHandler(err)
+	err = migrations.CreateUserIdentityTable(db)
+	utils.ErrHandler(err)
+	err = migrations.CreateOIDCLoginStateTable(db)
+	utils.ErrHandler(err)
+	err = migrations.CreateAPIKeyTable(db)
+	utils.ErrHandler(err)
+	err = migrations.CreateUserAuditLogTable(db)
+	utils.ErrHandler(err)
+	err = migrations.CreateAccountDeletionRequestTable(db)
+	utils.ErrHandler(err)
+	err = migrations.CreateSellerOnboardingTable(db)
+	utils.ErrHandler(err)
+	err = migrations.CreateSellerDocumentTable(db)
+	utils.ErrHandler(err)
+	err = migrations.CreateSellerStorefrontTable(db)
+	utils.ErrHandler(err)
+	err = migrations.CreateCommissionRateTable(db)
+	utils.ErrHandler(err)
+	err = migrations.CreateLedgerTransactionTable(db)
+	utils.ErrHandler(err)
+	err = migrations.CreateLedgerEntryTable(db)
+	utils.ErrHandler(err)
+	err = migrations.CreatePayoutBatchTable(db)
+	utils.ErrHandler(err)
+	err = migrations.CreatePayoutTable(db)
+	utils.ErrHandler(err)
+	err = migrations.CreateShipmentTable(db)
+	utils.ErrHandler(err)
+	err = migrations.CreateShipmentItemTable(db)
+	utils.ErrHandler(err)
+	err = migrations.CreateRefundTable(db)
+	utils.ErrHandler(err)
+	err = migrations.CreateReturnRequestTable(db)
+	utils.ErrHandler(err)
+	err = migrations.CreateReturnPhotoTable(db)
+	utils.ErrHandler(err)
+	err = migrations.CreateStoreCreditTable(db)
+	utils.ErrHandler(err)
+	err = migrations.CreateDocumentSequenceTable(db)
+	utils.ErrHandler(err)
+	err = migrations.CreateInvoiceTable(db)
+	utils.ErrHandler(err)
+	err = migrations.CreateInvoiceLineTable(db)
+	utils.ErrHandler(err)
+	err = migrations.CreateCreditNoteTable(db)
+	utils.ErrHandler(err)
+	err = migrations.CreateIdempotencyKeyTable(db)
+	utils.ErrHandler(err)
+	err = migrations.CreateWishlistTable(db)
+	utils.ErrHandler(err)
+	err = migrations.CreateWishlistItemTable(db)
+	utils.ErrHandler(err)
+	err = migrations.CreateProductReviewTable(db)
+	utils.ErrHandler(err)
+	err = migrations.CreateProductReviewVoteTable(db)
+	utils.ErrHandler(err)
+	err = migrations.CreateProductQuestionTable(db)
+	utils.ErrHandler(err)
+	err = migrations.CreateProductAnswerTable(db)
+	utils.ErrHandler(err)
+	err = migrations.CreateProductPriceChangeTable(db)
+	utils.ErrHandler(err)
+	err = migrations.CreateProductSaleTable(db)
+	utils.ErrHandler(err)
+	err = migrations.CreateStockMovementTable(db)
+	utils.ErrHandler(err)
+	err = migrations.CreateLowStockDigestTable(db)
+	utils.ErrHandler(err)
 	
 }
 
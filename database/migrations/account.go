@@ -0,0 +1,28 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+func CreateAccountDeletionRequestTable(db *sql.DB) error {
+	query := `CREATE TABLE IF NOT EXISTS AccountDeletionRequest (
+		UserID VARCHAR(255) PRIMARY KEY,
+		RequestedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		ScheduledFor TIMESTAMP NOT NULL,
+		CompletedAt TIMESTAMP NULL,
+		INDEX (ScheduledFor),
+		FOREIGN KEY (UserID) REFERENCES User(ID)
+	)
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+
+	_, err := db.ExecContext(ctx, query)
+	return utils.ErrHandler(err)
+
+}
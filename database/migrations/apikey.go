@@ -0,0 +1,34 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+func CreateAPIKeyTable(db *sql.DB) error {
+	query := `CREATE TABLE IF NOT EXISTS APIKey (
+		ID VARCHAR(255) PRIMARY KEY,
+		UserID VARCHAR(255) NOT NULL,
+		Name VARCHAR(50) NOT NULL,
+		Prefix VARCHAR(50) NOT NULL UNIQUE,
+		KeyHash VARCHAR(64) NOT NULL,
+		Scopes VARCHAR(255) NOT NULL,
+		LastUsedAt TIMESTAMP NULL,
+		ExpiresAt TIMESTAMP NULL,
+		RevokedAt TIMESTAMP NULL,
+		CreatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		INDEX (UserID),
+		FOREIGN KEY (UserID) REFERENCES User(ID)
+	)
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+
+	_, err := db.ExecContext(ctx, query)
+	return utils.ErrHandler(err)
+
+}
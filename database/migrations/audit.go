@@ -0,0 +1,32 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+func CreateUserAuditLogTable(db *sql.DB) error {
+	query := `CREATE TABLE IF NOT EXISTS UserAuditLog (
+		ID VARCHAR(255) PRIMARY KEY,
+		UserID VARCHAR(255) NOT NULL,
+		Action VARCHAR(50) NOT NULL,
+		OldValue VARCHAR(255),
+		NewValue VARCHAR(255),
+		IPAddress VARCHAR(45),
+		UserAgent VARCHAR(255),
+		CreatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		INDEX (UserID, CreatedAt),
+		FOREIGN KEY (UserID) REFERENCES User(ID)
+	)
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+
+	_, err := db.ExecContext(ctx, query)
+	return utils.ErrHandler(err)
+
+}
@@ -43,4 +43,24 @@ func CreateCartItemTable (db *sql.DB) error{
 	_, err := db.ExecContext(ctx,query)
 	return utils.ErrHandler(err)
 
-}
\ No newline at end of file
+}
+func CreateSavedForLaterItemTable (db *sql.DB) error{
+	query := `CREATE TABLE IF NOT EXISTS SavedForLaterItem (
+		ID VARCHAR(255) PRIMARY KEY,
+		CustomerID VARCHAR(255) NOT NULL,
+		ProductID VARCHAR(255) NOT NULL,
+		Quantity INT NOT NULL,
+		CreatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UpdatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+		UNIQUE (CustomerID, ProductID),
+		FOREIGN KEY (CustomerID) REFERENCES Customer(ID),
+		FOREIGN KEY (ProductID) REFERENCES Product(ID)
+	)`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5 * time.Second)
+	defer cancel()
+
+	_, err := db.ExecContext(ctx,query)
+	return utils.ErrHandler(err)
+
+}
@@ -0,0 +1,32 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+)
+
+// columnExists reports whether a table of the current database has a column
+func columnExists(db *sql.DB, table string, column string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+
+	count := 0
+	err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND COLUMN_NAME = ?", table, column).Scan(&count)
+	return count > 0, err
+}
+
+// addColumnIfMissing adds a column to a table that was created before the column existed, CREATE TABLE IF NOT EXISTS leaves such tables as they are
+func addColumnIfMissing(db *sql.DB, table string, column string, definition string) error {
+	exists, err := columnExists(db, table, column)
+	if err != nil || exists {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+
+	_, err = db.ExecContext(ctx, "ALTER TABLE "+table+" ADD COLUMN "+column+" "+definition)
+	return err
+}
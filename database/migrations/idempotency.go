@@ -0,0 +1,36 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+func CreateIdempotencyKeyTable(db *sql.DB) error {
+	// keys are scoped to the user that sent them, requests that aren't signed in have an empty UserID
+	query := "CREATE TABLE IF NOT EXISTS IdempotencyKey ( " +
+		"UserID VARCHAR(255) NOT NULL, " +
+		"`Key` VARCHAR(255) NOT NULL, " +
+		`Method VARCHAR(10) NOT NULL,
+		Path VARCHAR(255) NOT NULL,
+		RequestHash CHAR(64) NOT NULL,
+		ResponseStatus INT NOT NULL DEFAULT 0,
+		ResponseContentType VARCHAR(100) NOT NULL DEFAULT '',
+		ResponseBody MEDIUMBLOB,
+		CreatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		CompletedAt TIMESTAMP NULL,
+		ExpiresAt TIMESTAMP NOT NULL,
+		PRIMARY KEY (UserID, ` + "`Key`" + `),
+		INDEX (ExpiresAt)
+	)
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+
+	_, err := db.ExecContext(ctx, query)
+	return utils.ErrHandler(err)
+
+}
@@ -0,0 +1,48 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+func CreateUserIdentityTable(db *sql.DB) error {
+	query := `CREATE TABLE IF NOT EXISTS UserIdentity (
+		ID VARCHAR(255) PRIMARY KEY,
+		UserID VARCHAR(255) NOT NULL,
+		Provider VARCHAR(50) NOT NULL,
+		Subject VARCHAR(255) NOT NULL,
+		Email VARCHAR(255),
+		CreatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (Provider, Subject),
+		FOREIGN KEY (UserID) REFERENCES User(ID)
+	)
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+
+	_, err := db.ExecContext(ctx, query)
+	return utils.ErrHandler(err)
+
+}
+func CreateOIDCLoginStateTable(db *sql.DB) error {
+	query := `CREATE TABLE IF NOT EXISTS OIDCLoginState (
+		State VARCHAR(255) PRIMARY KEY,
+		Provider VARCHAR(50) NOT NULL,
+		Nonce VARCHAR(255) NOT NULL,
+		CodeVerifier VARCHAR(255) NOT NULL,
+		CreatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		ExpiresAt TIMESTAMP NOT NULL
+	)
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+
+	_, err := db.ExecContext(ctx, query)
+	return utils.ErrHandler(err)
+
+}
@@ -0,0 +1,116 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+func CreateDocumentSequenceTable(db *sql.DB) error {
+	query := `CREATE TABLE IF NOT EXISTS DocumentSequence (
+		SellerID VARCHAR(255) NOT NULL,
+		Type VARCHAR(20) NOT NULL,
+		LastNumber BIGINT NOT NULL DEFAULT 0,
+		PRIMARY KEY (SellerID, Type),
+		FOREIGN KEY (SellerID) REFERENCES Seller(ID)
+	)
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+
+	_, err := db.ExecContext(ctx, query)
+	return utils.ErrHandler(err)
+
+}
+
+func CreateInvoiceTable(db *sql.DB) error {
+	query := `CREATE TABLE IF NOT EXISTS Invoice (
+		ID VARCHAR(255) PRIMARY KEY,
+		Number VARCHAR(30) NOT NULL,
+		SellerID VARCHAR(255) NOT NULL,
+		SellerName VARCHAR(255) NOT NULL,
+		OrderID VARCHAR(255) NOT NULL,
+		OrderNumber VARCHAR(20) NOT NULL DEFAULT '',
+		SubOrderID VARCHAR(255) NOT NULL,
+		CustomerID VARCHAR(255) NOT NULL,
+		BillToName VARCHAR(255) NOT NULL,
+		BillToAddress VARCHAR(500) NOT NULL,
+		PaymentID VARCHAR(255) NOT NULL,
+		PaymentMethod VARCHAR(255) NOT NULL,
+		PaidAt TIMESTAMP NULL,
+		Subtotal BIGINT NOT NULL,
+		TaxRateBps INT NOT NULL,
+		TaxAmount BIGINT NOT NULL,
+		Total BIGINT NOT NULL,
+		IssuedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (SellerID, Number),
+		UNIQUE (SubOrderID),
+		INDEX (OrderID),
+		FOREIGN KEY (SellerID) REFERENCES Seller(ID),
+		FOREIGN KEY (OrderID) REFERENCES ` + "`Order`" + `(ID),
+		FOREIGN KEY (SubOrderID) REFERENCES SubOrder(ID),
+		FOREIGN KEY (CustomerID) REFERENCES Customer(ID)
+	)
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+
+	_, err := db.ExecContext(ctx, query)
+	return utils.ErrHandler(err)
+
+}
+
+func CreateInvoiceLineTable(db *sql.DB) error {
+	query := `CREATE TABLE IF NOT EXISTS InvoiceLine (
+		InvoiceID VARCHAR(255) NOT NULL,
+		Position INT NOT NULL,
+		Description VARCHAR(255) NOT NULL,
+		Quantity INT NOT NULL,
+		UnitPrice BIGINT NOT NULL,
+		Total BIGINT NOT NULL,
+		PRIMARY KEY (InvoiceID, Position),
+		FOREIGN KEY (InvoiceID) REFERENCES Invoice(ID) ON DELETE CASCADE
+	)
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+
+	_, err := db.ExecContext(ctx, query)
+	return utils.ErrHandler(err)
+
+}
+
+func CreateCreditNoteTable(db *sql.DB) error {
+	query := `CREATE TABLE IF NOT EXISTS CreditNote (
+		ID VARCHAR(255) PRIMARY KEY,
+		Number VARCHAR(30) NOT NULL,
+		InvoiceID VARCHAR(255) NOT NULL,
+		SellerID VARCHAR(255) NOT NULL,
+		RefundID VARCHAR(255),
+		ReturnID VARCHAR(255),
+		Reason VARCHAR(255) NOT NULL,
+		Subtotal BIGINT NOT NULL,
+		TaxAmount BIGINT NOT NULL,
+		Total BIGINT NOT NULL,
+		IssuedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (SellerID, Number),
+		INDEX (InvoiceID),
+		FOREIGN KEY (InvoiceID) REFERENCES Invoice(ID),
+		FOREIGN KEY (SellerID) REFERENCES Seller(ID),
+		FOREIGN KEY (RefundID) REFERENCES Refund(ID),
+		FOREIGN KEY (ReturnID) REFERENCES ReturnRequest(ID)
+	)
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+
+	_, err := db.ExecContext(ctx, query)
+	return utils.ErrHandler(err)
+
+}
@@ -0,0 +1,119 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+func CreateCommissionRateTable(db *sql.DB) error {
+	query := `CREATE TABLE IF NOT EXISTS CommissionRate (
+		Scope VARCHAR(20) NOT NULL,
+		ScopeID VARCHAR(255) NOT NULL,
+		RateBps INT NOT NULL,
+		CreatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UpdatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+		PRIMARY KEY (Scope, ScopeID)
+	)
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+
+	_, err := db.ExecContext(ctx, query)
+	return utils.ErrHandler(err)
+
+}
+
+func CreateLedgerTransactionTable(db *sql.DB) error {
+	query := `CREATE TABLE IF NOT EXISTS LedgerTransaction (
+		ID VARCHAR(255) PRIMARY KEY,
+		Type VARCHAR(20) NOT NULL,
+		ReferenceID VARCHAR(255) NOT NULL,
+		Description VARCHAR(255),
+		CreatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (Type, ReferenceID),
+		INDEX (CreatedAt)
+	)
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+
+	_, err := db.ExecContext(ctx, query)
+	return utils.ErrHandler(err)
+
+}
+
+func CreateLedgerEntryTable(db *sql.DB) error {
+	query := `CREATE TABLE IF NOT EXISTS LedgerEntry (
+		ID VARCHAR(255) PRIMARY KEY,
+		TransactionID VARCHAR(255) NOT NULL,
+		Account VARCHAR(30) NOT NULL,
+		SellerID VARCHAR(255),
+		Amount BIGINT NOT NULL,
+		CreatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		INDEX (SellerID, Account),
+		FOREIGN KEY (TransactionID) REFERENCES LedgerTransaction(ID),
+		FOREIGN KEY (SellerID) REFERENCES Seller(ID)
+	)
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+
+	_, err := db.ExecContext(ctx, query)
+	return utils.ErrHandler(err)
+
+}
+
+func CreatePayoutBatchTable(db *sql.DB) error {
+	query := `CREATE TABLE IF NOT EXISTS PayoutBatch (
+		ID VARCHAR(255) PRIMARY KEY,
+		Method VARCHAR(20) NOT NULL,
+		CreatedBy VARCHAR(255) NOT NULL,
+		CreatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (CreatedBy) REFERENCES User(ID)
+	)
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+
+	_, err := db.ExecContext(ctx, query)
+	return utils.ErrHandler(err)
+
+}
+
+func CreatePayoutTable(db *sql.DB) error {
+	query := `CREATE TABLE IF NOT EXISTS Payout (
+		ID VARCHAR(255) PRIMARY KEY,
+		SellerID VARCHAR(255) NOT NULL,
+		Amount BIGINT NOT NULL,
+		Status VARCHAR(20) NOT NULL,
+		BatchID VARCHAR(255),
+		BankName VARCHAR(100) NOT NULL,
+		BankCode VARCHAR(10) NOT NULL,
+		BankAccountName VARCHAR(100) NOT NULL,
+		BankAccountNumber VARCHAR(10) NOT NULL,
+		GatewayReference VARCHAR(100),
+		FailureReason VARCHAR(255),
+		CompletedAt TIMESTAMP NULL,
+		CreatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UpdatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+		INDEX (Status),
+		INDEX (SellerID),
+		FOREIGN KEY (SellerID) REFERENCES Seller(ID),
+		FOREIGN KEY (BatchID) REFERENCES PayoutBatch(ID)
+	)
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+
+	_, err := db.ExecContext(ctx, query)
+	return utils.ErrHandler(err)
+
+}
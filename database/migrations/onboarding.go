@@ -0,0 +1,62 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+func CreateSellerOnboardingTable(db *sql.DB) error {
+	query := `CREATE TABLE IF NOT EXISTS SellerOnboarding (
+		SellerID VARCHAR(255) PRIMARY KEY,
+		Status VARCHAR(20) NOT NULL DEFAULT 'draft',
+		BusinessName VARCHAR(100),
+		BusinessType VARCHAR(20),
+		RegistrationNumber VARCHAR(50),
+		BusinessAddress VARCHAR(255),
+		PhoneNumber VARCHAR(20),
+		BankName VARCHAR(100),
+		BankCode VARCHAR(10),
+		BankAccountName VARCHAR(100),
+		BankAccountNumber VARCHAR(10),
+		ReviewerID VARCHAR(255),
+		ReviewerComment VARCHAR(500),
+		SubmittedAt TIMESTAMP NULL,
+		ReviewedAt TIMESTAMP NULL,
+		CreatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UpdatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+		INDEX (Status),
+		FOREIGN KEY (SellerID) REFERENCES Seller(ID),
+		FOREIGN KEY (ReviewerID) REFERENCES User(ID)
+	)
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+
+	_, err := db.ExecContext(ctx, query)
+	return utils.ErrHandler(err)
+
+}
+func CreateSellerDocumentTable(db *sql.DB) error {
+	query := `CREATE TABLE IF NOT EXISTS SellerDocument (
+		ID VARCHAR(255) PRIMARY KEY,
+		SellerID VARCHAR(255) NOT NULL,
+		Type VARCHAR(50) NOT NULL,
+		FileUrl VARCHAR(255) NOT NULL,
+		ContentType VARCHAR(50) NOT NULL,
+		CreatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		INDEX (SellerID),
+		FOREIGN KEY (SellerID) REFERENCES Seller(ID)
+	)
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+
+	_, err := db.ExecContext(ctx, query)
+	return utils.ErrHandler(err)
+
+}
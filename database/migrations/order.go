@@ -10,9 +10,12 @@ import (
 func CreateOrderTable (db *sql.DB) error{
 	query := "CREATE TABLE IF NOT EXISTS `Order` ( " +
 	"ID VARCHAR(255) PRIMARY KEY, " +
+	"Number VARCHAR(20) UNIQUE, " +
 	"CustomerID VARCHAR(255) NOT NULL, " +
 	"TotalAmount FLOAT NOT NULL, " +
 	"DeliveryAddressID VARCHAR(255) NOT NULL, " +
+	"Status VARCHAR(20) NOT NULL DEFAULT 'placed', " +
+	"CancelledAt TIMESTAMP NULL, " +
 	"CreatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP, " +
 	"UpdatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP, " +
 	"FOREIGN KEY (CustomerID) REFERENCES Customer(ID), " +
@@ -25,6 +28,21 @@ func CreateOrderTable (db *sql.DB) error{
 	_, err := db.ExecContext(ctx,query)
 	return utils.ErrHandler(err)
 
+}
+// CreateOrderNumberSequenceTable holds the last order number given out in each year
+func CreateOrderNumberSequenceTable (db *sql.DB) error{
+	query := `
+	CREATE TABLE IF NOT EXISTS OrderNumberSequence (
+		Year INT PRIMARY KEY,
+		LastNumber BIGINT NOT NULL DEFAULT 0
+	)`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5 * time.Second)
+	defer cancel()
+
+	_, err := db.ExecContext(ctx,query)
+	return utils.ErrHandler(err)
+
 }
 func CreateOrderItemTable (db *sql.DB) error{
 	query := `
@@ -33,6 +51,7 @@ func CreateOrderItemTable (db *sql.DB) error{
 		ProductID VARCHAR(255) NOT NULL,
 		OrderID VARCHAR(255) NOT NULL,
 		Quantity INT NOT NULL,
+		UnitPrice INT NOT NULL DEFAULT 0,
 		TotalPrice FLOAT DEFAULT 0,
 		CreatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 		UpdatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
@@ -47,4 +66,28 @@ func CreateOrderItemTable (db *sql.DB) error{
 	_, err := db.ExecContext(ctx,query)
 	return utils.ErrHandler(err)
 
-}
\ No newline at end of file
+}
+func CreateSubOrderTable (db *sql.DB) error{
+	query := `
+	CREATE TABLE IF NOT EXISTS SubOrder (
+		ID VARCHAR(255) PRIMARY KEY,
+		OrderID VARCHAR(255) NOT NULL,
+		SellerID VARCHAR(255) NOT NULL,
+		Status VARCHAR(20) NOT NULL DEFAULT 'pending',
+		Subtotal FLOAT NOT NULL DEFAULT 0,
+		CancelledAt TIMESTAMP NULL,
+		CancelledBy VARCHAR(20),
+		CancellationReason VARCHAR(255),
+		CreatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UpdatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+		UNIQUE (OrderID, SellerID),
+		INDEX (SellerID, Status),
+		FOREIGN KEY (SellerID) REFERENCES Seller(ID),
+		FOREIGN KEY (OrderID) REFERENCES ` + "`Order`" + `(ID) ON DELETE CASCADE
+	)`
+	
+	ctx, cancel := context.WithTimeout(context.Background(), 5 * time.Second)
+	defer cancel()
+	_, err := db.ExecContext(ctx,query)
+	return utils.ErrHandler(err)
+}
@@ -0,0 +1,55 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+func CreateProductPriceChangeTable(db *sql.DB) error {
+	// every change to what customers pay for a product or to its regular price during a sale, the rows are never updated
+	query := `CREATE TABLE IF NOT EXISTS ProductPriceChange (
+		ID VARCHAR(255) PRIMARY KEY,
+		ProductID VARCHAR(255) NOT NULL,
+		SaleID VARCHAR(255),
+		OldPrice INT NOT NULL,
+		NewPrice INT NOT NULL,
+		Reason VARCHAR(20) NOT NULL,
+		CreatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		INDEX (ProductID, CreatedAt),
+		FOREIGN KEY (ProductID) REFERENCES Product(ID)
+	)`
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+
+	_, err := db.ExecContext(ctx, query)
+	return utils.ErrHandler(err)
+}
+
+func CreateProductSaleTable(db *sql.DB) error {
+	// RegularPrice is the price the product goes back to when the sale ends, it is taken when the sale starts
+	query := `CREATE TABLE IF NOT EXISTS ProductSale (
+		ID VARCHAR(255) PRIMARY KEY,
+		ProductID VARCHAR(255) NOT NULL,
+		SalePrice INT NOT NULL,
+		RegularPrice INT,
+		StartsAt TIMESTAMP NOT NULL,
+		EndsAt TIMESTAMP NOT NULL,
+		Status VARCHAR(20) NOT NULL DEFAULT 'scheduled',
+		CreatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UpdatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+		INDEX (ProductID, Status),
+		INDEX (Status, StartsAt),
+		INDEX (Status, EndsAt),
+		FOREIGN KEY (ProductID) REFERENCES Product(ID)
+	)`
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+
+	_, err := db.ExecContext(ctx, query)
+	return utils.ErrHandler(err)
+}
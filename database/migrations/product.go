@@ -10,18 +10,34 @@ import (
 
 
 func CreateProductTable (db *sql.DB) error{
+	// the seller is told when the Quantity drops to the ReorderThreshold, a threshold of 0 turns it off
 	query := `CREATE TABLE IF NOT EXISTS Product  (
 		ID VARCHAR(255) PRIMARY KEY,
 		Name VARCHAR(255) NOT NULL,
 		Description TEXT,
 		Price INT NOT NULL,
+		WasPrice INT,
 		Quantity INT NOT NULL,
+		ReorderThreshold INT NOT NULL DEFAULT 0,
 		CategoryID VARCHAR(255) NOT NULL,
 		OwnerID VARCHAR(255) NOT NULL,
+		RatingAverage DECIMAL(3,2) NOT NULL DEFAULT 0,
+		RatingCount INT NOT NULL DEFAULT 0,
+		OneStarCount INT NOT NULL DEFAULT 0,
+		TwoStarCount INT NOT NULL DEFAULT 0,
+		ThreeStarCount INT NOT NULL DEFAULT 0,
+		FourStarCount INT NOT NULL DEFAULT 0,
+		FiveStarCount INT NOT NULL DEFAULT 0,
+		Status VARCHAR(20) NOT NULL DEFAULT 'active',
+		PublishAt TIMESTAMP NULL,
+		UnpublishAt TIMESTAMP NULL,
+		DeletedAt TIMESTAMP NULL,
 		CreatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 		UpdatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
 		FOREIGN KEY (CategoryID) REFERENCES Category(ID),
-		FOREIGN KEY (OwnerID) REFERENCES Seller(ID)
+		FOREIGN KEY (OwnerID) REFERENCES Seller(ID),
+		INDEX (Status, PublishAt),
+		INDEX (Status, UnpublishAt)
 	)`
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5 * time.Second)
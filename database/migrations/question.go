@@ -0,0 +1,56 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+func CreateProductQuestionTable(db *sql.DB) error {
+	query := `CREATE TABLE IF NOT EXISTS ProductQuestion (
+		ID VARCHAR(255) PRIMARY KEY,
+		ProductID VARCHAR(255) NOT NULL,
+		CustomerID VARCHAR(255) NOT NULL,
+		Question TEXT NOT NULL,
+		Status VARCHAR(20) NOT NULL DEFAULT 'published',
+		ModerationReason VARCHAR(255),
+		CreatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UpdatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+		INDEX (ProductID, Status),
+		INDEX (Status),
+		FOREIGN KEY (ProductID) REFERENCES Product(ID),
+		FOREIGN KEY (CustomerID) REFERENCES Customer(ID)
+	)`
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+
+	_, err := db.ExecContext(ctx, query)
+	return utils.ErrHandler(err)
+}
+
+func CreateProductAnswerTable(db *sql.DB) error {
+	query := `CREATE TABLE IF NOT EXISTS ProductAnswer (
+		ID VARCHAR(255) PRIMARY KEY,
+		QuestionID VARCHAR(255) NOT NULL,
+		UserID VARCHAR(255) NOT NULL,
+		AuthorRole VARCHAR(20) NOT NULL,
+		Answer TEXT NOT NULL,
+		Status VARCHAR(20) NOT NULL DEFAULT 'published',
+		ModerationReason VARCHAR(255),
+		CreatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UpdatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+		INDEX (QuestionID, Status),
+		INDEX (Status),
+		FOREIGN KEY (QuestionID) REFERENCES ProductQuestion(ID) ON DELETE CASCADE,
+		FOREIGN KEY (UserID) REFERENCES User(ID)
+	)`
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+
+	_, err := db.ExecContext(ctx, query)
+	return utils.ErrHandler(err)
+}
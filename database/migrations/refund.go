@@ -0,0 +1,37 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+func CreateRefundTable(db *sql.DB) error {
+	query := `CREATE TABLE IF NOT EXISTS Refund (
+		ID VARCHAR(255) PRIMARY KEY,
+		PaymentID VARCHAR(255) NOT NULL,
+		OrderID VARCHAR(255) NOT NULL,
+		SubOrderID VARCHAR(255),
+		ReturnID VARCHAR(255),
+		Amount BIGINT NOT NULL,
+		Status VARCHAR(20) NOT NULL DEFAULT 'pending',
+		GatewayReference VARCHAR(255),
+		FailureReason VARCHAR(255),
+		CreatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UpdatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+		INDEX (Status),
+		INDEX (OrderID),
+		FOREIGN KEY (PaymentID) REFERENCES Payment(ID),
+		FOREIGN KEY (OrderID) REFERENCES ` + "`Order`" + `(ID)
+	)
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+
+	_, err := db.ExecContext(ctx, query)
+	return utils.ErrHandler(err)
+
+}
@@ -0,0 +1,100 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+func CreateReturnRequestTable(db *sql.DB) error {
+	query := `CREATE TABLE IF NOT EXISTS ReturnRequest (
+		ID VARCHAR(255) PRIMARY KEY,
+		OrderID VARCHAR(255) NOT NULL,
+		OrderItemID VARCHAR(255) NOT NULL,
+		SubOrderID VARCHAR(255) NOT NULL,
+		CustomerID VARCHAR(255) NOT NULL,
+		SellerID VARCHAR(255) NOT NULL,
+		ProductID VARCHAR(255) NOT NULL,
+		Quantity INT NOT NULL,
+		Reason VARCHAR(30) NOT NULL,
+		Details TEXT,
+		PreferredResolution VARCHAR(20) NOT NULL,
+		Status VARCHAR(20) NOT NULL DEFAULT 'requested',
+		Instructions TEXT,
+		LabelUrl VARCHAR(255),
+		RejectionReason TEXT,
+		InspectionNotes TEXT,
+		Restocked BOOLEAN NOT NULL DEFAULT false,
+		Resolution VARCHAR(20),
+		Amount BIGINT NOT NULL DEFAULT 0,
+		RefundID VARCHAR(255),
+		ReplacementOrderID VARCHAR(255),
+		ApprovedAt TIMESTAMP NULL,
+		ReceivedAt TIMESTAMP NULL,
+		ResolvedAt TIMESTAMP NULL,
+		CreatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UpdatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+		INDEX (CustomerID),
+		INDEX (SellerID, Status),
+		INDEX (OrderItemID),
+		FOREIGN KEY (OrderID) REFERENCES ` + "`Order`" + `(ID),
+		FOREIGN KEY (OrderItemID) REFERENCES OrderItem(ID),
+		FOREIGN KEY (SubOrderID) REFERENCES SubOrder(ID),
+		FOREIGN KEY (CustomerID) REFERENCES Customer(ID),
+		FOREIGN KEY (SellerID) REFERENCES Seller(ID),
+		FOREIGN KEY (RefundID) REFERENCES Refund(ID),
+		FOREIGN KEY (ReplacementOrderID) REFERENCES ` + "`Order`" + `(ID)
+	)
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+
+	_, err := db.ExecContext(ctx, query)
+	return utils.ErrHandler(err)
+
+}
+
+func CreateReturnPhotoTable(db *sql.DB) error {
+	query := `CREATE TABLE IF NOT EXISTS ReturnPhoto (
+		ID VARCHAR(255) PRIMARY KEY,
+		ReturnID VARCHAR(255) NOT NULL,
+		FileUrl VARCHAR(255) NOT NULL,
+		ContentType VARCHAR(50) NOT NULL,
+		CreatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		INDEX (ReturnID),
+		FOREIGN KEY (ReturnID) REFERENCES ReturnRequest(ID) ON DELETE CASCADE
+	)
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+
+	_, err := db.ExecContext(ctx, query)
+	return utils.ErrHandler(err)
+
+}
+
+func CreateStoreCreditTable(db *sql.DB) error {
+	query := `CREATE TABLE IF NOT EXISTS StoreCredit (
+		ID VARCHAR(255) PRIMARY KEY,
+		CustomerID VARCHAR(255) NOT NULL,
+		ReturnID VARCHAR(255),
+		Amount BIGINT NOT NULL,
+		Description VARCHAR(255) NOT NULL,
+		CreatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		INDEX (CustomerID),
+		FOREIGN KEY (CustomerID) REFERENCES Customer(ID),
+		FOREIGN KEY (ReturnID) REFERENCES ReturnRequest(ID)
+	)
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+
+	_, err := db.ExecContext(ctx, query)
+	return utils.ErrHandler(err)
+
+}
@@ -0,0 +1,55 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+func CreateProductReviewTable(db *sql.DB) error {
+	// a customer reviews a product once, they edit their review after that
+	query := `CREATE TABLE IF NOT EXISTS ProductReview (
+		ID VARCHAR(255) PRIMARY KEY,
+		ProductID VARCHAR(255) NOT NULL,
+		CustomerID VARCHAR(255) NOT NULL,
+		Rating TINYINT NOT NULL,
+		Title VARCHAR(120),
+		Body TEXT,
+		Status VARCHAR(20) NOT NULL DEFAULT 'published',
+		ModerationReason VARCHAR(255),
+		HelpfulCount INT NOT NULL DEFAULT 0,
+		SellerResponse TEXT,
+		SellerRespondedAt TIMESTAMP NULL,
+		CreatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UpdatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+		UNIQUE (ProductID, CustomerID),
+		INDEX (ProductID, Status, HelpfulCount),
+		INDEX (Status),
+		FOREIGN KEY (ProductID) REFERENCES Product(ID),
+		FOREIGN KEY (CustomerID) REFERENCES Customer(ID)
+	)`
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+
+	_, err := db.ExecContext(ctx, query)
+	return utils.ErrHandler(err)
+}
+
+func CreateProductReviewVoteTable(db *sql.DB) error {
+	query := `CREATE TABLE IF NOT EXISTS ProductReviewVote (
+		ReviewID VARCHAR(255) NOT NULL,
+		UserID VARCHAR(255) NOT NULL,
+		CreatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (ReviewID, UserID),
+		FOREIGN KEY (ReviewID) REFERENCES ProductReview(ID) ON DELETE CASCADE
+	)`
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+
+	_, err := db.ExecContext(ctx, query)
+	return utils.ErrHandler(err)
+}
@@ -0,0 +1,54 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+func CreateShipmentTable(db *sql.DB) error {
+	query := `CREATE TABLE IF NOT EXISTS Shipment (
+		ID VARCHAR(255) PRIMARY KEY,
+		SubOrderID VARCHAR(255) NOT NULL,
+		Carrier VARCHAR(100) NOT NULL,
+		TrackingNumber VARCHAR(100) NOT NULL,
+		TrackingUrl VARCHAR(255),
+		Status VARCHAR(20) NOT NULL DEFAULT 'shipped',
+		ShippedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		DeliveredAt TIMESTAMP NULL,
+		CreatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UpdatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+		INDEX (SubOrderID),
+		FOREIGN KEY (SubOrderID) REFERENCES SubOrder(ID) ON DELETE CASCADE
+	)
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+
+	_, err := db.ExecContext(ctx, query)
+	return utils.ErrHandler(err)
+
+}
+
+func CreateShipmentItemTable(db *sql.DB) error {
+	query := `CREATE TABLE IF NOT EXISTS ShipmentItem (
+		ShipmentID VARCHAR(255) NOT NULL,
+		OrderItemID VARCHAR(255) NOT NULL,
+		Quantity INT NOT NULL,
+		PRIMARY KEY (ShipmentID, OrderItemID),
+		INDEX (OrderItemID),
+		FOREIGN KEY (ShipmentID) REFERENCES Shipment(ID) ON DELETE CASCADE,
+		FOREIGN KEY (OrderItemID) REFERENCES OrderItem(ID) ON DELETE CASCADE
+	)
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+
+	_, err := db.ExecContext(ctx, query)
+	return utils.ErrHandler(err)
+
+}
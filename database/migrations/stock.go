@@ -0,0 +1,48 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+func CreateStockMovementTable(db *sql.DB) error {
+	// every change to the quantity of a product, the rows are never updated.
+	// ActorID is the seller or customer behind the change and Reference the order or return it was part of
+	query := `CREATE TABLE IF NOT EXISTS StockMovement (
+		ID VARCHAR(255) PRIMARY KEY,
+		ProductID VARCHAR(255) NOT NULL,
+		QuantityChange INT NOT NULL,
+		QuantityAfter INT NOT NULL,
+		Reason VARCHAR(20) NOT NULL,
+		Actor VARCHAR(20) NOT NULL,
+		ActorID VARCHAR(255),
+		Reference VARCHAR(255),
+		CreatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		INDEX (ProductID, CreatedAt),
+		FOREIGN KEY (ProductID) REFERENCES Product(ID)
+	)`
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+
+	_, err := db.ExecContext(ctx, query)
+	return utils.ErrHandler(err)
+}
+
+func CreateLowStockDigestTable(db *sql.DB) error {
+	// when each seller was last sent the products that are running low
+	query := `CREATE TABLE IF NOT EXISTS LowStockDigest (
+		SellerID VARCHAR(255) PRIMARY KEY,
+		SentAt TIMESTAMP NOT NULL,
+		FOREIGN KEY (SellerID) REFERENCES Seller(ID)
+	)`
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+
+	_, err := db.ExecContext(ctx, query)
+	return utils.ErrHandler(err)
+}
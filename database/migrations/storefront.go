@@ -0,0 +1,35 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+func CreateSellerStorefrontTable(db *sql.DB) error {
+	query := `CREATE TABLE IF NOT EXISTS SellerStorefront (
+		SellerID VARCHAR(255) PRIMARY KEY,
+		ShopName VARCHAR(60) NOT NULL,
+		Slug VARCHAR(60) NOT NULL UNIQUE,
+		LogoUrl VARCHAR(255),
+		BannerUrl VARCHAR(255),
+		Description TEXT,
+		ReturnPolicy TEXT,
+		ShippingPolicy TEXT,
+		ContactEmail VARCHAR(255),
+		ContactPhone VARCHAR(20),
+		CreatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UpdatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+		FOREIGN KEY (SellerID) REFERENCES Seller(ID)
+	)
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+
+	_, err := db.ExecContext(ctx, query)
+	return utils.ErrHandler(err)
+
+}
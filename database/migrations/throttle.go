@@ -0,0 +1,34 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+func CreateAuthThrottleTable(db *sql.DB) error {
+	query := `CREATE TABLE IF NOT EXISTS AuthThrottle (
+		ID VARCHAR(255) PRIMARY KEY,
+		` + "`Key`" + ` VARCHAR(255) NOT NULL UNIQUE,
+		FailedAttempts INT NOT NULL DEFAULT 0,
+		LastFailedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		LockedUntil TIMESTAMP NULL,
+		LockoutNotified BOOLEAN NOT NULL DEFAULT FALSE
+	)
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+
+	_, err := db.ExecContext(ctx, query)
+	return utils.ErrHandler(err)
+
+}
+
+// AlterAuthThrottleTable brings throttle tables created before the lockout email was sent only once up to date
+func AlterAuthThrottleTable(db *sql.DB) error {
+	err := addColumnIfMissing(db, "AuthThrottle", "LockoutNotified", "BOOLEAN NOT NULL DEFAULT FALSE")
+	return utils.ErrHandler(err)
+}
@@ -11,10 +11,11 @@ import (
 func CreatePasswordResetTokenTable(db *sql.DB) error {
 	query := `CREATE TABLE IF NOT EXISTS  PasswordResetToken (
 		ID VARCHAR(255) PRIMARY KEY,
-		Token VARCHAR(255) NOT NULL,
-		Email VARCHAR(255) NOT NULL UNIQUE,
+		TokenHash VARCHAR(64) NOT NULL,
+		Email VARCHAR(255) NOT NULL,
 		CreatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		ExpiresAt TIMESTAMP,
+		ExpiresAt TIMESTAMP NOT NULL,
+		INDEX (Email, ExpiresAt),
 		FOREIGN KEY (Email) REFERENCES User(Email)
 	)
 	`
@@ -29,10 +30,11 @@ func CreatePasswordResetTokenTable(db *sql.DB) error {
 func CreateVerificationTokenTable(db *sql.DB) error {
 	query := `CREATE TABLE IF NOT EXISTS  VerificationToken (
 		ID VARCHAR(255) PRIMARY KEY,
-		Token VARCHAR(255) NOT NULL,
-		Email VARCHAR(255) NOT NULL UNIQUE,
+		TokenHash VARCHAR(64) NOT NULL,
+		Email VARCHAR(255) NOT NULL,
 		CreatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		ExpiresAt TIMESTAMP,
+		ExpiresAt TIMESTAMP NOT NULL,
+		INDEX (Email, ExpiresAt),
 		FOREIGN KEY (Email) REFERENCES User(Email)
 	)
 	`
@@ -43,4 +45,75 @@ func CreateVerificationTokenTable(db *sql.DB) error {
 	_, err := db.ExecContext(ctx, query)
 	return utils.ErrHandler(err)
 
-}
\ No newline at end of file
+}
+func CreateMagicLinkTokenTable(db *sql.DB) error {
+	query := `CREATE TABLE IF NOT EXISTS  MagicLinkToken (
+		ID VARCHAR(255) PRIMARY KEY,
+		TokenHash VARCHAR(64) NOT NULL,
+		DeviceHash VARCHAR(64) NOT NULL,
+		Email VARCHAR(255) NOT NULL,
+		CreatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		ExpiresAt TIMESTAMP NOT NULL,
+		INDEX (Email, ExpiresAt),
+		FOREIGN KEY (Email) REFERENCES User(Email)
+	)
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+
+	_, err := db.ExecContext(ctx, query)
+	return utils.ErrHandler(err)
+
+}
+func CreateEmailChangeTokenTable(db *sql.DB) error {
+	query := `CREATE TABLE IF NOT EXISTS EmailChangeToken (
+		ID VARCHAR(255) PRIMARY KEY,
+		TokenHash VARCHAR(64) NOT NULL,
+		UserID VARCHAR(255) NOT NULL,
+		NewEmail VARCHAR(255) NOT NULL,
+		CreatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		ExpiresAt TIMESTAMP NOT NULL,
+		INDEX (NewEmail, ExpiresAt),
+		FOREIGN KEY (UserID) REFERENCES User(ID)
+	)
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+
+	_, err := db.ExecContext(ctx, query)
+	return utils.ErrHandler(err)
+
+}
+
+// AlterTokenTables moves verification and password reset tables created with plain tokens over to hashed tokens that each expire.
+// The plain tokens can't be kept, so they are deleted and their owners have to ask for new ones
+func AlterTokenTables(db *sql.DB) error {
+	for _, table := range []string{"PasswordResetToken", "VerificationToken"} {
+		plain, err := columnExists(db, table, "Token")
+		if err != nil {
+			return utils.ErrHandler(err)
+		}
+		if !plain {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+		_, err = db.ExecContext(ctx, "DELETE FROM "+table)
+		if err == nil {
+			// the new index is added before the unique one on Email is dropped, as the foreign key on Email needs one of them
+			_, err = db.ExecContext(ctx, "ALTER TABLE "+table+`
+				DROP COLUMN Token,
+				ADD COLUMN TokenHash VARCHAR(64) NOT NULL AFTER ID,
+				MODIFY ExpiresAt TIMESTAMP NOT NULL,
+				ADD INDEX (Email, ExpiresAt),
+				DROP INDEX Email`)
+		}
+		cancel()
+		if err != nil {
+			return utils.ErrHandler(err)
+		}
+	}
+	return nil
+}
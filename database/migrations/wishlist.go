@@ -0,0 +1,52 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+func CreateWishlistTable(db *sql.DB) error {
+	query := `CREATE TABLE IF NOT EXISTS Wishlist (
+		ID VARCHAR(255) PRIMARY KEY,
+		CustomerID VARCHAR(255) NOT NULL,
+		Name VARCHAR(60) NOT NULL,
+		ShareToken VARCHAR(64) UNIQUE,
+		CreatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UpdatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+		UNIQUE (CustomerID, Name),
+		FOREIGN KEY (CustomerID) REFERENCES Customer(ID)
+	)`
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+
+	_, err := db.ExecContext(ctx, query)
+	return utils.ErrHandler(err)
+}
+
+func CreateWishlistItemTable(db *sql.DB) error {
+	query := `CREATE TABLE IF NOT EXISTS WishlistItem (
+		ID VARCHAR(255) PRIMARY KEY,
+		WishlistID VARCHAR(255) NOT NULL,
+		ProductID VARCHAR(255) NOT NULL,
+		NotifyWhenInStock BOOLEAN NOT NULL DEFAULT FALSE,
+		NotifyOnPriceDrop BOOLEAN NOT NULL DEFAULT FALSE,
+		LastSeenPrice INT NOT NULL,
+		LastSeenQuantity INT NOT NULL,
+		CreatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UpdatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+		UNIQUE (WishlistID, ProductID),
+		INDEX (ProductID),
+		FOREIGN KEY (WishlistID) REFERENCES Wishlist(ID) ON DELETE CASCADE,
+		FOREIGN KEY (ProductID) REFERENCES Product(ID)
+	)`
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+
+	_, err := db.ExecContext(ctx, query)
+	return utils.ErrHandler(err)
+}
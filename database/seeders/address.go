@@ -159,7 +159,7 @@ func AddAddresses(db *sql.DB) error {
 	var params []interface{}
 	for _, address := range data {
 		inserts = append(inserts, "(?, ?, ?, ?, ?)")
-		id, _ := utils.GenerateRandomID(10)
+		id := utils.NewID()
 		params = append(params, id, address.StreetAddress, address.LgaID, address.StateID, address.CountryID)
 	}
 	queryVals := strings.Join(inserts, ",")
@@ -0,0 +1,49 @@
+package jobs
+
+import (
+	"fmt"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/types"
+)
+
+// ProcessAccountDeletionsJob anonymises the accounts whose deletion grace period has passed
+func ProcessAccountDeletionsJob(accountRepo types.AccountRepository, storage types.FileStorage, privateStorage types.FileStorage) Job {
+	return Job{
+		Name:     "process-account-deletions",
+		Interval: constants.AccountDeletionCheckInterval,
+		Run: func() error {
+			requests, err := accountRepo.RetrieveDueAccountDeletions()
+			if err != nil {
+				return err
+			}
+			// one failing account shouldn't hold up the rest
+			failed := 0
+			for _, request := range requests {
+				files, err := accountRepo.AnonymiseUser(request.UserID)
+				if err != nil {
+					fmt.Println("unable to delete account", request.UserID, err)
+					failed++
+					continue
+				}
+				for _, url := range files.Public {
+					if err = storage.Delete(url); err != nil {
+						fmt.Println("unable to delete file of deleted account", request.UserID, err)
+					}
+				}
+				for _, url := range files.Private {
+					if err = privateStorage.Delete(url); err != nil {
+						fmt.Println("unable to delete private file of deleted account", request.UserID, err)
+					}
+				}
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d of %d account deletions failed", failed, len(requests))
+			}
+			if len(requests) > 0 {
+				fmt.Println("deleted", len(requests), "accounts")
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,26 @@
+package jobs
+
+import (
+	"fmt"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/types"
+)
+
+// PurgeExpiredIdempotencyKeysJob removes saved responses that are no longer replayed
+func PurgeExpiredIdempotencyKeysJob(idempotencyRepo types.IdempotencyKeyRepository) Job {
+	return Job{
+		Name:     "purge-expired-idempotency-keys",
+		Interval: constants.IdempotencyKeyCleanupInterval,
+		Run: func() error {
+			count, err := idempotencyRepo.DeleteExpiredIdempotencyKeys()
+			if err != nil {
+				return err
+			}
+			if count > 0 {
+				fmt.Println("purged", count, "expired idempotency keys")
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,44 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+// Job is a piece of background work that is run on a fixed interval
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func() error
+}
+
+// Start runs each job in its own goroutine, once straight away and then on every interval, until ctx is done
+func Start(ctx context.Context, jobs ...Job) {
+	for _, job := range jobs {
+		go run(ctx, job)
+	}
+}
+
+func run(ctx context.Context, job Job) {
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+	for {
+		runOnce(job)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runOnce keeps a failing or panicking job from taking the server down with it
+func runOnce(job Job) {
+	defer utils.Recover()
+	if err := job.Run(); err != nil {
+		fmt.Println("job", job.Name, "failed:", err)
+	}
+}
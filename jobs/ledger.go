@@ -0,0 +1,24 @@
+package jobs
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/types"
+)
+
+// ReleaseSellerFundsJob makes sales available for payout once their hold period has passed
+func ReleaseSellerFundsJob(ledgerRepo types.LedgerRepository) Job {
+	return Job{
+		Name:     "release-seller-funds",
+		Interval: constants.SellerFundsReleaseInterval,
+		Run: func() error {
+			released, err := ledgerRepo.ReleaseHeldFunds(time.Now().Add(-constants.SellerFundsHoldPeriod))
+			if released > 0 {
+				fmt.Println("released", released, "sales to seller balances")
+			}
+			return err
+		},
+	}
+}
@@ -0,0 +1,24 @@
+package jobs
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/types"
+)
+
+// CancelUnpaidOrdersJob cancels orders that were not paid for in time, putting their stock back on sale
+func CancelUnpaidOrdersJob(orderRepo types.OrderRepository) Job {
+	return Job{
+		Name:     "cancel-unpaid-orders",
+		Interval: constants.UnpaidOrderCheckInterval,
+		Run: func() error {
+			cancelled, err := orderRepo.CancelUnpaidOrders(time.Now().Add(-constants.UnpaidOrderTimeout))
+			if cancelled > 0 {
+				fmt.Println("cancelled", cancelled, "unpaid orders")
+			}
+			return err
+		},
+	}
+}
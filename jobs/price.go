@@ -0,0 +1,26 @@
+package jobs
+
+import (
+	"fmt"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/types"
+)
+
+// ApplyProductSalesJob starts sales whose time has come and puts products back to their regular price when their sale ends
+func ApplyProductSalesJob(priceRepo types.PriceRepository) Job {
+	return Job{
+		Name:     "apply-product-sales",
+		Interval: constants.ProductSaleCheckInterval,
+		Run: func() error {
+			started, ended, err := priceRepo.ApplyScheduledSales()
+			if err != nil {
+				return err
+			}
+			if started > 0 || ended > 0 {
+				fmt.Println("started", started, "and ended", ended, "product sales")
+			}
+			return nil
+		},
+	}
+}
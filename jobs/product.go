@@ -0,0 +1,26 @@
+package jobs
+
+import (
+	"fmt"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/types"
+)
+
+// PublishScheduledProductsJob puts drafts on sale and archives products at the times their sellers scheduled
+func PublishScheduledProductsJob(productRepo types.ProductRepository) Job {
+	return Job{
+		Name:     "publish-scheduled-products",
+		Interval: constants.ProductScheduleCheckInterval,
+		Run: func() error {
+			published, unpublished, err := productRepo.PublishScheduledProducts()
+			if err != nil {
+				return err
+			}
+			if published > 0 || unpublished > 0 {
+				fmt.Println("published", published, "and unpublished", unpublished, "scheduled products")
+			}
+			return nil
+		},
+	}
+}
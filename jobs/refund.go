@@ -0,0 +1,33 @@
+package jobs
+
+import (
+	"fmt"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/types"
+)
+
+// ProcessRefundsJob sends pending refunds to the payment gateway, failed ones wait for an admin to retry them
+func ProcessRefundsJob(refundRepo types.RefundRepository, gateway types.RefundGateway) Job {
+	return Job{
+		Name:     "process-refunds",
+		Interval: constants.RefundProcessInterval,
+		Run: func() error {
+			refunds, err := refundRepo.ClaimPendingRefunds()
+			if err != nil {
+				return err
+			}
+			for _, refund := range refunds {
+				failureReason := ""
+				reference, err := gateway.Refund(refund)
+				if err != nil {
+					failureReason = err.Error()
+				}
+				if err = refundRepo.CompleteRefund(refund.ID, reference, failureReason); err != nil {
+					fmt.Println("unable to complete refund", refund.ID, err)
+				}
+			}
+			return nil
+		},
+	}
+}
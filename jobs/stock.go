@@ -0,0 +1,52 @@
+package jobs
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/models"
+	"github.com/kaasikodes/e-commerce-go/types"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+// SendLowStockDigestsJob emails sellers the products that are at or below their reorder threshold, each seller gets at most one email a day
+func SendLowStockDigestsJob(stockRepo types.StockRepository) Job {
+	return Job{
+		Name:     "send-low-stock-digests",
+		Interval: constants.LowStockDigestCheckInterval,
+		Run: func() error {
+			digests, err := stockRepo.RetrieveLowStockDigests()
+			if err != nil {
+				return err
+			}
+			sent := 0
+			for _, digest := range digests {
+				// a digest that couldn't be sent is tried again on the next run
+				if err = utils.SendMail([]string{digest.SellerEmail}, "Some of your products are running low", lowStockDigestBody(digest)); err != nil {
+					fmt.Println("unable to send low stock digest to", digest.SellerEmail, err)
+					continue
+				}
+				if err = stockRepo.MarkLowStockDigestSent(digest.SellerID); err != nil {
+					fmt.Println("unable to mark low stock digest as sent", digest.SellerID, err)
+					continue
+				}
+				sent++
+			}
+			if sent > 0 {
+				fmt.Println("sent", sent, "low stock digests")
+			}
+			return nil
+		},
+	}
+}
+
+func lowStockDigestBody(digest models.LowStockDigest) string {
+	var b strings.Builder
+	b.WriteString("These products are at or below the stock level you asked to be told about:\n\n")
+	for _, product := range digest.Products {
+		fmt.Fprintf(&b, "- %s: %d left, reorder at %d\n", product.Name, product.Quantity, product.ReorderThreshold)
+	}
+	fmt.Fprintf(&b, "\nRestock them at %s/products", constants.FrontendUrl)
+	return b.String()
+}
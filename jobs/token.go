@@ -0,0 +1,26 @@
+package jobs
+
+import (
+	"fmt"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/types"
+)
+
+// PurgeExpiredTokensJob removes verification and password reset tokens that can no longer be used
+func PurgeExpiredTokensJob(tokenRepo types.TokenRepository) Job {
+	return Job{
+		Name:     "purge-expired-tokens",
+		Interval: constants.ExpiredTokenCleanupInterval,
+		Run: func() error {
+			count, err := tokenRepo.DeleteExpiredTokens()
+			if err != nil {
+				return err
+			}
+			if count > 0 {
+				fmt.Println("purged", count, "expired tokens")
+			}
+			return nil
+		},
+	}
+}
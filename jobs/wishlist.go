@@ -0,0 +1,69 @@
+package jobs
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/models"
+	"github.com/kaasikodes/e-commerce-go/types"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+// SendWishlistAlertsJob emails customers about the wishlisted products they asked to hear about that came back in stock or got cheaper,
+// each customer gets one email covering all of their products
+func SendWishlistAlertsJob(wishlistRepo types.WishlistRepository) Job {
+	return Job{
+		Name:     "send-wishlist-alerts",
+		Interval: constants.WishlistAlertCheckInterval,
+		Run: func() error {
+			alerts, err := wishlistRepo.RetrieveWishlistAlerts()
+			if err != nil {
+				return err
+			}
+			// alerts come ordered by email
+			for start := 0; start < len(alerts); {
+				end := start
+				for end < len(alerts) && alerts[end].CustomerEmail == alerts[start].CustomerEmail {
+					end++
+				}
+				batch := alerts[start:end]
+				start = end
+				// an alert that couldn't be sent is tried again on the next run
+				if err = utils.SendMail([]string{batch[0].CustomerEmail}, "Good news about your wishlist", wishlistAlertBody(batch)); err != nil {
+					fmt.Println("unable to send wishlist alert to", batch[0].CustomerEmail, err)
+					continue
+				}
+				for _, alert := range batch {
+					if err = wishlistRepo.MarkWishlistAlertSent(alert); err != nil {
+						fmt.Println("unable to mark wishlist alert as sent", alert.ItemID, err)
+					}
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// a product on more than one of the customer's wishlists is only mentioned once
+func wishlistAlertBody(alerts []models.WishlistAlert) string {
+	var b strings.Builder
+	b.WriteString("Some products on your wishlist have changed:\n\n")
+	mentioned := map[string]bool{}
+	for _, alert := range alerts {
+		if mentioned[alert.ProductID] {
+			continue
+		}
+		mentioned[alert.ProductID] = true
+		switch {
+		case alert.Restocked && alert.Price < alert.PreviousPrice:
+			fmt.Fprintf(&b, "- %s is back in stock and down from NGN %d to NGN %d\n", alert.ProductName, alert.PreviousPrice, alert.Price)
+		case alert.Restocked:
+			fmt.Fprintf(&b, "- %s is back in stock\n", alert.ProductName)
+		default:
+			fmt.Fprintf(&b, "- %s is down from NGN %d to NGN %d\n", alert.ProductName, alert.PreviousPrice, alert.Price)
+		}
+	}
+	fmt.Fprintf(&b, "\nSee your wishlists at %s/wishlists", constants.FrontendUrl)
+	return b.String()
+}
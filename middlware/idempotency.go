@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/models"
+	"github.com/kaasikodes/e-commerce-go/types"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+// IdempotencyMiddleware makes retrying a request with the same Idempotency-Key header safe: the request is handled once and its response is replayed
+// for every retry within ttl. A retry sent while the first request is still being handled gets a 409, and reusing a key for a different request a 422.
+// Requests without the header are handled as usual. Keys are scoped to the signed in user, so it should run after RequireAuthMiddleware
+func IdempotencyMiddleware(idempotencyRepo types.IdempotencyKeyRepository, ttl time.Duration) Middleware {
+	return func(next http.HandlerFunc ) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request)  {
+			key := r.Header.Get(constants.IdempotencyKeyHeader)
+			if key == "" {
+				next(w, r)
+				return
+			}
+			if len(key) > constants.MaxIdempotencyKeyLength {
+				utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{constants.ErrInvalidIdempotencyKey})
+				return
+			}
+			userID := ""
+			if user, err := utils.RetrieveUserFromRequestContext(r); err == nil {
+				userID = user.ID
+			}
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			saved, claimed, err := idempotencyRepo.ClaimIdempotencyKey(models.IdempotencyKey{
+				UserID: userID,
+				Key: key,
+				Method: r.Method,
+				Path: r.URL.Path,
+				RequestHash: requestHash(r.Method, r.URL.Path, body),
+				ExpiresAt: time.Now().Add(ttl),
+			})
+			if err == constants.ErrIdempotencyKeyInProgress {
+				utils.WriteError(w, http.StatusConflict, constants.MsgValidationError, []error{err})
+				return
+			}
+			if err != nil {
+				utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+				return
+			}
+			if !claimed {
+				switch {
+				case saved.RequestHash != requestHash(r.Method, r.URL.Path, body):
+					utils.WriteError(w, http.StatusUnprocessableEntity, constants.MsgValidationError, []error{constants.ErrIdempotencyKeyReused})
+				case saved.CompletedAt == nil:
+					utils.WriteError(w, http.StatusConflict, constants.MsgValidationError, []error{constants.ErrIdempotencyKeyInProgress})
+				default:
+					if saved.ResponseContentType != "" {
+						w.Header().Set("Content-Type", saved.ResponseContentType)
+					}
+					w.Header().Set("Idempotent-Replayed", "true")
+					w.WriteHeader(saved.ResponseStatus)
+					w.Write(saved.ResponseBody)
+				}
+				return
+			}
+
+			recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+			next(recorder, r)
+			// server errors are saved too, a handler may have got part of the way before failing and a retry must not do that part again
+			if err = idempotencyRepo.CompleteIdempotencyKey(userID, key, recorder.status, recorder.Header().Get("Content-Type"), recorder.body.Bytes()); err != nil {
+				log.Println("unable to save the response of idempotency key", key, err)
+			}
+		}
+	}
+}
+
+// private
+// requestHash fingerprints a request, a key can only be retried with the same method, path and body
+func requestHash(method string, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method + " " + path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseRecorder passes the response through while keeping a copy of it
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.wroteHeader = true
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/models"
+)
+
+// memoryIdempotencyKeys keeps keys in memory, the way the database does
+type memoryIdempotencyKeys struct {
+	keys map[string]models.IdempotencyKey
+}
+
+func (m *memoryIdempotencyKeys) ClaimIdempotencyKey(key models.IdempotencyKey) (models.IdempotencyKey, bool, error) {
+	if saved, ok := m.keys[key.UserID+":"+key.Key]; ok {
+		return saved, false, nil
+	}
+	m.keys[key.UserID+":"+key.Key] = key
+	return key, true, nil
+}
+
+func (m *memoryIdempotencyKeys) CompleteIdempotencyKey(userID string, key string, status int, contentType string, body []byte) error {
+	saved := m.keys[userID+":"+key]
+	now := time.Now()
+	saved.ResponseStatus, saved.ResponseContentType, saved.ResponseBody, saved.CompletedAt = status, contentType, body, &now
+	m.keys[userID+":"+key] = saved
+	return nil
+}
+
+func (m *memoryIdempotencyKeys) DeleteExpiredIdempotencyKeys() (int64, error) {
+	return 0, nil
+}
+
+func serveIdempotent(handler http.HandlerFunc, key string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/cart/checkout", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(constants.IdempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	handler(w, req)
+	return w
+}
+
+func TestIdempotencyMiddleware_ReplaysResponse(t *testing.T) {
+	calls := 0
+	handler := IdempotencyMiddleware(&memoryIdempotencyKeys{keys: map[string]models.IdempotencyKey{}}, time.Hour)(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"order":"1"}`))
+	})
+
+	first := serveIdempotent(handler, "key-1", `{"address":"1"}`)
+	retry := serveIdempotent(handler, "key-1", `{"address":"1"}`)
+	if calls != 1 {
+		t.Fatalf("handler was called %d times, want 1", calls)
+	}
+	if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() || retry.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("retry got %d %q, want the first response replayed", retry.Code, retry.Body.String())
+	}
+	if w := serveIdempotent(handler, "key-1", `{"address":"2"}`); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("reusing the key for another body got %d, want %d", w.Code, http.StatusUnprocessableEntity)
+	}
+	serveIdempotent(handler, "", `{"address":"1"}`)
+	if calls != 2 {
+		t.Errorf("requests without a key should always be handled, handler was called %d times, want 2", calls)
+	}
+}
+
+func TestIdempotencyMiddleware_RejectsConcurrentDuplicate(t *testing.T) {
+	repo := &memoryIdempotencyKeys{keys: map[string]models.IdempotencyKey{}}
+	var duplicate *httptest.ResponseRecorder
+	var handler http.HandlerFunc
+	handler = IdempotencyMiddleware(repo, time.Hour)(func(w http.ResponseWriter, r *http.Request) {
+		// the client gives up and retries while the first request is still being handled
+		if duplicate == nil {
+			duplicate = serveIdempotent(handler, "key-1", `{}`)
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	serveIdempotent(handler, "key-1", `{}`)
+	if duplicate.Code != http.StatusConflict {
+		t.Errorf("concurrent duplicate got %d, want %d", duplicate.Code, http.StatusConflict)
+	}
+}
+
+func TestIdempotencyMiddleware_ReplaysServerError(t *testing.T) {
+	calls := 0
+	handler := IdempotencyMiddleware(&memoryIdempotencyKeys{keys: map[string]models.IdempotencyKey{}}, time.Hour)(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	serveIdempotent(handler, "key-1", `{}`)
+	// the first request may have done part of its work, so a retry gets its error rather than doing it again
+	if w := serveIdempotent(handler, "key-1", `{}`); w.Code != http.StatusInternalServerError || calls != 1 {
+		t.Errorf("retry after a server error got %d after %d calls, want the error replayed", w.Code, calls)
+	}
+}
@@ -3,10 +3,13 @@ package middleware
 import (
 	"context"
 	"fmt"
+	"log"
 	"net/http"
+	"strings"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/models"
 	"github.com/kaasikodes/e-commerce-go/types"
 	"github.com/kaasikodes/e-commerce-go/utils"
 )
@@ -34,38 +37,168 @@ func RequireAuthMiddleware(userRepo types.UserRepository) Middleware {
 				utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
 				return
 			}
-			// validate jwt 
-			token, err := utils.ValidateJWT(tokenStr)
-			
+			user, status, err := authenticateJWT(userRepo, tokenStr)
 			if err != nil {
-				utils.WriteError(w, http.StatusUnauthorized, "Authorization Error", []error{err})
-				return
-			}
-			if !token.Valid {
-				err = fmt.Errorf("invalid token")
-				utils.WriteError(w, http.StatusUnauthorized, "Authorization Error", []error{err})
+				msg := constants.MsgAuthorizationError
+				if status == http.StatusBadRequest {
+					msg = "User not found!"
+				}
+				utils.WriteError(w, status, msg, []error{err})
 				return
 			}
+			ctx := context.WithValue(r.Context(), constants.JWTAuthUserContextKey, user)
+			r = r.WithContext(ctx)
+			next(w, r)
+		}
 	
-			claims := token.Claims.(jwt.MapClaims)
-			userIdMapKey := constants.JWTUserIdMapKey
-
-			userId := claims[string(userIdMapKey)].(string)
 	
-			user, err := userRepo.RetrieveUserByID(userId)
-			fmt.Println("user from auth middleware", user)
+	}
+
+}
+
+// RequireAuthOrAPIKeyMiddleware works like RequireAuthMiddleware but also accepts an api key with the scope in place of the jwt, the key's owner is put in the context as the user
+func RequireAuthOrAPIKeyMiddleware(userRepo types.UserRepository, apiKeyRepo types.APIKeyRepository, scope string) Middleware {
+	return func(next http.HandlerFunc ) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request)  {
+			tokenStr, err := utils.GetAccessTokenFromRequest(r)
+			if err != nil {
+				utils.WriteError(w, http.StatusBadRequest, constants.MsgValidationError, []error{err})
+				return
+			}
+			if !strings.HasPrefix(tokenStr, constants.APIKeyPrefix) {
+				RequireAuthMiddleware(userRepo)(next)(w, r)
+				return
+			}
+			apiKey, err := apiKeyRepo.RetrieveAPIKeyByKey(tokenStr)
+			if err != nil {
+				utils.WriteError(w, http.StatusUnauthorized, constants.MsgAuthorizationError, []error{constants.ErrInvalidAPIKey})
+				return
+			}
+			if !apiKey.HasScope(scope) {
+				utils.WriteError(w, http.StatusForbidden, constants.MsgAuthorizationError, []error{constants.ErrAPIKeyScopeMissing})
+				return
+			}
+			user, err := userRepo.RetrieveUserByID(apiKey.UserID)
 			if err != nil {
-				utils.WriteError(w, http.StatusBadRequest, "User not found!", []error{err})
+				utils.WriteError(w, http.StatusUnauthorized, constants.MsgAuthorizationError, []error{constants.ErrInvalidAPIKey})
 				return
 			}
+			// last used tracking shouldn't fail the request
+			if err = apiKeyRepo.TouchAPIKey(apiKey.ID); err != nil {
+				log.Println("unable to record api key use", apiKey.ID, err)
+			}
 			ctx := context.WithValue(r.Context(), constants.JWTAuthUserContextKey, user)
+			ctx = context.WithValue(ctx, constants.APIKeyContextKey, apiKey)
 			r = r.WithContext(ctx)
 			next(w, r)
 		}
+	}
+}
+
+// authenticateJWT returns the user the jwt was issued to, along with the status to respond with when it can't
+func authenticateJWT(userRepo types.UserRepository, tokenStr string) (models.User, int, error) {
+	// validate jwt 
+	token, err := utils.ValidateJWT(tokenStr)
 	
-	
+	if err != nil {
+		return models.User{}, http.StatusUnauthorized, err
+	}
+	if !token.Valid {
+		err = fmt.Errorf("invalid token")
+		return models.User{}, http.StatusUnauthorized, err
+	}
+
+	claims := token.Claims.(jwt.MapClaims)
+	userIdMapKey := constants.JWTUserIdMapKey
+
+	userId := claims[string(userIdMapKey)].(string)
+
+	user, err := userRepo.RetrieveUserByID(userId)
+	if err != nil {
+		return models.User{}, http.StatusBadRequest, err
 	}
+	return user, http.StatusOK, nil
+}
 
+// RequireCustomerMiddleware only lets through users with a customer profile, it must run after RequireAuthMiddleware
+func RequireCustomerMiddleware() Middleware {
+	return func(next http.HandlerFunc ) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request)  {
+			user, err := utils.RetrieveUserFromRequestContext(r)
+			if err != nil {
+				utils.WriteError(w, http.StatusUnauthorized, constants.MsgAuthorizationError, []error{err})
+				return
+			}
+			if user.Customer == nil {
+				utils.WriteError(w, http.StatusForbidden, constants.MsgAuthorizationError, []error{constants.ErrCustomerProfileRequired})
+				return
+			}
+			next(w, r)
+		}
+	}
+}
+
+// RequireSellerMiddleware only lets through users with a seller profile, it must run after RequireAuthMiddleware
+func RequireSellerMiddleware() Middleware {
+	return func(next http.HandlerFunc ) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request)  {
+			user, err := utils.RetrieveUserFromRequestContext(r)
+			if err != nil {
+				utils.WriteError(w, http.StatusUnauthorized, constants.MsgAuthorizationError, []error{err})
+				return
+			}
+			if user.Seller == nil {
+				utils.WriteError(w, http.StatusForbidden, constants.MsgAuthorizationError, []error{constants.ErrSellerProfileRequired})
+				return
+			}
+			next(w, r)
+		}
+	}
+}
+
+// RequireApprovedSellerMiddleware only lets through sellers whose onboarding has been approved, it must run after RequireSellerMiddleware
+func RequireApprovedSellerMiddleware(onboardingRepo types.SellerOnboardingRepository) Middleware {
+	return func(next http.HandlerFunc ) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request)  {
+			user, err := utils.RetrieveUserFromRequestContext(r)
+			if err != nil {
+				utils.WriteError(w, http.StatusUnauthorized, constants.MsgAuthorizationError, []error{err})
+				return
+			}
+			if user.Seller == nil {
+				utils.WriteError(w, http.StatusForbidden, constants.MsgAuthorizationError, []error{constants.ErrSellerProfileRequired})
+				return
+			}
+			onboarding, err := onboardingRepo.RetrieveSellerOnboarding(user.Seller.ID)
+			if err != nil {
+				utils.WriteError(w, http.StatusInternalServerError, constants.MsgInternalServerError, []error{err})
+				return
+			}
+			if onboarding.Status != constants.SellerOnboardingStatuses.Approved {
+				utils.WriteError(w, http.StatusForbidden, constants.MsgAuthorizationError, []error{constants.ErrSellerNotApproved})
+				return
+			}
+			next(w, r)
+		}
+	}
+}
+
+// RequireAdminMiddleware only lets through admins, it must run after RequireAuthMiddleware
+func RequireAdminMiddleware() Middleware {
+	return func(next http.HandlerFunc ) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request)  {
+			user, err := utils.RetrieveUserFromRequestContext(r)
+			if err != nil {
+				utils.WriteError(w, http.StatusUnauthorized, constants.MsgAuthorizationError, []error{err})
+				return
+			}
+			if !user.HasRole(constants.AdminRole) {
+				utils.WriteError(w, http.StatusForbidden, constants.MsgAuthorizationError, []error{constants.ErrAdminRoleRequired})
+				return
+			}
+			next(w, r)
+		}
+	}
 }
 
 func RequirePermissionsMiddleware() {
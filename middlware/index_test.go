@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/services"
+	"github.com/kaasikodes/e-commerce-go/utils"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+const testAPIKey = "eck_abcd1234_secret"
+
+func expectAPIKeyLookup(mock sqlmock.Sqlmock, scopes string, revokedAt interface{}) {
+	mock.ExpectPrepare("SELECT (.+) FROM APIKey").ExpectQuery().WithArgs("eck_abcd1234").
+		WillReturnRows(sqlmock.NewRows([]string{"ID", "UserID", "Name", "Prefix", "KeyHash", "Scopes", "LastUsedAt", "ExpiresAt", "RevokedAt", "CreatedAt"}).
+			AddRow("key-1", "user-1", "erp", "eck_abcd1234", utils.HashToken(testAPIKey), scopes, nil, nil, revokedAt, time.Now()))
+}
+
+func serveWithAPIKey(t *testing.T, scopes string, revokedAt interface{}) *httptest.ResponseRecorder {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	expectAPIKeyLookup(mock, scopes, revokedAt)
+
+	chain := MiddlewareChain(RequireAuthOrAPIKeyMiddleware(services.NewUserRepository(db), services.NewAPIKeyRepository(db), constants.APIKeyScopeProductsWrite))
+	handler := chain(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	req := httptest.NewRequest(http.MethodPost, "/products", nil)
+	req.Header.Set("Authorization", "Bearer "+testAPIKey)
+	w := httptest.NewRecorder()
+	handler(w, req)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err)
+	}
+	return w
+}
+
+func TestRequireAuthOrAPIKeyMiddleware_RejectsKeyWithoutScope(t *testing.T) {
+	w := serveWithAPIKey(t, constants.APIKeyScopeProductsRead, nil)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusForbidden)
+	}
+}
+
+func TestRequireAuthOrAPIKeyMiddleware_RejectsRevokedKey(t *testing.T) {
+	w := serveWithAPIKey(t, constants.APIKeyScopeProductsWrite, time.Now().Add(-time.Hour))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusUnauthorized)
+	}
+}
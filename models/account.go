@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// AccountDeletionRequest is a user's request to delete their account, it is carried out once ScheduledFor has passed
+type AccountDeletionRequest struct {
+	UserID       string     `json:"userId"`
+	RequestedAt  time.Time  `json:"requestedAt"`
+	ScheduledFor time.Time  `json:"scheduledFor"`
+	CompletedAt  *time.Time `json:"completedAt"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// UserAuditLog records a change made to a user's account
+type UserAuditLog struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"userId"`
+	Action    string    `json:"action"`
+	OldValue  string    `json:"oldValue"`
+	NewValue  string    `json:"newValue"`
+	IPAddress string    `json:"ipAddress"`
+	UserAgent string    `json:"userAgent"`
+	CreatedAt time.Time `json:"createdAt"`
+}
@@ -6,6 +6,7 @@ type Cart struct {
 	ID        string     `json:"id"`
 	CustomerID    string     `json:"customerId"`
 	Items     []CartItem `json:"items"`
+	SavedForLater []SavedItem `json:"savedForLater"`
 	CreatedAt time.Time  `json:"createdAt"`
 	UpdatedAt time.Time  `json:"updatedAt"`
 }
@@ -18,4 +19,26 @@ type CartItem struct {
 	Quantity  int `json:"quantity"`
 	CreatedAt         time.Time   `json:"createdAt"`
 	UpdatedAt         time.Time   `json:"updatedAt"`
-}
\ No newline at end of file
+}
+
+// SavedItem is an item the customer moved out of their cart to buy later, it stays when the cart is checked out
+type SavedItem struct {
+	ID         string    `json:"id"`
+	CustomerID string    `json:"customerId"`
+	ProductID  string    `json:"productId"`
+	Product    Product   `json:"product"`
+	Quantity   int       `json:"quantity"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// ReorderItem is an item of a past order that couldn't be put back in the cart as it was ordered
+type ReorderItem struct {
+	ProductID     string  `json:"productId"`
+	Name          string  `json:"name"`
+	Quantity      int     `json:"quantity"`      // how many were ordered
+	AddedQuantity int     `json:"addedQuantity"` // how many were put in the cart, fewer when there isn't enough in stock
+	PreviousPrice float64 `json:"previousPrice"` // the unit price paid in the order
+	Price         int     `json:"price"`         // the unit price now
+}
+
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// IdempotencyKey is a request made with an Idempotency-Key header and, once it has been handled, the response to replay when the request is retried
+type IdempotencyKey struct {
+	UserID              string     `json:"userId"`
+	Key                 string     `json:"key"`
+	Method              string     `json:"method"`
+	Path                string     `json:"path"`
+	RequestHash         string     `json:"requestHash"`
+	ResponseStatus      int        `json:"responseStatus"`
+	ResponseContentType string     `json:"responseContentType"`
+	ResponseBody        []byte     `json:"-"`
+	CreatedAt           time.Time  `json:"createdAt"`
+	CompletedAt         *time.Time `json:"completedAt,omitempty"` // empty while the request is still being handled
+	ExpiresAt           time.Time  `json:"expiresAt"`
+}
@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// Invoice is issued by a seller for their part of a paid order, amounts are in kobo and include vat.
+// Its details are copied when it is issued so it doesn't change with the order or the products afterwards
+type Invoice struct {
+	ID            string        `json:"id"`
+	Number        string        `json:"number"` // sequential for each seller, without gaps
+	SellerID      string        `json:"sellerId"`
+	SellerName    string        `json:"sellerName"`
+	OrderID       string        `json:"orderId"`
+	OrderNumber   string        `json:"orderNumber"`
+	SubOrderID    string        `json:"subOrderId"`
+	CustomerID    string        `json:"customerId"`
+	BillToName    string        `json:"billToName"`
+	BillToAddress string        `json:"billToAddress"`
+	PaymentID     string        `json:"paymentId"`
+	PaymentMethod string        `json:"paymentMethod"`
+	PaidAt        time.Time     `json:"paidAt"`
+	Lines         []InvoiceLine `json:"lines"`
+	Subtotal      int64         `json:"subtotal"`
+	TaxRateBps    int64         `json:"taxRateBps"`
+	TaxAmount     int64         `json:"taxAmount"`
+	Total         int64         `json:"total"`
+	CreditNotes   []CreditNote  `json:"creditNotes"`
+	IssuedAt      time.Time     `json:"issuedAt"`
+}
+
+type InvoiceLine struct {
+	Description string `json:"description"`
+	Quantity    int    `json:"quantity"`
+	UnitPrice   int64  `json:"unitPrice"`
+	Total       int64  `json:"total"`
+}
+
+// CreditNote takes back some or all of an invoice when what it was for is refunded, numbered in its own sequence
+type CreditNote struct {
+	ID        string    `json:"id"`
+	Number    string    `json:"number"`
+	InvoiceID string    `json:"invoiceId"`
+	SellerID  string    `json:"sellerId"`
+	RefundID  string    `json:"refundId,omitempty"`
+	ReturnID  string    `json:"returnId,omitempty"`
+	Reason    string    `json:"reason"`
+	Subtotal  int64     `json:"subtotal"`
+	TaxAmount int64     `json:"taxAmount"`
+	Total     int64     `json:"total"`
+	IssuedAt  time.Time `json:"issuedAt"`
+}
@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+// CommissionRate overrides the default commission for a category, a seller or every sale.
+// A seller's rate wins over the category of the product, which wins over the global rate
+type CommissionRate struct {
+	Scope     string    `json:"scope"` // category, seller or global
+	ScopeID   string    `json:"scopeId"`
+	RateBps   int       `json:"rateBps"` // in basis points, 1000 is 10%
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// LedgerTransaction groups ledger entries that move money together, the amounts of its entries always add up to zero
+type LedgerTransaction struct {
+	ID          string        `json:"id"`
+	Type        string        `json:"type"`
+	ReferenceID string        `json:"referenceId"` // the order item, payout or transaction that caused it
+	Description string        `json:"description"`
+	Entries     []LedgerEntry `json:"entries"`
+	CreatedAt   time.Time     `json:"createdAt"`
+}
+
+type LedgerEntry struct {
+	ID            string    `json:"id"`
+	TransactionID string    `json:"transactionId"`
+	Account       string    `json:"account"`
+	SellerID      string    `json:"sellerId"`
+	Amount        int64     `json:"amount"` // in kobo, debits are positive and credits negative
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// SellerBalance is what the marketplace owes a seller, in kobo
+type SellerBalance struct {
+	Pending   int64 `json:"pending"`   // still within the hold period
+	Available int64 `json:"available"` // can be paid out
+	InTransit int64 `json:"inTransit"` // requested payouts that haven't been paid yet
+}
+
+// SellerStatement is the movement of a seller's balance (pending and available) over a period, amounts are in kobo
+type SellerStatement struct {
+	From           time.Time             `json:"from"`
+	To             time.Time             `json:"to"`
+	OpeningBalance int64                 `json:"openingBalance"`
+	ClosingBalance int64                 `json:"closingBalance"`
+	GrossSales     int64                 `json:"grossSales"`
+	Commission     int64                 `json:"commission"`
+	NetSales       int64                 `json:"netSales"`
+	Refunds        int64                 `json:"refunds"`
+	Payouts        int64                 `json:"payouts"`
+	Lines          []SellerStatementLine `json:"lines"`
+}
+
+type SellerStatementLine struct {
+	TransactionID string    `json:"transactionId"`
+	Type          string    `json:"type"`
+	ReferenceID   string    `json:"referenceId"`
+	Description   string    `json:"description"`
+	Amount        int64     `json:"amount"`  // what the transaction added to or took from the seller's balance
+	Balance       int64     `json:"balance"` // the seller's balance after the transaction
+	CreatedAt     time.Time `json:"createdAt"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// SellerOnboarding holds the business and payout details a seller is reviewed on before they can list products
+type SellerOnboarding struct {
+	SellerID           string           `json:"sellerId"`
+	Status             string           `json:"status"`
+	BusinessName       string           `json:"businessName"`
+	BusinessType       string           `json:"businessType"`
+	RegistrationNumber string           `json:"registrationNumber"`
+	BusinessAddress    string           `json:"businessAddress"`
+	PhoneNumber        string           `json:"phoneNumber"`
+	BankName           string           `json:"bankName"`
+	BankCode           string           `json:"bankCode"`
+	BankAccountName    string           `json:"bankAccountName"`
+	BankAccountNumber  string           `json:"-"`
+	BankAccountLast4   string           `json:"bankAccountLast4"` // the account number is only ever shown masked
+	ReviewerID         string           `json:"reviewerId"`
+	ReviewerComment    string           `json:"reviewerComment"`
+	Documents          []SellerDocument `json:"documents"`
+	SubmittedAt        *time.Time       `json:"submittedAt"`
+	ReviewedAt         *time.Time       `json:"reviewedAt"`
+	CreatedAt          time.Time        `json:"createdAt"`
+	UpdatedAt          time.Time        `json:"updatedAt"`
+}
+
+// SellerDocument is an identity or business document uploaded during onboarding, it is kept in private storage
+type SellerDocument struct {
+	ID          string    `json:"id"`
+	SellerID    string    `json:"sellerId"`
+	Type        string    `json:"type"`
+	FileUrl     string    `json:"-"`
+	ContentType string    `json:"contentType"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
@@ -4,22 +4,47 @@ import "time"
 
 type Order struct {
 	ID                string      `json:"id"`
+	Number            string      `json:"number"` // what customers and support refer to the order by, e.g. ORD-2026-000123
 	CustomerID            string      `json:"customerId"`
 	Items             []OrderItem `json:"items"`
 	Payment           Payment     `json:"payment"`
 	TotalAmount       float64         `json:"totalAmount"`
 	DeliveryAddressID string      `json:"deliveryAddressId"`
 	DeliveryAddress   Address     `json:"deliveryAddress"`
+	Status            string      `json:"status"`
+	CancelledAt       *time.Time  `json:"cancelledAt,omitempty"`
+	SubOrders         []SubOrder  `json:"subOrders"`
+	Refunds           []Refund    `json:"refunds,omitempty"`
+	Returns           []ReturnRequest `json:"returns,omitempty"`
 	CreatedAt         time.Time   `json:"createdAt"`
 	UpdatedAt         time.Time   `json:"updatedAt"`
 }
 
+// SubOrder is the part of an order that a single seller fulfils, its items are the order items of the seller's products
+type SubOrder struct {
+	ID              string      `json:"id"`
+	OrderID         string      `json:"orderId"`
+	OrderNumber     string      `json:"orderNumber"`
+	SellerID        string      `json:"sellerId"`
+	Status          string      `json:"status"`
+	Subtotal        float64     `json:"subtotal"`
+	Items           []OrderItem `json:"items,omitempty"`
+	DeliveryAddress *Address    `json:"deliveryAddress,omitempty"`
+	Shipments       []Shipment  `json:"shipments,omitempty"`
+	CancelledAt        *time.Time `json:"cancelledAt,omitempty"`
+	CancelledBy        string     `json:"cancelledBy,omitempty"`
+	CancellationReason string     `json:"cancellationReason,omitempty"`
+	CreatedAt       time.Time   `json:"createdAt"`
+	UpdatedAt       time.Time   `json:"updatedAt"`
+}
+
 type OrderItem struct {
 	ID        string `json:"id"`
 	ProductID string `json:"productId"`
 	OrderID   string `json:"orderId"`
 	Product   Product
 	Quantity  int `json:"quantity"`
+	UnitPrice int `json:"unitPrice"` // the price of the product when it was bought
 	TotalPrice float64 `json:"totalPrice"`
 	CreatedAt         time.Time   `json:"createdAt"`
 	UpdatedAt         time.Time   `json:"updatedAt"`
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Payout is a seller's request to be paid their available balance, the bank details are the ones they had when they asked
+type Payout struct {
+	ID                string     `json:"id"`
+	SellerID          string     `json:"sellerId"`
+	Amount            int64      `json:"amount"` // in kobo
+	Status            string     `json:"status"`
+	BatchID           string     `json:"batchId"`
+	BankName          string     `json:"bankName"`
+	BankCode          string     `json:"bankCode"`
+	BankAccountName   string     `json:"bankAccountName"`
+	BankAccountNumber string     `json:"-"`
+	BankAccountLast4  string     `json:"bankAccountLast4"`
+	GatewayReference  string     `json:"gatewayReference"`
+	FailureReason     string     `json:"failureReason"`
+	CompletedAt       *time.Time `json:"completedAt"`
+	CreatedAt         time.Time  `json:"createdAt"`
+	UpdatedAt         time.Time  `json:"updatedAt"`
+}
+
+// PayoutBatch is a set of payouts sent out together, either through the payment gateway or by manual bank transfer
+type PayoutBatch struct {
+	ID        string    `json:"id"`
+	Method    string    `json:"method"`
+	CreatedBy string    `json:"createdBy"`
+	Payouts   []Payout  `json:"payouts"`
+	CreatedAt time.Time `json:"createdAt"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// ProductPriceChange is a change to what customers pay for a product, or to the regular price it goes back to after a sale
+type ProductPriceChange struct {
+	ID        string    `json:"id"`
+	ProductID string    `json:"productId"`
+	SaleID    string    `json:"saleId,omitempty"` // the sale that started or ended, if the change came from one
+	OldPrice  int       `json:"oldPrice"`
+	NewPrice  int       `json:"newPrice"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// ProductSale lowers the price of a product between StartsAt and EndsAt
+type ProductSale struct {
+	ID           string    `json:"id"`
+	ProductID    string    `json:"productId"`
+	SalePrice    int       `json:"salePrice"`
+	RegularPrice *int      `json:"regularPrice,omitempty"` // the price of the product when the sale started
+	StartsAt     time.Time `json:"startsAt"`
+	EndsAt       time.Time `json:"endsAt"`
+	Status       string    `json:"status"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
@@ -7,13 +7,26 @@ type Product struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
 	Price       int    `json:"price"`
+	WasPrice    *int   `json:"wasPrice,omitempty"` // the regular price while the product is on sale, Price is what it costs now
 	Quantity    int    `json:"quantity"`
+	ReorderThreshold int `json:"reorderThreshold"` // the seller is told when the quantity drops to it, 0 turns it off
 	CategoryID  string `json:"categoryId"`
 	SellerID     string `json:"sellerId"`
 	Seller       *Seller
 	Category    *Category
+	Rating      ProductRating `json:"rating"`
+	Questions   []ProductQuestion `json:"questions,omitempty"` // the latest questions, only filled in when a single product is retrieved
+	Status      string     `json:"status"`
+	PublishAt   *time.Time `json:"publishAt,omitempty"`   // when a draft goes on sale
+	UnpublishAt *time.Time `json:"unpublishAt,omitempty"` // when an active product is archived
+	DeletedAt   *time.Time `json:"deletedAt,omitempty"`   // deleted products are kept for the orders they are part of
 	CreatedAt   time.Time `json:"createdAt"`
 	UpdatedAt   time.Time `json:"updatedAt"`
 }
 
-
+// ProductRating sums up the visible reviews of a product, it is kept up to date as reviews are written, edited and moderated
+type ProductRating struct {
+	Average      float64     `json:"average"`
+	Count        int         `json:"count"`
+	Distribution map[int]int `json:"distribution"` // the number of reviews with each star rating, 1 to 5
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// ProductQuestion is a question a customer asked about a product before buying it
+type ProductQuestion struct {
+	ID               string          `json:"id"`
+	ProductID        string          `json:"productId"`
+	CustomerID       string          `json:"customerId"`
+	AskerName        string          `json:"askerName"`
+	AskerUserID      string          `json:"-"`
+	AskerEmail       string          `json:"-"` // told when the question is answered
+	Question         string          `json:"question"`
+	Status           string          `json:"status"`
+	ModerationReason string          `json:"moderationReason,omitempty"`
+	Answers          []ProductAnswer `json:"answers"`
+	CreatedAt        time.Time       `json:"createdAt"`
+	UpdatedAt        time.Time       `json:"updatedAt"`
+}
+
+// ProductAnswer is an answer to a product question by the product's seller or a customer who has bought the product
+type ProductAnswer struct {
+	ID               string    `json:"id"`
+	QuestionID       string    `json:"questionId"`
+	UserID           string    `json:"-"`
+	AuthorName       string    `json:"authorName"`
+	AuthorRole       string    `json:"authorRole"` // seller or verified_buyer
+	Answer           string    `json:"answer"`
+	Status           string    `json:"status"`
+	ModerationReason string    `json:"moderationReason,omitempty"`
+	CreatedAt        time.Time `json:"createdAt"`
+	UpdatedAt        time.Time `json:"updatedAt"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// Refund is money given back on a payment when all or part of its order is cancelled or returned, amounts are in kobo
+type Refund struct {
+	ID               string    `json:"id"`
+	PaymentID        string    `json:"paymentId"`
+	OrderID          string    `json:"orderId"`
+	SubOrderID       string    `json:"subOrderId,omitempty"` // empty when the whole order was cancelled at once
+	ReturnID         string    `json:"returnId,omitempty"`
+	Amount           int64     `json:"amount"`
+	Status           string    `json:"status"`
+	GatewayReference string    `json:"gatewayReference,omitempty"`
+	FailureReason    string    `json:"failureReason,omitempty"`
+	CreatedAt        time.Time `json:"createdAt"`
+	UpdatedAt        time.Time `json:"updatedAt"`
+}
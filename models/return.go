@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// ReturnRequest is a customer's request to send back some or all of an order item, amounts are in kobo
+type ReturnRequest struct {
+	ID                  string        `json:"id"`
+	OrderID             string        `json:"orderId"`
+	OrderItemID         string        `json:"orderItemId"`
+	SubOrderID          string        `json:"subOrderId"`
+	CustomerID          string        `json:"customerId"`
+	SellerID            string        `json:"sellerId"`
+	ProductID           string        `json:"productId"`
+	Quantity            int           `json:"quantity"`
+	Reason              string        `json:"reason"`
+	Details             string        `json:"details"`
+	PreferredResolution string        `json:"preferredResolution"`
+	Status              string        `json:"status"`
+	Photos              []ReturnPhoto `json:"photos,omitempty"`
+	Instructions        string        `json:"instructions,omitempty"` // how to send the item back, given when the return is approved
+	LabelUrl            string        `json:"labelUrl,omitempty"`
+	RejectionReason     string        `json:"rejectionReason,omitempty"`
+	InspectionNotes     string        `json:"inspectionNotes,omitempty"`
+	Restocked           bool          `json:"restocked"`
+	Resolution          string        `json:"resolution,omitempty"`
+	Amount              int64         `json:"amount"`                       // refunded or given as store credit
+	RefundID            string        `json:"refundId,omitempty"`           // the refund of the payment, when resolved with a refund
+	ReplacementOrderID  string        `json:"replacementOrderId,omitempty"` // when resolved with a replacement
+	ApprovedAt          *time.Time    `json:"approvedAt"`
+	ReceivedAt          *time.Time    `json:"receivedAt"`
+	ResolvedAt          *time.Time    `json:"resolvedAt"`
+	CreatedAt           time.Time     `json:"createdAt"`
+	UpdatedAt           time.Time     `json:"updatedAt"`
+}
+
+// ReturnPhoto shows the state of a returned item, it is kept in private storage
+type ReturnPhoto struct {
+	ID          string    `json:"id"`
+	ReturnID    string    `json:"returnId"`
+	FileUrl     string    `json:"-"`
+	ContentType string    `json:"contentType"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// StoreCredit is what a customer has been given as store credit for returned items, amounts are in kobo
+type StoreCredit struct {
+	Balance int64              `json:"balance"`
+	Entries []StoreCreditEntry `json:"entries"`
+}
+
+type StoreCreditEntry struct {
+	ID          string    `json:"id"`
+	CustomerID  string    `json:"customerId"`
+	ReturnID    string    `json:"returnId,omitempty"`
+	Amount      int64     `json:"amount"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
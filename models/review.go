@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// ProductReview is a verified buyer's rating of a product, the seller of the product can respond to it
+type ProductReview struct {
+	ID                string     `json:"id"`
+	ProductID         string     `json:"productId"`
+	CustomerID        string     `json:"customerId"`
+	AuthorName        string     `json:"authorName"`
+	Rating            int        `json:"rating"` // 1 to 5 stars
+	Title             string     `json:"title"`
+	Body              string     `json:"body"`
+	Status            string     `json:"status"`
+	ModerationReason  string     `json:"moderationReason,omitempty"`
+	HelpfulCount      int        `json:"helpfulCount"`
+	SellerResponse    string     `json:"sellerResponse,omitempty"`
+	SellerRespondedAt *time.Time `json:"sellerRespondedAt,omitempty"`
+	CreatedAt         time.Time  `json:"createdAt"`
+	UpdatedAt         time.Time  `json:"updatedAt"`
+}
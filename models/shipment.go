@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Shipment is a parcel a seller sent for their sub-order, a sub-order can be shipped in parts
+type Shipment struct {
+	ID             string         `json:"id"`
+	SubOrderID     string         `json:"subOrderId"`
+	Carrier        string         `json:"carrier"`
+	TrackingNumber string         `json:"trackingNumber"`
+	TrackingUrl    string         `json:"trackingUrl"`
+	Status         string         `json:"status"`
+	Items          []ShipmentItem `json:"items"`
+	ShippedAt      time.Time      `json:"shippedAt"`
+	DeliveredAt    *time.Time     `json:"deliveredAt"`
+	CreatedAt      time.Time      `json:"createdAt"`
+	UpdatedAt      time.Time      `json:"updatedAt"`
+}
+
+// ShipmentItem is how much of an order item went out in a shipment
+type ShipmentItem struct {
+	OrderItemID string `json:"orderItemId"`
+	ProductID   string `json:"productId"`
+	Quantity    int    `json:"quantity"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// StockMovement is a change to the quantity of a product
+type StockMovement struct {
+	ID            string    `json:"id"`
+	ProductID     string    `json:"productId"`
+	Change        int       `json:"change"` // negative when stock went out
+	QuantityAfter int       `json:"quantityAfter"`
+	Reason        string    `json:"reason"`
+	Actor         string    `json:"actor"`               // seller, customer or system
+	ActorID       string    `json:"actorId,omitempty"`   // the seller or customer, if the change was made by one
+	Reference     string    `json:"reference,omitempty"` // the order or return the change was part of
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// LowStockDigest lists the products of a seller that are at or below their reorder threshold
+type LowStockDigest struct {
+	SellerID    string            `json:"sellerId"`
+	SellerEmail string            `json:"sellerEmail"`
+	Products    []LowStockProduct `json:"products"`
+}
+
+type LowStockProduct struct {
+	ProductID        string `json:"productId"`
+	Name             string `json:"name"`
+	Quantity         int    `json:"quantity"`
+	ReorderThreshold int    `json:"reorderThreshold"`
+}
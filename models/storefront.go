@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Storefront is how a seller presents themselves to buyers, it is shown publicly on the shop page
+type Storefront struct {
+	SellerID       string    `json:"sellerId"`
+	ShopName       string    `json:"shopName"`
+	Slug           string    `json:"slug"`
+	LogoUrl        string    `json:"logoUrl"`
+	BannerUrl      string    `json:"bannerUrl"`
+	Description    string    `json:"description"`
+	ReturnPolicy   string    `json:"returnPolicy"`
+	ShippingPolicy string    `json:"shippingPolicy"`
+	ContactEmail   string    `json:"contactEmail"`
+	ContactPhone   string    `json:"contactPhone"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+type ShopRating struct {
+	Average float64 `json:"average"`
+	Count   int     `json:"count"`
+}
+
+type ShopStats struct {
+	ActiveProducts int       `json:"activeProducts"`
+	UnitsSold      int       `json:"unitsSold"` // only counts paid orders
+	MemberSince    time.Time `json:"memberSince"`
+}
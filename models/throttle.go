@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+type AuthThrottle struct {
+	ID             string    `json:"id"`
+	Key            string    `json:"key"`
+	FailedAttempts int       `json:"failedAttempts"`
+	LastFailedAt   time.Time `json:"lastFailedAt"`
+	LockedUntil    time.Time `json:"lockedUntil"`
+	// LockoutNotified is set once the owner has been told about the current lockout
+	LockoutNotified bool `json:"lockoutNotified"`
+	// NotifyLockout is only set on the failure that should tell the owner about the lockout
+	NotifyLockout bool `json:"-"`
+}
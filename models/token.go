@@ -2,17 +2,40 @@ package models
 
 import "time"
 
+// Only the hash of a token is stored, Token holds the plain value and is only set when the token is created
 type VerificationToken struct {
 	ID          string    `json:"id"`
 	Email        string    `json:"email"`
-	Token string    `json:"token"`
+	Token string    `json:"-"`
+	TokenHash string    `json:"-"`
 	CreatedAt   time.Time `json:"createdAt"`
 	ExpiresAt   time.Time `json:"expiresAt"`
 }
 type PasswordResetToken struct {
 	ID          string    `json:"id"`
 	Email        string    `json:"email"`
-	Token string    `json:"token"`
+	Token string    `json:"-"`
+	TokenHash string    `json:"-"`
 	CreatedAt   time.Time `json:"createdAt"`
 	ExpiresAt   time.Time `json:"expiresAt"`
-}
\ No newline at end of file
+}
+// MagicLinkToken is bound to the device that asked for it, only the hash of that device's secret is stored
+type MagicLinkToken struct {
+	ID          string    `json:"id"`
+	Email        string    `json:"email"`
+	Token string    `json:"-"`
+	TokenHash string    `json:"-"`
+	DeviceHash string    `json:"-"`
+	CreatedAt   time.Time `json:"createdAt"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+}
+// EmailChangeToken confirms that a user owns the new email they want to switch to
+type EmailChangeToken struct {
+	ID          string    `json:"id"`
+	UserID        string    `json:"userId"`
+	NewEmail        string    `json:"newEmail"`
+	Token string    `json:"-"`
+	TokenHash string    `json:"-"`
+	CreatedAt   time.Time `json:"createdAt"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+}
@@ -10,12 +10,23 @@ type User struct {
 	Image     string `json:"image"`
 	Customer  *Customer `json:"customer"`
 	Seller    *Seller `json:"seller"`
+	Roles     []string `json:"roles"`
 	EmailVerified bool `json:"emailVerified"`
 	EmailVerifiedAt time.Time `json:"emailVerifiedAt"`
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
 }
 
+// HasRole reports whether the user has been given the role
+func (u User) HasRole(role string) bool {
+	for _, r := range u.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
 type Customer struct {
 	ID     string  `json:"id"`
 	UserID string  `json:"userId"`
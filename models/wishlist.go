@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Wishlist is a named list of products a customer is keeping an eye on, it can be shared through a link while ShareToken is set
+type Wishlist struct {
+	ID         string         `json:"id"`
+	CustomerID string         `json:"customerId,omitempty"` // left out of shared wishlists
+	Name       string         `json:"name"`
+	ShareToken string         `json:"shareToken,omitempty"`
+	Items      []WishlistItem `json:"items"`
+	CreatedAt  time.Time      `json:"createdAt"`
+	UpdatedAt  time.Time      `json:"updatedAt"`
+}
+
+// WishlistItem is a product on a wishlist. The customer is emailed when they opted in and the product comes back in stock or gets cheaper,
+// LastSeenPrice and LastSeenQuantity are what the product was like when it was added or last checked
+type WishlistItem struct {
+	ID                string    `json:"id"`
+	WishlistID        string    `json:"wishlistId"`
+	ProductID         string    `json:"productId"`
+	Product           Product   `json:"product"`
+	NotifyWhenInStock bool      `json:"notifyWhenInStock"`
+	NotifyOnPriceDrop bool      `json:"notifyOnPriceDrop"`
+	LastSeenPrice     int       `json:"-"`
+	LastSeenQuantity  int       `json:"-"`
+	CreatedAt         time.Time `json:"createdAt"`
+	UpdatedAt         time.Time `json:"updatedAt"`
+}
+
+// WishlistAlert is a wishlisted product that came back in stock or dropped in price since the customer last heard about it
+type WishlistAlert struct {
+	ItemID        string `json:"itemId"`
+	CustomerEmail string `json:"customerEmail"`
+	WishlistName  string `json:"wishlistName"`
+	ProductID     string `json:"productId"`
+	ProductName   string `json:"productName"`
+	Restocked     bool   `json:"restocked"`
+	PreviousPrice int    `json:"previousPrice"`
+	Price         int    `json:"price"`
+	Quantity      int    `json:"quantity"`
+}
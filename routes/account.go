@@ -0,0 +1,32 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/kaasikodes/e-commerce-go/controllers"
+	middleware "github.com/kaasikodes/e-commerce-go/middlware"
+	"github.com/kaasikodes/e-commerce-go/types"
+)
+
+type AccountRoutes struct {
+	userRepo types.UserRepository
+	accountRepo types.AccountRepository
+}
+
+func NewAccountRoutes(userRepo types.UserRepository, accountRepo types.AccountRepository) *AccountRoutes {
+	return &AccountRoutes{
+		userRepo: userRepo,
+		accountRepo: accountRepo,
+	}
+}
+
+func (c *AccountRoutes) RegisterAccountRoutes (router *mux.Router){
+	controller := controllers.NewAccountController(c.accountRepo)
+	middlewareChain := middleware.MiddlewareChain(middleware.RequireAuthMiddleware(c.userRepo))
+
+	router.HandleFunc("/me/export", middlewareChain(controller.ExportAccountDataHandler)).Methods(http.MethodGet)
+	router.HandleFunc("/me", middlewareChain(controller.DeleteAccountHandler)).Methods(http.MethodDelete)
+	router.HandleFunc("/me/restore", middlewareChain(controller.RestoreAccountHandler)).Methods(http.MethodPost)
+
+}
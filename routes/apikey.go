@@ -0,0 +1,33 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/kaasikodes/e-commerce-go/controllers"
+	middleware "github.com/kaasikodes/e-commerce-go/middlware"
+	"github.com/kaasikodes/e-commerce-go/types"
+)
+
+type APIKeyRoutes struct {
+	userRepo types.UserRepository
+	apiKeyRepo types.APIKeyRepository
+}
+
+func NewAPIKeyRoutes(userRepo types.UserRepository, apiKeyRepo types.APIKeyRepository) *APIKeyRoutes {
+	return &APIKeyRoutes{
+		userRepo: userRepo,
+		apiKeyRepo: apiKeyRepo,
+	}
+}
+
+func (c *APIKeyRoutes) RegisterAPIKeyRoutes (router *mux.Router){
+	controller := controllers.NewAPIKeyController(c.apiKeyRepo)
+	// keys are managed with a jwt only, so a leaked key can't be used to mint more keys
+	sellerMiddlewareChain := middleware.MiddlewareChain(middleware.RequireAuthMiddleware(c.userRepo), middleware.RequireSellerMiddleware())
+
+	router.HandleFunc("/api-keys", sellerMiddlewareChain(controller.CreateAPIKeyHandler)).Methods(http.MethodPost)
+	router.HandleFunc("/api-keys", sellerMiddlewareChain(controller.GetAPIKeysHandler)).Methods(http.MethodGet)
+	router.HandleFunc("/api-keys/{id}", sellerMiddlewareChain(controller.RevokeAPIKeyHandler)).Methods(http.MethodDelete)
+
+}
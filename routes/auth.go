@@ -12,12 +12,14 @@ import (
 type AuthRoutes struct {
 	userRepo types.UserRepository
 	tokenRepo types.TokenRepository
+	throttleRepo types.AuthThrottleRepository
 }
 
-func NewAuthRoutes(userRepo types.UserRepository, tokenRepo types.TokenRepository) *AuthRoutes {
+func NewAuthRoutes(userRepo types.UserRepository, tokenRepo types.TokenRepository, throttleRepo types.AuthThrottleRepository) *AuthRoutes {
 	return &AuthRoutes{
 		userRepo: userRepo,
 		tokenRepo: tokenRepo,
+		throttleRepo: throttleRepo,
 	}
 }
 
@@ -25,12 +27,14 @@ func (c *AuthRoutes) RegisterAuthRoutes (router *mux.Router){
 
 	middlewareChain := middleware.MiddlewareChain(middleware.RequireAuthMiddleware(c.userRepo))
 
-	controller := controllers.NewAuthController(c.userRepo, c.tokenRepo)
+	controller := controllers.NewAuthController(c.userRepo, c.tokenRepo, c.throttleRepo)
 	router.HandleFunc("/register", controller.RegisterUserHandler).Methods(http.MethodPost)
 	router.HandleFunc("/login", controller.LoginUser).Methods(http.MethodPost)
 	router.HandleFunc("/forgot-password", controller.ForgotPwdHandler).Methods(http.MethodPost)
 	router.HandleFunc("/reset-password", controller.ResetPwdrHandler).Methods(http.MethodPatch)
 	router.HandleFunc("/verify-user", controller.VerifyUserHandler).Methods(http.MethodPost)
+	router.HandleFunc("/auth/magic-link", controller.MagicLinkHandler).Methods(http.MethodPost)
+	router.HandleFunc("/auth/magic-link/verify", controller.VerifyMagicLinkHandler).Methods(http.MethodPost)
 	router.HandleFunc("/me/profile", middlewareChain(controller.AuthProfile)).Methods(http.MethodGet)
 	router.HandleFunc("/me/change-password", middlewareChain(controller.ChangePassword)).Methods(http.MethodPatch)
 }
\ No newline at end of file
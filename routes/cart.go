@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/gorilla/mux"
+	"github.com/kaasikodes/e-commerce-go/constants"
 	"github.com/kaasikodes/e-commerce-go/controllers"
 	middleware "github.com/kaasikodes/e-commerce-go/middlware"
 	"github.com/kaasikodes/e-commerce-go/types"
@@ -15,27 +16,41 @@ type CartRoutes struct {
 	orderRepo types.OrderRepository
 	paymentRepo types.PaymentRepository
 	addressRepo types.AddressRepository
+	ledgerRepo types.LedgerRepository
+	refundRepo types.RefundRepository
+	invoiceRepo types.InvoiceRepository
+	idempotencyRepo types.IdempotencyKeyRepository
 }
 
-func NewCartRoutes(  cartRepo types.CartRepository,  userRepo types.UserRepository, orderRepo types.OrderRepository, paymentRepo types.PaymentRepository, addressRepo types.AddressRepository) *CartRoutes {
+func NewCartRoutes(  cartRepo types.CartRepository,  userRepo types.UserRepository, orderRepo types.OrderRepository, paymentRepo types.PaymentRepository, addressRepo types.AddressRepository, ledgerRepo types.LedgerRepository, refundRepo types.RefundRepository, invoiceRepo types.InvoiceRepository, idempotencyRepo types.IdempotencyKeyRepository) *CartRoutes {
 	return &CartRoutes{
 		cartRepo: cartRepo,
 		userRepo: userRepo,
 		orderRepo: orderRepo,
 		paymentRepo: paymentRepo,
 		addressRepo: addressRepo,
+		ledgerRepo: ledgerRepo,
+		refundRepo: refundRepo,
+		invoiceRepo: invoiceRepo,
+		idempotencyRepo: idempotencyRepo,
 	}
 }
 
 func (c *CartRoutes) RegisterCartRoutes (router *mux.Router){
-	controller := controllers.NewCartController(c.cartRepo, c.orderRepo, c.paymentRepo, c.addressRepo)
-	middlewareChain := middleware.MiddlewareChain(middleware.RequireAuthMiddleware(c.userRepo))
+	controller := controllers.NewCartController(c.cartRepo, c.orderRepo, c.paymentRepo, c.addressRepo, c.ledgerRepo, c.refundRepo, c.invoiceRepo)
+	middlewareChain := middleware.MiddlewareChain(middleware.RequireAuthMiddleware(c.userRepo), middleware.RequireCustomerMiddleware())
 	
 	router.HandleFunc("/cart", middlewareChain(controller.SaveCartHandler)).Methods(http.MethodPost)
-	router.HandleFunc("/cart/checkout", middlewareChain(controller.CheckoutCartHandler)).Methods(http.MethodPost)
+	// a retried checkout mustn't place the order and start the payment twice
+	router.HandleFunc("/cart/checkout", middleware.MiddlewareChain(middlewareChain, middleware.IdempotencyMiddleware(c.idempotencyRepo, constants.IdempotencyKeyTTL))(controller.CheckoutCartHandler)).Methods(http.MethodPost)
 	router.HandleFunc("/cart/checkout/verify-payment/{reference}", middlewareChain(controller.VerifyPaymentHandler)).Methods(http.MethodGet)
 	router.HandleFunc("/cart", middlewareChain(controller.GetCartHandler)).Methods(http.MethodGet)
 	router.HandleFunc("/cart", middlewareChain(controller.DeleteCartHandler)).Methods(http.MethodDelete)
+	router.HandleFunc("/cart/saved", middlewareChain(controller.GetSavedItemsHandler)).Methods(http.MethodGet)
+	router.HandleFunc("/cart/items/{productId}/save-for-later", middlewareChain(controller.SaveForLaterHandler)).Methods(http.MethodPost)
+	router.HandleFunc("/cart/saved/{productId}/move-to-cart", middlewareChain(controller.MoveToCartHandler)).Methods(http.MethodPost)
+	router.HandleFunc("/cart/saved/{productId}", middlewareChain(controller.DeleteSavedItemHandler)).Methods(http.MethodDelete)
+	router.HandleFunc("/orders/{id}/reorder", middlewareChain(controller.ReorderHandler)).Methods(http.MethodPost)
 
 	
 	
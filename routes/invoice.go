@@ -0,0 +1,33 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/kaasikodes/e-commerce-go/controllers"
+	middleware "github.com/kaasikodes/e-commerce-go/middlware"
+	"github.com/kaasikodes/e-commerce-go/types"
+)
+
+type InvoiceRoutes struct {
+	userRepo types.UserRepository
+	invoiceRepo types.InvoiceRepository
+	orderRepo types.OrderRepository
+}
+
+func NewInvoiceRoutes(userRepo types.UserRepository, invoiceRepo types.InvoiceRepository, orderRepo types.OrderRepository) *InvoiceRoutes {
+	return &InvoiceRoutes{
+		userRepo: userRepo,
+		invoiceRepo: invoiceRepo,
+		orderRepo: orderRepo,
+	}
+}
+
+func (c *InvoiceRoutes) RegisterInvoiceRoutes(router *mux.Router){
+	controller := controllers.NewInvoiceController(c.invoiceRepo, c.orderRepo)
+	customerMiddlewareChain := middleware.MiddlewareChain(middleware.RequireAuthMiddleware(c.userRepo), middleware.RequireCustomerMiddleware())
+	sellerMiddlewareChain := middleware.MiddlewareChain(middleware.RequireAuthMiddleware(c.userRepo), middleware.RequireSellerMiddleware())
+
+	router.HandleFunc("/orders/{id}/invoice.pdf", customerMiddlewareChain(controller.GetOrderInvoiceHandler)).Methods(http.MethodGet)
+	router.HandleFunc("/seller/orders/{id}/invoice.pdf", sellerMiddlewareChain(controller.GetSellerInvoiceHandler)).Methods(http.MethodGet)
+}
@@ -0,0 +1,29 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/kaasikodes/e-commerce-go/controllers"
+	"github.com/kaasikodes/e-commerce-go/types"
+)
+
+type OIDCRoutes struct {
+	userRepo types.UserRepository
+	identityRepo types.UserIdentityRepository
+	providers map[string]types.OIDCClient
+}
+
+func NewOIDCRoutes(userRepo types.UserRepository, identityRepo types.UserIdentityRepository, providers map[string]types.OIDCClient) *OIDCRoutes {
+	return &OIDCRoutes{
+		userRepo: userRepo,
+		identityRepo: identityRepo,
+		providers: providers,
+	}
+}
+
+func (c *OIDCRoutes) RegisterOIDCRoutes (router *mux.Router){
+	controller := controllers.NewOIDCController(c.userRepo, c.identityRepo, c.providers)
+	router.HandleFunc("/auth/oidc/{provider}/start", controller.StartOIDCLoginHandler).Methods(http.MethodGet)
+	router.HandleFunc("/auth/oidc/{provider}/callback", controller.OIDCCallbackHandler).Methods(http.MethodPost)
+}
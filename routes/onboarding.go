@@ -0,0 +1,41 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/kaasikodes/e-commerce-go/controllers"
+	middleware "github.com/kaasikodes/e-commerce-go/middlware"
+	"github.com/kaasikodes/e-commerce-go/types"
+)
+
+type SellerOnboardingRoutes struct {
+	userRepo types.UserRepository
+	onboardingRepo types.SellerOnboardingRepository
+	storage types.FileStorage
+}
+
+func NewSellerOnboardingRoutes(userRepo types.UserRepository, onboardingRepo types.SellerOnboardingRepository, storage types.FileStorage) *SellerOnboardingRoutes {
+	return &SellerOnboardingRoutes{
+		userRepo: userRepo,
+		onboardingRepo: onboardingRepo,
+		storage: storage,
+	}
+}
+
+func (c *SellerOnboardingRoutes) RegisterSellerOnboardingRoutes (router *mux.Router){
+	controller := controllers.NewSellerOnboardingController(c.onboardingRepo, c.userRepo, c.storage)
+	sellerMiddlewareChain := middleware.MiddlewareChain(middleware.RequireAuthMiddleware(c.userRepo), middleware.RequireSellerMiddleware())
+	adminMiddlewareChain := middleware.MiddlewareChain(middleware.RequireAuthMiddleware(c.userRepo), middleware.RequireAdminMiddleware())
+
+	router.HandleFunc("/seller/onboarding", sellerMiddlewareChain(controller.GetOnboardingHandler)).Methods(http.MethodGet)
+	router.HandleFunc("/seller/onboarding", sellerMiddlewareChain(controller.SaveOnboardingHandler)).Methods(http.MethodPut)
+	router.HandleFunc("/seller/onboarding/documents", sellerMiddlewareChain(controller.UploadDocumentHandler)).Methods(http.MethodPost)
+	router.HandleFunc("/seller/onboarding/submit", sellerMiddlewareChain(controller.SubmitOnboardingHandler)).Methods(http.MethodPost)
+
+	router.HandleFunc("/admin/seller-onboardings", adminMiddlewareChain(controller.GetOnboardingsHandler)).Methods(http.MethodGet)
+	router.HandleFunc("/admin/seller-onboardings/{sellerId}", adminMiddlewareChain(controller.GetSellerOnboardingHandler)).Methods(http.MethodGet)
+	router.HandleFunc("/admin/seller-onboardings/{sellerId}/documents/{documentId}", adminMiddlewareChain(controller.GetSellerDocumentHandler)).Methods(http.MethodGet)
+	router.HandleFunc("/admin/seller-onboardings/{sellerId}/review", adminMiddlewareChain(controller.ReviewOnboardingHandler)).Methods(http.MethodPost)
+
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/gorilla/mux"
+	"github.com/kaasikodes/e-commerce-go/constants"
 	"github.com/kaasikodes/e-commerce-go/controllers"
 	middleware "github.com/kaasikodes/e-commerce-go/middlware"
 	"github.com/kaasikodes/e-commerce-go/types"
@@ -12,23 +13,35 @@ import (
 type OrderRoutes struct {
 	orderRepo types.OrderRepository
 	userRepo types.UserRepository
+	apiKeyRepo types.APIKeyRepository
+	idempotencyRepo types.IdempotencyKeyRepository
 }
 
-func NewOrderRoutes(  orderRepo types.OrderRepository,  userRepo types.UserRepository) *OrderRoutes {
+func NewOrderRoutes(  orderRepo types.OrderRepository,  userRepo types.UserRepository, apiKeyRepo types.APIKeyRepository, idempotencyRepo types.IdempotencyKeyRepository) *OrderRoutes {
 	return &OrderRoutes{
 		orderRepo: orderRepo,
 		userRepo: userRepo,
+		apiKeyRepo: apiKeyRepo,
+		idempotencyRepo: idempotencyRepo,
 	}
 }
 
 func (c *OrderRoutes) RegisterOrderRoutes (router *mux.Router){
 	controller := controllers.NewOrderController( c.orderRepo,  c.userRepo)
-	middlewareChain := middleware.MiddlewareChain(middleware.RequireAuthMiddleware(c.userRepo))
+	middlewareChain := middleware.MiddlewareChain(middleware.RequireAuthMiddleware(c.userRepo), middleware.RequireCustomerMiddleware())
+	// api keys with the orders:read scope can read a seller's orders, but not change them
+	sellerReadMiddlewareChain := middleware.MiddlewareChain(middleware.RequireAuthOrAPIKeyMiddleware(c.userRepo, c.apiKeyRepo, constants.APIKeyScopeOrdersRead), middleware.RequireSellerMiddleware())
+	sellerWriteMiddlewareChain := middleware.MiddlewareChain(middleware.RequireAuthMiddleware(c.userRepo), middleware.RequireSellerMiddleware())
 	
-	router.HandleFunc("/orders/{id}", middlewareChain(controller.DeleteOrderHandler)).Methods(http.MethodDelete)
+	router.HandleFunc("/orders/{id}/cancel", middleware.MiddlewareChain(middlewareChain, middleware.IdempotencyMiddleware(c.idempotencyRepo, constants.IdempotencyKeyTTL))(controller.CancelOrderHandler)).Methods(http.MethodPost)
 	router.HandleFunc("/orders/{id}", middlewareChain(controller.GetOrderHandler)).Methods(http.MethodGet)
 	router.HandleFunc("/orders", middlewareChain(controller.GetOrdersHandler)).Methods(http.MethodGet)
 
+	router.HandleFunc("/seller/orders", sellerReadMiddlewareChain(controller.GetSellerOrdersHandler)).Methods(http.MethodGet)
+	router.HandleFunc("/seller/orders/{id}", sellerReadMiddlewareChain(controller.GetSellerOrderHandler)).Methods(http.MethodGet)
+	router.HandleFunc("/seller/orders/{id}/status", sellerWriteMiddlewareChain(controller.UpdateSellerOrderStatusHandler)).Methods(http.MethodPatch)
+	router.HandleFunc("/seller/orders/{id}/cancel", middleware.MiddlewareChain(sellerWriteMiddlewareChain, middleware.IdempotencyMiddleware(c.idempotencyRepo, constants.IdempotencyKeyTTL))(controller.CancelSellerOrderHandler)).Methods(http.MethodPost)
+
 
 	
 	
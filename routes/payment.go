@@ -23,7 +23,7 @@ func NewPaymentRoutes(  paymentRepo types.PaymentRepository,  userRepo types.Use
 
 func (c *PaymentRoutes) RegisterPaymentRoutes (router *mux.Router){
 	controller := controllers.NewPaymentController(c.paymentRepo)
-	middlewareChain := middleware.MiddlewareChain(middleware.RequireAuthMiddleware(c.userRepo))
+	middlewareChain := middleware.MiddlewareChain(middleware.RequireAuthMiddleware(c.userRepo), middleware.RequireCustomerMiddleware())
 	
 	router.HandleFunc("/payments/{id}", middlewareChain(controller.GetPaymentHandler)).Methods(http.MethodGet)
 	router.HandleFunc("/payments", middlewareChain(controller.GetPaymentsHandler)).Methods(http.MethodGet)
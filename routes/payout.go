@@ -0,0 +1,55 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/controllers"
+	middleware "github.com/kaasikodes/e-commerce-go/middlware"
+	"github.com/kaasikodes/e-commerce-go/types"
+)
+
+type PayoutRoutes struct {
+	userRepo types.UserRepository
+	ledgerRepo types.LedgerRepository
+	payoutRepo types.PayoutRepository
+	onboardingRepo types.SellerOnboardingRepository
+	gateway types.PayoutGateway
+	idempotencyRepo types.IdempotencyKeyRepository
+}
+
+func NewPayoutRoutes(userRepo types.UserRepository, ledgerRepo types.LedgerRepository, payoutRepo types.PayoutRepository, onboardingRepo types.SellerOnboardingRepository, gateway types.PayoutGateway, idempotencyRepo types.IdempotencyKeyRepository) *PayoutRoutes {
+	return &PayoutRoutes{
+		userRepo: userRepo,
+		ledgerRepo: ledgerRepo,
+		payoutRepo: payoutRepo,
+		onboardingRepo: onboardingRepo,
+		gateway: gateway,
+		idempotencyRepo: idempotencyRepo,
+	}
+}
+
+func (c *PayoutRoutes) RegisterPayoutRoutes (router *mux.Router){
+	controller := controllers.NewPayoutController(c.ledgerRepo, c.payoutRepo, c.gateway)
+	sellerMiddlewareChain := middleware.MiddlewareChain(middleware.RequireAuthMiddleware(c.userRepo), middleware.RequireSellerMiddleware())
+	// payouts go to the bank account that was reviewed during onboarding
+	approvedSellerMiddlewareChain := middleware.MiddlewareChain(middleware.RequireAuthMiddleware(c.userRepo), middleware.RequireSellerMiddleware(), middleware.RequireApprovedSellerMiddleware(c.onboardingRepo))
+	adminMiddlewareChain := middleware.MiddlewareChain(middleware.RequireAuthMiddleware(c.userRepo), middleware.RequireAdminMiddleware())
+
+	router.HandleFunc("/seller/balance", sellerMiddlewareChain(controller.GetBalanceHandler)).Methods(http.MethodGet)
+	router.HandleFunc("/seller/statement", sellerMiddlewareChain(controller.GetStatementHandler)).Methods(http.MethodGet)
+	router.HandleFunc("/seller/payouts", sellerMiddlewareChain(controller.GetPayoutsHandler)).Methods(http.MethodGet)
+	router.HandleFunc("/seller/payouts", middleware.MiddlewareChain(approvedSellerMiddlewareChain, middleware.IdempotencyMiddleware(c.idempotencyRepo, constants.IdempotencyKeyTTL))(controller.RequestPayoutHandler)).Methods(http.MethodPost)
+
+	router.HandleFunc("/admin/commission-rates", adminMiddlewareChain(controller.GetCommissionRatesHandler)).Methods(http.MethodGet)
+	router.HandleFunc("/admin/commission-rates", adminMiddlewareChain(controller.SaveCommissionRateHandler)).Methods(http.MethodPut)
+	router.HandleFunc("/admin/commission-rates/{scope}/{scopeId}", adminMiddlewareChain(controller.DeleteCommissionRateHandler)).Methods(http.MethodDelete)
+	router.HandleFunc("/admin/commission-rates/{scope}", adminMiddlewareChain(controller.DeleteCommissionRateHandler)).Methods(http.MethodDelete) // the global rate
+	router.HandleFunc("/admin/payouts", adminMiddlewareChain(controller.GetAllPayoutsHandler)).Methods(http.MethodGet)
+	router.HandleFunc("/admin/payouts/{id}/complete", adminMiddlewareChain(controller.CompletePayoutHandler)).Methods(http.MethodPost)
+	router.HandleFunc("/admin/payout-batches", middleware.MiddlewareChain(adminMiddlewareChain, middleware.IdempotencyMiddleware(c.idempotencyRepo, constants.IdempotencyKeyTTL))(controller.CreatePayoutBatchHandler)).Methods(http.MethodPost)
+	router.HandleFunc("/admin/payout-batches/{id}", adminMiddlewareChain(controller.GetPayoutBatchHandler)).Methods(http.MethodGet)
+	router.HandleFunc("/admin/payout-batches/{id}/export", adminMiddlewareChain(controller.ExportPayoutBatchHandler)).Methods(http.MethodGet)
+
+}
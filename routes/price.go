@@ -0,0 +1,38 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/controllers"
+	middleware "github.com/kaasikodes/e-commerce-go/middlware"
+	"github.com/kaasikodes/e-commerce-go/types"
+)
+
+type PriceRoutes struct {
+	userRepo types.UserRepository
+	apiKeyRepo types.APIKeyRepository
+	priceRepo types.PriceRepository
+}
+
+func NewPriceRoutes(userRepo types.UserRepository, apiKeyRepo types.APIKeyRepository, priceRepo types.PriceRepository) *PriceRoutes {
+	return &PriceRoutes{
+		userRepo: userRepo,
+		apiKeyRepo: apiKeyRepo,
+		priceRepo: priceRepo,
+	}
+}
+
+func (c *PriceRoutes) RegisterPriceRoutes (router *mux.Router){
+	controller := controllers.NewPriceController(c.priceRepo)
+	// api keys are accepted in place of a jwt, the same as for the rest of the product routes
+	sellerReadMiddlewareChain := middleware.MiddlewareChain(middleware.RequireAuthOrAPIKeyMiddleware(c.userRepo, c.apiKeyRepo, constants.APIKeyScopeProductsRead), middleware.RequireSellerMiddleware())
+	sellerWriteMiddlewareChain := middleware.MiddlewareChain(middleware.RequireAuthOrAPIKeyMiddleware(c.userRepo, c.apiKeyRepo, constants.APIKeyScopeProductsWrite), middleware.RequireSellerMiddleware())
+
+	router.HandleFunc("/products/{id}/price-history", sellerReadMiddlewareChain(controller.GetPriceHistoryHandler)).Methods(http.MethodGet)
+	router.HandleFunc("/products/{id}/sales", sellerReadMiddlewareChain(controller.GetSalesHandler)).Methods(http.MethodGet)
+	router.HandleFunc("/products/{id}/sales", sellerWriteMiddlewareChain(controller.ScheduleSaleHandler)).Methods(http.MethodPost)
+	router.HandleFunc("/products/{id}/sales/{saleId}", sellerWriteMiddlewareChain(controller.CancelSaleHandler)).Methods(http.MethodDelete)
+
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/gorilla/mux"
+	"github.com/kaasikodes/e-commerce-go/constants"
 	"github.com/kaasikodes/e-commerce-go/controllers"
 	middleware "github.com/kaasikodes/e-commerce-go/middlware"
 	"github.com/kaasikodes/e-commerce-go/types"
@@ -13,27 +14,39 @@ type ProductRoutes struct {
 	userRepo types.UserRepository
 	productRepo types.ProductRepository
 	categoryRepo types.CategoryRepository
+	apiKeyRepo types.APIKeyRepository
+	onboardingRepo types.SellerOnboardingRepository
+	questionRepo types.QuestionRepository
 }
 
-func NewProductRoutes( userRepo types.UserRepository, productRepo types.ProductRepository, categoryRepo types.CategoryRepository) *ProductRoutes {
+func NewProductRoutes( userRepo types.UserRepository, productRepo types.ProductRepository, categoryRepo types.CategoryRepository, apiKeyRepo types.APIKeyRepository, onboardingRepo types.SellerOnboardingRepository, questionRepo types.QuestionRepository) *ProductRoutes {
 	return &ProductRoutes{
 		userRepo: userRepo,
 		productRepo: productRepo,
 		categoryRepo: categoryRepo,
+		apiKeyRepo: apiKeyRepo,
+		onboardingRepo: onboardingRepo,
+		questionRepo: questionRepo,
 	}
 }
 
 func (c *ProductRoutes) RegisterProductRoutes (router *mux.Router){
-	controller := controllers.NewProductController(c.productRepo, c.categoryRepo)
-	middlewareChain := middleware.MiddlewareChain(middleware.RequireAuthMiddleware(c.userRepo))
+	controller := controllers.NewProductController(c.productRepo, c.categoryRepo, c.questionRepo)
+	// api keys are accepted in place of a jwt, as long as they have the scope of the route
+	readMiddlewareChain := middleware.MiddlewareChain(middleware.RequireAuthOrAPIKeyMiddleware(c.userRepo, c.apiKeyRepo, constants.APIKeyScopeProductsRead))
+	sellerReadMiddlewareChain := middleware.MiddlewareChain(middleware.RequireAuthOrAPIKeyMiddleware(c.userRepo, c.apiKeyRepo, constants.APIKeyScopeProductsRead), middleware.RequireSellerMiddleware())
+	sellerWriteMiddlewareChain := middleware.MiddlewareChain(middleware.RequireAuthOrAPIKeyMiddleware(c.userRepo, c.apiKeyRepo, constants.APIKeyScopeProductsWrite), middleware.RequireSellerMiddleware())
+	// listing products is only open to sellers that have been approved
+	publishMiddlewareChain := middleware.MiddlewareChain(middleware.RequireAuthOrAPIKeyMiddleware(c.userRepo, c.apiKeyRepo, constants.APIKeyScopeProductsWrite), middleware.RequireSellerMiddleware(), middleware.RequireApprovedSellerMiddleware(c.onboardingRepo))
 	
-	router.HandleFunc("/products", middlewareChain(controller.AddProductHandler)).Methods(http.MethodPost)
-	router.HandleFunc("/products", middlewareChain(controller.GetProductsHandler)).Methods(http.MethodGet)
-	router.HandleFunc("/products/{id}", middlewareChain(controller.EditProductHandler)).Methods(http.MethodPut)
-	router.HandleFunc("/products/{id}", middlewareChain(controller.DeleteProductHandler)).Methods(http.MethodDelete)
-	router.HandleFunc("/products/{id}", middlewareChain(controller.GetProductHandler)).Methods(http.MethodGet)
-	router.HandleFunc("/products/bulk/template", middlewareChain(controller.GetImportProductTemplateHandler)).Methods(http.MethodGet)
-	router.HandleFunc("/products/bulk/import", middlewareChain(controller.ImportMultipleProductHandler)).Methods(http.MethodPost)
+	router.HandleFunc("/products", publishMiddlewareChain(controller.AddProductHandler)).Methods(http.MethodPost)
+	router.HandleFunc("/products", sellerReadMiddlewareChain(controller.GetProductsHandler)).Methods(http.MethodGet)
+	router.HandleFunc("/products/{id}", sellerWriteMiddlewareChain(controller.EditProductHandler)).Methods(http.MethodPut)
+	router.HandleFunc("/products/{id}", sellerWriteMiddlewareChain(controller.DeleteProductHandler)).Methods(http.MethodDelete)
+	router.HandleFunc("/products/{id}", readMiddlewareChain(controller.GetProductHandler)).Methods(http.MethodGet)
+	router.HandleFunc("/products/{id}/status", sellerWriteMiddlewareChain(controller.UpdateProductStatusHandler)).Methods(http.MethodPut)
+	router.HandleFunc("/products/bulk/template", sellerReadMiddlewareChain(controller.GetImportProductTemplateHandler)).Methods(http.MethodGet)
+	router.HandleFunc("/products/bulk/import", publishMiddlewareChain(controller.ImportMultipleProductHandler)).Methods(http.MethodPost)
 	
 	
 }
\ No newline at end of file
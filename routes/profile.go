@@ -0,0 +1,40 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/kaasikodes/e-commerce-go/controllers"
+	middleware "github.com/kaasikodes/e-commerce-go/middlware"
+	"github.com/kaasikodes/e-commerce-go/types"
+)
+
+type ProfileRoutes struct {
+	userRepo types.UserRepository
+	tokenRepo types.TokenRepository
+	auditRepo types.UserAuditLogRepository
+	storage types.FileStorage
+}
+
+func NewProfileRoutes(userRepo types.UserRepository, tokenRepo types.TokenRepository, auditRepo types.UserAuditLogRepository, storage types.FileStorage) *ProfileRoutes {
+	return &ProfileRoutes{
+		userRepo: userRepo,
+		tokenRepo: tokenRepo,
+		auditRepo: auditRepo,
+		storage: storage,
+	}
+}
+
+func (c *ProfileRoutes) RegisterProfileRoutes (router *mux.Router){
+	controller := controllers.NewProfileController(c.userRepo, c.tokenRepo, c.auditRepo, c.storage)
+	middlewareChain := middleware.MiddlewareChain(middleware.RequireAuthMiddleware(c.userRepo))
+
+	router.HandleFunc("/me/profile", middlewareChain(controller.UpdateProfileHandler)).Methods(http.MethodPatch)
+	router.HandleFunc("/me/email-change", middlewareChain(controller.ChangeEmailHandler)).Methods(http.MethodPost)
+	router.HandleFunc("/me/email-change/confirm", controller.ConfirmEmailChangeHandler).Methods(http.MethodPost)
+	router.HandleFunc("/me/avatar", middlewareChain(controller.UploadAvatarHandler)).Methods(http.MethodPost)
+	router.HandleFunc("/me/roles/customer", middlewareChain(controller.AddCustomerRoleHandler)).Methods(http.MethodPost)
+	router.HandleFunc("/me/roles/seller", middlewareChain(controller.AddSellerRoleHandler)).Methods(http.MethodPost)
+	router.HandleFunc("/me/audit-log", middlewareChain(controller.GetAuditLogsHandler)).Methods(http.MethodGet)
+
+}
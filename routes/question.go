@@ -0,0 +1,42 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/kaasikodes/e-commerce-go/controllers"
+	middleware "github.com/kaasikodes/e-commerce-go/middlware"
+	"github.com/kaasikodes/e-commerce-go/types"
+)
+
+type QuestionRoutes struct {
+	userRepo types.UserRepository
+	questionRepo types.QuestionRepository
+}
+
+func NewQuestionRoutes(userRepo types.UserRepository, questionRepo types.QuestionRepository) *QuestionRoutes {
+	return &QuestionRoutes{
+		userRepo: userRepo,
+		questionRepo: questionRepo,
+	}
+}
+
+func (c *QuestionRoutes) RegisterQuestionRoutes (router *mux.Router){
+	controller := controllers.NewQuestionController(c.questionRepo)
+	authMiddlewareChain := middleware.MiddlewareChain(middleware.RequireAuthMiddleware(c.userRepo))
+	customerMiddlewareChain := middleware.MiddlewareChain(middleware.RequireAuthMiddleware(c.userRepo), middleware.RequireCustomerMiddleware())
+	adminMiddlewareChain := middleware.MiddlewareChain(middleware.RequireAuthMiddleware(c.userRepo), middleware.RequireAdminMiddleware())
+
+	router.HandleFunc("/products/{id}/questions", controller.GetProductQuestionsHandler).Methods(http.MethodGet)
+	router.HandleFunc("/products/{id}/questions", customerMiddlewareChain(controller.AskQuestionHandler)).Methods(http.MethodPost)
+	// sellers and customers can both answer, the repository decides whether they may
+	router.HandleFunc("/questions/{id}/answers", authMiddlewareChain(controller.AnswerQuestionHandler)).Methods(http.MethodPost)
+	router.HandleFunc("/questions/{id}/report", authMiddlewareChain(controller.ReportQuestionHandler)).Methods(http.MethodPost)
+	router.HandleFunc("/answers/{id}/report", authMiddlewareChain(controller.ReportAnswerHandler)).Methods(http.MethodPost)
+
+	router.HandleFunc("/admin/questions", adminMiddlewareChain(controller.GetAdminQuestionsHandler)).Methods(http.MethodGet)
+	router.HandleFunc("/admin/answers", adminMiddlewareChain(controller.GetAdminAnswersHandler)).Methods(http.MethodGet)
+	router.HandleFunc("/admin/questions/{id}/moderation", adminMiddlewareChain(controller.ModerateQuestionHandler)).Methods(http.MethodPut)
+	router.HandleFunc("/admin/answers/{id}/moderation", adminMiddlewareChain(controller.ModerateAnswerHandler)).Methods(http.MethodPut)
+
+}
@@ -0,0 +1,31 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/kaasikodes/e-commerce-go/controllers"
+	middleware "github.com/kaasikodes/e-commerce-go/middlware"
+	"github.com/kaasikodes/e-commerce-go/types"
+)
+
+type RefundRoutes struct {
+	userRepo types.UserRepository
+	refundRepo types.RefundRepository
+}
+
+func NewRefundRoutes(userRepo types.UserRepository, refundRepo types.RefundRepository) *RefundRoutes {
+	return &RefundRoutes{
+		userRepo: userRepo,
+		refundRepo: refundRepo,
+	}
+}
+
+func (c *RefundRoutes) RegisterRefundRoutes (router *mux.Router){
+	controller := controllers.NewRefundController(c.refundRepo)
+	adminMiddlewareChain := middleware.MiddlewareChain(middleware.RequireAuthMiddleware(c.userRepo), middleware.RequireAdminMiddleware())
+
+	router.HandleFunc("/admin/refunds", adminMiddlewareChain(controller.GetRefundsHandler)).Methods(http.MethodGet)
+	router.HandleFunc("/admin/refunds/{id}/retry", adminMiddlewareChain(controller.RetryRefundHandler)).Methods(http.MethodPost)
+
+}
@@ -0,0 +1,49 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/controllers"
+	middleware "github.com/kaasikodes/e-commerce-go/middlware"
+	"github.com/kaasikodes/e-commerce-go/types"
+)
+
+type ReturnRoutes struct {
+	userRepo types.UserRepository
+	returnRepo types.ReturnRepository
+	storage types.FileStorage
+	idempotencyRepo types.IdempotencyKeyRepository
+}
+
+func NewReturnRoutes(userRepo types.UserRepository, returnRepo types.ReturnRepository, storage types.FileStorage, idempotencyRepo types.IdempotencyKeyRepository) *ReturnRoutes {
+	return &ReturnRoutes{
+		userRepo: userRepo,
+		returnRepo: returnRepo,
+		storage: storage,
+		idempotencyRepo: idempotencyRepo,
+	}
+}
+
+func (c *ReturnRoutes) RegisterReturnRoutes (router *mux.Router){
+	controller := controllers.NewReturnController(c.returnRepo, c.userRepo, c.storage)
+	customerMiddlewareChain := middleware.MiddlewareChain(middleware.RequireAuthMiddleware(c.userRepo), middleware.RequireCustomerMiddleware())
+	sellerMiddlewareChain := middleware.MiddlewareChain(middleware.RequireAuthMiddleware(c.userRepo), middleware.RequireSellerMiddleware())
+
+	router.HandleFunc("/returns", middleware.MiddlewareChain(customerMiddlewareChain, middleware.IdempotencyMiddleware(c.idempotencyRepo, constants.IdempotencyKeyTTL))(controller.CreateReturnHandler)).Methods(http.MethodPost)
+	router.HandleFunc("/returns", customerMiddlewareChain(controller.GetReturnsHandler)).Methods(http.MethodGet)
+	router.HandleFunc("/returns/{id}", customerMiddlewareChain(controller.GetReturnHandler)).Methods(http.MethodGet)
+	router.HandleFunc("/returns/{id}/photos", customerMiddlewareChain(controller.UploadReturnPhotoHandler)).Methods(http.MethodPost)
+	router.HandleFunc("/returns/{id}/photos/{photoId}", customerMiddlewareChain(controller.GetReturnPhotoHandler)).Methods(http.MethodGet)
+	router.HandleFunc("/store-credit", customerMiddlewareChain(controller.GetStoreCreditHandler)).Methods(http.MethodGet)
+
+	router.HandleFunc("/seller/returns", sellerMiddlewareChain(controller.GetSellerReturnsHandler)).Methods(http.MethodGet)
+	router.HandleFunc("/seller/returns/{id}", sellerMiddlewareChain(controller.GetSellerReturnHandler)).Methods(http.MethodGet)
+	router.HandleFunc("/seller/returns/{id}/photos/{photoId}", sellerMiddlewareChain(controller.GetSellerReturnPhotoHandler)).Methods(http.MethodGet)
+	router.HandleFunc("/seller/returns/{id}/approve", sellerMiddlewareChain(controller.ApproveReturnHandler)).Methods(http.MethodPost)
+	router.HandleFunc("/seller/returns/{id}/reject", sellerMiddlewareChain(controller.RejectReturnHandler)).Methods(http.MethodPost)
+	router.HandleFunc("/seller/returns/{id}/receive", sellerMiddlewareChain(controller.ReceiveReturnHandler)).Methods(http.MethodPost)
+	router.HandleFunc("/seller/returns/{id}/resolve", sellerMiddlewareChain(controller.ResolveReturnHandler)).Methods(http.MethodPost)
+
+}
@@ -0,0 +1,45 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/kaasikodes/e-commerce-go/controllers"
+	middleware "github.com/kaasikodes/e-commerce-go/middlware"
+	"github.com/kaasikodes/e-commerce-go/types"
+)
+
+type ReviewRoutes struct {
+	userRepo types.UserRepository
+	reviewRepo types.ReviewRepository
+}
+
+func NewReviewRoutes(userRepo types.UserRepository, reviewRepo types.ReviewRepository) *ReviewRoutes {
+	return &ReviewRoutes{
+		userRepo: userRepo,
+		reviewRepo: reviewRepo,
+	}
+}
+
+func (c *ReviewRoutes) RegisterReviewRoutes (router *mux.Router){
+	controller := controllers.NewReviewController(c.reviewRepo)
+	authMiddlewareChain := middleware.MiddlewareChain(middleware.RequireAuthMiddleware(c.userRepo))
+	customerMiddlewareChain := middleware.MiddlewareChain(middleware.RequireAuthMiddleware(c.userRepo), middleware.RequireCustomerMiddleware())
+	sellerMiddlewareChain := middleware.MiddlewareChain(middleware.RequireAuthMiddleware(c.userRepo), middleware.RequireSellerMiddleware())
+	adminMiddlewareChain := middleware.MiddlewareChain(middleware.RequireAuthMiddleware(c.userRepo), middleware.RequireAdminMiddleware())
+
+	router.HandleFunc("/products/{id}/reviews", controller.GetProductReviewsHandler).Methods(http.MethodGet)
+	router.HandleFunc("/products/{id}/reviews", customerMiddlewareChain(controller.CreateReviewHandler)).Methods(http.MethodPost)
+	router.HandleFunc("/reviews/{id}", customerMiddlewareChain(controller.UpdateReviewHandler)).Methods(http.MethodPut)
+	router.HandleFunc("/reviews/{id}", customerMiddlewareChain(controller.DeleteReviewHandler)).Methods(http.MethodDelete)
+	router.HandleFunc("/reviews/{id}/helpful", authMiddlewareChain(controller.VoteReviewHelpfulHandler)).Methods(http.MethodPost)
+	router.HandleFunc("/reviews/{id}/helpful", authMiddlewareChain(controller.RemoveReviewHelpfulVoteHandler)).Methods(http.MethodDelete)
+	router.HandleFunc("/reviews/{id}/report", authMiddlewareChain(controller.ReportReviewHandler)).Methods(http.MethodPost)
+
+	router.HandleFunc("/seller/reviews", sellerMiddlewareChain(controller.GetSellerReviewsHandler)).Methods(http.MethodGet)
+	router.HandleFunc("/seller/reviews/{id}/response", sellerMiddlewareChain(controller.RespondToReviewHandler)).Methods(http.MethodPut)
+
+	router.HandleFunc("/admin/reviews", adminMiddlewareChain(controller.GetAdminReviewsHandler)).Methods(http.MethodGet)
+	router.HandleFunc("/admin/reviews/{id}/moderation", adminMiddlewareChain(controller.ModerateReviewHandler)).Methods(http.MethodPut)
+
+}
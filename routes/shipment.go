@@ -0,0 +1,34 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/controllers"
+	middleware "github.com/kaasikodes/e-commerce-go/middlware"
+	"github.com/kaasikodes/e-commerce-go/types"
+)
+
+type ShipmentRoutes struct {
+	userRepo types.UserRepository
+	shipmentRepo types.ShipmentRepository
+	idempotencyRepo types.IdempotencyKeyRepository
+}
+
+func NewShipmentRoutes(userRepo types.UserRepository, shipmentRepo types.ShipmentRepository, idempotencyRepo types.IdempotencyKeyRepository) *ShipmentRoutes {
+	return &ShipmentRoutes{
+		userRepo: userRepo,
+		shipmentRepo: shipmentRepo,
+		idempotencyRepo: idempotencyRepo,
+	}
+}
+
+func (c *ShipmentRoutes) RegisterShipmentRoutes (router *mux.Router){
+	controller := controllers.NewShipmentController(c.shipmentRepo)
+	sellerMiddlewareChain := middleware.MiddlewareChain(middleware.RequireAuthMiddleware(c.userRepo), middleware.RequireSellerMiddleware())
+
+	router.HandleFunc("/seller/orders/{id}/shipments", middleware.MiddlewareChain(sellerMiddlewareChain, middleware.IdempotencyMiddleware(c.idempotencyRepo, constants.IdempotencyKeyTTL))(controller.CreateShipmentHandler)).Methods(http.MethodPost)
+	router.HandleFunc("/seller/shipments/{id}/delivered", sellerMiddlewareChain(controller.MarkShipmentDeliveredHandler)).Methods(http.MethodPost)
+
+}
@@ -0,0 +1,34 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/controllers"
+	middleware "github.com/kaasikodes/e-commerce-go/middlware"
+	"github.com/kaasikodes/e-commerce-go/types"
+)
+
+type StockRoutes struct {
+	userRepo types.UserRepository
+	apiKeyRepo types.APIKeyRepository
+	stockRepo types.StockRepository
+}
+
+func NewStockRoutes(userRepo types.UserRepository, apiKeyRepo types.APIKeyRepository, stockRepo types.StockRepository) *StockRoutes {
+	return &StockRoutes{
+		userRepo: userRepo,
+		apiKeyRepo: apiKeyRepo,
+		stockRepo: stockRepo,
+	}
+}
+
+func (c *StockRoutes) RegisterStockRoutes (router *mux.Router){
+	controller := controllers.NewStockController(c.stockRepo)
+	// api keys are accepted in place of a jwt, the same as for the rest of the product routes
+	sellerReadMiddlewareChain := middleware.MiddlewareChain(middleware.RequireAuthOrAPIKeyMiddleware(c.userRepo, c.apiKeyRepo, constants.APIKeyScopeProductsRead), middleware.RequireSellerMiddleware())
+
+	router.HandleFunc("/products/{id}/stock-movements", sellerReadMiddlewareChain(controller.GetStockMovementsHandler)).Methods(http.MethodGet)
+
+}
@@ -0,0 +1,37 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/kaasikodes/e-commerce-go/controllers"
+	middleware "github.com/kaasikodes/e-commerce-go/middlware"
+	"github.com/kaasikodes/e-commerce-go/types"
+)
+
+type StorefrontRoutes struct {
+	userRepo types.UserRepository
+	storefrontRepo types.StorefrontRepository
+	storage types.FileStorage
+}
+
+func NewStorefrontRoutes(userRepo types.UserRepository, storefrontRepo types.StorefrontRepository, storage types.FileStorage) *StorefrontRoutes {
+	return &StorefrontRoutes{
+		userRepo: userRepo,
+		storefrontRepo: storefrontRepo,
+		storage: storage,
+	}
+}
+
+func (c *StorefrontRoutes) RegisterStorefrontRoutes (router *mux.Router){
+	controller := controllers.NewStorefrontController(c.storefrontRepo, c.storage)
+	sellerMiddlewareChain := middleware.MiddlewareChain(middleware.RequireAuthMiddleware(c.userRepo), middleware.RequireSellerMiddleware())
+
+	router.HandleFunc("/shops/{slug}", controller.GetShopHandler).Methods(http.MethodGet)
+
+	router.HandleFunc("/seller/storefront", sellerMiddlewareChain(controller.GetStorefrontHandler)).Methods(http.MethodGet)
+	router.HandleFunc("/seller/storefront", sellerMiddlewareChain(controller.SaveStorefrontHandler)).Methods(http.MethodPut)
+	router.HandleFunc("/seller/storefront/logo", sellerMiddlewareChain(controller.UploadLogoHandler)).Methods(http.MethodPost)
+	router.HandleFunc("/seller/storefront/banner", sellerMiddlewareChain(controller.UploadBannerHandler)).Methods(http.MethodPost)
+
+}
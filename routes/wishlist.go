@@ -0,0 +1,41 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/kaasikodes/e-commerce-go/controllers"
+	middleware "github.com/kaasikodes/e-commerce-go/middlware"
+	"github.com/kaasikodes/e-commerce-go/types"
+)
+
+type WishlistRoutes struct {
+	userRepo types.UserRepository
+	wishlistRepo types.WishlistRepository
+}
+
+func NewWishlistRoutes(userRepo types.UserRepository, wishlistRepo types.WishlistRepository) *WishlistRoutes {
+	return &WishlistRoutes{
+		userRepo: userRepo,
+		wishlistRepo: wishlistRepo,
+	}
+}
+
+func (c *WishlistRoutes) RegisterWishlistRoutes (router *mux.Router){
+	controller := controllers.NewWishlistController(c.wishlistRepo)
+	middlewareChain := middleware.MiddlewareChain(middleware.RequireAuthMiddleware(c.userRepo), middleware.RequireCustomerMiddleware())
+
+	router.HandleFunc("/wishlists/shared/{token}", controller.GetSharedWishlistHandler).Methods(http.MethodGet)
+
+	router.HandleFunc("/wishlists", middlewareChain(controller.GetWishlistsHandler)).Methods(http.MethodGet)
+	router.HandleFunc("/wishlists", middlewareChain(controller.CreateWishlistHandler)).Methods(http.MethodPost)
+	router.HandleFunc("/wishlists/{id}", middlewareChain(controller.GetWishlistHandler)).Methods(http.MethodGet)
+	router.HandleFunc("/wishlists/{id}", middlewareChain(controller.RenameWishlistHandler)).Methods(http.MethodPut)
+	router.HandleFunc("/wishlists/{id}", middlewareChain(controller.DeleteWishlistHandler)).Methods(http.MethodDelete)
+	router.HandleFunc("/wishlists/{id}/items", middlewareChain(controller.AddWishlistItemHandler)).Methods(http.MethodPost)
+	router.HandleFunc("/wishlists/{id}/items/{productId}", middlewareChain(controller.UpdateWishlistItemHandler)).Methods(http.MethodPatch)
+	router.HandleFunc("/wishlists/{id}/items/{productId}", middlewareChain(controller.RemoveWishlistItemHandler)).Methods(http.MethodDelete)
+	router.HandleFunc("/wishlists/{id}/share", middlewareChain(controller.ShareWishlistHandler)).Methods(http.MethodPost)
+	router.HandleFunc("/wishlists/{id}/share", middlewareChain(controller.UnshareWishlistHandler)).Methods(http.MethodDelete)
+
+}
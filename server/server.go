@@ -1,13 +1,18 @@
 package server
 
 import (
+	"context"
 	"database/sql"
 	"log"
 	"net/http"
+	"strings"
 
 	"github.com/gorilla/mux"
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/jobs"
 	"github.com/kaasikodes/e-commerce-go/routes"
 	"github.com/kaasikodes/e-commerce-go/services"
+	"github.com/kaasikodes/e-commerce-go/types"
 )
 
 type ApiServer struct {
@@ -36,19 +41,90 @@ func (s *ApiServer) Start() error {
 	orderRepo := services.NewOrderRepository(s.db)
 	paymentRepo := services.NewPaymentRepository(s.db)
 	addressRepo := services.NewAddressRepository(s.db)
+	throttleRepo := services.NewAuthThrottleRepository(s.db)
+	identityRepo := services.NewUserIdentityRepository(s.db)
+	apiKeyRepo := services.NewAPIKeyRepository(s.db)
+	auditRepo := services.NewUserAuditLogRepository(s.db)
+	fileStorage := services.NewLocalFileStorage(constants.UploadDir, constants.ApiUrl)
+	accountRepo := services.NewAccountRepository(s.db)
+	onboardingRepo := services.NewSellerOnboardingRepository(s.db)
+	privateFileStorage := services.NewLocalFileStorage(constants.PrivateUploadDir, "")
+	storefrontRepo := services.NewStorefrontRepository(s.db)
+	ledgerRepo := services.NewLedgerRepository(s.db)
+	payoutRepo := services.NewPayoutRepository(s.db)
+	shipmentRepo := services.NewShipmentRepository(s.db)
+	refundRepo := services.NewRefundRepository(s.db)
+	returnRepo := services.NewReturnRepository(s.db)
+	invoiceRepo := services.NewInvoiceRepository(s.db)
+	idempotencyRepo := services.NewIdempotencyKeyRepository(s.db)
+	wishlistRepo := services.NewWishlistRepository(s.db)
+	reviewRepo := services.NewReviewRepository(s.db)
+	questionRepo := services.NewQuestionRepository(s.db)
+	priceRepo := services.NewPriceRepository(s.db)
+	stockRepo := services.NewStockRepository(s.db)
+	refundGateway := services.NewPaystackRefundGateway(constants.PaystackSecretKey, nil)
+	payoutGateway := services.NewPaystackPayoutGateway(constants.PaystackSecretKey, nil)
+	oidcProviders := map[string]types.OIDCClient{}
+	for name, config := range constants.OIDCProviders {
+		oidcProviders[name] = services.NewOIDCClient(config, nil)
+	}
 
 	// define routes and map them to controllers
 	routes.NewHomeRoutes().RegisterHomeRoutes(subrouter)
-	routes.NewAuthRoutes(userRepo, tokenRepo).RegisterAuthRoutes(subrouter)
+	routes.NewAuthRoutes(userRepo, tokenRepo, throttleRepo).RegisterAuthRoutes(subrouter)
+	routes.NewOIDCRoutes(userRepo, identityRepo, oidcProviders).RegisterOIDCRoutes(subrouter)
 	routes.NewCategoryRoutes(categoryRepo, userRepo).RegisterCategoryRoutes(subrouter)
 	routes.NewUserRoutes(userRepo).RegisterUserRoutes(subrouter)
-	routes.NewProductRoutes(userRepo, productRepo, categoryRepo).RegisterProductRoutes(subrouter)
-	routes.NewCartRoutes( cartRepo, userRepo, orderRepo, paymentRepo, addressRepo).RegisterCartRoutes(subrouter)
-	routes.NewOrderRoutes( orderRepo, userRepo).RegisterOrderRoutes(subrouter)
+	routes.NewProductRoutes(userRepo, productRepo, categoryRepo, apiKeyRepo, onboardingRepo, questionRepo).RegisterProductRoutes(subrouter)
+	routes.NewCartRoutes( cartRepo, userRepo, orderRepo, paymentRepo, addressRepo, ledgerRepo, refundRepo, invoiceRepo, idempotencyRepo).RegisterCartRoutes(subrouter)
+	routes.NewOrderRoutes( orderRepo, userRepo, apiKeyRepo, idempotencyRepo).RegisterOrderRoutes(subrouter)
 	routes.NewPaymentRoutes( paymentRepo, userRepo).RegisterPaymentRoutes(subrouter)
 	routes.NewAddressRoutes( addressRepo).RegisterAddressRoutes(subrouter)
+	routes.NewAPIKeyRoutes(userRepo, apiKeyRepo).RegisterAPIKeyRoutes(subrouter)
+	routes.NewProfileRoutes(userRepo, tokenRepo, auditRepo, fileStorage).RegisterProfileRoutes(subrouter)
+	routes.NewAccountRoutes(userRepo, accountRepo).RegisterAccountRoutes(subrouter)
+	routes.NewSellerOnboardingRoutes(userRepo, onboardingRepo, privateFileStorage).RegisterSellerOnboardingRoutes(subrouter)
+	routes.NewStorefrontRoutes(userRepo, storefrontRepo, fileStorage).RegisterStorefrontRoutes(subrouter)
+	routes.NewPayoutRoutes(userRepo, ledgerRepo, payoutRepo, onboardingRepo, payoutGateway, idempotencyRepo).RegisterPayoutRoutes(subrouter)
+	routes.NewShipmentRoutes(userRepo, shipmentRepo, idempotencyRepo).RegisterShipmentRoutes(subrouter)
+	routes.NewRefundRoutes(userRepo, refundRepo).RegisterRefundRoutes(subrouter)
+	routes.NewReturnRoutes(userRepo, returnRepo, privateFileStorage, idempotencyRepo).RegisterReturnRoutes(subrouter)
+	routes.NewInvoiceRoutes(userRepo, invoiceRepo, orderRepo).RegisterInvoiceRoutes(subrouter)
+	routes.NewWishlistRoutes(userRepo, wishlistRepo).RegisterWishlistRoutes(subrouter)
+	routes.NewReviewRoutes(userRepo, reviewRepo).RegisterReviewRoutes(subrouter)
+	routes.NewQuestionRoutes(userRepo, questionRepo).RegisterQuestionRoutes(subrouter)
+	routes.NewPriceRoutes(userRepo, apiKeyRepo, priceRepo).RegisterPriceRoutes(subrouter)
+	routes.NewStockRoutes(userRepo, apiKeyRepo, stockRepo).RegisterStockRoutes(subrouter)
+
+	// serve uploaded files, without directory listings
+	uploadsPrefix := "/" + constants.UploadDir + "/"
+	router.PathPrefix(uploadsPrefix).Handler(http.StripPrefix(uploadsPrefix, noDirListing(http.FileServer(http.Dir(constants.UploadDir)))))
+
+	// start background jobs
+	jobs.Start(context.Background(),
+		jobs.PurgeExpiredTokensJob(tokenRepo),
+		jobs.PurgeExpiredIdempotencyKeysJob(idempotencyRepo),
+		jobs.ProcessAccountDeletionsJob(accountRepo, fileStorage, privateFileStorage),
+		jobs.ReleaseSellerFundsJob(ledgerRepo),
+		jobs.CancelUnpaidOrdersJob(orderRepo),
+		jobs.ProcessRefundsJob(refundRepo, refundGateway),
+		jobs.SendWishlistAlertsJob(wishlistRepo),
+		jobs.PublishScheduledProductsJob(productRepo),
+		jobs.ApplyProductSalesJob(priceRepo),
+		jobs.SendLowStockDigestsJob(stockRepo),
+	)
 
 	log.Println("Listening on ...", s.addr)
 	
 	return http.ListenAndServe(s.addr, router)
-}
\ No newline at end of file
+}
+
+func noDirListing(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "" || strings.HasSuffix(r.URL.Path, "/") {
+			http.NotFound(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
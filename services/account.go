@@ -0,0 +1,466 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/models"
+	"github.com/kaasikodes/e-commerce-go/types"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+// AccountRepository works across the tables that hold a user's data, for exports and account deletion
+type AccountRepository struct {
+	db *sql.DB
+}
+
+func NewAccountRepository(db *sql.DB) *AccountRepository {
+	return &AccountRepository{
+		db: db,
+	}
+}
+
+func (r *AccountRepository) RetrieveAccountData(user models.User) (types.AccountDataExport, error){
+	data := types.AccountDataExport{
+		Profile: user,
+		Addresses: []models.Address{},
+		Orders: []models.Order{},
+		Payments: []models.Payment{},
+		Returns: []models.ReturnRequest{},
+		Wishlists: []models.Wishlist{},
+		Reviews: []models.ProductReview{},
+		Questions: []models.ProductQuestion{},
+		Products: []models.Product{},
+		AuditLogs: []models.UserAuditLog{},
+	}
+	var err error
+	if user.Customer != nil {
+		if data.Addresses, err = r.retrieveCustomerAddresses(user.Customer.ID); err !=nil {
+			return data, err
+		}
+		if data.Orders, err = r.retrieveCustomerOrders(user.Customer.ID); err !=nil {
+			return data, err
+		}
+		if data.Payments, err = r.retrieveCustomerPayments(user.Customer.ID); err !=nil {
+			return data, err
+		}
+		if data.Returns, err = r.retrieveCustomerReturns(user.Customer.ID); err !=nil {
+			return data, err
+		}
+		storeCredit, err := NewReturnRepository(r.db).RetrieveStoreCredit(user.Customer.ID)
+		if err !=nil {
+			return data, err
+		}
+		data.StoreCredit = &storeCredit
+		if data.Wishlists, err = NewWishlistRepository(r.db).RetrieveWishlists(user.Customer.ID); err !=nil {
+			return data, err
+		}
+		if data.Reviews, err = r.retrieveCustomerReviews(user.Customer.ID); err !=nil {
+			return data, err
+		}
+		if data.Questions, err = r.retrieveCustomerQuestions(user.Customer.ID); err !=nil {
+			return data, err
+		}
+	}
+	if user.Seller != nil {
+		if data.Products, err = r.retrieveSellerProducts(user.Seller.ID); err !=nil {
+			return data, err
+		}
+		onboarding, err := NewSellerOnboardingRepository(r.db).RetrieveSellerOnboarding(user.Seller.ID)
+		if err !=nil {
+			return data, err
+		}
+		data.SellerOnboarding = &onboarding
+		storefront, err := NewStorefrontRepository(r.db).RetrieveStorefront(user.Seller.ID)
+		if err !=nil && err != constants.ErrStorefrontNotFound {
+			return data, err
+		}
+		if err == nil {
+			data.Storefront = &storefront
+		}
+	}
+	data.AuditLogs, err = NewUserAuditLogRepository(r.db).RetrieveUserAuditLogs(user.ID)
+	return data, err
+}
+
+func (r *AccountRepository) ScheduleAccountDeletion(userID string, scheduledFor time.Time) (models.AccountDeletionRequest, error){
+	db := r.db
+	request := models.AccountDeletionRequest{}
+	// prepare query
+	query := `INSERT INTO AccountDeletionRequest (UserID, RequestedAt, ScheduledFor) VALUES (?, ?, ?)`
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	exists := 0
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM AccountDeletionRequest WHERE UserID = ?`, userID).Scan(&exists); err !=nil {
+		return request, err
+	}
+	if exists > 0 {
+		return request, constants.ErrAccountDeletionAlreadyScheduled
+	}
+	// prepare the statement
+	stmt, err := db.PrepareContext(ctx, query)
+	if err !=nil {
+		return request, err
+	}
+	defer stmt.Close() //close the statement after use
+	// execute the statement
+	now := time.Now()
+	_, err = stmt.ExecContext(ctx, userID, now, scheduledFor)
+	if err !=nil {
+		return request, err
+	}
+
+	request = models.AccountDeletionRequest{UserID: userID, RequestedAt: now, ScheduledFor: scheduledFor}
+	return request, nil
+}
+
+func (r *AccountRepository) CancelAccountDeletion(userID string) error{
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	// a deletion that has been carried out can't be cancelled
+	res, err := r.db.ExecContext(ctx, `DELETE FROM AccountDeletionRequest WHERE UserID = ? AND CompletedAt IS NULL`, userID)
+	if err !=nil {
+		return err
+	}
+	count, err := res.RowsAffected()
+	if err !=nil {
+		return err
+	}
+	if count == 0 {
+		return constants.ErrAccountDeletionNotScheduled
+	}
+	return nil
+}
+
+func (r *AccountRepository) RetrieveDueAccountDeletions() ([]models.AccountDeletionRequest, error){
+	db := r.db
+	// prepare query
+	query := `SELECT UserID, RequestedAt, ScheduledFor FROM AccountDeletionRequest WHERE CompletedAt IS NULL AND ScheduledFor <= ?`
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	requests := []models.AccountDeletionRequest{}
+	rows, err := db.QueryContext(ctx, query, time.Now())
+	if err !=nil {
+		return requests, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		request := models.AccountDeletionRequest{}
+		if err = rows.Scan(&request.UserID, &request.RequestedAt, &request.ScheduledFor); err !=nil {
+			return requests, err
+		}
+		requests = append(requests, request)
+	}
+	return requests, rows.Err()
+}
+
+func (r *AccountRepository) AnonymiseUser(userID string) (types.AccountFiles, error){
+	db := r.db
+	files := types.AccountFiles{}
+	user, err := NewUserRepository(db).RetrieveUserByID(userID)
+	if err !=nil {
+		return files, err
+	}
+	files.Public = append(files.Public, user.Image)
+	if user.Customer != nil {
+		if files.Private, err = r.retrieveCustomerReturnPhotos(user.Customer.ID); err !=nil {
+			return files, err
+		}
+	}
+	if user.Seller != nil {
+		onboarding, err := NewSellerOnboardingRepository(db).RetrieveSellerOnboarding(user.Seller.ID)
+		if err !=nil {
+			return files, err
+		}
+		for _, document := range onboarding.Documents {
+			files.Private = append(files.Private, document.FileUrl)
+		}
+		storefront, err := NewStorefrontRepository(db).RetrieveStorefront(user.Seller.ID)
+		if err !=nil && err != constants.ErrStorefrontNotFound {
+			return files, err
+		}
+		files.Public = append(files.Public, storefront.LogoUrl, storefront.BannerUrl)
+	}
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	// everything is removed together or not at all
+	tx, err := db.BeginTx(ctx, nil)
+	if err !=nil {
+		return files, err
+	}
+	defer tx.Rollback()
+	now := time.Now()
+	statements := []accountStatement{
+		// tokens are tied to the email, so they go before it is changed
+		{`DELETE FROM VerificationToken WHERE Email = ?`, []interface{}{user.Email}},
+		{`DELETE FROM PasswordResetToken WHERE Email = ?`, []interface{}{user.Email}},
+		{`DELETE FROM MagicLinkToken WHERE Email = ?`, []interface{}{user.Email}},
+		{`DELETE FROM EmailChangeToken WHERE UserID = ?`, []interface{}{userID}},
+		{"DELETE FROM AuthThrottle WHERE SUBSTRING_INDEX(`Key`, ':account:', -1) = ?", []interface{}{strings.ToLower(user.Email)}},
+		{`DELETE FROM UserIdentity WHERE UserID = ?`, []interface{}{userID}},
+		{`UPDATE APIKey SET RevokedAt = ? WHERE UserID = ? AND RevokedAt IS NULL`, []interface{}{now, userID}},
+		{`DELETE FROM UserAuditLog WHERE UserID = ?`, []interface{}{userID}},
+		// the password can't be matched by any bcrypt hash, so the account can no longer be signed in to
+		{`UPDATE User SET Name = ?, Email = ?, Password = '', Image = NULL, RefreshToken = NULL, AccessToken = NULL, EmailVerified = FALSE WHERE ID = ?`, []interface{}{constants.AnonymisedUserName, "deleted-" + userID + "@deleted.invalid", userID}},
+		{`UPDATE AccountDeletionRequest SET CompletedAt = ? WHERE UserID = ?`, []interface{}{now, userID}},
+	}
+	if user.Customer != nil {
+		statements = append(statements, []accountStatement{
+			{`DELETE FROM CartItem WHERE CartID IN (SELECT ID FROM Cart WHERE CustomerID = ?)`, []interface{}{user.Customer.ID}},
+			{`DELETE FROM Cart WHERE CustomerID = ?`, []interface{}{user.Customer.ID}},
+			{`DELETE FROM SavedForLaterItem WHERE CustomerID = ?`, []interface{}{user.Customer.ID}},
+			{`DELETE FROM Wishlist WHERE CustomerID = ?`, []interface{}{user.Customer.ID}},
+			// orders keep their address for the records, but not the street
+			{"UPDATE Address SET StreetAddress = ? WHERE ID IN (SELECT DeliveryAddressID FROM `Order` WHERE CustomerID = ?)", []interface{}{constants.AnonymisedStreetAddress, user.Customer.ID}},
+			// returns stay with the order and payment records, what the customer wrote and the photos they took don't
+			{`DELETE FROM ReturnPhoto WHERE ReturnID IN (SELECT ID FROM ReturnRequest WHERE CustomerID = ?)`, []interface{}{user.Customer.ID}},
+			{`UPDATE ReturnRequest SET Details = NULL WHERE CustomerID = ?`, []interface{}{user.Customer.ID}},
+			// invoices keep their numbers and amounts for the sellers' books, but not who they were billed to
+			{`UPDATE Invoice SET BillToName = ?, BillToAddress = ? WHERE CustomerID = ?`, []interface{}{constants.AnonymisedUserName, constants.AnonymisedStreetAddress, user.Customer.ID}},
+		}...)
+	}
+	if user.Seller != nil {
+		// products stay as order items point at them, they just can't be bought anymore
+		statements = append(statements, []accountStatement{
+			{`UPDATE Product SET Quantity = 0, Status = ?, PublishAt = NULL, UnpublishAt = NULL WHERE OwnerID = ?`, []interface{}{constants.ProductStatuses.Archived, user.Seller.ID}},
+			// the business name stays with the seller's sales, contact, bank and identity details don't
+			{`UPDATE SellerOnboarding SET BusinessAddress = NULL, PhoneNumber = NULL, BankName = NULL, BankCode = NULL, BankAccountName = NULL, BankAccountNumber = NULL WHERE SellerID = ?`, []interface{}{user.Seller.ID}},
+			{`DELETE FROM SellerDocument WHERE SellerID = ?`, []interface{}{user.Seller.ID}},
+			{`DELETE FROM SellerStorefront WHERE SellerID = ?`, []interface{}{user.Seller.ID}},
+		}...)
+	}
+	// the stock the seller's products had is taken out of the stock movements as well
+	stock := map[string]int{}
+	if user.Seller != nil {
+		if stock, err = retrieveSellerStock(ctx, tx, user.Seller.ID); err !=nil {
+			return files, err
+		}
+	}
+	for _, statement := range statements {
+		if _, err = tx.ExecContext(ctx, statement.query, statement.args...); err !=nil {
+			return files, err
+		}
+	}
+	for productID, quantity := range stock {
+		if err = recordStockMovement(ctx, tx, productID, -quantity, constants.StockMovementReasons.Adjustment, constants.StockMovementActors.System, "", ""); err !=nil {
+			return files, err
+		}
+	}
+	// keep a record that the account was deleted, without any of its data
+	id := utils.NewID()
+	if _, err = tx.ExecContext(ctx, `INSERT INTO UserAuditLog (ID, UserID, Action) VALUES (?, ?, ?)`, id, userID, "account.deleted"); err !=nil {
+		return files, err
+	}
+
+	return files, tx.Commit()
+}
+
+// private
+type accountStatement struct {
+	query string
+	args  []interface{}
+}
+
+func (r *AccountRepository) retrieveCustomerAddresses(customerID string) ([]models.Address, error){
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	addresses := []models.Address{}
+	query := "SELECT DISTINCT a.ID, a.StreetAddress, a.LgaID, a.StateID, a.CountryID FROM Address a JOIN `Order` o ON o.DeliveryAddressID = a.ID WHERE o.CustomerID = ?"
+	rows, err := r.db.QueryContext(ctx, query, customerID)
+	if err !=nil {
+		return addresses, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		address := models.Address{}
+		if err = rows.Scan(&address.ID, &address.StreetAddress, &address.LgaID, &address.StateID, &address.CountryID); err !=nil {
+			return addresses, err
+		}
+		addresses = append(addresses, address)
+	}
+	return addresses, rows.Err()
+}
+func (r *AccountRepository) retrieveCustomerOrders(customerID string) ([]models.Order, error){
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	orders := []models.Order{}
+	query := "SELECT ID, COALESCE(Number, ''), CustomerID, TotalAmount, DeliveryAddressID, CreatedAt, UpdatedAt FROM `Order` WHERE CustomerID = ? ORDER BY CreatedAt"
+	rows, err := r.db.QueryContext(ctx, query, customerID)
+	if err !=nil {
+		return orders, err
+	}
+	defer rows.Close()
+	indexes := map[string]int{}
+	for rows.Next() {
+		order := models.Order{Items: []models.OrderItem{}}
+		if err = rows.Scan(&order.ID, &order.Number, &order.CustomerID, &order.TotalAmount, &order.DeliveryAddressID, &order.CreatedAt, &order.UpdatedAt); err !=nil {
+			return orders, err
+		}
+		indexes[order.ID] = len(orders)
+		orders = append(orders, order)
+	}
+	if err = rows.Err(); err !=nil {
+		return orders, err
+	}
+	query = "SELECT oi.ID, oi.ProductID, oi.OrderID, oi.Quantity, oi.UnitPrice, oi.TotalPrice, oi.CreatedAt, oi.UpdatedAt FROM OrderItem oi JOIN `Order` o ON o.ID = oi.OrderID WHERE o.CustomerID = ?"
+	itemRows, err := r.db.QueryContext(ctx, query, customerID)
+	if err !=nil {
+		return orders, err
+	}
+	defer itemRows.Close()
+	for itemRows.Next() {
+		item := models.OrderItem{}
+		if err = itemRows.Scan(&item.ID, &item.ProductID, &item.OrderID, &item.Quantity, &item.UnitPrice, &item.TotalPrice, &item.CreatedAt, &item.UpdatedAt); err !=nil {
+			return orders, err
+		}
+		if i, ok := indexes[item.OrderID]; ok {
+			orders[i].Items = append(orders[i].Items, item)
+		}
+	}
+	return orders, itemRows.Err()
+}
+func (r *AccountRepository) retrieveCustomerPayments(customerID string) ([]models.Payment, error){
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	payments := []models.Payment{}
+	query := "SELECT p.ID, p.OrderID, p.Amount, p.Paid, p.PaidAt, p.Method FROM Payment p JOIN `Order` o ON o.ID = p.OrderID WHERE o.CustomerID = ?"
+	rows, err := r.db.QueryContext(ctx, query, customerID)
+	if err !=nil {
+		return payments, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		payment := models.Payment{}
+		if err = rows.Scan(&payment.ID, &payment.OrderID, &payment.Amount, &payment.Paid, &payment.PaidAt, &payment.Method); err !=nil {
+			return payments, err
+		}
+		payments = append(payments, payment)
+	}
+	return payments, rows.Err()
+}
+func (r *AccountRepository) retrieveCustomerReturns(customerID string) ([]models.ReturnRequest, error){
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	returns := []models.ReturnRequest{}
+	rows, err := r.db.QueryContext(ctx, `SELECT `+returnColumns+` FROM ReturnRequest WHERE CustomerID = ? ORDER BY CreatedAt`, customerID)
+	if err !=nil {
+		return returns, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		ret, err := scanReturn(rows)
+		if err !=nil {
+			return returns, err
+		}
+		returns = append(returns, ret)
+	}
+	return returns, rows.Err()
+}
+func (r *AccountRepository) retrieveCustomerReviews(customerID string) ([]models.ProductReview, error){
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	reviews := []models.ProductReview{}
+	rows, err := r.db.QueryContext(ctx, `SELECT `+reviewColumns+` FROM `+reviewTables+` WHERE r.CustomerID = ? ORDER BY r.CreatedAt`, customerID)
+	if err !=nil {
+		return reviews, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		review, err := scanReview(rows)
+		if err !=nil {
+			return reviews, err
+		}
+		reviews = append(reviews, review)
+	}
+	return reviews, rows.Err()
+}
+func (r *AccountRepository) retrieveCustomerQuestions(customerID string) ([]models.ProductQuestion, error){
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	questions := []models.ProductQuestion{}
+	rows, err := r.db.QueryContext(ctx, `SELECT `+questionColumns+` FROM `+questionTables+` WHERE q.CustomerID = ? ORDER BY q.CreatedAt`, customerID)
+	if err !=nil {
+		return questions, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		question, err := scanQuestion(rows)
+		if err !=nil {
+			return questions, err
+		}
+		questions = append(questions, question)
+	}
+	return questions, rows.Err()
+}
+func (r *AccountRepository) retrieveCustomerReturnPhotos(customerID string) ([]string, error){
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	fileUrls := []string{}
+	rows, err := r.db.QueryContext(ctx, `SELECT p.FileUrl FROM ReturnPhoto p JOIN ReturnRequest r ON r.ID = p.ReturnID WHERE r.CustomerID = ?`, customerID)
+	if err !=nil {
+		return fileUrls, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		fileUrl := ""
+		if err = rows.Scan(&fileUrl); err !=nil {
+			return fileUrls, err
+		}
+		fileUrls = append(fileUrls, fileUrl)
+	}
+	return fileUrls, rows.Err()
+}
+func (r *AccountRepository) retrieveSellerProducts(sellerID string) ([]models.Product, error){
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	products := []models.Product{}
+	// deleted products are part of the seller's data too
+	query := `SELECT ` + productColumns + ` FROM Product WHERE OwnerID = ?`
+	rows, err := r.db.QueryContext(ctx, query, sellerID)
+	if err !=nil {
+		return products, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		product, err := scanProduct(rows)
+		if err !=nil {
+			return products, err
+		}
+		products = append(products, product)
+	}
+	return products, rows.Err()
+}
+
+// the quantity of each of the seller's products that has stock, locked until tx is done
+func retrieveSellerStock(ctx context.Context, tx *sql.Tx, sellerID string) (map[string]int, error){
+	stock := map[string]int{}
+	rows, err := tx.QueryContext(ctx, `SELECT ID, Quantity FROM Product WHERE OwnerID = ? AND Quantity != 0 FOR UPDATE`, sellerID)
+	if err !=nil {
+		return stock, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var productID string
+		var quantity int
+		if err = rows.Scan(&productID, &quantity); err !=nil {
+			return stock, err
+		}
+		stock[productID] = quantity
+	}
+	return stock, rows.Err()
+}
@@ -34,7 +34,7 @@ func (r *AddressRepository) CreateAddress(data types.AddressInput, ) (addressId
 	}
 	defer stmt.Close() //close the statement after use
 	// execute the statement
-	addressId, _ = utils.GenerateRandomID(10)
+	addressId = utils.NewID()
 	res, err := stmt.ExecContext(ctx, addressId, data.StreetAddress, data.LgaID, data.StateID, data.CountryID)
 	if err !=nil {
 		return "", err
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/models"
+	"github.com/kaasikodes/e-commerce-go/types"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+type APIKeyRepository struct {
+	db *sql.DB
+}
+
+func NewAPIKeyRepository(db *sql.DB) *APIKeyRepository {
+	return &APIKeyRepository{
+		db: db,
+	}
+}
+
+// CreateAPIKey creates a key of the form eck_<prefix>_<secret>, the prefix is stored as is so the key can be found and recognised, the rest only as a hash
+func (r *APIKeyRepository) CreateAPIKey(userID string, input types.CreateAPIKeyInput) (models.APIKey, error){
+	db := r.db
+	apiKey := models.APIKey{}
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	active := 0
+	err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM APIKey WHERE UserID = ? AND RevokedAt IS NULL AND (ExpiresAt IS NULL OR ExpiresAt > ?)`, userID, time.Now()).Scan(&active)
+	if err !=nil {
+		return apiKey, err
+	}
+	if active >= constants.MaxActiveAPIKeysPerUser {
+		return apiKey, constants.ErrTooManyAPIKeys
+	}
+	// prepare query
+	query := `INSERT INTO APIKey (ID, UserID, Name, Prefix, KeyHash, Scopes, ExpiresAt) VALUES (?, ?, ?, ?, ?, ?, ?)`
+	// prepare the statement
+	stmt, err := db.PrepareContext(ctx, query)
+	if err !=nil {
+		return apiKey, err
+	}
+	defer stmt.Close() //close the statement after use
+	// execute the statement
+	id := utils.NewID()
+	prefixID, err := utils.GenerateRandomID(8)
+	if err !=nil {
+		return apiKey, err
+	}
+	secret, err := utils.GenerateSecureToken()
+	if err !=nil {
+		return apiKey, err
+	}
+	prefix := constants.APIKeyPrefix + prefixID
+	key := prefix + "_" + secret
+	keyHash := utils.HashToken(key)
+	now := time.Now()
+	var expiresAt *time.Time
+	if input.ExpiresInDays > 0 {
+		t := now.AddDate(0, 0, input.ExpiresInDays)
+		expiresAt = &t
+	}
+	_, err = stmt.ExecContext(ctx, id, userID, input.Name, prefix, keyHash, strings.Join(input.Scopes, ","), expiresAt)
+	if err !=nil {
+		return apiKey, err
+	}
+
+	apiKey = models.APIKey{ID: id, UserID: userID, Name: input.Name, Prefix: prefix, Key: key, KeyHash: keyHash, Scopes: input.Scopes, ExpiresAt: expiresAt, CreatedAt: now}
+	return apiKey, nil
+}
+
+func (r *APIKeyRepository) RetrieveAPIKeys(userID string) ([]models.APIKey, error){
+	db := r.db
+	// prepare query
+	query := `SELECT ID, UserID, Name, Prefix, KeyHash, Scopes, LastUsedAt, ExpiresAt, RevokedAt, CreatedAt FROM APIKey WHERE UserID = ? ORDER BY CreatedAt DESC`
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	// prepare the statement
+	apiKeys := []models.APIKey{}
+	stmt, err := db.PrepareContext(ctx, query)
+	if err !=nil {
+		return apiKeys, err
+	}
+	defer stmt.Close() //close the statement after use
+	// execute the statement
+	rows, err := stmt.QueryContext(ctx, userID)
+	if err !=nil {
+		return apiKeys, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		apiKey, err := scanAPIKey(rows)
+		if err !=nil {
+			return apiKeys, err
+		}
+		apiKeys = append(apiKeys, apiKey)
+	}
+	return apiKeys, rows.Err()
+}
+
+// RetrieveAPIKeyByKey returns the key matching the plain key, revoked and expired keys are treated as unknown
+func (r *APIKeyRepository) RetrieveAPIKeyByKey(key string) (models.APIKey, error){
+	db := r.db
+	apiKey := models.APIKey{}
+	// eck_<prefix>_<secret>
+	parts := strings.Split(key, "_")
+	if !strings.HasPrefix(key, constants.APIKeyPrefix) || len(parts) != 3 {
+		return apiKey, constants.ErrInvalidAPIKey
+	}
+	prefix := parts[0] + "_" + parts[1]
+	// prepare query
+	query := `SELECT ID, UserID, Name, Prefix, KeyHash, Scopes, LastUsedAt, ExpiresAt, RevokedAt, CreatedAt FROM APIKey WHERE Prefix = ?`
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	// prepare the statement
+	stmt, err := db.PrepareContext(ctx, query)
+	if err !=nil {
+		return apiKey, err
+	}
+	defer stmt.Close() //close the statement after use
+	// execute the statement
+	apiKey, err = scanAPIKey(stmt.QueryRowContext(ctx, prefix))
+	if err == sql.ErrNoRows {
+		return models.APIKey{}, constants.ErrInvalidAPIKey
+	}
+	if err !=nil {
+		return models.APIKey{}, err
+	}
+	if !utils.CompareTokenHash(key, apiKey.KeyHash) || apiKey.RevokedAt != nil || (apiKey.ExpiresAt != nil && time.Now().After(*apiKey.ExpiresAt)) {
+		return models.APIKey{}, constants.ErrInvalidAPIKey
+	}
+
+	return apiKey, nil
+}
+
+func (r *APIKeyRepository) RevokeAPIKey(userID string, id string) (models.APIKey, error){
+	db := r.db
+	apiKey := models.APIKey{}
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	// revoking an already revoked key keeps the original revocation time
+	_, err := db.ExecContext(ctx, `UPDATE APIKey SET RevokedAt = ? WHERE ID = ? AND UserID = ? AND RevokedAt IS NULL`, time.Now(), id, userID)
+	if err !=nil {
+		return apiKey, err
+	}
+	query := `SELECT ID, UserID, Name, Prefix, KeyHash, Scopes, LastUsedAt, ExpiresAt, RevokedAt, CreatedAt FROM APIKey WHERE ID = ? AND UserID = ?`
+	apiKey, err = scanAPIKey(db.QueryRowContext(ctx, query, id, userID))
+	if err == sql.ErrNoRows {
+		return apiKey, constants.ErrAPIKeyNotFound
+	}
+
+	return apiKey, err
+}
+
+// TouchAPIKey records that a key was used, it is only written once per APIKeyLastUsedResolution so busy keys don't cause a write on every request
+func (r *APIKeyRepository) TouchAPIKey(id string) error{
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	now := time.Now()
+	_, err := r.db.ExecContext(ctx, `UPDATE APIKey SET LastUsedAt = ? WHERE ID = ? AND (LastUsedAt IS NULL OR LastUsedAt < ?)`, now, id, now.Add(-constants.APIKeyLastUsedResolution))
+	return err
+}
+
+// private
+type apiKeyScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAPIKey(row apiKeyScanner) (models.APIKey, error){
+	apiKey := models.APIKey{}
+	var scopes string
+	var lastUsedAt, expiresAt, revokedAt sql.NullTime
+	err := row.Scan(&apiKey.ID, &apiKey.UserID, &apiKey.Name, &apiKey.Prefix, &apiKey.KeyHash, &scopes, &lastUsedAt, &expiresAt, &revokedAt, &apiKey.CreatedAt)
+	if err !=nil {
+		return apiKey, err
+	}
+	apiKey.Scopes = strings.Split(scopes, ",")
+	if lastUsedAt.Valid {
+		apiKey.LastUsedAt = &lastUsedAt.Time
+	}
+	if expiresAt.Valid {
+		apiKey.ExpiresAt = &expiresAt.Time
+	}
+	if revokedAt.Valid {
+		apiKey.RevokedAt = &revokedAt.Time
+	}
+	return apiKey, nil
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/models"
+	"github.com/kaasikodes/e-commerce-go/types"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+type UserAuditLogRepository struct {
+	db *sql.DB
+}
+
+func NewUserAuditLogRepository(db *sql.DB) *UserAuditLogRepository {
+	return &UserAuditLogRepository{
+		db: db,
+	}
+}
+
+func (r *UserAuditLogRepository) AddUserAuditLog(input types.AddUserAuditLogInput) (models.UserAuditLog, error){
+	db := r.db
+	// prepare query
+	query := `INSERT INTO UserAuditLog (ID, UserID, Action, OldValue, NewValue, IPAddress, UserAgent) VALUES (?, ?, ?, ?, ?, ?, ?)`
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	// prepare the statement
+	log := models.UserAuditLog{}
+	stmt, err := db.PrepareContext(ctx, query)
+	if err !=nil {
+		return log, err
+	}
+	defer stmt.Close() //close the statement after use
+	// execute the statement
+	id := utils.NewID()
+	// the user agent is client controlled, so it is cut to fit the column
+	userAgent := input.UserAgent
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+	_, err = stmt.ExecContext(ctx, id, input.UserID, input.Action, input.OldValue, input.NewValue, input.IPAddress, userAgent)
+	if err !=nil {
+		return log, err
+	}
+
+	log = models.UserAuditLog{ID: id, UserID: input.UserID, Action: input.Action, OldValue: input.OldValue, NewValue: input.NewValue, IPAddress: input.IPAddress, UserAgent: userAgent, CreatedAt: time.Now()}
+	return log, nil
+}
+
+func (r *UserAuditLogRepository) RetrieveUserAuditLogs(userID string) ([]models.UserAuditLog, error){
+	db := r.db
+	// prepare query
+	query := `SELECT ID, UserID, Action, OldValue, NewValue, IPAddress, UserAgent, CreatedAt FROM UserAuditLog WHERE UserID = ? ORDER BY CreatedAt DESC`
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	// prepare the statement
+	logs := []models.UserAuditLog{}
+	stmt, err := db.PrepareContext(ctx, query)
+	if err !=nil {
+		return logs, err
+	}
+	defer stmt.Close() //close the statement after use
+	// execute the statement
+	rows, err := stmt.QueryContext(ctx, userID)
+	if err !=nil {
+		return logs, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		log := models.UserAuditLog{}
+		var oldValue, newValue, ipAddress, userAgent sql.NullString
+		err = rows.Scan(&log.ID, &log.UserID, &log.Action, &oldValue, &newValue, &ipAddress, &userAgent, &log.CreatedAt)
+		if err !=nil {
+			return logs, err
+		}
+		log.OldValue = oldValue.String
+		log.NewValue = newValue.String
+		log.IPAddress = ipAddress.String
+		log.UserAgent = userAgent.String
+		logs = append(logs, log)
+	}
+
+	return logs, rows.Err()
+}
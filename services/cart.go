@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"strconv"
 	"strings"
@@ -121,7 +122,7 @@ func (c *CartRepository) CheckoutCart(customerId string, userEmail string) (mode
 	order := models.Order{}
 	payment := models.Payment{}
 	order.CustomerID = customerId
-	orderId, _:= utils.GenerateRandomID(10)
+	orderId := utils.NewID()
 	order.ID = orderId
 	// retrieve the cart
 	cart, err := c.RetrieveCart(customerId);
@@ -132,11 +133,13 @@ func (c *CartRepository) CheckoutCart(customerId string, userEmail string) (mode
 	for _, item := range cart.Items {
 		itemPrice := float64(item.Product.Price) * float64(item.Quantity)
 		orderItem := models.OrderItem{}
-		orderItemId, _:= utils.GenerateRandomID(10)
+		orderItemId := utils.NewID()
 		orderItem.ID = orderItemId
 		orderItem.OrderID = orderId
 		orderItem.ProductID = item.ProductID
+		orderItem.Product = item.Product
 		orderItem.Quantity = item.Quantity
+		orderItem.UnitPrice = item.Product.Price
 		orderItem.TotalPrice = itemPrice
 
 		totalPrice += itemPrice
@@ -146,7 +149,7 @@ func (c *CartRepository) CheckoutCart(customerId string, userEmail string) (mode
 	order.TotalAmount = totalPrice
 	order.Items = orderItems
 	payment.Amount = totalPrice
-	payment.ID, _ = utils.GenerateRandomID(10)
+	payment.ID = utils.NewID()
 	payment.OrderID = orderId
 	payment.Paid = false
 	// payment.Method = "cash" //this should be in constant
@@ -162,7 +165,7 @@ func (c *CartRepository) retrieveCartItems(cartId string) ([]models.CartItem, er
 	db := c.db
 	// prepare query
 	query := `
-    SELECT c.*, p.*
+    SELECT ` + prefixColumns("p", productColumns) + `, c.ID, c.ProductID, c.CartID, c.Quantity, c.CreatedAt, c.UpdatedAt
     FROM CartItem c
     JOIN Product p ON p.ID = c.ProductID
     WHERE c.CartID = ?
@@ -186,8 +189,7 @@ func (c *CartRepository) retrieveCartItems(cartId string) ([]models.CartItem, er
 	defer rows.Close()
 	for rows.Next() {
 		item := models.CartItem{}
-		item.Product = models.Product{}
-		err = rows.Scan(&item.ID, &item.ProductID, &item.CartID, &item.Quantity,  &item.CreatedAt, &item.UpdatedAt, &item.Product.ID, &item.Product.Name, &item.Product.Description, &item.Product.Price,&item.Product.Quantity, &item.Product.CategoryID,  &item.Product.SellerID, &item.Product.CreatedAt, &item.Product.UpdatedAt)
+		item.Product, err = scanProduct(rows, &item.ID, &item.ProductID, &item.CartID, &item.Quantity,  &item.CreatedAt, &item.UpdatedAt)
 		if err !=nil {
 			return items, err
 		}
@@ -211,7 +213,7 @@ func (c *CartRepository) createCartItems(input types.SaveCartInput, cartId strin
 	for _, data := range items {
 		cartItem := models.CartItem{}
 		inserts = append(inserts, "(?, ?, ?, ?)")
-		id, _:= utils.GenerateRandomID(10)
+		id := utils.NewID()
 		params = append(params, id, data.ProductID, data.Quantity, cartId)
 		cartItem.CartID = cartId
 		cartItem.ProductID = data.ProductID
@@ -251,6 +253,9 @@ func (c *CartRepository) CreateCart(input types.SaveCartInput, customerId string
 	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
 	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
 	defer cancel()
+	if err := c.checkProductsForSale(ctx, input.Items); err != nil {
+		return models.Cart{}, err
+	}
 	// prepare the statement
 	stmt, err := db.PrepareContext(ctx, query)
 	cart := models.Cart{}
@@ -259,7 +264,7 @@ func (c *CartRepository) CreateCart(input types.SaveCartInput, customerId string
 	}
 	defer stmt.Close() //close the statement after use
 	// execute the statement
-	id, _ := utils.GenerateRandomID(10)
+	id := utils.NewID()
 	_, err = stmt.ExecContext(ctx, id,  customerId)
 	if err != nil {
 		return  cart, err
@@ -302,6 +307,10 @@ func (c *CartRepository) RetrieveCart(customerId string) (models.Cart, error){
 		return cart, err
 	}
 	cart.Items = items
+	cart.SavedForLater, err = c.RetrieveSavedItems(customerId)
+	if err !=nil {
+		return cart, err
+	}
 	
 	return cart, nil
 
@@ -326,6 +335,11 @@ func (c *CartRepository)DeleteCart(customerId string) (error){
 	if err !=nil {
 		return err
 	}
+	// the items have to go first, they reference the cart
+	_, err = db.ExecContext(ctx, "DELETE FROM CartItem WHERE CartID IN (SELECT ID FROM Cart WHERE CustomerID = ?)", customerId)
+	if err !=nil {
+		return  err
+	}
 	// execute the statement
 	_, err = stmt.ExecContext(ctx, customerId)
 	if err !=nil {
@@ -336,3 +350,267 @@ func (c *CartRepository)DeleteCart(customerId string) (error){
 	return  nil
 }
 
+// add what is still available of a past order to the customer's cart, a cart is created if they don't have one
+func (c *CartRepository) Reorder(orderId string, customerId string) (types.ReorderOutput, error) {
+	output := types.ReorderOutput{
+		Unavailable: []models.ReorderItem{},
+		Repriced: []models.ReorderItem{},
+	}
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return output, err
+	}
+	defer tx.Rollback()
+	owner := ""
+	err = tx.QueryRowContext(ctx, "SELECT CustomerID FROM `Order` WHERE ID = ? OR Number = ?", orderId, orderId).Scan(&owner)
+	if err == sql.ErrNoRows || (err == nil && owner != customerId) {
+		return output, constants.ErrOrderNotFound
+	}
+	if err != nil {
+		return output, err
+	}
+	cartId := ""
+	err = tx.QueryRowContext(ctx, "SELECT ID FROM Cart WHERE CustomerID = ? FOR UPDATE", customerId).Scan(&cartId)
+	if err == sql.ErrNoRows {
+		cartId = utils.NewID()
+		_, err = tx.ExecContext(ctx, "INSERT INTO Cart (ID, CustomerID) VALUES (?, ?)", cartId, customerId)
+	}
+	if err != nil {
+		return output, err
+	}
+
+	// a product may appear more than once in an order, e.g. after a replacement, so the items are added up
+	// products that are no longer for sale count as out of stock
+	query := `SELECT oi.ProductID, p.Name, SUM(oi.Quantity), SUM(oi.TotalPrice), p.Price, IF(` + productForSaleCondition + `, p.Quantity, 0), COALESCE(ci.Quantity, 0)
+	FROM OrderItem oi
+	JOIN ` + "`Order`" + ` o ON o.ID = oi.OrderID
+	JOIN Product p ON p.ID = oi.ProductID
+	LEFT JOIN (SELECT ProductID, SUM(Quantity) AS Quantity FROM CartItem WHERE CartID = ? GROUP BY ProductID) ci ON ci.ProductID = oi.ProductID
+	WHERE o.ID = ? OR o.Number = ?
+	GROUP BY oi.ProductID, p.Name, p.Price, p.Quantity, p.Status, p.DeletedAt, ci.Quantity`
+	rows, err := tx.QueryContext(ctx, query, cartId, orderId, orderId)
+	if err != nil {
+		return output, err
+	}
+	type reorderLine struct {
+		item models.ReorderItem
+		inStock int
+		inCart int
+	}
+	lines := []reorderLine{}
+	for rows.Next() {
+		line := reorderLine{}
+		paid := 0.0
+		if err = rows.Scan(&line.item.ProductID, &line.item.Name, &line.item.Quantity, &paid, &line.item.Price, &line.inStock, &line.inCart); err != nil {
+			rows.Close()
+			return output, err
+		}
+		if line.item.Quantity > 0 {
+			line.item.PreviousPrice = paid / float64(line.item.Quantity)
+		}
+		lines = append(lines, line)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return output, err
+	}
+
+	for _, line := range lines {
+		item := line.item
+		// what is already in the cart counts against the stock
+		item.AddedQuantity = line.item.Quantity
+		if available := line.inStock - line.inCart; item.AddedQuantity > available {
+			item.AddedQuantity = max(available, 0)
+		}
+		if item.AddedQuantity > 0 {
+			if err = addCartItem(ctx, tx, cartId, item.ProductID, item.AddedQuantity); err != nil {
+				return output, err
+			}
+		}
+		if item.AddedQuantity < item.Quantity {
+			output.Unavailable = append(output.Unavailable, item)
+		}
+		if item.AddedQuantity > 0 && math.Round(item.PreviousPrice * 100) != float64(item.Price) * 100 {
+			output.Repriced = append(output.Repriced, item)
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return output, err
+	}
+
+	output.Cart, err = c.RetrieveCart(customerId)
+	return output, err
+}
+
+// the products the customer saved for later, the most recently saved first
+func (c *CartRepository) RetrieveSavedItems(customerId string) ([]models.SavedItem, error) {
+	db := c.db
+	// prepare query
+	query := `
+	SELECT ` + prefixColumns("p", productColumns) + `, s.ID, s.CustomerID, s.ProductID, s.Quantity, s.CreatedAt, s.UpdatedAt
+	FROM SavedForLaterItem s
+	JOIN Product p ON p.ID = s.ProductID
+	WHERE s.CustomerID = ?
+	ORDER BY s.CreatedAt DESC, s.ID DESC
+	`
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	items := []models.SavedItem{}
+	rows, err := db.QueryContext(ctx, query, customerId)
+	if err !=nil {
+		return items, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		item := models.SavedItem{}
+		item.Product, err = scanProduct(rows, &item.ID, &item.CustomerID, &item.ProductID, &item.Quantity, &item.CreatedAt, &item.UpdatedAt)
+		if err !=nil {
+			return items, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// move a product from the cart to the saved for later list, saving it again adds to the quantity saved
+func (c *CartRepository) SaveForLater(productId string, customerId string) (models.Cart, error) {
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Cart{}, err
+	}
+	defer tx.Rollback()
+	itemId, quantity := "", 0
+	err = tx.QueryRowContext(ctx, `SELECT ci.ID, ci.Quantity FROM CartItem ci JOIN Cart c ON c.ID = ci.CartID WHERE c.CustomerID = ? AND ci.ProductID = ? FOR UPDATE`, customerId, productId).Scan(&itemId, &quantity)
+	if err == sql.ErrNoRows {
+		return models.Cart{}, constants.ErrCartItemNotFound
+	}
+	if err != nil {
+		return models.Cart{}, err
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO SavedForLaterItem (ID, CustomerID, ProductID, Quantity) VALUES (?, ?, ?, ?)
+	ON DUPLICATE KEY UPDATE Quantity = Quantity + VALUES(Quantity)`, utils.NewID(), customerId, productId, quantity)
+	if err != nil {
+		return models.Cart{}, err
+	}
+	if _, err = tx.ExecContext(ctx, "DELETE FROM CartItem WHERE ID = ?", itemId); err != nil {
+		return models.Cart{}, err
+	}
+	if err = tx.Commit(); err != nil {
+		return models.Cart{}, err
+	}
+	return c.RetrieveCart(customerId)
+}
+
+// move a saved product back to the cart, a cart is created if the customer doesn't have one
+func (c *CartRepository) MoveToCart(productId string, customerId string) (models.Cart, error) {
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Cart{}, err
+	}
+	defer tx.Rollback()
+	itemId, quantity := "", 0
+	err = tx.QueryRowContext(ctx, `SELECT ID, Quantity FROM SavedForLaterItem WHERE CustomerID = ? AND ProductID = ? FOR UPDATE`, customerId, productId).Scan(&itemId, &quantity)
+	if err == sql.ErrNoRows {
+		return models.Cart{}, constants.ErrSavedItemNotFound
+	}
+	if err != nil {
+		return models.Cart{}, err
+	}
+	// saved products stay saved while they are off sale, in case they come back
+	forSale := false
+	if err = tx.QueryRowContext(ctx, `SELECT COUNT(*) > 0 FROM Product p WHERE p.ID = ? AND `+productForSaleCondition, productId).Scan(&forSale); err != nil {
+		return models.Cart{}, err
+	}
+	if !forSale {
+		return models.Cart{}, constants.ErrProductNotForSale
+	}
+	cartId := ""
+	err = tx.QueryRowContext(ctx, "SELECT ID FROM Cart WHERE CustomerID = ? FOR UPDATE", customerId).Scan(&cartId)
+	if err == sql.ErrNoRows {
+		cartId = utils.NewID()
+		_, err = tx.ExecContext(ctx, "INSERT INTO Cart (ID, CustomerID) VALUES (?, ?)", cartId, customerId)
+	}
+	if err != nil {
+		return models.Cart{}, err
+	}
+	if err = addCartItem(ctx, tx, cartId, productId, quantity); err != nil {
+		return models.Cart{}, err
+	}
+	if _, err = tx.ExecContext(ctx, "DELETE FROM SavedForLaterItem WHERE ID = ?", itemId); err != nil {
+		return models.Cart{}, err
+	}
+	if err = tx.Commit(); err != nil {
+		return models.Cart{}, err
+	}
+	return c.RetrieveCart(customerId)
+}
+
+// remove a product from the saved for later list
+func (c *CartRepository) DeleteSavedItem(productId string, customerId string) error {
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	res, err := c.db.ExecContext(ctx, "DELETE FROM SavedForLaterItem WHERE CustomerID = ? AND ProductID = ?", customerId, productId)
+	if err !=nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err !=nil {
+		return err
+	}
+	if affected == 0 {
+		return constants.ErrSavedItemNotFound
+	}
+	return nil
+}
+
+// private
+// checkProductsForSale makes sure every product in the items can be bought
+func (c *CartRepository) checkProductsForSale(ctx context.Context, items []types.CartItemInput) error {
+	if len(items) == 0 {
+		return nil
+	}
+	ids := map[string]bool{}
+	placeholders := []string{}
+	args := []interface{}{}
+	for _, item := range items {
+		if ids[item.ProductID] {
+			continue
+		}
+		ids[item.ProductID] = true
+		placeholders = append(placeholders, "?")
+		args = append(args, item.ProductID)
+	}
+	count := 0
+	query := `SELECT COUNT(*) FROM Product p WHERE p.ID IN (` + strings.Join(placeholders, ", ") + `) AND ` + productForSaleCondition
+	if err := c.db.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return err
+	}
+	if count != len(ids) {
+		return constants.ErrProductNotForSale
+	}
+	return nil
+}
+
+// addCartItem adds to the quantity of the product in the cart, or puts it in the cart if it isn't there yet
+func addCartItem(ctx context.Context, tx *sql.Tx, cartId string, productId string, quantity int) error {
+	res, err := tx.ExecContext(ctx, "UPDATE CartItem SET Quantity = Quantity + ? WHERE CartID = ? AND ProductID = ? LIMIT 1", quantity, cartId, productId)
+	if err != nil {
+		return err
+	}
+	if affected, err := res.RowsAffected(); err != nil || affected > 0 {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "INSERT INTO CartItem (ID, ProductID, Quantity, CartID) VALUES (?, ?, ?, ?)", utils.NewID(), productId, quantity, cartId)
+	return err
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestReorder(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT CustomerID FROM `Order`").WithArgs("order-1", "order-1").WillReturnRows(sqlmock.NewRows([]string{"CustomerID"}).AddRow("customer-1"))
+	mock.ExpectQuery("SELECT ID FROM Cart").WithArgs("customer-1").WillReturnRows(sqlmock.NewRows([]string{"ID"}).AddRow("cart-1"))
+	mock.ExpectQuery("SELECT oi.ProductID").WithArgs("cart-1", "order-1", "order-1").WillReturnRows(sqlmock.NewRows([]string{"ProductID", "Name", "Quantity", "TotalPrice", "Price", "Stock", "InCart"}).
+		// bought 2 at 500, now 600
+		AddRow("product-1", "Kettle", 2, 1000.0, 600, 10, 0).
+		// bought 3, one is already in the cart and only 2 are left
+		AddRow("product-2", "Mug", 3, 300.0, 100, 2, 1).
+		// sold out
+		AddRow("product-3", "Plate", 1, 50.0, 50, 0, 0))
+	mock.ExpectExec("UPDATE CartItem SET Quantity").WithArgs(2, "cart-1", "product-1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO CartItem").WithArgs(sqlmock.AnyArg(), "product-1", 2, "cart-1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE CartItem SET Quantity").WithArgs(1, "cart-1", "product-2").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectPrepare("SELECT \\* FROM Cart").ExpectQuery().WithArgs("customer-1").WillReturnRows(sqlmock.NewRows([]string{"ID", "CustomerID", "CreatedAt", "UpdatedAt"}).AddRow("cart-1", "customer-1", time.Now(), time.Now()))
+	mock.ExpectPrepare("FROM CartItem c").ExpectQuery().WithArgs("cart-1").WillReturnRows(sqlmock.NewRows([]string{"ID"}))
+	mock.ExpectQuery("FROM SavedForLaterItem s").WithArgs("customer-1").WillReturnRows(sqlmock.NewRows([]string{"ID"}))
+
+	output, err := NewCartRepository(db).Reorder("order-1", "customer-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(output.Unavailable) != 2 || output.Unavailable[0].ProductID != "product-2" || output.Unavailable[0].AddedQuantity != 1 || output.Unavailable[1].AddedQuantity != 0 {
+		t.Errorf("unavailable = %+v, want the mug with 1 added and the plate with none", output.Unavailable)
+	}
+	if len(output.Repriced) != 1 || output.Repriced[0].ProductID != "product-1" || output.Repriced[0].PreviousPrice != 500 || output.Repriced[0].Price != 600 {
+		t.Errorf("repriced = %+v, want the kettle from 500 to 600", output.Repriced)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestReorder_SomeoneElsesOrder(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT CustomerID FROM `Order`").WithArgs("order-1", "order-1").WillReturnRows(sqlmock.NewRows([]string{"CustomerID"}).AddRow("customer-2"))
+	mock.ExpectRollback()
+
+	if _, err = NewCartRepository(db).Reorder("order-1", "customer-1"); err != constants.ErrOrderNotFound {
+		t.Errorf("error = %v, want order not found", err)
+	}
+}
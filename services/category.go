@@ -181,7 +181,7 @@ func (c *CategoryRepository) AddMultipleCategories( categories []types.MultipleC
 	var params []interface{}
 	for _, category := range categories {
 		inserts = append(inserts, "(?, ?, ?)")
-		id, _:= utils.GenerateRandomID(10)
+		id := utils.NewID()
 		params = append(params, id, category.Name, category.Description)
 
 	}
@@ -225,7 +225,7 @@ func (c *CategoryRepository) AddCategory( cat models.Category) (models.Category,
 	}
 	defer stmt.Close() //close the statement after use
 	// execute the statement
-	id, _ := utils.GenerateRandomID(10)
+	id := utils.NewID()
 	res, err := stmt.ExecContext(ctx, id, cat.Name, cat.Description, )
 	if err !=nil {
 		return category, err
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/models"
+)
+
+type IdempotencyKeyRepository struct {
+	db *sql.DB
+}
+
+func NewIdempotencyKeyRepository(db *sql.DB) *IdempotencyKeyRepository {
+	return &IdempotencyKeyRepository{
+		db: db,
+	}
+}
+
+func (r *IdempotencyKeyRepository) ClaimIdempotencyKey(key models.IdempotencyKey) (models.IdempotencyKey, bool, error){
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	now := time.Now()
+	// an expired key, or one whose request never finished, can be used again
+	query := "DELETE FROM IdempotencyKey WHERE UserID = ? AND `Key` = ? AND (ExpiresAt <= ? OR (CompletedAt IS NULL AND CreatedAt <= ?))"
+	if _, err := r.db.ExecContext(ctx, query, key.UserID, key.Key, now, now.Add(-constants.IdempotencyKeyLockTimeout)); err !=nil {
+		return key, false, err
+	}
+	// the primary key makes sure only one of two requests sent at the same time gets the key
+	query = "INSERT IGNORE INTO IdempotencyKey (UserID, `Key`, Method, Path, RequestHash, CreatedAt, ExpiresAt) VALUES (?, ?, ?, ?, ?, ?, ?)"
+	res, err := r.db.ExecContext(ctx, query, key.UserID, key.Key, key.Method, key.Path, key.RequestHash, now, key.ExpiresAt)
+	if err !=nil {
+		return key, false, err
+	}
+	count, err := res.RowsAffected()
+	if err !=nil {
+		return key, false, err
+	}
+	if count > 0 {
+		key.CreatedAt = now
+		return key, true, nil
+	}
+
+	saved := models.IdempotencyKey{}
+	var completedAt sql.NullTime
+	query = "SELECT UserID, `Key`, Method, Path, RequestHash, ResponseStatus, ResponseContentType, COALESCE(ResponseBody, ''), CreatedAt, CompletedAt, ExpiresAt FROM IdempotencyKey WHERE UserID = ? AND `Key` = ?"
+	err = r.db.QueryRowContext(ctx, query, key.UserID, key.Key).Scan(&saved.UserID, &saved.Key, &saved.Method, &saved.Path, &saved.RequestHash, &saved.ResponseStatus, &saved.ResponseContentType, &saved.ResponseBody, &saved.CreatedAt, &completedAt, &saved.ExpiresAt)
+	if err == sql.ErrNoRows {
+		// it was released between the insert and the select, the client can simply retry
+		return key, false, constants.ErrIdempotencyKeyInProgress
+	}
+	if err !=nil {
+		return key, false, err
+	}
+	if completedAt.Valid {
+		saved.CompletedAt = &completedAt.Time
+	}
+	return saved, false, nil
+}
+
+func (r *IdempotencyKeyRepository) CompleteIdempotencyKey(userID string, key string, status int, contentType string, body []byte) error{
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	query := "UPDATE IdempotencyKey SET ResponseStatus = ?, ResponseContentType = ?, ResponseBody = ?, CompletedAt = ? WHERE UserID = ? AND `Key` = ?"
+	_, err := r.db.ExecContext(ctx, query, status, contentType, body, time.Now(), userID, key)
+	return err
+}
+
+func (r *IdempotencyKeyRepository) DeleteExpiredIdempotencyKeys() (int64, error){
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	res, err := r.db.ExecContext(ctx, `DELETE FROM IdempotencyKey WHERE ExpiresAt <= ?`, time.Now())
+	if err !=nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/models"
+	"github.com/kaasikodes/e-commerce-go/types"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+type UserIdentityRepository struct {
+	db *sql.DB
+}
+
+func NewUserIdentityRepository(db *sql.DB) *UserIdentityRepository {
+	return &UserIdentityRepository{
+		db: db,
+	}
+}
+
+func (r *UserIdentityRepository) AddUserIdentity(input types.AddUserIdentityInput) (models.UserIdentity, error){
+	db := r.db
+	// prepare query
+	query := `INSERT INTO UserIdentity (ID, UserID, Provider, Subject, Email) VALUES (?, ?, ?, ?, ?)`
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	// prepare the statement
+	identity := models.UserIdentity{}
+	stmt, err := db.PrepareContext(ctx, query)
+	if err !=nil {
+		return identity, err
+	}
+	defer stmt.Close() //close the statement after use
+	// execute the statement
+	id := utils.NewID()
+	_, err = stmt.ExecContext(ctx, id, input.UserID, input.Provider, input.Subject, input.Email)
+	if err !=nil {
+		return identity, err
+	}
+
+	identity = models.UserIdentity{ID: id, UserID: input.UserID, Provider: input.Provider, Subject: input.Subject, Email: input.Email, CreatedAt: time.Now()}
+	return identity, nil
+}
+
+func (r *UserIdentityRepository) RetrieveUserIdentity(provider, subject string) (models.UserIdentity, error){
+	db := r.db
+	// prepare query
+	query := `SELECT ID, UserID, Provider, Subject, Email, CreatedAt FROM UserIdentity WHERE Provider = ? AND Subject = ?`
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	// prepare the statement
+	identity := models.UserIdentity{}
+	stmt, err := db.PrepareContext(ctx, query)
+	if err !=nil {
+		return identity, err
+	}
+	defer stmt.Close() //close the statement after use
+	// execute the statement
+	var email sql.NullString
+	err = stmt.QueryRowContext(ctx, provider, subject).Scan(&identity.ID, &identity.UserID, &identity.Provider, &identity.Subject, &email, &identity.CreatedAt)
+	if err !=nil {
+		return identity, err
+	}
+	identity.Email = email.String
+
+	return identity, nil
+}
+
+func (r *UserIdentityRepository) CreateOIDCLoginState(state models.OIDCLoginState) ( error){
+	db := r.db
+	// prepare query
+	query := `INSERT INTO OIDCLoginState (State, Provider, Nonce, CodeVerifier, ExpiresAt) VALUES (?, ?, ?, ?, ?)`
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	// prepare the statement
+	stmt, err := db.PrepareContext(ctx, query)
+	if err !=nil {
+		return err
+	}
+	defer stmt.Close() //close the statement after use
+	// execute the statement
+	_, err = stmt.ExecContext(ctx, state.State, state.Provider, state.Nonce, state.CodeVerifier, state.ExpiresAt)
+	return err
+}
+
+func (r *UserIdentityRepository) ConsumeOIDCLoginState(stateVal string) (models.OIDCLoginState, error){
+	db := r.db
+	state := models.OIDCLoginState{}
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	// the state is removed in the same transaction it is read in, so two callbacks can't both use it
+	tx, err := db.BeginTx(ctx, nil)
+	if err !=nil {
+		return state, err
+	}
+	defer tx.Rollback()
+	query := `SELECT State, Provider, Nonce, CodeVerifier, CreatedAt, ExpiresAt FROM OIDCLoginState WHERE State = ? FOR UPDATE`
+	err = tx.QueryRowContext(ctx, query, stateVal).Scan(&state.State, &state.Provider, &state.Nonce, &state.CodeVerifier, &state.CreatedAt, &state.ExpiresAt)
+	if err == sql.ErrNoRows {
+		return state, constants.ErrInvalidOIDCState
+	}
+	if err !=nil {
+		return state, err
+	}
+	// expired states are removed as well
+	if _, err = tx.ExecContext(ctx, `DELETE FROM OIDCLoginState WHERE State = ? OR ExpiresAt <= ?`, stateVal, time.Now()); err !=nil {
+		return state, err
+	}
+	if err = tx.Commit(); err !=nil {
+		return state, err
+	}
+	if time.Now().After(state.ExpiresAt) {
+		return state, constants.ErrInvalidOIDCState
+	}
+
+	return state, nil
+}
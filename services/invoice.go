@@ -0,0 +1,313 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/models"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+type InvoiceRepository struct {
+	db *sql.DB
+}
+
+func NewInvoiceRepository(db *sql.DB) *InvoiceRepository {
+	return &InvoiceRepository{
+		db: db,
+	}
+}
+
+func (r *InvoiceRepository) IssueOrderInvoices(orderID string) ([]models.Invoice, error){
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	var paid bool
+	err := r.db.QueryRowContext(ctx, `SELECT Paid FROM Payment WHERE OrderID = ?`, orderID).Scan(&paid)
+	if err == sql.ErrNoRows {
+		return nil, constants.ErrOrderNotFound
+	}
+	if err !=nil {
+		return nil, err
+	}
+	if !paid {
+		return nil, constants.ErrOrderNotPaid
+	}
+	// the sellers' parts of the order that haven't been invoiced yet, cancelled ones are never invoiced
+	query := `SELECT s.ID FROM SubOrder s LEFT JOIN Invoice i ON i.SubOrderID = s.ID WHERE s.OrderID = ? AND s.Status != ? AND i.ID IS NULL`
+	rows, err := r.db.QueryContext(ctx, query, orderID, constants.SubOrderStatuses.Cancelled)
+	if err !=nil {
+		return nil, err
+	}
+	subOrderIDs := []string{}
+	for rows.Next() {
+		var id string
+		if err = rows.Scan(&id); err !=nil {
+			rows.Close()
+			return nil, err
+		}
+		subOrderIDs = append(subOrderIDs, id)
+	}
+	rows.Close()
+	if err = rows.Err(); err !=nil {
+		return nil, err
+	}
+	for _, id := range subOrderIDs {
+		if err = r.issueInvoice(ctx, orderID, id); err !=nil {
+			return nil, err
+		}
+	}
+	return r.retrieveInvoices(ctx, `OrderID = ?`, orderID)
+}
+
+func (r *InvoiceRepository) RetrieveSellerInvoice(subOrderID string, sellerID string) (models.Invoice, error){
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	var orderID string
+	err := r.db.QueryRowContext(ctx, `SELECT OrderID FROM SubOrder WHERE ID = ? AND SellerID = ?`, subOrderID, sellerID).Scan(&orderID)
+	if err == sql.ErrNoRows {
+		return models.Invoice{}, constants.ErrSubOrderNotFound
+	}
+	if err !=nil {
+		return models.Invoice{}, err
+	}
+	invoices, err := r.IssueOrderInvoices(orderID)
+	if err !=nil {
+		return models.Invoice{}, err
+	}
+	for _, invoice := range invoices {
+		if invoice.SubOrderID == subOrderID {
+			return invoice, nil
+		}
+	}
+	return models.Invoice{}, constants.ErrInvoiceNotFound
+}
+
+// private
+// issueInvoice issues the invoice of a sub-order in its own transaction.
+// The order is locked first, like cancelling does, so the sub-order can't be cancelled while it is being invoiced
+func (r *InvoiceRepository) issueInvoice(ctx context.Context, orderID string, subOrderID string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err !=nil {
+		return err
+	}
+	defer tx.Rollback()
+	var locked string
+	if err = tx.QueryRowContext(ctx, "SELECT ID FROM `Order` WHERE ID = ? FOR UPDATE", orderID).Scan(&locked); err !=nil {
+		return err
+	}
+	// someone else may have invoiced or cancelled it in the meantime
+	var status string
+	var invoices int
+	query := `SELECT s.Status, (SELECT COUNT(*) FROM Invoice i WHERE i.SubOrderID = s.ID) FROM SubOrder s WHERE s.ID = ?`
+	if err = tx.QueryRowContext(ctx, query, subOrderID).Scan(&status, &invoices); err !=nil {
+		return err
+	}
+	if status == constants.SubOrderStatuses.Cancelled || invoices > 0 {
+		return nil
+	}
+
+	invoice := models.Invoice{SubOrderID: subOrderID, OrderID: orderID, TaxRateBps: constants.VATRateBps}
+	var paidAt sql.NullTime
+	var street, lga, state, country string
+	query = "SELECT s.SellerID, COALESCE(NULLIF(ob.BusinessName, ''), su.Name), COALESCE(o.Number, ''), o.CustomerID, cu.Name, a.StreetAddress, COALESCE(l.Name, ''), COALESCE(st.Name, ''), COALESCE(c.Name, ''), p.ID, p.Method, p.PaidAt " +
+		"FROM SubOrder s JOIN `Order` o ON o.ID = s.OrderID JOIN Payment p ON p.OrderID = o.ID " +
+		`JOIN Seller se ON se.ID = s.SellerID JOIN User su ON su.ID = se.UserID LEFT JOIN SellerOnboarding ob ON ob.SellerID = s.SellerID
+		JOIN Customer cs ON cs.ID = o.CustomerID JOIN User cu ON cu.ID = cs.UserID
+		JOIN Address a ON a.ID = o.DeliveryAddressID LEFT JOIN Lga l ON l.ID = a.LgaID LEFT JOIN State st ON st.ID = a.StateID LEFT JOIN Country c ON c.ID = a.CountryID
+		WHERE s.ID = ?`
+	err = tx.QueryRowContext(ctx, query, subOrderID).Scan(&invoice.SellerID, &invoice.SellerName, &invoice.OrderNumber, &invoice.CustomerID, &invoice.BillToName, &street, &lga, &state, &country, &invoice.PaymentID, &invoice.PaymentMethod, &paidAt)
+	if err !=nil {
+		return err
+	}
+	invoice.PaidAt = paidAt.Time
+	invoice.BillToAddress = joinAddress(street, lga, state, country)
+
+	query = `SELECT p.Name, oi.Quantity, oi.TotalPrice FROM OrderItem oi JOIN Product p ON p.ID = oi.ProductID WHERE oi.OrderID = ? AND p.OwnerID = ? ORDER BY oi.ID`
+	rows, err := tx.QueryContext(ctx, query, orderID, invoice.SellerID)
+	if err !=nil {
+		return err
+	}
+	for rows.Next() {
+		line := models.InvoiceLine{}
+		var totalPrice float64
+		if err = rows.Scan(&line.Description, &line.Quantity, &totalPrice); err !=nil {
+			rows.Close()
+			return err
+		}
+		line.Total = int64(math.Round(totalPrice * 100))
+		if line.Quantity > 0 {
+			line.UnitPrice = line.Total / int64(line.Quantity)
+		}
+		invoice.Total += line.Total
+		invoice.Lines = append(invoice.Lines, line)
+	}
+	rows.Close()
+	if err = rows.Err(); err !=nil {
+		return err
+	}
+	invoice.TaxAmount = includedVAT(invoice.Total)
+	invoice.Subtotal = invoice.Total - invoice.TaxAmount
+
+	if invoice.Number, err = nextDocumentNumber(ctx, tx, invoice.SellerID, constants.InvoiceNumberPrefix); err !=nil {
+		return err
+	}
+	invoice.ID = utils.NewID()
+	query = `INSERT INTO Invoice (ID, Number, SellerID, SellerName, OrderID, OrderNumber, SubOrderID, CustomerID, BillToName, BillToAddress, PaymentID, PaymentMethod, PaidAt, Subtotal, TaxRateBps, TaxAmount, Total)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err = tx.ExecContext(ctx, query, invoice.ID, invoice.Number, invoice.SellerID, invoice.SellerName, invoice.OrderID, invoice.OrderNumber, invoice.SubOrderID, invoice.CustomerID, invoice.BillToName, invoice.BillToAddress,
+		invoice.PaymentID, invoice.PaymentMethod, paidAt, invoice.Subtotal, invoice.TaxRateBps, invoice.TaxAmount, invoice.Total)
+	if err !=nil {
+		return err
+	}
+	for i, line := range invoice.Lines {
+		query = `INSERT INTO InvoiceLine (InvoiceID, Position, Description, Quantity, UnitPrice, Total) VALUES (?, ?, ?, ?, ?, ?)`
+		if _, err = tx.ExecContext(ctx, query, invoice.ID, i + 1, line.Description, line.Quantity, line.UnitPrice, line.Total); err !=nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// retrieveInvoices returns the invoices matching where with their lines and credit notes
+func (r *InvoiceRepository) retrieveInvoices(ctx context.Context, where string, args ...interface{}) ([]models.Invoice, error) {
+	query := `SELECT ` + invoiceColumns + ` FROM Invoice WHERE ` + where + ` ORDER BY IssuedAt ASC, ID ASC`
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err !=nil {
+		return nil, err
+	}
+	invoices := []models.Invoice{}
+	for rows.Next() {
+		invoice, err := scanInvoice(rows)
+		if err !=nil {
+			rows.Close()
+			return nil, err
+		}
+		invoices = append(invoices, invoice)
+	}
+	rows.Close()
+	if err = rows.Err(); err !=nil {
+		return nil, err
+	}
+	for i := range invoices {
+		if invoices[i].Lines, err = r.retrieveInvoiceLines(ctx, invoices[i].ID); err !=nil {
+			return nil, err
+		}
+		if invoices[i].CreditNotes, err = r.retrieveCreditNotes(ctx, invoices[i].ID); err !=nil {
+			return nil, err
+		}
+	}
+	return invoices, nil
+}
+
+func (r *InvoiceRepository) retrieveInvoiceLines(ctx context.Context, invoiceID string) ([]models.InvoiceLine, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT Description, Quantity, UnitPrice, Total FROM InvoiceLine WHERE InvoiceID = ? ORDER BY Position ASC`, invoiceID)
+	if err !=nil {
+		return nil, err
+	}
+	defer rows.Close()
+	lines := []models.InvoiceLine{}
+	for rows.Next() {
+		line := models.InvoiceLine{}
+		if err = rows.Scan(&line.Description, &line.Quantity, &line.UnitPrice, &line.Total); err !=nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+	return lines, rows.Err()
+}
+
+func (r *InvoiceRepository) retrieveCreditNotes(ctx context.Context, invoiceID string) ([]models.CreditNote, error) {
+	query := `SELECT ID, Number, InvoiceID, SellerID, COALESCE(RefundID, ''), COALESCE(ReturnID, ''), Reason, Subtotal, TaxAmount, Total, IssuedAt FROM CreditNote WHERE InvoiceID = ? ORDER BY IssuedAt ASC, ID ASC`
+	rows, err := r.db.QueryContext(ctx, query, invoiceID)
+	if err !=nil {
+		return nil, err
+	}
+	defer rows.Close()
+	notes := []models.CreditNote{}
+	for rows.Next() {
+		note := models.CreditNote{}
+		if err = rows.Scan(&note.ID, &note.Number, &note.InvoiceID, &note.SellerID, &note.RefundID, &note.ReturnID, &note.Reason, &note.Subtotal, &note.TaxAmount, &note.Total, &note.IssuedAt); err !=nil {
+			return nil, err
+		}
+		notes = append(notes, note)
+	}
+	return notes, rows.Err()
+}
+
+// issueCreditNote credits total back against the invoice of a sub-order in tx, nothing is issued when it was never invoiced
+func issueCreditNote(ctx context.Context, tx *sql.Tx, subOrderID string, refundID string, returnID string, reason string, total int64) error {
+	if total <= 0 {
+		return nil
+	}
+	var invoiceID, sellerID string
+	err := tx.QueryRowContext(ctx, `SELECT ID, SellerID FROM Invoice WHERE SubOrderID = ?`, subOrderID).Scan(&invoiceID, &sellerID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	number, err := nextDocumentNumber(ctx, tx, sellerID, constants.CreditNoteNumberPrefix)
+	if err != nil {
+		return err
+	}
+	id := utils.NewID()
+	tax := includedVAT(total)
+	query := `INSERT INTO CreditNote (ID, Number, InvoiceID, SellerID, RefundID, ReturnID, Reason, Subtotal, TaxAmount, Total) VALUES (?, ?, ?, ?, NULLIF(?, ''), NULLIF(?, ''), ?, ?, ?, ?)`
+	_, err = tx.ExecContext(ctx, query, id, number, invoiceID, sellerID, refundID, returnID, reason, total - tax, tax, total)
+	return err
+}
+
+// nextDocumentNumber takes the next number of the seller's sequence for a type of document in tx.
+// The sequence row stays locked until tx ends, so numbers are only used up by documents that get saved and there are no gaps
+func nextDocumentNumber(ctx context.Context, tx *sql.Tx, sellerID string, prefix string) (string, error) {
+	if _, err := tx.ExecContext(ctx, `INSERT IGNORE INTO DocumentSequence (SellerID, Type, LastNumber) VALUES (?, ?, 0)`, sellerID, prefix); err != nil {
+		return "", err
+	}
+	var last int64
+	if err := tx.QueryRowContext(ctx, `SELECT LastNumber FROM DocumentSequence WHERE SellerID = ? AND Type = ? FOR UPDATE`, sellerID, prefix).Scan(&last); err != nil {
+		return "", err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE DocumentSequence SET LastNumber = ? WHERE SellerID = ? AND Type = ?`, last + 1, sellerID, prefix); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s-%06d", prefix, last + 1), nil
+}
+
+// includedVAT is the vat already in a vat inclusive amount, in kobo
+func includedVAT(total int64) int64 {
+	rate := int64(constants.VATRateBps)
+	return (total * rate + (10000 + rate) / 2) / (10000 + rate)
+}
+
+func joinAddress(parts ...string) string {
+	nonEmpty := []string{}
+	for _, part := range parts {
+		if part != "" {
+			nonEmpty = append(nonEmpty, part)
+		}
+	}
+	return strings.Join(nonEmpty, ", ")
+}
+
+const invoiceColumns = `ID, Number, SellerID, SellerName, OrderID, OrderNumber, SubOrderID, CustomerID, BillToName, BillToAddress, PaymentID, PaymentMethod, PaidAt, Subtotal, TaxRateBps, TaxAmount, Total, IssuedAt`
+
+type invoiceScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanInvoice(row invoiceScanner, extra ...interface{}) (models.Invoice, error) {
+	invoice := models.Invoice{}
+	var paidAt sql.NullTime
+	dest := []interface{}{&invoice.ID, &invoice.Number, &invoice.SellerID, &invoice.SellerName, &invoice.OrderID, &invoice.OrderNumber, &invoice.SubOrderID, &invoice.CustomerID, &invoice.BillToName, &invoice.BillToAddress,
+		&invoice.PaymentID, &invoice.PaymentMethod, &paidAt, &invoice.Subtotal, &invoice.TaxRateBps, &invoice.TaxAmount, &invoice.Total, &invoice.IssuedAt}
+	err := row.Scan(append(dest, extra...)...)
+	invoice.PaidAt = paidAt.Time
+	return invoice, err
+}
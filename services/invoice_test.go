@@ -0,0 +1,45 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestIssueCreditNote(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT ID, SellerID FROM Invoice").WithArgs("sub-order-1").WillReturnRows(sqlmock.NewRows([]string{"ID", "SellerID"}).AddRow("invoice-1", "seller-1"))
+	mock.ExpectExec("INSERT IGNORE INTO DocumentSequence").WithArgs("seller-1", constants.CreditNoteNumberPrefix).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT LastNumber FROM DocumentSequence").WithArgs("seller-1", constants.CreditNoteNumberPrefix).WillReturnRows(sqlmock.NewRows([]string{"LastNumber"}).AddRow(41))
+	mock.ExpectExec("UPDATE DocumentSequence SET LastNumber").WithArgs(int64(42), "seller-1", constants.CreditNoteNumberPrefix).WillReturnResult(sqlmock.NewResult(0, 1))
+	// 10,750.00 including 7.5% vat is 10,000.00 and 750.00 of vat
+	mock.ExpectExec("INSERT INTO CreditNote").WithArgs(sqlmock.AnyArg(), "CN-000042", "invoice-1", "seller-1", "refund-1", "", "Order cancelled", int64(1000000), int64(75000), int64(1075000)).WillReturnResult(sqlmock.NewResult(0, 1))
+	// a sub-order that was never invoiced
+	mock.ExpectQuery("SELECT ID, SellerID FROM Invoice").WithArgs("sub-order-2").WillReturnRows(sqlmock.NewRows([]string{"ID", "SellerID"}))
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = issueCreditNote(context.Background(), tx, "sub-order-1", "refund-1", "", "Order cancelled", 1075000); err != nil {
+		t.Fatal(err)
+	}
+	if err = issueCreditNote(context.Background(), tx, "sub-order-2", "refund-1", "", "Order cancelled", 1075000); err != nil {
+		t.Fatalf("uninvoiced sub-order error = %v, want nil", err)
+	}
+	// nothing is credited for nothing
+	if err = issueCreditNote(context.Background(), tx, "sub-order-1", "", "", "Order cancelled", 0); err != nil {
+		t.Fatal(err)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
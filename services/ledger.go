@@ -0,0 +1,444 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/models"
+	"github.com/kaasikodes/e-commerce-go/types"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+type LedgerRepository struct {
+	db *sql.DB
+}
+
+func NewLedgerRepository(db *sql.DB) *LedgerRepository {
+	return &LedgerRepository{
+		db: db,
+	}
+}
+
+func (r *LedgerRepository) RetrieveCommissionRates() ([]models.CommissionRate, error){
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	rates := []models.CommissionRate{}
+	rows, err := r.db.QueryContext(ctx, `SELECT Scope, ScopeID, RateBps, CreatedAt, UpdatedAt FROM CommissionRate ORDER BY Scope, ScopeID`)
+	if err !=nil {
+		return rates, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		rate := models.CommissionRate{}
+		if err = rows.Scan(&rate.Scope, &rate.ScopeID, &rate.RateBps, &rate.CreatedAt, &rate.UpdatedAt); err !=nil {
+			return rates, err
+		}
+		rates = append(rates, rate)
+	}
+	return rates, rows.Err()
+}
+
+func (r *LedgerRepository) SaveCommissionRate(input types.SaveCommissionRateInput) (models.CommissionRate, error){
+	db := r.db
+	rate := models.CommissionRate{}
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	// there is only one global rate, so it is saved without a scope id
+	if input.Scope == "global" {
+		input.ScopeID = ""
+	} else {
+		table := "Category"
+		if input.Scope == "seller" {
+			table = "Seller"
+		}
+		exists := 0
+		if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM `+table+` WHERE ID = ?`, input.ScopeID).Scan(&exists); err !=nil {
+			return rate, err
+		}
+		if exists == 0 {
+			return rate, constants.ErrCommissionScopeNotFound
+		}
+	}
+	query := `INSERT INTO CommissionRate (Scope, ScopeID, RateBps) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE RateBps = VALUES(RateBps)`
+	if _, err := db.ExecContext(ctx, query, input.Scope, input.ScopeID, input.RateBps); err !=nil {
+		return rate, err
+	}
+	err := db.QueryRowContext(ctx, `SELECT Scope, ScopeID, RateBps, CreatedAt, UpdatedAt FROM CommissionRate WHERE Scope = ? AND ScopeID = ?`, input.Scope, input.ScopeID).Scan(&rate.Scope, &rate.ScopeID, &rate.RateBps, &rate.CreatedAt, &rate.UpdatedAt)
+	return rate, err
+}
+
+func (r *LedgerRepository) DeleteCommissionRate(scope string, scopeID string) error{
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	res, err := r.db.ExecContext(ctx, `DELETE FROM CommissionRate WHERE Scope = ? AND ScopeID = ?`, scope, scopeID)
+	if err !=nil {
+		return err
+	}
+	count, err := res.RowsAffected()
+	if err !=nil {
+		return err
+	}
+	if count == 0 {
+		return constants.ErrCommissionRateNotFound
+	}
+	return nil
+}
+
+func (r *LedgerRepository) PostPaymentSales(paymentID string) error{
+	db := r.db
+	// the seller's own rate wins over the rate of the product's category, then the global rate and last of all the default
+	query := `SELECT oi.ID, oi.TotalPrice, p.OwnerID, p.Name, COALESCE(sr.RateBps, cr.RateBps, gr.RateBps, ?)
+		FROM Payment pa
+		JOIN OrderItem oi ON oi.OrderID = pa.OrderID
+		JOIN Product p ON p.ID = oi.ProductID
+		LEFT JOIN CommissionRate sr ON sr.Scope = 'seller' AND sr.ScopeID = p.OwnerID
+		LEFT JOIN CommissionRate cr ON cr.Scope = 'category' AND cr.ScopeID = p.CategoryID
+		LEFT JOIN CommissionRate gr ON gr.Scope = 'global' AND gr.ScopeID = ''
+		LEFT JOIN SubOrder so ON so.OrderID = oi.OrderID AND so.SellerID = p.OwnerID
+		WHERE pa.ID = ? AND pa.Paid = true AND (so.Status IS NULL OR so.Status != ?)`
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	type sale struct {
+		orderItemID string
+		totalPrice  float64
+		sellerID    string
+		productName string
+		rateBps     int64
+	}
+	sales := []sale{}
+	rows, err := db.QueryContext(ctx, query, constants.DefaultCommissionRateBps, paymentID, constants.SubOrderStatuses.Cancelled)
+	if err !=nil {
+		return err
+	}
+	for rows.Next() {
+		s := sale{}
+		if err = rows.Scan(&s.orderItemID, &s.totalPrice, &s.sellerID, &s.productName, &s.rateBps); err !=nil {
+			rows.Close()
+			return err
+		}
+		sales = append(sales, s)
+	}
+	rows.Close()
+	if err = rows.Err(); err !=nil {
+		return err
+	}
+	// the whole order is posted or none of it
+	tx, err := db.BeginTx(ctx, nil)
+	if err !=nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, s := range sales {
+		gross := int64(math.Round(s.totalPrice * 100))
+		commission := (gross * s.rateBps + 5000) / 10000
+		description := fmt.Sprintf("Sale of %s, %d.%02d%% commission", s.productName, s.rateBps / 100, s.rateBps % 100)
+		_, err = postLedgerTransaction(ctx, tx, constants.LedgerTransactionTypes.Sale, s.orderItemID, description, []ledgerEntry{
+			{constants.LedgerAccounts.GatewayClearing, s.sellerID, gross},
+			{constants.LedgerAccounts.SellerPending, s.sellerID, -(gross - commission)},
+			{constants.LedgerAccounts.PlatformCommission, s.sellerID, -commission},
+		})
+		if err !=nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *LedgerRepository) ReleaseHeldFunds(postedBefore time.Time) (int, error){
+	db := r.db
+	// sales that don't have a release yet and haven't been refunded
+	query := `SELECT t.ID, e.SellerID
+		FROM LedgerTransaction t
+		JOIN LedgerEntry e ON e.TransactionID = t.ID AND e.Account = ?
+		LEFT JOIN LedgerTransaction rt ON rt.Type = ? AND rt.ReferenceID = t.ID
+		LEFT JOIN LedgerTransaction ft ON ft.Type = ? AND ft.ReferenceID = t.ReferenceID
+		WHERE t.Type = ? AND t.CreatedAt <= ? AND rt.ID IS NULL AND ft.ID IS NULL`
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	type held struct {
+		saleID   string
+		sellerID string
+	}
+	funds := []held{}
+	rows, err := db.QueryContext(ctx, query, constants.LedgerAccounts.SellerPending, constants.LedgerTransactionTypes.Release, constants.LedgerTransactionTypes.Refund, constants.LedgerTransactionTypes.Sale, postedBefore)
+	if err !=nil {
+		return 0, err
+	}
+	for rows.Next() {
+		h := held{}
+		if err = rows.Scan(&h.saleID, &h.sellerID); err !=nil {
+			rows.Close()
+			return 0, err
+		}
+		funds = append(funds, h)
+	}
+	rows.Close()
+	if err = rows.Err(); err !=nil {
+		return 0, err
+	}
+	released := 0
+	for _, h := range funds {
+		tx, err := db.BeginTx(ctx, nil)
+		if err !=nil {
+			return released, err
+		}
+		// the sale is locked so it can't be refunded while it is being released
+		posted := false
+		refunded, err := isSaleRefunded(ctx, tx, h.saleID)
+		if err == nil && !refunded {
+			// what is left of the pending credit is moved over to available, returns may have taken some of it back already
+			var amount int64
+			amount, err = heldSaleAmount(ctx, tx, h.saleID)
+			if err == nil {
+				posted, err = postLedgerTransaction(ctx, tx, constants.LedgerTransactionTypes.Release, h.saleID, "Hold period ended", []ledgerEntry{
+					{constants.LedgerAccounts.SellerPending, h.sellerID, -amount},
+					{constants.LedgerAccounts.SellerAvailable, h.sellerID, amount},
+				})
+			}
+		}
+		if err == nil {
+			err = tx.Commit()
+		}
+		tx.Rollback()
+		if err !=nil {
+			return released, err
+		}
+		if posted {
+			released++
+		}
+	}
+	return released, nil
+}
+
+func (r *LedgerRepository) RetrieveSellerBalance(sellerID string) (models.SellerBalance, error){
+	balance := models.SellerBalance{}
+	// the seller's accounts are credits, so their balances are the negated sums
+	query := `SELECT
+		COALESCE(-SUM(CASE WHEN Account = ? THEN Amount END), 0),
+		COALESCE(-SUM(CASE WHEN Account = ? THEN Amount END), 0),
+		COALESCE(-SUM(CASE WHEN Account = ? THEN Amount END), 0)
+		FROM LedgerEntry WHERE SellerID = ?`
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	err := r.db.QueryRowContext(ctx, query, constants.LedgerAccounts.SellerPending, constants.LedgerAccounts.SellerAvailable, constants.LedgerAccounts.PayoutsInTransit, sellerID).Scan(&balance.Pending, &balance.Available, &balance.InTransit)
+	return balance, err
+}
+
+func (r *LedgerRepository) RetrieveSellerStatement(sellerID string, from time.Time, to time.Time) (models.SellerStatement, error){
+	db := r.db
+	statement := models.SellerStatement{From: from, To: to, Lines: []models.SellerStatementLine{}}
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	accounts := []interface{}{constants.LedgerAccounts.SellerPending, constants.LedgerAccounts.SellerAvailable}
+	openingQuery := `SELECT COALESCE(-SUM(e.Amount), 0) FROM LedgerEntry e JOIN LedgerTransaction t ON t.ID = e.TransactionID
+		WHERE e.SellerID = ? AND e.Account IN (?, ?) AND t.CreatedAt < ?`
+	if err := db.QueryRowContext(ctx, openingQuery, sellerID, accounts[0], accounts[1], from).Scan(&statement.OpeningBalance); err !=nil {
+		return statement, err
+	}
+	// releases and paid payouts only move money between accounts, they don't change the balance
+	query := `SELECT t.ID, t.Type, t.ReferenceID, COALESCE(t.Description, ''), t.CreatedAt,
+			COALESCE(-SUM(CASE WHEN e.Account IN (?, ?) THEN e.Amount END), 0),
+			COALESCE(SUM(CASE WHEN e.Account = ? THEN e.Amount END), 0),
+			COALESCE(-SUM(CASE WHEN e.Account = ? THEN e.Amount END), 0)
+		FROM LedgerTransaction t
+		JOIN LedgerEntry e ON e.TransactionID = t.ID
+		WHERE e.SellerID = ? AND t.Type IN (?, ?, ?, ?) AND t.CreatedAt >= ? AND t.CreatedAt < ?
+		GROUP BY t.ID, t.Type, t.ReferenceID, t.Description, t.CreatedAt
+		ORDER BY t.CreatedAt ASC, t.ID ASC`
+	rows, err := db.QueryContext(ctx, query, accounts[0], accounts[1], constants.LedgerAccounts.GatewayClearing, constants.LedgerAccounts.PlatformCommission,
+		sellerID, constants.LedgerTransactionTypes.Sale, constants.LedgerTransactionTypes.Refund, constants.LedgerTransactionTypes.Payout, constants.LedgerTransactionTypes.PayoutFailed, from, to)
+	if err !=nil {
+		return statement, err
+	}
+	defer rows.Close()
+	balance := statement.OpeningBalance
+	for rows.Next() {
+		line := models.SellerStatementLine{}
+		var gross, commission int64
+		if err = rows.Scan(&line.TransactionID, &line.Type, &line.ReferenceID, &line.Description, &line.CreatedAt, &line.Amount, &gross, &commission); err !=nil {
+			return statement, err
+		}
+		balance += line.Amount
+		line.Balance = balance
+		switch line.Type {
+		case constants.LedgerTransactionTypes.Sale:
+			statement.GrossSales += gross
+			statement.Commission += commission
+			statement.NetSales += line.Amount
+		case constants.LedgerTransactionTypes.Refund:
+			statement.Refunds -= line.Amount
+		case constants.LedgerTransactionTypes.Payout, constants.LedgerTransactionTypes.PayoutFailed:
+			statement.Payouts -= line.Amount
+		}
+		statement.Lines = append(statement.Lines, line)
+	}
+	statement.ClosingBalance = balance
+	return statement, rows.Err()
+}
+
+// reverseSale posts a refund that undoes the sale of an order item in tx, commission included.
+// Once a sale has been released the seller gives it back from their available balance instead of pending.
+// Items that were never sold, as their order wasn't paid for, are left alone
+func reverseSale(ctx context.Context, tx *sql.Tx, orderItemID string) error {
+	saleID := ""
+	err := tx.QueryRowContext(ctx, `SELECT ID FROM LedgerTransaction WHERE Type = ? AND ReferenceID = ? FOR UPDATE`, constants.LedgerTransactionTypes.Sale, orderItemID).Scan(&saleID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	released := 0
+	if err = tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM LedgerTransaction WHERE Type = ? AND ReferenceID = ?`, constants.LedgerTransactionTypes.Release, saleID).Scan(&released); err != nil {
+		return err
+	}
+	rows, err := tx.QueryContext(ctx, `SELECT Account, SellerID, Amount FROM LedgerEntry WHERE TransactionID = ?`, saleID)
+	if err != nil {
+		return err
+	}
+	entries := []ledgerEntry{}
+	for rows.Next() {
+		entry := ledgerEntry{}
+		if err = rows.Scan(&entry.account, &entry.sellerID, &entry.amount); err != nil {
+			rows.Close()
+			return err
+		}
+		if released > 0 && entry.account == constants.LedgerAccounts.SellerPending {
+			entry.account = constants.LedgerAccounts.SellerAvailable
+		}
+		entry.amount = -entry.amount
+		entries = append(entries, entry)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	_, err = postLedgerTransaction(ctx, tx, constants.LedgerTransactionTypes.Refund, orderItemID, "Refund of cancelled item", entries)
+	return err
+}
+
+// reverseReturnedSale posts a refund in tx that takes amount of an order item's sale back from the seller, with the same share of the commission.
+// The customer is owed the amount on creditAccount, the gateway clearing account when they are refunded through the payment gateway.
+// Like reverseSale, the seller gives it back from wherever the sale's funds are: pending while they are held, available once released
+func reverseReturnedSale(ctx context.Context, tx *sql.Tx, orderItemID string, returnID string, amount int64, creditAccount string, description string) error {
+	// the sale is locked so it can't be released while part of it is being taken back
+	saleID := ""
+	err := tx.QueryRowContext(ctx, `SELECT ID FROM LedgerTransaction WHERE Type = ? AND ReferenceID = ? FOR UPDATE`, constants.LedgerTransactionTypes.Sale, orderItemID).Scan(&saleID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	released := 0
+	if err = tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM LedgerTransaction WHERE Type = ? AND ReferenceID = ?`, constants.LedgerTransactionTypes.Release, saleID).Scan(&released); err != nil {
+		return err
+	}
+	sellerAccount := constants.LedgerAccounts.SellerPending
+	if released > 0 {
+		sellerAccount = constants.LedgerAccounts.SellerAvailable
+	}
+	rows, err := tx.QueryContext(ctx, `SELECT Account, SellerID, Amount FROM LedgerEntry WHERE TransactionID = ?`, saleID)
+	if err != nil {
+		return err
+	}
+	var sellerID string
+	var gross, commission int64
+	for rows.Next() {
+		entry := ledgerEntry{}
+		if err = rows.Scan(&entry.account, &entry.sellerID, &entry.amount); err != nil {
+			rows.Close()
+			return err
+		}
+		sellerID = entry.sellerID
+		switch entry.account {
+		case constants.LedgerAccounts.GatewayClearing:
+			gross = entry.amount
+		case constants.LedgerAccounts.PlatformCommission:
+			commission = -entry.amount
+		}
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	// items that were never sold have nothing to take back
+	if gross <= 0 || amount <= 0 {
+		return nil
+	}
+	commissionShare := (commission * amount + gross / 2) / gross
+	_, err = postLedgerTransaction(ctx, tx, constants.LedgerTransactionTypes.Refund, returnID, description, []ledgerEntry{
+		{creditAccount, sellerID, -amount},
+		{sellerAccount, sellerID, amount - commissionShare},
+		{constants.LedgerAccounts.PlatformCommission, sellerID, commissionShare},
+	})
+	return err
+}
+
+// heldSaleAmount is what is left on the pending account of a sale that hasn't been released, once the returns taken back from it are counted.
+// The seller's accounts are credits, so it is negative
+func heldSaleAmount(ctx context.Context, tx *sql.Tx, saleID string) (int64, error) {
+	query := `SELECT COALESCE(SUM(e.Amount), 0) FROM LedgerEntry e JOIN LedgerTransaction t ON t.ID = e.TransactionID
+		WHERE e.Account = ? AND (t.ID = ? OR (t.Type = ? AND t.ReferenceID IN (
+			SELECT r.ID FROM ReturnRequest r JOIN LedgerTransaction s ON s.ReferenceID = r.OrderItemID WHERE s.ID = ?)))`
+	var amount int64
+	err := tx.QueryRowContext(ctx, query, constants.LedgerAccounts.SellerPending, saleID, constants.LedgerTransactionTypes.Refund, saleID).Scan(&amount)
+	return amount, err
+}
+
+// isSaleRefunded locks the sale in tx and reports whether it has been refunded
+func isSaleRefunded(ctx context.Context, tx *sql.Tx, saleID string) (bool, error) {
+	orderItemID := ""
+	if err := tx.QueryRowContext(ctx, `SELECT ReferenceID FROM LedgerTransaction WHERE ID = ? FOR UPDATE`, saleID).Scan(&orderItemID); err != nil {
+		return false, err
+	}
+	refunds := 0
+	err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM LedgerTransaction WHERE Type = ? AND ReferenceID = ?`, constants.LedgerTransactionTypes.Refund, orderItemID).Scan(&refunds)
+	return refunds > 0, err
+}
+
+// ledgerEntry is an entry that is yet to be posted
+type ledgerEntry struct {
+	account  string
+	sellerID string
+	amount   int64
+}
+
+// postLedgerTransaction records the entries as one transaction in tx, a transaction with the same type and reference is only ever posted once.
+// It reports whether the transaction was posted
+func postLedgerTransaction(ctx context.Context, tx *sql.Tx, transactionType string, referenceID string, description string, entries []ledgerEntry) (bool, error) {
+	var sum int64
+	for _, entry := range entries {
+		sum += entry.amount
+	}
+	if sum != 0 {
+		return false, constants.ErrUnbalancedLedgerTransaction
+	}
+	id := utils.NewID()
+	res, err := tx.ExecContext(ctx, `INSERT IGNORE INTO LedgerTransaction (ID, Type, ReferenceID, Description) VALUES (?, ?, ?, ?)`, id, transactionType, referenceID, description)
+	if err != nil {
+		return false, err
+	}
+	count, err := res.RowsAffected()
+	if err != nil || count == 0 {
+		return false, err
+	}
+	for _, entry := range entries {
+		entryID := utils.NewID()
+		if _, err = tx.ExecContext(ctx, `INSERT INTO LedgerEntry (ID, TransactionID, Account, SellerID, Amount) VALUES (?, ?, ?, ?, ?)`, entryID, id, entry.account, entry.sellerID, entry.amount); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/types"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestPostLedgerTransaction(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT IGNORE INTO LedgerTransaction").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO LedgerEntry").WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), constants.LedgerAccounts.GatewayClearing, "seller-1", int64(10000)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO LedgerEntry").WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), constants.LedgerAccounts.SellerPending, "seller-1", int64(-9000)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO LedgerEntry").WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), constants.LedgerAccounts.PlatformCommission, "seller-1", int64(-1000)).WillReturnResult(sqlmock.NewResult(0, 1))
+	// the same sale verified a second time
+	mock.ExpectExec("INSERT IGNORE INTO LedgerTransaction").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := []ledgerEntry{
+		{account: constants.LedgerAccounts.GatewayClearing, sellerID: "seller-1", amount: 10000},
+		{account: constants.LedgerAccounts.SellerPending, sellerID: "seller-1", amount: -9000},
+		{account: constants.LedgerAccounts.PlatformCommission, sellerID: "seller-1", amount: -1000},
+	}
+	posted, err := postLedgerTransaction(context.Background(), tx, constants.LedgerTransactionTypes.Sale, "item-1", "", entries)
+	if err != nil || !posted {
+		t.Fatalf("first post = %v, %v, want true, nil", posted, err)
+	}
+	posted, err = postLedgerTransaction(context.Background(), tx, constants.LedgerTransactionTypes.Sale, "item-1", "", entries)
+	if err != nil || posted {
+		t.Fatalf("second post = %v, %v, want false, nil", posted, err)
+	}
+
+	entries[2].amount = -900
+	if _, err = postLedgerTransaction(context.Background(), tx, constants.LedgerTransactionTypes.Sale, "item-2", "", entries); err != constants.ErrUnbalancedLedgerTransaction {
+		t.Fatalf("unbalanced post error = %v, want %v", err, constants.ErrUnbalancedLedgerTransaction)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestReverseSaleAfterRelease(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT ID FROM LedgerTransaction").WithArgs(constants.LedgerTransactionTypes.Sale, "item-1").WillReturnRows(sqlmock.NewRows([]string{"ID"}).AddRow("sale-1"))
+	mock.ExpectQuery("SELECT COUNT").WithArgs(constants.LedgerTransactionTypes.Release, "sale-1").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery("SELECT Account, SellerID, Amount FROM LedgerEntry").WithArgs("sale-1").WillReturnRows(sqlmock.NewRows([]string{"Account", "SellerID", "Amount"}).
+		AddRow(constants.LedgerAccounts.GatewayClearing, "seller-1", 10000).
+		AddRow(constants.LedgerAccounts.SellerPending, "seller-1", -9000).
+		AddRow(constants.LedgerAccounts.PlatformCommission, "seller-1", -1000))
+	mock.ExpectExec("INSERT IGNORE INTO LedgerTransaction").WithArgs(sqlmock.AnyArg(), constants.LedgerTransactionTypes.Refund, "item-1", sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	// the released funds are taken back from the available balance
+	mock.ExpectExec("INSERT INTO LedgerEntry").WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), constants.LedgerAccounts.GatewayClearing, "seller-1", int64(-10000)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO LedgerEntry").WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), constants.LedgerAccounts.SellerAvailable, "seller-1", int64(9000)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO LedgerEntry").WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), constants.LedgerAccounts.PlatformCommission, "seller-1", int64(1000)).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = reverseSale(context.Background(), tx, "item-1"); err != nil {
+		t.Fatal(err)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func expectReturnedSale(mock sqlmock.Sqlmock, releases int) {
+	mock.ExpectQuery("SELECT ID FROM LedgerTransaction WHERE Type = \\? AND ReferenceID = \\? FOR UPDATE").WithArgs(constants.LedgerTransactionTypes.Sale, "item-1").WillReturnRows(sqlmock.NewRows([]string{"ID"}).AddRow("sale-1"))
+	mock.ExpectQuery("SELECT COUNT").WithArgs(constants.LedgerTransactionTypes.Release, "sale-1").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(releases))
+	mock.ExpectQuery("SELECT Account, SellerID, Amount FROM LedgerEntry").WithArgs("sale-1").WillReturnRows(sqlmock.NewRows([]string{"Account", "SellerID", "Amount"}).
+		AddRow(constants.LedgerAccounts.GatewayClearing, "seller-1", 10000).
+		AddRow(constants.LedgerAccounts.SellerPending, "seller-1", -9000).
+		AddRow(constants.LedgerAccounts.PlatformCommission, "seller-1", -1000))
+	mock.ExpectExec("INSERT IGNORE INTO LedgerTransaction").WithArgs(sqlmock.AnyArg(), constants.LedgerTransactionTypes.Refund, "return-1", sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func TestReverseReturnedSaleToStoreCredit(t *testing.T) {
+	cases := []struct {
+		name          string
+		releases      int
+		sellerAccount string
+	}{
+		// the funds are still held, so they are taken back from pending and available is left alone
+		{"held", 0, constants.LedgerAccounts.SellerPending},
+		{"released", 1, constants.LedgerAccounts.SellerAvailable},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			mock.ExpectBegin()
+			expectReturnedSale(mock, c.releases)
+			// one of four units is returned, so a quarter of the commission goes back with it
+			mock.ExpectExec("INSERT INTO LedgerEntry").WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), constants.LedgerAccounts.CustomerStoreCredit, "seller-1", int64(-2500)).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec("INSERT INTO LedgerEntry").WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), c.sellerAccount, "seller-1", int64(2250)).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec("INSERT INTO LedgerEntry").WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), constants.LedgerAccounts.PlatformCommission, "seller-1", int64(250)).WillReturnResult(sqlmock.NewResult(0, 1))
+
+			tx, err := db.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = reverseReturnedSale(context.Background(), tx, "item-1", "return-1", 2500, constants.LedgerAccounts.CustomerStoreCredit, ""); err != nil {
+				t.Fatal(err)
+			}
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestReleaseHeldFunds_AfterReturn(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT t.ID, e.SellerID").WillReturnRows(sqlmock.NewRows([]string{"ID", "SellerID"}).AddRow("sale-1", "seller-1"))
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT ReferenceID FROM LedgerTransaction WHERE ID = \\? FOR UPDATE").WithArgs("sale-1").WillReturnRows(sqlmock.NewRows([]string{"ReferenceID"}).AddRow("item-1"))
+	mock.ExpectQuery("SELECT COUNT").WithArgs(constants.LedgerTransactionTypes.Refund, "item-1").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	// 9000 was held and a return took 2250 of it back, so 6750 is released
+	mock.ExpectQuery("SELECT COALESCE\\(SUM\\(e.Amount\\), 0\\) FROM LedgerEntry").WithArgs(constants.LedgerAccounts.SellerPending, "sale-1", constants.LedgerTransactionTypes.Refund, "sale-1").
+		WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(-6750))
+	mock.ExpectExec("INSERT IGNORE INTO LedgerTransaction").WithArgs(sqlmock.AnyArg(), constants.LedgerTransactionTypes.Release, "sale-1", sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO LedgerEntry").WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), constants.LedgerAccounts.SellerPending, "seller-1", int64(6750)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO LedgerEntry").WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), constants.LedgerAccounts.SellerAvailable, "seller-1", int64(-6750)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	released, err := NewLedgerRepository(db).ReleaseHeldFunds(time.Now())
+	if err != nil || released != 1 {
+		t.Errorf("released = %v, %v, want 1, nil", released, err)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSaveCommissionRate_Global(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// there is no category or seller to look up, and any scope id passed in is dropped
+	mock.ExpectExec("INSERT INTO CommissionRate").WithArgs("global", "", 500).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT Scope, ScopeID, RateBps, CreatedAt, UpdatedAt FROM CommissionRate").WithArgs("global", "").
+		WillReturnRows(sqlmock.NewRows([]string{"Scope", "ScopeID", "RateBps", "CreatedAt", "UpdatedAt"}).AddRow("global", "", 500, time.Now(), time.Now()))
+
+	rate, err := NewLedgerRepository(db).SaveCommissionRate(types.SaveCommissionRateInput{Scope: "global", ScopeID: "ignored", RateBps: 500})
+	if err != nil {
+		t.Fatalf("error = %v, want nil", err)
+	}
+	if rate.Scope != "global" || rate.RateBps != 500 {
+		t.Errorf("rate = %+v, want the global rate of 500", rate)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPostPaymentSales_GlobalRateBeforeDefault(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// the item has no seller or category rate, so the global 5% is used instead of the default
+	mock.ExpectQuery("COALESCE\\(sr.RateBps, cr.RateBps, gr.RateBps, \\?\\)(.+)LEFT JOIN CommissionRate gr ON gr.Scope = 'global'").
+		WithArgs(constants.DefaultCommissionRateBps, "payment-1", constants.SubOrderStatuses.Cancelled).
+		WillReturnRows(sqlmock.NewRows([]string{"ID", "TotalPrice", "OwnerID", "Name", "RateBps"}).AddRow("item-1", 100.0, "seller-1", "Lamp", 500))
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT IGNORE INTO LedgerTransaction").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO LedgerEntry").WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), constants.LedgerAccounts.GatewayClearing, "seller-1", int64(10000)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO LedgerEntry").WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), constants.LedgerAccounts.SellerPending, "seller-1", int64(-9500)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO LedgerEntry").WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), constants.LedgerAccounts.PlatformCommission, "seller-1", int64(-500)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err = NewLedgerRepository(db).PostPaymentSales("payment-1"); err != nil {
+		t.Fatalf("error = %v, want nil", err)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,248 @@
+package services
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/types"
+)
+
+// OIDCClient is a generic OpenID Connect client, the provider's endpoints are found through discovery on first use
+type OIDCClient struct {
+	config     constants.OIDCProviderConfig
+	httpClient *http.Client
+
+	mu            sync.Mutex
+	discovery     *oidcDiscovery
+	keys          map[string]*rsa.PublicKey
+	keysFetchedAt time.Time
+}
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksURI               string `json:"jwks_uri"`
+}
+
+type oidcJwks struct {
+	Keys []struct {
+		Kid string `json:"kid"`
+		Kty string `json:"kty"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+	} `json:"keys"`
+}
+
+type oidcIDTokenClaims struct {
+	Email   string `json:"email"`
+	Name    string `json:"name"`
+	Picture string `json:"picture"`
+	Nonce   string `json:"nonce"`
+	// some providers send email_verified as a string
+	EmailVerified interface{} `json:"email_verified"`
+	jwt.RegisteredClaims
+}
+
+func NewOIDCClient(config constants.OIDCProviderConfig, httpClient *http.Client) *OIDCClient {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: constants.OIDCHTTPTimeout}
+	}
+	return &OIDCClient{
+		config:     config,
+		httpClient: httpClient,
+	}
+}
+
+// AuthCodeURL returns the url the user is sent to, to sign in at the provider
+func (c *OIDCClient) AuthCodeURL(state, nonce, codeChallenge string) (string, error) {
+	discovery, err := c.discover()
+	if err != nil {
+		return "", err
+	}
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {c.config.ClientID},
+		"redirect_uri":          {c.config.RedirectURL},
+		"scope":                 {strings.Join(c.config.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {codeChallenge},
+		"code_challenge_method": {"S256"},
+	}
+	separator := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return discovery.AuthorizationEndpoint + separator + params.Encode(), nil
+}
+
+// Exchange swaps an authorization code for the provider's tokens, proving possession of the pkce code verifier
+func (c *OIDCClient) Exchange(code, codeVerifier string) (types.OIDCTokenResponse, error) {
+	var tokenResponse types.OIDCTokenResponse
+	discovery, err := c.discover()
+	if err != nil {
+		return tokenResponse, err
+	}
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {c.config.RedirectURL},
+		"client_id":     {c.config.ClientID},
+		"client_secret": {c.config.ClientSecret},
+		"code_verifier": {codeVerifier},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), constants.OIDCHTTPTimeout) // ensure the request does not time out
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return tokenResponse, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return tokenResponse, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return tokenResponse, fmt.Errorf("token exchange failed with status %s", res.Status)
+	}
+	if err = json.NewDecoder(res.Body).Decode(&tokenResponse); err != nil {
+		return tokenResponse, err
+	}
+	if tokenResponse.IDToken == "" {
+		return tokenResponse, errors.New("provider did not return an id token")
+	}
+	return tokenResponse, nil
+}
+
+// VerifyIDToken checks the id token's signature, issuer, audience, expiry and nonce and returns its claims
+func (c *OIDCClient) VerifyIDToken(rawIDToken, nonce string) (types.OIDCClaims, error) {
+	discovery, err := c.discover()
+	if err != nil {
+		return types.OIDCClaims{}, err
+	}
+	claims := oidcIDTokenClaims{}
+	_, err = jwt.ParseWithClaims(rawIDToken, &claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return c.publicKey(discovery.JwksURI, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256"}),
+		jwt.WithIssuer(discovery.Issuer),
+		jwt.WithAudience(c.config.ClientID),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return types.OIDCClaims{}, err
+	}
+	if subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1 {
+		return types.OIDCClaims{}, errors.New("id token nonce does not match")
+	}
+	if claims.Subject == "" {
+		return types.OIDCClaims{}, errors.New("id token has no subject")
+	}
+	emailVerified := claims.EmailVerified == true || claims.EmailVerified == "true"
+	return types.OIDCClaims{
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: emailVerified,
+		Name:          claims.Name,
+		Picture:       claims.Picture,
+		Nonce:         claims.Nonce,
+	}, nil
+}
+
+// private
+func (c *OIDCClient) discover() (*oidcDiscovery, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.discovery != nil {
+		return c.discovery, nil
+	}
+	discovery := &oidcDiscovery{}
+	wellKnown := strings.TrimSuffix(c.config.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := c.getJSON(wellKnown, discovery); err != nil {
+		return nil, err
+	}
+	// the issuer must be the one we were configured with, otherwise tokens from another issuer could be accepted
+	if discovery.Issuer != c.config.Issuer {
+		return nil, fmt.Errorf("discovered issuer %s does not match configured issuer %s", discovery.Issuer, c.config.Issuer)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JwksURI == "" {
+		return nil, errors.New("provider discovery document is incomplete")
+	}
+	c.discovery = discovery
+	return discovery, nil
+}
+
+// publicKey returns the provider's signing key with the kid, the key set is refetched when the kid is unknown to pick up rotated keys.
+// Tokens with made up kids can't make it be fetched more than once per OIDCJwksRefetchInterval
+func (c *OIDCClient) publicKey(jwksURI, kid string) (*rsa.PublicKey, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if key, ok := c.keys[kid]; ok {
+		return key, nil
+	}
+	if !c.keysFetchedAt.IsZero() && time.Since(c.keysFetchedAt) < constants.OIDCJwksRefetchInterval {
+		return nil, fmt.Errorf("no signing key found for kid %q", kid)
+	}
+	c.keysFetchedAt = time.Now()
+	jwks := oidcJwks{}
+	if err := c.getJSON(jwksURI, &jwks); err != nil {
+		return nil, err
+	}
+	keys := map[string]*rsa.PublicKey{}
+	for _, k := range jwks.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	c.keys = keys
+	key, ok := keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("no signing key found for kid %q", kid)
+	}
+	return key, nil
+}
+
+func (c *OIDCClient) getJSON(apiUrl string, v interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), constants.OIDCHTTPTimeout) // ensure the request does not time out
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiUrl, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("request to %s failed with status %s", apiUrl, res.Status)
+	}
+	return json.NewDecoder(res.Body).Decode(v)
+}
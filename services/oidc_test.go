@@ -0,0 +1,203 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+// stubOIDCProvider is a minimal local OpenID Connect provider that issues id tokens for a single authorization code
+type stubOIDCProvider struct {
+	server        *httptest.Server
+	key           *rsa.PrivateKey
+	code          string
+	codeChallenge string
+	idTokenClaims jwt.MapClaims
+	jwksFetches   int
+}
+
+func newStubOIDCProvider(t *testing.T) *stubOIDCProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("unable to generate key: %v", err)
+	}
+	p := &stubOIDCProvider{key: key, code: "auth-code"}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 p.server.URL,
+			"authorization_endpoint": p.server.URL + "/authorize",
+			"token_endpoint":         p.server.URL + "/token",
+			"jwks_uri":               p.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		p.jwksFetches++
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kid": "stub-key",
+				"kty": "RSA",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		// the code verifier has to match the challenge sent when the login was started
+		if r.Form.Get("code") != p.code || utils.PKCECodeChallenge(r.Form.Get("code_verifier")) != p.codeChallenge {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "access-token",
+			"token_type":   "Bearer",
+			"id_token":     p.signIDToken(t, p.idTokenClaims),
+		})
+	})
+	p.server = httptest.NewServer(mux)
+	return p
+}
+
+func (p *stubOIDCProvider) signIDToken(t *testing.T, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "stub-key"
+	signed, err := token.SignedString(p.key)
+	if err != nil {
+		t.Fatalf("unable to sign id token: %v", err)
+	}
+	return signed
+}
+
+func (p *stubOIDCProvider) claims(nonce string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":            p.server.URL,
+		"aud":            "client-id",
+		"sub":            "subject-1",
+		"email":          "jane@example.com",
+		"email_verified": true,
+		"name":           "Jane",
+		"nonce":          nonce,
+		"exp":            time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func (p *stubOIDCProvider) client() *OIDCClient {
+	return NewOIDCClient(constants.OIDCProviderConfig{
+		Issuer:       p.server.URL,
+		ClientID:     "client-id",
+		ClientSecret: "client-secret",
+		RedirectURL:  "http://localhost:3000/callback",
+		Scopes:       []string{"openid", "email"},
+	}, p.server.Client())
+}
+
+func TestOIDCClient_LoginFlow(t *testing.T) {
+	provider := newStubOIDCProvider(t)
+	defer provider.server.Close()
+	client := provider.client()
+
+	codeVerifier, _ := utils.GenerateSecureToken()
+	authUrl, err := client.AuthCodeURL("state-1", "nonce-1", utils.PKCECodeChallenge(codeVerifier))
+	if err != nil {
+		t.Fatalf("unexpected error building auth url: %v", err)
+	}
+	parsed, err := url.Parse(authUrl)
+	if err != nil {
+		t.Fatalf("invalid auth url: %v", err)
+	}
+	query := parsed.Query()
+	if query.Get("state") != "state-1" || query.Get("nonce") != "nonce-1" || query.Get("code_challenge_method") != "S256" {
+		t.Errorf("auth url is missing state, nonce or pkce params: %s", authUrl)
+	}
+	provider.codeChallenge = query.Get("code_challenge")
+	provider.idTokenClaims = provider.claims("nonce-1")
+
+	tokens, err := client.Exchange(provider.code, codeVerifier)
+	if err != nil {
+		t.Fatalf("unexpected error exchanging code: %v", err)
+	}
+	claims, err := client.VerifyIDToken(tokens.IDToken, "nonce-1")
+	if err != nil {
+		t.Fatalf("unexpected error verifying id token: %v", err)
+	}
+	if claims.Subject != "subject-1" || claims.Email != "jane@example.com" || !claims.EmailVerified {
+		t.Errorf("unexpected claims: %+v", claims)
+	}
+}
+
+func TestOIDCClient_ExchangeRejectsWrongCodeVerifier(t *testing.T) {
+	provider := newStubOIDCProvider(t)
+	defer provider.server.Close()
+	client := provider.client()
+
+	provider.codeChallenge = utils.PKCECodeChallenge("the-right-verifier")
+	provider.idTokenClaims = provider.claims("nonce-1")
+	if _, err := client.Exchange(provider.code, "a-wrong-verifier"); err == nil {
+		t.Errorf("expected the exchange to fail with the wrong code verifier")
+	}
+}
+
+func TestOIDCClient_VerifyIDTokenRejectsInvalidTokens(t *testing.T) {
+	provider := newStubOIDCProvider(t)
+	defer provider.server.Close()
+	client := provider.client()
+
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	foreignToken := jwt.NewWithClaims(jwt.SigningMethodRS256, provider.claims("nonce-1"))
+	foreignToken.Header["kid"] = "stub-key"
+	foreignSigned, _ := foreignToken.SignedString(otherKey)
+
+	wrongAudience := provider.claims("nonce-1")
+	wrongAudience["aud"] = "another-client"
+	wrongIssuer := provider.claims("nonce-1")
+	wrongIssuer["iss"] = "https://evil.example.com"
+	expired := provider.claims("nonce-1")
+	expired["exp"] = time.Now().Add(-time.Minute).Unix()
+
+	cases := map[string]string{
+		"wrong nonce":     provider.signIDToken(t, provider.claims("another-nonce")),
+		"wrong audience":  provider.signIDToken(t, wrongAudience),
+		"wrong issuer":    provider.signIDToken(t, wrongIssuer),
+		"expired":         provider.signIDToken(t, expired),
+		"wrong signature": foreignSigned,
+	}
+	for name, idToken := range cases {
+		if _, err := client.VerifyIDToken(idToken, "nonce-1"); err == nil {
+			t.Errorf("%s: expected id token to be rejected", name)
+		}
+	}
+}
+
+func TestOIDCClient_UnknownKidDoesNotRefetchKeysEveryTime(t *testing.T) {
+	provider := newStubOIDCProvider(t)
+	defer provider.server.Close()
+	client := provider.client()
+
+	if _, err := client.VerifyIDToken(provider.signIDToken(t, provider.claims("nonce-1")), "nonce-1"); err != nil {
+		t.Fatalf("unexpected error verifying id token: %v", err)
+	}
+	// tokens with made up kids are rejected without going back to the provider for its keys
+	for i := 0; i < 5; i++ {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, provider.claims("nonce-1"))
+		token.Header["kid"] = "made-up-" + string(rune('a'+i))
+		signed, _ := token.SignedString(provider.key)
+		if _, err := client.VerifyIDToken(signed, "nonce-1"); err == nil {
+			t.Errorf("expected an id token with an unknown kid to be rejected")
+		}
+	}
+	if provider.jwksFetches != 1 {
+		t.Errorf("keys were fetched %d times, want 1", provider.jwksFetches)
+	}
+}
@@ -0,0 +1,305 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/models"
+	"github.com/kaasikodes/e-commerce-go/types"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+type SellerOnboardingRepository struct {
+	db *sql.DB
+}
+
+func NewSellerOnboardingRepository(db *sql.DB) *SellerOnboardingRepository {
+	return &SellerOnboardingRepository{
+		db: db,
+	}
+}
+
+const sellerOnboardingColumns = `SellerID, Status, BusinessName, BusinessType, RegistrationNumber, BusinessAddress, PhoneNumber, BankName, BankCode, BankAccountName, BankAccountNumber, ReviewerID, ReviewerComment, SubmittedAt, ReviewedAt, CreatedAt, UpdatedAt`
+
+func (r *SellerOnboardingRepository) RetrieveSellerOnboarding(sellerID string) (models.SellerOnboarding, error){
+	db := r.db
+	// prepare query
+	query := `SELECT ` + sellerOnboardingColumns + ` FROM SellerOnboarding WHERE SellerID = ?`
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	// prepare the statement
+	stmt, err := db.PrepareContext(ctx, query)
+	if err !=nil {
+		return models.SellerOnboarding{}, err
+	}
+	defer stmt.Close() //close the statement after use
+	// execute the statement
+	onboarding, err := scanSellerOnboarding(stmt.QueryRowContext(ctx, sellerID))
+	if err == sql.ErrNoRows {
+		onboarding = models.SellerOnboarding{SellerID: sellerID, Status: constants.SellerOnboardingStatuses.Draft}
+	} else if err !=nil {
+		return onboarding, err
+	}
+	onboarding.Documents, err = r.retrieveSellerDocuments(sellerID)
+
+	return onboarding, err
+}
+
+func (r *SellerOnboardingRepository) SaveSellerOnboarding(sellerID string, input types.SaveSellerOnboardingInput) (models.SellerOnboarding, error){
+	db := r.db
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	tx, err := db.BeginTx(ctx, nil)
+	if err !=nil {
+		return models.SellerOnboarding{}, err
+	}
+	defer tx.Rollback()
+	if err = ensureSellerOnboardingEditable(ctx, tx, sellerID); err !=nil {
+		return models.SellerOnboarding{}, err
+	}
+	query := `INSERT INTO SellerOnboarding (SellerID, Status, BusinessName, BusinessType, RegistrationNumber, BusinessAddress, PhoneNumber, BankName, BankCode, BankAccountName, BankAccountNumber)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE Status = VALUES(Status), BusinessName = VALUES(BusinessName), BusinessType = VALUES(BusinessType), RegistrationNumber = VALUES(RegistrationNumber),
+		BusinessAddress = VALUES(BusinessAddress), PhoneNumber = VALUES(PhoneNumber), BankName = VALUES(BankName), BankCode = VALUES(BankCode),
+		BankAccountName = VALUES(BankAccountName), BankAccountNumber = VALUES(BankAccountNumber)`
+	_, err = tx.ExecContext(ctx, query, sellerID, constants.SellerOnboardingStatuses.Draft, input.BusinessName, input.BusinessType, input.RegistrationNumber, input.BusinessAddress, input.PhoneNumber, input.BankName, input.BankCode, input.BankAccountName, input.BankAccountNumber)
+	if err !=nil {
+		return models.SellerOnboarding{}, err
+	}
+	if err = tx.Commit(); err !=nil {
+		return models.SellerOnboarding{}, err
+	}
+
+	return r.RetrieveSellerOnboarding(sellerID)
+}
+
+func (r *SellerOnboardingRepository) AddSellerDocument(sellerID string, input types.AddSellerDocumentInput) (models.SellerDocument, error){
+	db := r.db
+	document := models.SellerDocument{}
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	tx, err := db.BeginTx(ctx, nil)
+	if err !=nil {
+		return document, err
+	}
+	defer tx.Rollback()
+	if err = ensureSellerOnboardingEditable(ctx, tx, sellerID); err !=nil {
+		return document, err
+	}
+	// documents can be added before any details are saved
+	if _, err = tx.ExecContext(ctx, `INSERT IGNORE INTO SellerOnboarding (SellerID, Status) VALUES (?, ?)`, sellerID, constants.SellerOnboardingStatuses.Draft); err !=nil {
+		return document, err
+	}
+	id := utils.NewID()
+	_, err = tx.ExecContext(ctx, `INSERT INTO SellerDocument (ID, SellerID, Type, FileUrl, ContentType) VALUES (?, ?, ?, ?, ?)`, id, sellerID, input.Type, input.FileUrl, input.ContentType)
+	if err !=nil {
+		return document, err
+	}
+	if err = tx.Commit(); err !=nil {
+		return document, err
+	}
+
+	document = models.SellerDocument{ID: id, SellerID: sellerID, Type: input.Type, FileUrl: input.FileUrl, ContentType: input.ContentType, CreatedAt: time.Now()}
+	return document, nil
+}
+
+func (r *SellerOnboardingRepository) RetrieveSellerDocument(sellerID string, id string) (models.SellerDocument, error){
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	document := models.SellerDocument{}
+	query := `SELECT ID, SellerID, Type, FileUrl, ContentType, CreatedAt FROM SellerDocument WHERE SellerID = ? AND ID = ?`
+	err := r.db.QueryRowContext(ctx, query, sellerID, id).Scan(&document.ID, &document.SellerID, &document.Type, &document.FileUrl, &document.ContentType, &document.CreatedAt)
+	if err == sql.ErrNoRows {
+		return document, constants.ErrSellerDocumentNotFound
+	}
+	return document, err
+}
+
+func (r *SellerOnboardingRepository) SubmitSellerOnboarding(sellerID string) (models.SellerOnboarding, error){
+	onboarding, err := r.RetrieveSellerOnboarding(sellerID)
+	if err !=nil {
+		return onboarding, err
+	}
+	if onboarding.Status != constants.SellerOnboardingStatuses.Draft && onboarding.Status != constants.SellerOnboardingStatuses.Rejected {
+		return onboarding, constants.ErrSellerOnboardingNotEditable
+	}
+	hasGovernmentID := false
+	for _, document := range onboarding.Documents {
+		if document.Type == "government_id" {
+			hasGovernmentID = true
+		}
+	}
+	if onboarding.BusinessName == "" || onboarding.BankAccountNumber == "" || !hasGovernmentID {
+		return onboarding, constants.ErrSellerOnboardingIncomplete
+	}
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	// the status is checked again in the update, in case it changed since it was read
+	res, err := r.db.ExecContext(ctx, `UPDATE SellerOnboarding SET Status = ?, SubmittedAt = ?, ReviewerID = NULL, ReviewerComment = NULL, ReviewedAt = NULL WHERE SellerID = ? AND Status IN (?, ?)`,
+		constants.SellerOnboardingStatuses.Submitted, time.Now(), sellerID, constants.SellerOnboardingStatuses.Draft, constants.SellerOnboardingStatuses.Rejected)
+	if err !=nil {
+		return onboarding, err
+	}
+	count, err := res.RowsAffected()
+	if err !=nil {
+		return onboarding, err
+	}
+	if count == 0 {
+		return onboarding, constants.ErrSellerOnboardingNotEditable
+	}
+
+	return r.RetrieveSellerOnboarding(sellerID)
+}
+
+func (r *SellerOnboardingRepository) RetrieveSellerOnboardings(input types.RetrieveSellerOnboardingsInput) (types.PaginatedDataOutput, error){
+	db := r.db
+	// prepare query
+	query := `SELECT ` + sellerOnboardingColumns + `,
+		(SELECT COUNT(*) FROM SellerOnboarding WHERE Status = ?) AS total
+		FROM SellerOnboarding
+		WHERE Status = ? AND SellerID > ?
+		ORDER BY SellerID ASC
+		LIMIT ?`
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	// prepare the statement
+	output := types.PaginatedDataOutput{}
+	stmt, err := db.PrepareContext(ctx, query)
+	if err !=nil {
+		return output, err
+	}
+	defer stmt.Close() //close the statement after use
+	// execute the statement
+	status := utils.Ternary(input.Status == "", constants.SellerOnboardingStatuses.Submitted, input.Status)
+	rows, err := stmt.QueryContext(ctx, status, status, input.Pagination.NextCursor, utils.Ternary(input.Pagination.PageSize == 0, constants.DefaultPageSize, input.Pagination.PageSize))
+	if err !=nil {
+		return output, err
+	}
+	defer rows.Close()
+	onboardings := []models.SellerOnboarding{}
+	total := 0
+	for rows.Next() {
+		onboarding, err := scanSellerOnboarding(rows, &total)
+		if err !=nil {
+			return output, err
+		}
+		onboardings = append(onboardings, onboarding)
+	}
+	if err = rows.Err(); err !=nil {
+		return output, err
+	}
+	lastItemId := ""
+	// select last item in the list
+	if len(onboardings) > 0 {
+		lastItemId = onboardings[len(onboardings)-1].SellerID
+	}
+
+	output = types.PaginatedDataOutput{
+		Data: onboardings,
+		NextCursor: lastItemId,
+		HasMore:    len(onboardings) < total,
+		Total:      total,
+	}
+	return output, nil
+}
+
+func (r *SellerOnboardingRepository) ReviewSellerOnboarding(sellerID string, reviewerID string, input types.ReviewSellerOnboardingInput) (models.SellerOnboarding, error){
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	// only a submitted onboarding can be reviewed, so a seller can't be approved on details they are still changing
+	res, err := r.db.ExecContext(ctx, `UPDATE SellerOnboarding SET Status = ?, ReviewerID = ?, ReviewerComment = ?, ReviewedAt = ? WHERE SellerID = ? AND Status = ?`,
+		input.Status, reviewerID, input.Comment, time.Now(), sellerID, constants.SellerOnboardingStatuses.Submitted)
+	if err !=nil {
+		return models.SellerOnboarding{}, err
+	}
+	count, err := res.RowsAffected()
+	if err !=nil {
+		return models.SellerOnboarding{}, err
+	}
+	if count == 0 {
+		return models.SellerOnboarding{}, constants.ErrSellerOnboardingNotSubmitted
+	}
+
+	return r.RetrieveSellerOnboarding(sellerID)
+}
+
+// private
+func ensureSellerOnboardingEditable(ctx context.Context, tx *sql.Tx, sellerID string) error {
+	var status string
+	err := tx.QueryRowContext(ctx, `SELECT Status FROM SellerOnboarding WHERE SellerID = ? FOR UPDATE`, sellerID).Scan(&status)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err !=nil {
+		return err
+	}
+	if status != constants.SellerOnboardingStatuses.Draft && status != constants.SellerOnboardingStatuses.Rejected {
+		return constants.ErrSellerOnboardingNotEditable
+	}
+	return nil
+}
+
+func (r *SellerOnboardingRepository) retrieveSellerDocuments(sellerID string) ([]models.SellerDocument, error){
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	documents := []models.SellerDocument{}
+	rows, err := r.db.QueryContext(ctx, `SELECT ID, SellerID, Type, FileUrl, ContentType, CreatedAt FROM SellerDocument WHERE SellerID = ? ORDER BY CreatedAt`, sellerID)
+	if err !=nil {
+		return documents, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		document := models.SellerDocument{}
+		if err = rows.Scan(&document.ID, &document.SellerID, &document.Type, &document.FileUrl, &document.ContentType, &document.CreatedAt); err !=nil {
+			return documents, err
+		}
+		documents = append(documents, document)
+	}
+	return documents, rows.Err()
+}
+
+type sellerOnboardingScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanSellerOnboarding scans the sellerOnboardingColumns, followed by any extra columns
+func scanSellerOnboarding(row sellerOnboardingScanner, extra ...interface{}) (models.SellerOnboarding, error){
+	onboarding := models.SellerOnboarding{Documents: []models.SellerDocument{}}
+	var businessName, businessType, registrationNumber, businessAddress, phoneNumber, bankName, bankCode, bankAccountName, bankAccountNumber, reviewerID, reviewerComment sql.NullString
+	var submittedAt, reviewedAt sql.NullTime
+	dest := []interface{}{&onboarding.SellerID, &onboarding.Status, &businessName, &businessType, &registrationNumber, &businessAddress, &phoneNumber, &bankName, &bankCode, &bankAccountName, &bankAccountNumber, &reviewerID, &reviewerComment, &submittedAt, &reviewedAt, &onboarding.CreatedAt, &onboarding.UpdatedAt}
+	if err := row.Scan(append(dest, extra...)...); err !=nil {
+		return onboarding, err
+	}
+	onboarding.BusinessName = businessName.String
+	onboarding.BusinessType = businessType.String
+	onboarding.RegistrationNumber = registrationNumber.String
+	onboarding.BusinessAddress = businessAddress.String
+	onboarding.PhoneNumber = phoneNumber.String
+	onboarding.BankName = bankName.String
+	onboarding.BankCode = bankCode.String
+	onboarding.BankAccountName = bankAccountName.String
+	onboarding.BankAccountNumber = bankAccountNumber.String
+	if len(onboarding.BankAccountNumber) >= 4 {
+		onboarding.BankAccountLast4 = onboarding.BankAccountNumber[len(onboarding.BankAccountNumber)-4:]
+	}
+	onboarding.ReviewerID = reviewerID.String
+	onboarding.ReviewerComment = reviewerComment.String
+	if submittedAt.Valid {
+		onboarding.SubmittedAt = &submittedAt.Time
+	}
+	if reviewedAt.Valid {
+		onboarding.ReviewedAt = &reviewedAt.Time
+	}
+	return onboarding, nil
+}
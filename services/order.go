@@ -3,6 +3,9 @@ package services
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"math"
+	"time"
 
 	"github.com/kaasikodes/e-commerce-go/constants"
 	"github.com/kaasikodes/e-commerce-go/models"
@@ -20,39 +23,53 @@ func NewOrderRepository(db *sql.DB) *OrderRepository {
 	}
 }
 
-// create order
-func (c *OrderRepository) CreateOrder(data types.CreateOrderInput, customerId, addressId string) ( orderId string, error error) {
+// create order, the stock, the order, its items, its sub-orders and its payment are saved together or not at all
+func (c *OrderRepository) CreateOrder(data types.CreateOrderInput, customerId, addressId string) ( orderId string, orderNumber string, error error) {
 	db := c.db
 	orderId = ""
 	// prepare query
-	query := `INSERT INTO ` + "`Order`" + ` (ID, CustomerID, TotalAmount, DeliveryAddressID) VALUES (?, ?, ?, ?)`
+	query := `INSERT INTO ` + "`Order`" + ` (ID, Number, CustomerID, TotalAmount, DeliveryAddressID) VALUES (?, ?, ?, ?, ?)`
 
 	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
 	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
 	defer cancel()
-	// prepare the statement
-	stmt, err := db.PrepareContext(ctx, query)
+	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
-		return orderId, err
+		return orderId, orderNumber, err
 	}
-	defer stmt.Close() //close the statement after use
-	// execute the statement
-	orderId, _ = utils.GenerateRandomID(10)
-	res, err := stmt.ExecContext(ctx, orderId, customerId, data.TotalAmount, addressId)
-	if err != nil {
-		return orderId, err
+	defer tx.Rollback()
+	// the id is taken up front so the stock movements can point at the order
+	orderId = utils.NewID()
+	// take the stock first so two customers can't both buy the last unit, it goes back if the order is cancelled
+	if err = reserveStock(ctx, tx, orderId, customerId, data.OrderItems); err != nil {
+		return orderId, orderNumber, err
 	}
-	_, err = res.RowsAffected()
+	// the number is taken in the same transaction as the order is saved, so a failed order doesn't use one up
+	orderNumber, err = nextOrderNumber(ctx, tx)
 	if err != nil {
-		return orderId, err
+		return orderId, orderNumber, err
+	}
+	// execute the statement
+	if _, err = tx.ExecContext(ctx, query, orderId, orderNumber, customerId, data.TotalAmount, addressId); err != nil {
+		return orderId, orderNumber, err
 	}
-
 	// create order items
-	if err = c.createOrderItems(orderId, data.OrderItems); err != nil {
-		return orderId, err
+	if err = createOrderItems(ctx, tx, orderId, data.OrderItems); err != nil {
+		return orderId, orderNumber, err
+	}
+	// each seller fulfils their part of the order on their own
+	if err = createSubOrders(ctx, tx, orderId, data.OrderItems); err != nil {
+		return orderId, orderNumber, err
+	}
+	// orders are always looked up with their payment, and unpaid ones are cancelled through it to release their stock
+	if _, err = tx.ExecContext(ctx, `INSERT INTO Payment (ID, OrderID, Amount, Paid, Method) VALUES (?, ?, ?, ?, ?)`, data.Payment.Reference, orderId, data.Payment.Amount, data.Payment.Paid, data.Payment.Method); err != nil {
+		return orderId, orderNumber, err
+	}
+	if err = tx.Commit(); err != nil {
+		return orderId, orderNumber, err
 	}
 
-	return orderId,nil
+	return orderId, orderNumber, nil
 }
 // retrieve order
 func (c *OrderRepository) RetrieveOrder(id string) (models.Order, error) {
@@ -60,7 +77,7 @@ func (c *OrderRepository) RetrieveOrder(id string) (models.Order, error) {
 	db := c.db
 	order := models.Order{}
 	// prepare query
-	query := `SELECT * FROM ` + "`Order`" + ` o JOIN Payment p ON o.ID = p.OrderID WHERE o.ID = ?`
+	query := `SELECT ` + orderColumns + `, p.ID, p.OrderID, p.Amount, p.Paid, p.PaidAt, p.Method FROM ` + "`Order`" + ` o JOIN Payment p ON o.ID = p.OrderID WHERE o.ID = ? OR o.Number = ?`
 
 	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
 	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
@@ -72,9 +89,14 @@ func (c *OrderRepository) RetrieveOrder(id string) (models.Order, error) {
 	}
 	defer stmt.Close() //close the statement after use
 	// execute the statement
-	row := stmt.QueryRowContext(ctx, id)
-	order.Payment = models.Payment{}
-	err = row.Scan(&order.ID, &order.CustomerID, &order.TotalAmount, &order.DeliveryAddressID, &order.CreatedAt, &order.UpdatedAt, &order.Payment.ID, &order.Payment.OrderID, &order.Payment.Amount, &order.Payment.Paid, &order.Payment.PaidAt, &order.Payment.Method)
+	// customers and support can use the order number as well as the id
+	row := stmt.QueryRowContext(ctx, id, id)
+	payment := models.Payment{}
+	order, err = scanOrder(row, &payment.ID, &payment.OrderID, &payment.Amount, &payment.Paid, &payment.PaidAt, &payment.Method)
+	order.Payment = payment
+	if err == sql.ErrNoRows {
+		return order, constants.ErrOrderNotFound
+	}
 	if err != nil {
 		return order, err
 	}
@@ -85,6 +107,25 @@ func (c *OrderRepository) RetrieveOrder(id string) (models.Order, error) {
 		return order, err
 	}
 	order.Items = orderItems
+	order.SubOrders, err = c.retrieveSubOrders(order.ID)
+	if err != nil {
+		return order, err
+	}
+	// shipments are how the customer tracks each seller's part of the order
+	shipmentRepo := NewShipmentRepository(c.db)
+	for i := range order.SubOrders {
+		if order.SubOrders[i].Shipments, err = shipmentRepo.RetrieveSubOrderShipments(order.SubOrders[i].ID); err != nil {
+			return order, err
+		}
+	}
+	order.Refunds, err = NewRefundRepository(c.db).retrieveOrderRefunds(order.ID)
+	if err != nil {
+		return order, err
+	}
+	order.Returns, err = NewReturnRepository(c.db).retrieveOrderReturns(order.ID)
+	if err != nil {
+		return order, err
+	}
 	return order, nil
 	
 }
@@ -93,7 +134,7 @@ func (c *OrderRepository) RetrieveOrders(input types.RetrievOrdersInput, custome
 	db := c.db
 	// prepare query
 	query := `
-    SELECT o.*,
+    SELECT ` + orderColumns + `,
            p.ID AS payment_id,
            p.OrderID AS payment_order_id,
            p.Amount AS payment_amount,
@@ -128,9 +169,9 @@ func (c *OrderRepository) RetrieveOrders(input types.RetrievOrdersInput, custome
 	defer rows.Close()
 	total := 0
 	for rows.Next() {
-		order := models.Order{}
-		order.Payment = models.Payment{}
-		err = rows.Scan(&order.ID, &order.CustomerID, &order.TotalAmount, &order.DeliveryAddressID,&order.CreatedAt, &order.UpdatedAt, &order.Payment.ID, &order.Payment.OrderID, &order.Payment.Amount, &order.Payment.Paid, &order.Payment.PaidAt, &order.Payment.Method, &total)
+		payment := models.Payment{}
+		order, err := scanOrder(rows, &payment.ID, &payment.OrderID, &payment.Amount, &payment.Paid, &payment.PaidAt, &payment.Method, &total)
+		order.Payment = payment
 		if err !=nil {
 			return output, err
 		}
@@ -150,55 +191,539 @@ func (c *OrderRepository) RetrieveOrders(input types.RetrievOrdersInput, custome
 	return output, nil
 	
 }
-// delete order
-func (c *OrderRepository) DeleteOrder(id string) ( error){
+// cancel a customer's order, only the customer who placed it can cancel it
+func (c *OrderRepository) CancelOrder(id string, customerId string, input types.CancelOrderInput) (models.Order, error) {
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Order{}, err
+	}
+	defer tx.Rollback()
+	owner := ""
+	err = tx.QueryRowContext(ctx, "SELECT CustomerID FROM `Order` WHERE ID = ?", id).Scan(&owner)
+	if err == sql.ErrNoRows || (err == nil && owner != customerId) {
+		return models.Order{}, constants.ErrOrderNotFound
+	}
+	if err != nil {
+		return models.Order{}, err
+	}
+	if err = cancelOrder(ctx, tx, id, "", constants.CancelledByValues.Customer, input.Reason); err != nil {
+		return models.Order{}, err
+	}
+	if err = tx.Commit(); err != nil {
+		return models.Order{}, err
+	}
+
+	return c.RetrieveOrder(id)
+}
+
+// cancel the orders that were not paid for in time, so their stock can be bought by someone else
+func (c *OrderRepository) CancelUnpaidOrders(placedBefore time.Time) (int, error) {
 	db := c.db
-	// prepare query
-	query := "DELETE FROM `Order` WHERE ID = ?"
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	query := "SELECT o.ID FROM `Order` o JOIN Payment p ON p.OrderID = o.ID WHERE o.Status = ? AND p.Paid = false AND o.CreatedAt <= ?"
+	rows, err := db.QueryContext(ctx, query, constants.OrderStatuses.Placed, placedBefore)
+	if err != nil {
+		return 0, err
+	}
+	orderIds := []string{}
+	for rows.Next() {
+		orderId := ""
+		if err = rows.Scan(&orderId); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		orderIds = append(orderIds, orderId)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return 0, err
+	}
+	cancelled := 0
+	for _, orderId := range orderIds {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return cancelled, err
+		}
+		// the payment is locked so it can't be marked paid while the order is being cancelled
+		paid := false
+		err = tx.QueryRowContext(ctx, `SELECT Paid FROM Payment WHERE OrderID = ? FOR UPDATE`, orderId).Scan(&paid)
+		if err == nil && !paid {
+			err = cancelOrder(ctx, tx, orderId, "", constants.CancelledByValues.System, "Not paid for in time")
+			if err == nil {
+				err = tx.Commit()
+			}
+			if err == nil {
+				cancelled++
+			}
+		}
+		tx.Rollback()
+		// an order that was cancelled or shipped in the meantime is left as it is
+		if err != nil && err != constants.ErrOrderNotCancellable && err != constants.ErrOrderAlreadyCancelled {
+			return cancelled, err
+		}
+	}
+	return cancelled, nil
+}
+
+// retrieve the sub-orders of a seller
+func (c *OrderRepository) RetrieveSellerOrders(input types.RetrieveSellerOrdersInput, sellerId string) (types.PaginatedDataOutput, error) {
+	db := c.db
+	// prepare query, an empty status matches every status
+	query := `
+	SELECT ` + subOrderColumns + `,
+		(SELECT COUNT(*) FROM SubOrder s JOIN Payment p ON p.OrderID = s.OrderID WHERE s.SellerID = ? AND p.Paid = true AND (? = '' OR s.Status = ?)) AS total_orders
+	FROM SubOrder s
+	JOIN Payment p ON p.OrderID = s.OrderID
+	WHERE s.SellerID = ? AND p.Paid = true AND (? = '' OR s.Status = ?) AND s.ID > ?
+	ORDER BY s.ID ASC
+	LIMIT ?`
 
 	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
 	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
 	defer cancel()
 	// prepare the statement
+	output := types.PaginatedDataOutput{}
 	stmt, err := db.PrepareContext(ctx, query)
-	if err !=nil {
-		return  err
+	if err != nil {
+		return output, err
 	}
-	
 	defer stmt.Close() //close the statement after use
-	_, err = c.RetrieveOrder(id)
-	if err !=nil {
-		return  err
-	}
 	// execute the statement
-	_, err = stmt.ExecContext(ctx, id)
+	subOrders := []models.SubOrder{}
+	rows, err := stmt.QueryContext(ctx, sellerId, input.Status, input.Status, sellerId, input.Status, input.Status, input.Pagination.NextCursor, utils.Ternary(input.Pagination.PageSize == 0, constants.DefaultPageSize, input.Pagination.PageSize))
 	if err !=nil {
-		return  err
+		return output, err
 	}
-	
-	
-	return  nil
+	defer rows.Close()
+	total := 0
+	for rows.Next() {
+		subOrder, err := scanSubOrder(rows, &total)
+		if err !=nil {
+			return output, err
+		}
+		subOrders = append(subOrders, subOrder)
+	}
+	if err = rows.Err(); err !=nil {
+		return output, err
+	}
+	lastItemId := ""
+	// select last item in the list
+	if len(subOrders) > 0 {
+		lastItemId = subOrders[len(subOrders)-1].ID
+	}
+
+	output.Data = subOrders
+	output.NextCursor = lastItemId
+	output.HasMore = len(subOrders) < total
+	output.Total = total
+	return output, nil
 }
 
-// private
-// create order items
-func (c *OrderRepository) createOrderItems(orderId string, items []types.OrderItemInput) error {
+// retrieve a sub-order of a seller, with the seller's items and where they should be delivered
+func (c *OrderRepository) RetrieveSellerOrder(id string, sellerId string) (models.SubOrder, error) {
 	db := c.db
 	// prepare query
-	query := `INSERT INTO OrderItem (ID, OrderID, ProductID, Quantity, TotalPrice) VALUES (?, ?, ?, ?, ?)`
+	query := `
+	SELECT ` + subOrderColumns + `, a.ID, a.StreetAddress, a.LgaID, a.StateID, a.CountryID
+	FROM SubOrder s
+	JOIN Payment p ON p.OrderID = s.OrderID
+	JOIN ` + "`Order`" + ` o ON o.ID = s.OrderID
+	JOIN Address a ON a.ID = o.DeliveryAddressID
+	WHERE s.ID = ? AND s.SellerID = ? AND p.Paid = true`
 	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
 	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
 	defer cancel()
 	// prepare the statement
 	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return models.SubOrder{}, err
+	}
+	defer stmt.Close() //close the statement after use
+	// execute the statement
+	address := models.Address{}
+	subOrder, err := scanSubOrder(stmt.QueryRowContext(ctx, id, sellerId), &address.ID, &address.StreetAddress, &address.LgaID, &address.StateID, &address.CountryID)
+	if err == sql.ErrNoRows {
+		return subOrder, constants.ErrSubOrderNotFound
+	}
+	if err != nil {
+		return subOrder, err
+	}
+	subOrder.DeliveryAddress = &address
+	subOrder.Items, err = c.retrieveSellerOrderItems(ctx, subOrder.OrderID, sellerId)
+	if err != nil {
+		return subOrder, err
+	}
+	subOrder.Shipments, err = NewShipmentRepository(c.db).RetrieveSubOrderShipments(subOrder.ID)
+	return subOrder, err
+}
+
+// move a sub-order on to its next status
+func (c *OrderRepository) UpdateSubOrderStatus(id string, sellerId string, input types.UpdateSubOrderStatusInput) (models.SubOrder, error) {
+	db := c.db
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.SubOrder{}, err
+	}
+	defer tx.Rollback()
+	// lock the sub-order so two updates can't both move it from the same status
+	status := ""
+	query := `SELECT s.Status FROM SubOrder s JOIN Payment p ON p.OrderID = s.OrderID WHERE s.ID = ? AND s.SellerID = ? AND p.Paid = true FOR UPDATE`
+	err = tx.QueryRowContext(ctx, query, id, sellerId).Scan(&status)
+	if err == sql.ErrNoRows {
+		return models.SubOrder{}, constants.ErrSubOrderNotFound
+	}
+	if err != nil {
+		return models.SubOrder{}, err
+	}
+	if constants.SubOrderNextStatus[status] != input.Status {
+		return models.SubOrder{}, constants.ErrInvalidSubOrderStatus
+	}
+	if _, err = tx.ExecContext(ctx, `UPDATE SubOrder SET Status = ? WHERE ID = ?`, input.Status, id); err != nil {
+		return models.SubOrder{}, err
+	}
+	if err = tx.Commit(); err != nil {
+		return models.SubOrder{}, err
+	}
+
+	return c.RetrieveSellerOrder(id, sellerId)
+}
+
+// cancel the seller's part of a paid order, the order is cancelled once all of its sub-orders are
+func (c *OrderRepository) CancelSubOrder(id string, sellerId string, input types.CancelSubOrderInput) (models.SubOrder, error) {
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.SubOrder{}, err
+	}
+	defer tx.Rollback()
+	orderId := ""
+	query := `SELECT s.OrderID FROM SubOrder s JOIN Payment p ON p.OrderID = s.OrderID WHERE s.ID = ? AND s.SellerID = ? AND p.Paid = true`
+	err = tx.QueryRowContext(ctx, query, id, sellerId).Scan(&orderId)
+	if err == sql.ErrNoRows {
+		return models.SubOrder{}, constants.ErrSubOrderNotFound
+	}
+	if err != nil {
+		return models.SubOrder{}, err
+	}
+	if err = cancelOrder(ctx, tx, orderId, id, constants.CancelledByValues.Seller, input.Reason); err != nil {
+		return models.SubOrder{}, err
+	}
+	if err = tx.Commit(); err != nil {
+		return models.SubOrder{}, err
+	}
+
+	return c.RetrieveSellerOrder(id, sellerId)
+}
+
+// private
+// cancelOrder cancels the sub-orders of an order in tx, all of them when subOrderId is empty.
+// Their items go back into stock and, when the order was paid for, the sellers' sales are reversed and a refund is recorded.
+// Nothing can be cancelled once any of it has been shipped
+func cancelOrder(ctx context.Context, tx *sql.Tx, orderId string, subOrderId string, cancelledBy string, reason string) error {
+	var orderStatus, customerId, paymentId string
+	var paid bool
+	query := "SELECT o.Status, o.CustomerID, p.ID, p.Paid FROM `Order` o JOIN Payment p ON p.OrderID = o.ID WHERE o.ID = ? FOR UPDATE"
+	if err := tx.QueryRowContext(ctx, query, orderId).Scan(&orderStatus, &customerId, &paymentId, &paid); err != nil {
+		return err
+	}
+	if orderStatus == constants.OrderStatuses.Cancelled {
+		return constants.ErrOrderAlreadyCancelled
+	}
+	// lock the sub-orders so nothing is shipped while they are being cancelled
+	query = `SELECT s.ID, s.SellerID, s.Status, (SELECT COUNT(*) FROM Shipment sh WHERE sh.SubOrderID = s.ID) FROM SubOrder s WHERE s.OrderID = ? FOR UPDATE`
+	rows, err := tx.QueryContext(ctx, query, orderId)
+	if err != nil {
+		return err
+	}
+	// sellers whose items are being cancelled, and those whose items stay in the order
+	cancelling, remaining := map[string]string{}, 0
+	alreadyCancelled := map[string]bool{}
+	var cancelErr error
+	for rows.Next() {
+		var id, sellerId, status string
+		var shipments int
+		if err = rows.Scan(&id, &sellerId, &status, &shipments); err != nil {
+			rows.Close()
+			return err
+		}
+		switch {
+		case status == constants.SubOrderStatuses.Cancelled:
+			alreadyCancelled[sellerId] = true
+			if id == subOrderId {
+				cancelErr = constants.ErrOrderAlreadyCancelled
+			}
+		case subOrderId != "" && id != subOrderId:
+			remaining++
+		case shipments > 0 || (status != constants.SubOrderStatuses.Pending && status != constants.SubOrderStatuses.Processing):
+			cancelErr = constants.ErrOrderNotCancellable
+		default:
+			cancelling[sellerId] = id
+		}
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	if cancelErr != nil {
+		return cancelErr
+	}
+
+	// the items of the sellers being cancelled, orders placed before sub-orders existed have all their items cancelled at once
+	query = `SELECT oi.ID, oi.ProductID, oi.Quantity, oi.TotalPrice, p.OwnerID FROM OrderItem oi JOIN Product p ON p.ID = oi.ProductID WHERE oi.OrderID = ?`
+	rows, err = tx.QueryContext(ctx, query, orderId)
+	if err != nil {
+		return err
+	}
+	items := []types.OrderItemInput{}
+	orderItemIds := []string{}
+	var amount float64
+	sellerAmounts := map[string]float64{}
+	for rows.Next() {
+		var orderItemId string
+		item := types.OrderItemInput{}
+		if err = rows.Scan(&orderItemId, &item.ProductId, &item.Quantity, &item.TotalPrice, &item.SellerId); err != nil {
+			rows.Close()
+			return err
+		}
+		if _, ok := cancelling[item.SellerId]; !ok && (subOrderId != "" || alreadyCancelled[item.SellerId]) {
+			continue
+		}
+		items = append(items, item)
+		orderItemIds = append(orderItemIds, orderItemId)
+		amount += item.TotalPrice
+		sellerAmounts[item.SellerId] += item.TotalPrice
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		if _, err = tx.ExecContext(ctx, `UPDATE Product SET Quantity = Quantity + ? WHERE ID = ?`, item.Quantity, item.ProductId); err != nil {
+			return err
+		}
+		// the stock movement is put down to whoever cancelled
+		actorId := ""
+		switch cancelledBy {
+		case constants.CancelledByValues.Customer:
+			actorId = customerId
+		case constants.CancelledByValues.Seller:
+			actorId = item.SellerId
+		}
+		if err = recordStockMovement(ctx, tx, item.ProductId, item.Quantity, constants.StockMovementReasons.Cancellation, cancelledBy, actorId, orderId); err != nil {
+			return err
+		}
+	}
+	for _, id := range cancelling {
+		query = `UPDATE SubOrder SET Status = ?, CancelledAt = NOW(), CancelledBy = ?, CancellationReason = NULLIF(?, '') WHERE ID = ?`
+		if _, err = tx.ExecContext(ctx, query, constants.SubOrderStatuses.Cancelled, cancelledBy, reason, id); err != nil {
+			return err
+		}
+	}
+	if paid {
+		for _, orderItemId := range orderItemIds {
+			if err = reverseSale(ctx, tx, orderItemId); err != nil {
+				return err
+			}
+		}
+		refundId, err := recordRefund(ctx, tx, paymentId, orderId, subOrderId, "", int64(math.Round(amount * 100)))
+		if err != nil {
+			return err
+		}
+		// the invoices of the cancelled sub-orders are credited back in full
+		creditReason := "Order cancelled"
+		if reason != "" {
+			creditReason = "Order cancelled: " + reason
+		}
+		for sellerId, id := range cancelling {
+			if err = issueCreditNote(ctx, tx, id, refundId, "", creditReason, int64(math.Round(sellerAmounts[sellerId] * 100))); err != nil {
+				return err
+			}
+		}
+	}
+	if remaining == 0 {
+		if _, err = tx.ExecContext(ctx, "UPDATE `Order` SET Status = ?, CancelledAt = NOW() WHERE ID = ?", constants.OrderStatuses.Cancelled, orderId); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nextOrderNumber takes the next order number of the year in tx, e.g. ORD-2026-000123.
+// The sequence row stays locked until tx ends, so orders placed at the same time can't get the same number
+func nextOrderNumber(ctx context.Context, tx *sql.Tx) (string, error) {
+	year := time.Now().Year()
+	if _, err := tx.ExecContext(ctx, `INSERT INTO OrderNumberSequence (Year, LastNumber) VALUES (?, 1) ON DUPLICATE KEY UPDATE LastNumber = LastNumber + 1`, year); err != nil {
+		return "", err
+	}
+	var number int64
+	if err := tx.QueryRowContext(ctx, `SELECT LastNumber FROM OrderNumberSequence WHERE Year = ?`, year).Scan(&number); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s-%d-%06d", constants.OrderNumberPrefix, year, number), nil
+}
+
+// reserveStock takes the items out of stock, all of them or none when one of them doesn't have enough left
+func reserveStock(ctx context.Context, tx *sql.Tx, orderId string, customerId string, items []types.OrderItemInput) error {
+	for _, item := range items {
+		res, err := tx.ExecContext(ctx, `UPDATE Product p SET p.Quantity = p.Quantity - ? WHERE p.ID = ? AND p.Quantity >= ? AND `+productForSaleCondition, item.Quantity, item.ProductId, item.Quantity)
+		if err != nil {
+			return err
+		}
+		count, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			// tell a product that was taken off sale apart from one that sold out
+			forSale := false
+			if err = tx.QueryRowContext(ctx, `SELECT COUNT(*) > 0 FROM Product p WHERE p.ID = ? AND `+productForSaleCondition, item.ProductId).Scan(&forSale); err != nil {
+				return err
+			}
+			if !forSale {
+				return constants.ErrProductNotForSale
+			}
+			return constants.ErrInsufficientStock
+		}
+		if err = recordStockMovement(ctx, tx, item.ProductId, -item.Quantity, constants.StockMovementReasons.Sale, constants.StockMovementActors.Customer, customerId, orderId); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// create sub-orders, one per seller in the order
+func createSubOrders(ctx context.Context, tx *sql.Tx, orderId string, items []types.OrderItemInput) error {
+	// work out what each seller is owed for their part of the order, keeping the order sellers first appear in
+	sellerIds := []string{}
+	subtotals := map[string]float64{}
+	for _, item := range items {
+		if _, ok := subtotals[item.SellerId]; !ok {
+			sellerIds = append(sellerIds, item.SellerId)
+		}
+		subtotals[item.SellerId] += item.TotalPrice
+	}
+	// prepare query
+	query := `INSERT INTO SubOrder (ID, OrderID, SellerID, Status, Subtotal) VALUES (?, ?, ?, ?, ?)`
+	// prepare the statement
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close() //close the statement after use
+	// execute the statement
+	for _, sellerId := range sellerIds {
+		id := utils.NewID()
+		if _, err = stmt.ExecContext(ctx, id, orderId, sellerId, constants.SubOrderStatuses.Pending, subtotals[sellerId]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// retrieve the sub-orders of an order, without their items as those are on the order
+func (c *OrderRepository) retrieveSubOrders(orderId string) ([]models.SubOrder, error) {
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	rows, err := c.db.QueryContext(ctx, `SELECT `+subOrderColumns+` FROM SubOrder s WHERE s.OrderID = ? ORDER BY s.CreatedAt ASC`, orderId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	subOrders := []models.SubOrder{}
+	for rows.Next() {
+		subOrder, err := scanSubOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		subOrders = append(subOrders, subOrder)
+	}
+	return subOrders, rows.Err()
+}
+
+// retrieve the items of an order that are the seller's products
+func (c *OrderRepository) retrieveSellerOrderItems(ctx context.Context, orderId string, sellerId string) ([]models.OrderItem, error) {
+	query := `
+	SELECT ` + prefixColumns("p", productColumns) + `, oi.ID, oi.ProductID, oi.OrderID, oi.Quantity, oi.UnitPrice, oi.TotalPrice, oi.CreatedAt, oi.UpdatedAt
+	FROM OrderItem oi
+	JOIN Product p ON p.ID = oi.ProductID
+	WHERE oi.OrderID = ? AND p.OwnerID = ?`
+	rows, err := c.db.QueryContext(ctx, query, orderId, sellerId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	orderItems := []models.OrderItem{}
+	for rows.Next() {
+		item := models.OrderItem{}
+		item.Product, err = scanProduct(rows, &item.ID, &item.ProductID, &item.OrderID, &item.Quantity, &item.UnitPrice, &item.TotalPrice, &item.CreatedAt, &item.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		orderItems = append(orderItems, item)
+	}
+	return orderItems, rows.Err()
+}
+
+const subOrderColumns = `s.ID, s.OrderID, COALESCE((SELECT so.Number FROM ` + "`Order`" + ` so WHERE so.ID = s.OrderID), ''), s.SellerID, s.Status, s.Subtotal, s.CancelledAt, COALESCE(s.CancelledBy, ''), COALESCE(s.CancellationReason, ''), s.CreatedAt, s.UpdatedAt`
+
+type subOrderScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSubOrder(row subOrderScanner, extra ...interface{}) (models.SubOrder, error) {
+	subOrder := models.SubOrder{}
+	var cancelledAt sql.NullTime
+	dest := []interface{}{&subOrder.ID, &subOrder.OrderID, &subOrder.OrderNumber, &subOrder.SellerID, &subOrder.Status, &subOrder.Subtotal, &cancelledAt, &subOrder.CancelledBy, &subOrder.CancellationReason, &subOrder.CreatedAt, &subOrder.UpdatedAt}
+	err := row.Scan(append(dest, extra...)...)
+	if cancelledAt.Valid {
+		subOrder.CancelledAt = &cancelledAt.Time
+	}
+	return subOrder, err
+}
+
+const orderColumns = `o.ID, COALESCE(o.Number, ''), o.CustomerID, o.TotalAmount, o.DeliveryAddressID, o.Status, o.CancelledAt, o.CreatedAt, o.UpdatedAt`
+
+type orderScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanOrder(row orderScanner, extra ...interface{}) (models.Order, error) {
+	order := models.Order{}
+	var cancelledAt sql.NullTime
+	dest := []interface{}{&order.ID, &order.Number, &order.CustomerID, &order.TotalAmount, &order.DeliveryAddressID, &order.Status, &cancelledAt, &order.CreatedAt, &order.UpdatedAt}
+	err := row.Scan(append(dest, extra...)...)
+	if cancelledAt.Valid {
+		order.CancelledAt = &cancelledAt.Time
+	}
+	return order, err
+}
+
+// create order items
+func createOrderItems(ctx context.Context, tx *sql.Tx, orderId string, items []types.OrderItemInput) error {
+	// prepare query
+	query := `INSERT INTO OrderItem (ID, OrderID, ProductID, Quantity, UnitPrice, TotalPrice) VALUES (?, ?, ?, ?, ?, ?)`
+	// prepare the statement
+	stmt, err := tx.PrepareContext(ctx, query)
 	if err != nil {
 		return err
 	}
 	defer stmt.Close() //close the statement after use
 	// execute the statement
 	for _, item := range items {
-		id, _ := utils.GenerateRandomID(10)
-		_, err := stmt.ExecContext(ctx, id, orderId, item.ProductId, item.Quantity, item.TotalPrice)
+		id := utils.NewID()
+		_, err := stmt.ExecContext(ctx, id, orderId, item.ProductId, item.Quantity, item.UnitPrice, item.TotalPrice)
 		if err != nil {
 			return err
 		} 
@@ -210,7 +735,12 @@ func (c *OrderRepository) createOrderItems(orderId string, items []types.OrderIt
 func (c *OrderRepository) retrieveOrderItems(orderId string) ([]models.OrderItem, error) {
 	db := c.db
 	// prepare query
-	query := `SELECT * FROM OrderItem WHERE OrderID = ?`
+	// the products are joined whatever their status, so archived and deleted products still show in the orders they are part of
+	query := `SELECT ` + prefixColumns("p", productColumns) + `, oi.ID, oi.ProductID, oi.OrderID, oi.Quantity, oi.UnitPrice, oi.TotalPrice, oi.CreatedAt, oi.UpdatedAt
+	FROM OrderItem oi
+	JOIN Product p ON p.ID = oi.ProductID
+	WHERE oi.OrderID = ?
+	ORDER BY oi.ID`
 	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
 	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
 	defer cancel()
@@ -229,7 +759,7 @@ func (c *OrderRepository) retrieveOrderItems(orderId string) ([]models.OrderItem
 	orderItems := []models.OrderItem{}
 	for rows.Next() {
 		orderItem := models.OrderItem{}
-		err = rows.Scan(&orderItem.ID, &orderItem.ProductID, &orderItem.OrderID, &orderItem.Quantity, &orderItem.TotalPrice, &orderItem.CreatedAt, &orderItem.UpdatedAt)
+		orderItem.Product, err = scanProduct(rows, &orderItem.ID, &orderItem.ProductID, &orderItem.OrderID, &orderItem.Quantity, &orderItem.UnitPrice, &orderItem.TotalPrice, &orderItem.CreatedAt, &orderItem.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
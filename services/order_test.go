@@ -0,0 +1,130 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/types"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+// two products of one seller and one of another, so two sub-orders
+var splitOrderInput = types.CreateOrderInput{
+	TotalAmount: 1700,
+	OrderItems: []types.OrderItemInput{
+		{ProductId: "product-1", SellerId: "seller-1", UnitPrice: 500, TotalPrice: 1000, Quantity: 2},
+		{ProductId: "product-2", SellerId: "seller-2", UnitPrice: 200, TotalPrice: 200, Quantity: 1},
+		{ProductId: "product-3", SellerId: "seller-1", UnitPrice: 500, TotalPrice: 500, Quantity: 1},
+	},
+	Payment: types.CreatePaymentInput{Reference: "payment-1", Amount: 1700},
+}
+
+func expectOrderCreated(mock sqlmock.Sqlmock) {
+	mock.ExpectBegin()
+	for _, item := range splitOrderInput.OrderItems {
+		mock.ExpectExec("UPDATE Product p SET p.Quantity = p.Quantity - \\?").WithArgs(item.Quantity, item.ProductId, item.Quantity).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO StockMovement").WithArgs(sqlmock.AnyArg(), -item.Quantity, constants.StockMovementReasons.Sale, constants.StockMovementActors.Customer, "customer-1", sqlmock.AnyArg(), item.ProductId).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectExec("INSERT INTO OrderNumberSequence").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT LastNumber FROM OrderNumberSequence").WillReturnRows(sqlmock.NewRows([]string{"LastNumber"}).AddRow(7))
+	mock.ExpectExec("INSERT INTO `Order`").WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "customer-1", 1700.0, "address-1").WillReturnResult(sqlmock.NewResult(0, 1))
+	items := mock.ExpectPrepare("INSERT INTO OrderItem")
+	for _, item := range splitOrderInput.OrderItems {
+		items.ExpectExec().WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), item.ProductId, item.Quantity, item.UnitPrice, item.TotalPrice).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+}
+
+func TestCreateOrder_SplitsBySeller(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	expectOrderCreated(mock)
+	// sellers keep the order they first appear in, each with the total of their own items
+	subOrders := mock.ExpectPrepare("INSERT INTO SubOrder")
+	subOrders.ExpectExec().WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "seller-1", constants.SubOrderStatuses.Pending, 1500.0).WillReturnResult(sqlmock.NewResult(0, 1))
+	subOrders.ExpectExec().WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "seller-2", constants.SubOrderStatuses.Pending, 200.0).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO Payment").WithArgs("payment-1", sqlmock.AnyArg(), 1700.0, false, "").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	_, orderNumber, err := NewOrderRepository(db).CreateOrder(splitOrderInput, "customer-1", "address-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orderNumber) == 0 {
+		t.Error("the order has no number")
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCreateOrder_RollsBackWhenASubOrderFails(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	expectOrderCreated(mock)
+	failure := errors.New("connection lost")
+	subOrders := mock.ExpectPrepare("INSERT INTO SubOrder")
+	subOrders.ExpectExec().WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "seller-1", constants.SubOrderStatuses.Pending, 1500.0).WillReturnError(failure)
+	// the stock, order and items are rolled back with it
+	mock.ExpectRollback()
+
+	if _, _, err = NewOrderRepository(db).CreateOrder(splitOrderInput, "customer-1", "address-1"); err != failure {
+		t.Errorf("error = %v, want %v", err, failure)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCreateOrder_RollsBackWhenThePaymentFails(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	expectOrderCreated(mock)
+	subOrders := mock.ExpectPrepare("INSERT INTO SubOrder")
+	subOrders.ExpectExec().WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "seller-1", constants.SubOrderStatuses.Pending, 1500.0).WillReturnResult(sqlmock.NewResult(0, 1))
+	subOrders.ExpectExec().WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "seller-2", constants.SubOrderStatuses.Pending, 200.0).WillReturnResult(sqlmock.NewResult(0, 1))
+	failure := errors.New("connection lost")
+	mock.ExpectExec("INSERT INTO Payment").WithArgs("payment-1", sqlmock.AnyArg(), 1700.0, false, "").WillReturnError(failure)
+	// no order is left behind without a payment, holding on to its stock
+	mock.ExpectRollback()
+
+	if _, _, err = NewOrderRepository(db).CreateOrder(splitOrderInput, "customer-1", "address-1"); err != failure {
+		t.Errorf("error = %v, want %v", err, failure)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCreateOrder_InsufficientStock(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE Product p SET p.Quantity = p.Quantity - \\?").WithArgs(2, "product-1", 2).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) > 0 FROM Product p").WithArgs("product-1").WillReturnRows(sqlmock.NewRows([]string{"forSale"}).AddRow(true))
+	mock.ExpectRollback()
+
+	if _, _, err = NewOrderRepository(db).CreateOrder(splitOrderInput, "customer-1", "address-1"); err != constants.ErrInsufficientStock {
+		t.Errorf("error = %v, want %v", err, constants.ErrInsufficientStock)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/kaasikodes/e-commerce-go/constants"
 	"github.com/kaasikodes/e-commerce-go/models"
@@ -46,6 +47,35 @@ func (c *PaymentRepository) UpdatePayment(data types.UpdatePaymentInput, referen
 	return nil
 
 }
+// mark payment paid, reports whether this call is the one that marked it so the customer is only told once
+func (c *PaymentRepository) MarkPaymentPaid(reference string, paidAt time.Time) (bool, error) {
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	res, err := c.db.ExecContext(ctx, "UPDATE Payment SET Paid = true, PaidAt = ? WHERE ID = ? AND Paid = false", paidAt, reference)
+	if err != nil {
+		return false, err
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return count == 1, nil
+}
+// retrieve the email of the customer whose order the payment is for
+func (c *PaymentRepository) RetrievePaymentCustomerEmail(reference string) (string, error) {
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	email := ""
+	query := `SELECT u.Email FROM Payment p
+		JOIN ` + "`Order`" + ` o ON o.ID = p.OrderID
+		JOIN Customer c ON c.ID = o.CustomerID
+		JOIN User u ON u.ID = c.UserID
+		WHERE p.ID = ?`
+	err := c.db.QueryRowContext(ctx, query, reference).Scan(&email)
+	return email, err
+}
 // create payment
 func (c *PaymentRepository) CreatePayment(data types.CreatePaymentInput, orderId string) ( error) {
 	db := c.db
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestMarkPaymentPaid_OnlyOnce(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	paidAt := time.Now()
+	mock.ExpectExec("UPDATE Payment SET Paid = true, PaidAt = \\? WHERE ID = \\? AND Paid = false").WithArgs(paidAt, "payment-1").WillReturnResult(sqlmock.NewResult(0, 1))
+	// the same payment verified again, or at the same time, finds it already paid
+	mock.ExpectExec("UPDATE Payment SET Paid = true, PaidAt = \\? WHERE ID = \\? AND Paid = false").WithArgs(paidAt, "payment-1").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	repo := NewPaymentRepository(db)
+	marked, err := repo.MarkPaymentPaid("payment-1", paidAt)
+	if err != nil || !marked {
+		t.Errorf("first mark = %v, %v, want true, nil", marked, err)
+	}
+	marked, err = repo.MarkPaymentPaid("payment-1", paidAt)
+	if err != nil || marked {
+		t.Errorf("second mark = %v, %v, want false, nil", marked, err)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRetrievePaymentCustomerEmail(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT u.Email FROM Payment p(.+)JOIN Customer c ON c.ID = o.CustomerID").WithArgs("payment-1").
+		WillReturnRows(sqlmock.NewRows([]string{"Email"}).AddRow("ada@example.com"))
+
+	email, err := NewPaymentRepository(db).RetrievePaymentCustomerEmail("payment-1")
+	if err != nil || email != "ada@example.com" {
+		t.Errorf("email = %v, %v, want ada@example.com, nil", email, err)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
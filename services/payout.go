@@ -0,0 +1,276 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/models"
+	"github.com/kaasikodes/e-commerce-go/types"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+type PayoutRepository struct {
+	db *sql.DB
+}
+
+func NewPayoutRepository(db *sql.DB) *PayoutRepository {
+	return &PayoutRepository{
+		db: db,
+	}
+}
+
+const payoutColumns = `ID, SellerID, Amount, Status, BatchID, BankName, BankCode, BankAccountName, BankAccountNumber, GatewayReference, FailureReason, CompletedAt, CreatedAt, UpdatedAt`
+
+func (r *PayoutRepository) RequestPayout(sellerID string, input types.RequestPayoutInput) (models.Payout, error){
+	db := r.db
+	if input.Amount < constants.MinPayoutAmount {
+		return models.Payout{}, constants.ErrPayoutBelowMinimum
+	}
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	tx, err := db.BeginTx(ctx, nil)
+	if err !=nil {
+		return models.Payout{}, err
+	}
+	defer tx.Rollback()
+	// locking the onboarding stops two requests from both spending the same balance, it also has the bank details the payout is sent to
+	var bankName, bankCode, bankAccountName, bankAccountNumber sql.NullString
+	query := `SELECT BankName, BankCode, BankAccountName, BankAccountNumber FROM SellerOnboarding WHERE SellerID = ? AND Status = ? FOR UPDATE`
+	err = tx.QueryRowContext(ctx, query, sellerID, constants.SellerOnboardingStatuses.Approved).Scan(&bankName, &bankCode, &bankAccountName, &bankAccountNumber)
+	if err == sql.ErrNoRows {
+		return models.Payout{}, constants.ErrSellerNotApproved
+	}
+	if err !=nil {
+		return models.Payout{}, err
+	}
+	var available int64
+	err = tx.QueryRowContext(ctx, `SELECT COALESCE(-SUM(Amount), 0) FROM LedgerEntry WHERE SellerID = ? AND Account = ?`, sellerID, constants.LedgerAccounts.SellerAvailable).Scan(&available)
+	if err !=nil {
+		return models.Payout{}, err
+	}
+	if input.Amount > available {
+		return models.Payout{}, constants.ErrInsufficientBalance
+	}
+	id := utils.NewID()
+	_, err = tx.ExecContext(ctx, `INSERT INTO Payout (ID, SellerID, Amount, Status, BankName, BankCode, BankAccountName, BankAccountNumber) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		id, sellerID, input.Amount, constants.PayoutStatuses.Requested, bankName.String, bankCode.String, bankAccountName.String, bankAccountNumber.String)
+	if err !=nil {
+		return models.Payout{}, err
+	}
+	_, err = postLedgerTransaction(ctx, tx, constants.LedgerTransactionTypes.Payout, id, "Payout requested", []ledgerEntry{
+		{constants.LedgerAccounts.SellerAvailable, sellerID, input.Amount},
+		{constants.LedgerAccounts.PayoutsInTransit, sellerID, -input.Amount},
+	})
+	if err !=nil {
+		return models.Payout{}, err
+	}
+	if err = tx.Commit(); err !=nil {
+		return models.Payout{}, err
+	}
+
+	return r.retrievePayout(id)
+}
+
+func (r *PayoutRepository) RetrieveSellerPayouts(sellerID string, input types.RetrievePayoutsInput) (types.PaginatedDataOutput, error){
+	return r.retrievePayouts(sellerID, input)
+}
+
+func (r *PayoutRepository) RetrievePayouts(input types.RetrievePayoutsInput) (types.PaginatedDataOutput, error){
+	return r.retrievePayouts("", input)
+}
+
+func (r *PayoutRepository) CreatePayoutBatch(createdBy string, input types.CreatePayoutBatchInput) (models.PayoutBatch, error){
+	db := r.db
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	tx, err := db.BeginTx(ctx, nil)
+	if err !=nil {
+		return models.PayoutBatch{}, err
+	}
+	defer tx.Rollback()
+	id := utils.NewID()
+	if _, err = tx.ExecContext(ctx, `INSERT INTO PayoutBatch (ID, Method, CreatedBy) VALUES (?, ?, ?)`, id, input.Method, createdBy); err !=nil {
+		return models.PayoutBatch{}, err
+	}
+	res, err := tx.ExecContext(ctx, `UPDATE Payout SET Status = ?, BatchID = ? WHERE Status = ?`, constants.PayoutStatuses.Processing, id, constants.PayoutStatuses.Requested)
+	if err !=nil {
+		return models.PayoutBatch{}, err
+	}
+	count, err := res.RowsAffected()
+	if err !=nil {
+		return models.PayoutBatch{}, err
+	}
+	if count == 0 {
+		return models.PayoutBatch{}, constants.ErrNoPayoutsToBatch
+	}
+	if err = tx.Commit(); err !=nil {
+		return models.PayoutBatch{}, err
+	}
+
+	return r.RetrievePayoutBatch(id)
+}
+
+func (r *PayoutRepository) RetrievePayoutBatch(id string) (models.PayoutBatch, error){
+	db := r.db
+	batch := models.PayoutBatch{Payouts: []models.Payout{}}
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	err := db.QueryRowContext(ctx, `SELECT ID, Method, CreatedBy, CreatedAt FROM PayoutBatch WHERE ID = ?`, id).Scan(&batch.ID, &batch.Method, &batch.CreatedBy, &batch.CreatedAt)
+	if err == sql.ErrNoRows {
+		return batch, constants.ErrPayoutBatchNotFound
+	}
+	if err !=nil {
+		return batch, err
+	}
+	rows, err := db.QueryContext(ctx, `SELECT `+payoutColumns+` FROM Payout WHERE BatchID = ? ORDER BY ID ASC`, id)
+	if err !=nil {
+		return batch, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		payout, err := scanPayout(rows)
+		if err !=nil {
+			return batch, err
+		}
+		batch.Payouts = append(batch.Payouts, payout)
+	}
+	return batch, rows.Err()
+}
+
+func (r *PayoutRepository) CompletePayout(id string, input types.CompletePayoutInput) (models.Payout, error){
+	db := r.db
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	tx, err := db.BeginTx(ctx, nil)
+	if err !=nil {
+		return models.Payout{}, err
+	}
+	defer tx.Rollback()
+	var sellerID, status string
+	var amount int64
+	err = tx.QueryRowContext(ctx, `SELECT SellerID, Amount, Status FROM Payout WHERE ID = ? FOR UPDATE`, id).Scan(&sellerID, &amount, &status)
+	if err == sql.ErrNoRows {
+		return models.Payout{}, constants.ErrPayoutNotFound
+	}
+	if err !=nil {
+		return models.Payout{}, err
+	}
+	if status != constants.PayoutStatuses.Processing {
+		return models.Payout{}, constants.ErrPayoutNotProcessing
+	}
+	// a paid payout leaves through the gateway, a failed one goes back to the seller's available balance
+	transactionType, description, to := constants.LedgerTransactionTypes.PayoutPaid, "Payout paid", constants.LedgerAccounts.GatewayClearing
+	if input.Status == constants.PayoutStatuses.Failed {
+		transactionType, description, to = constants.LedgerTransactionTypes.PayoutFailed, "Payout failed, returned to balance", constants.LedgerAccounts.SellerAvailable
+	}
+	query := `UPDATE Payout SET Status = ?, GatewayReference = NULLIF(?, ''), FailureReason = NULLIF(?, ''), CompletedAt = ? WHERE ID = ?`
+	if _, err = tx.ExecContext(ctx, query, input.Status, input.GatewayReference, input.FailureReason, time.Now(), id); err !=nil {
+		return models.Payout{}, err
+	}
+	_, err = postLedgerTransaction(ctx, tx, transactionType, id, description, []ledgerEntry{
+		{constants.LedgerAccounts.PayoutsInTransit, sellerID, amount},
+		{to, sellerID, -amount},
+	})
+	if err !=nil {
+		return models.Payout{}, err
+	}
+	if err = tx.Commit(); err !=nil {
+		return models.Payout{}, err
+	}
+
+	return r.retrievePayout(id)
+}
+
+func (r *PayoutRepository) retrievePayout(id string) (models.Payout, error){
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	payout, err := scanPayout(r.db.QueryRowContext(ctx, `SELECT `+payoutColumns+` FROM Payout WHERE ID = ?`, id))
+	if err == sql.ErrNoRows {
+		return payout, constants.ErrPayoutNotFound
+	}
+	return payout, err
+}
+
+// an empty seller or status matches all of them
+func (r *PayoutRepository) retrievePayouts(sellerID string, input types.RetrievePayoutsInput) (types.PaginatedDataOutput, error){
+	db := r.db
+	// prepare query
+	query := `SELECT ` + payoutColumns + `,
+		(SELECT COUNT(*) FROM Payout WHERE (? = '' OR SellerID = ?) AND (? = '' OR Status = ?)) AS total
+		FROM Payout
+		WHERE (? = '' OR SellerID = ?) AND (? = '' OR Status = ?) AND ID > ?
+		ORDER BY ID ASC
+		LIMIT ?`
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	// prepare the statement
+	output := types.PaginatedDataOutput{}
+	stmt, err := db.PrepareContext(ctx, query)
+	if err !=nil {
+		return output, err
+	}
+	defer stmt.Close() //close the statement after use
+	// execute the statement
+	rows, err := stmt.QueryContext(ctx, sellerID, sellerID, input.Status, input.Status, sellerID, sellerID, input.Status, input.Status, input.Pagination.NextCursor, utils.Ternary(input.Pagination.PageSize == 0, constants.DefaultPageSize, input.Pagination.PageSize))
+	if err !=nil {
+		return output, err
+	}
+	defer rows.Close()
+	payouts := []models.Payout{}
+	total := 0
+	for rows.Next() {
+		payout, err := scanPayout(rows, &total)
+		if err !=nil {
+			return output, err
+		}
+		payouts = append(payouts, payout)
+	}
+	if err = rows.Err(); err !=nil {
+		return output, err
+	}
+	lastItemId := ""
+	// select last item in the list
+	if len(payouts) > 0 {
+		lastItemId = payouts[len(payouts)-1].ID
+	}
+
+	output = types.PaginatedDataOutput{
+		Data: payouts,
+		NextCursor: lastItemId,
+		HasMore:    len(payouts) < total,
+		Total:      total,
+	}
+	return output, nil
+}
+
+type payoutScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanPayout(row payoutScanner, extra ...interface{}) (models.Payout, error){
+	payout := models.Payout{}
+	var batchID, gatewayReference, failureReason sql.NullString
+	var completedAt sql.NullTime
+	dest := []interface{}{&payout.ID, &payout.SellerID, &payout.Amount, &payout.Status, &batchID, &payout.BankName, &payout.BankCode, &payout.BankAccountName, &payout.BankAccountNumber, &gatewayReference, &failureReason, &completedAt, &payout.CreatedAt, &payout.UpdatedAt}
+	if err := row.Scan(append(dest, extra...)...); err !=nil {
+		return payout, err
+	}
+	payout.BatchID = batchID.String
+	payout.GatewayReference = gatewayReference.String
+	payout.FailureReason = failureReason.String
+	if completedAt.Valid {
+		payout.CompletedAt = &completedAt.Time
+	}
+	if len(payout.BankAccountNumber) >= 4 {
+		payout.BankAccountLast4 = payout.BankAccountNumber[len(payout.BankAccountNumber)-4:]
+	}
+	return payout, nil
+}
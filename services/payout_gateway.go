@@ -0,0 +1,48 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/kaasikodes/e-commerce-go/models"
+)
+
+// PaystackPayoutGateway sends payouts with paystack transfers, paid from the paystack balance
+type PaystackPayoutGateway struct {
+	paystackClient
+}
+
+func NewPaystackPayoutGateway(secretKey string, httpClient *http.Client) *PaystackPayoutGateway {
+	return &PaystackPayoutGateway{
+		paystackClient: newPaystackClient(secretKey, httpClient),
+	}
+}
+
+// Transfer creates a transfer recipient for the payout's bank account and sends the amount to it.
+// The payout id is the transfer reference, so paystack won't send the same payout twice
+func (g *PaystackPayoutGateway) Transfer(payout models.Payout) (string, error) {
+	recipient, err := g.post("/transferrecipient", map[string]interface{}{
+		"type":           "nuban",
+		"name":           payout.BankAccountName,
+		"account_number": payout.BankAccountNumber,
+		"bank_code":      payout.BankCode,
+		"currency":       "NGN",
+	})
+	if err != nil {
+		return "", err
+	}
+	transfer, err := g.post("/transfer", map[string]interface{}{
+		"source":    "balance",
+		"amount":    payout.Amount,
+		"recipient": recipient.Data.RecipientCode,
+		"reference": payout.ID,
+		"reason":    "Marketplace payout",
+	})
+	if err != nil {
+		return "", err
+	}
+	if transfer.Data.Status == "failed" || transfer.Data.Status == "reversed" {
+		return transfer.Data.Reference, fmt.Errorf("paystack transfer %s", transfer.Data.Status)
+	}
+	return transfer.Data.Reference, nil
+}
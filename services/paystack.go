@@ -0,0 +1,66 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+)
+
+// paystackClient makes authenticated calls to the paystack api
+type paystackClient struct {
+	secretKey  string
+	httpClient *http.Client
+}
+
+func newPaystackClient(secretKey string, httpClient *http.Client) paystackClient {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: constants.DefaultContextTimeOut}
+	}
+	return paystackClient{
+		secretKey:  secretKey,
+		httpClient: httpClient,
+	}
+}
+
+type paystackResponse struct {
+	Status  bool   `json:"status"`
+	Message string `json:"message"`
+	Data    struct {
+		ID            int64  `json:"id"`
+		RecipientCode string `json:"recipient_code"`
+		Reference     string `json:"reference"`
+		Status        string `json:"status"`
+	} `json:"data"`
+}
+
+func (c paystackClient) post(path string, payload map[string]interface{}) (paystackResponse, error) {
+	var response paystackResponse
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return response, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.paystack.co"+path, bytes.NewBuffer(body))
+	if err != nil {
+		return response, err
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.secretKey))
+	req.Header.Set("Content-Type", "application/json")
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return response, err
+	}
+	defer res.Body.Close()
+	if err = json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return response, err
+	}
+	if !response.Status {
+		return response, fmt.Errorf("paystack %s: %s", path, response.Message)
+	}
+	return response, nil
+}
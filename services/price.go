@@ -0,0 +1,358 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/models"
+	"github.com/kaasikodes/e-commerce-go/types"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+type PriceRepository struct {
+	db *sql.DB
+}
+
+func NewPriceRepository(db *sql.DB) *PriceRepository {
+	return &PriceRepository{
+		db: db,
+	}
+}
+
+const priceChangeColumns = `ID, ProductID, COALESCE(SaleID, ''), OldPrice, NewPrice, Reason, CreatedAt`
+
+const saleColumns = `ID, ProductID, SalePrice, RegularPrice, StartsAt, EndsAt, Status, CreatedAt, UpdatedAt`
+
+func (r *PriceRepository) RetrievePriceHistory(productID string, sellerID string, pagination types.Pagination) (types.PaginatedDataOutput, error){
+	db := r.db
+	// the cursor is the id of the last change of the previous page, ids are time ordered so the newest changes have the largest ids
+	query := `SELECT ` + priceChangeColumns + `,
+		(SELECT COUNT(*) FROM ProductPriceChange WHERE ProductID = ?) AS total
+		FROM ProductPriceChange
+		WHERE ProductID = ? AND (? = '' OR ID < ?)
+		ORDER BY ID DESC
+		LIMIT ?`
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	output := types.PaginatedDataOutput{}
+	if err := checkProductOwner(ctx, db, productID, sellerID); err !=nil {
+		return output, err
+	}
+	rows, err := db.QueryContext(ctx, query, productID, productID, pagination.NextCursor, pagination.NextCursor, utils.Ternary(pagination.PageSize == 0, constants.DefaultPageSize, pagination.PageSize))
+	if err !=nil {
+		return output, err
+	}
+	defer rows.Close()
+	changes := []models.ProductPriceChange{}
+	total := 0
+	for rows.Next() {
+		change := models.ProductPriceChange{}
+		if err = rows.Scan(&change.ID, &change.ProductID, &change.SaleID, &change.OldPrice, &change.NewPrice, &change.Reason, &change.CreatedAt, &total); err !=nil {
+			return output, err
+		}
+		changes = append(changes, change)
+	}
+	if err = rows.Err(); err !=nil {
+		return output, err
+	}
+	lastItemId := ""
+	// select last item in the list
+	if len(changes) > 0 {
+		lastItemId = changes[len(changes)-1].ID
+	}
+
+	output = types.PaginatedDataOutput{
+		Data: changes,
+		NextCursor: lastItemId,
+		HasMore:    len(changes) < total,
+		Total:      total,
+	}
+	return output, nil
+}
+
+// the sales of the product, the latest to start first
+func (r *PriceRepository) RetrieveSales(productID string, sellerID string) ([]models.ProductSale, error){
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	sales := []models.ProductSale{}
+	if err := checkProductOwner(ctx, r.db, productID, sellerID); err !=nil {
+		return sales, err
+	}
+	rows, err := r.db.QueryContext(ctx, `SELECT `+saleColumns+` FROM ProductSale WHERE ProductID = ? ORDER BY StartsAt DESC, ID DESC`, productID)
+	if err !=nil {
+		return sales, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		sale, err := scanSale(rows)
+		if err !=nil {
+			return sales, err
+		}
+		sales = append(sales, sale)
+	}
+	return sales, rows.Err()
+}
+
+// a sale that should have started already starts straight away, otherwise the scheduler starts it
+func (r *PriceRepository) ScheduleSale(productID string, sellerID string, input types.ScheduleSaleInput) (models.ProductSale, error){
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err !=nil {
+		return models.ProductSale{}, err
+	}
+	defer tx.Rollback()
+	owner, price := "", 0
+	var wasPrice sql.NullInt64
+	err = tx.QueryRowContext(ctx, `SELECT OwnerID, Price, WasPrice FROM Product WHERE ID = ? AND DeletedAt IS NULL FOR UPDATE`, productID).Scan(&owner, &price, &wasPrice)
+	if err == sql.ErrNoRows || (err == nil && owner != sellerID) {
+		return models.ProductSale{}, constants.ErrProductNotFound
+	}
+	if err !=nil {
+		return models.ProductSale{}, err
+	}
+	// a sale is measured against the regular price, not the price of a sale that is running now
+	if wasPrice.Valid {
+		price = int(wasPrice.Int64)
+	}
+	if input.SalePrice >= price {
+		return models.ProductSale{}, constants.ErrSalePriceNotLower
+	}
+	overlapping := 0
+	err = tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM ProductSale WHERE ProductID = ? AND Status IN (?, ?) AND StartsAt < ? AND EndsAt > ?`,
+		productID, constants.ProductSaleStatuses.Scheduled, constants.ProductSaleStatuses.Active, input.EndsAt, input.StartsAt).Scan(&overlapping)
+	if err !=nil {
+		return models.ProductSale{}, err
+	}
+	if overlapping > 0 {
+		return models.ProductSale{}, constants.ErrSaleOverlaps
+	}
+	id := utils.NewID()
+	_, err = tx.ExecContext(ctx, `INSERT INTO ProductSale (ID, ProductID, SalePrice, StartsAt, EndsAt, Status) VALUES (?, ?, ?, ?, ?, ?)`,
+		id, productID, input.SalePrice, input.StartsAt, input.EndsAt, constants.ProductSaleStatuses.Scheduled)
+	if err !=nil {
+		return models.ProductSale{}, err
+	}
+	if !input.StartsAt.After(time.Now()) {
+		if err = startSale(ctx, tx, id); err !=nil {
+			return models.ProductSale{}, err
+		}
+	}
+	if err = tx.Commit(); err !=nil {
+		return models.ProductSale{}, err
+	}
+	return r.retrieveSale(id)
+}
+
+func (r *PriceRepository) CancelSale(id string, productID string, sellerID string) (models.ProductSale, error){
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	if err := checkProductOwner(ctx, r.db, productID, sellerID); err !=nil {
+		return models.ProductSale{}, err
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err !=nil {
+		return models.ProductSale{}, err
+	}
+	defer tx.Rollback()
+	status := ""
+	err = tx.QueryRowContext(ctx, `SELECT Status FROM ProductSale WHERE ID = ? AND ProductID = ? FOR UPDATE`, id, productID).Scan(&status)
+	if err == sql.ErrNoRows {
+		return models.ProductSale{}, constants.ErrSaleNotFound
+	}
+	if err !=nil {
+		return models.ProductSale{}, err
+	}
+	switch status {
+	case constants.ProductSaleStatuses.Scheduled:
+		_, err = tx.ExecContext(ctx, `UPDATE ProductSale SET Status = ? WHERE ID = ?`, constants.ProductSaleStatuses.Cancelled, id)
+	case constants.ProductSaleStatuses.Active:
+		err = endSale(ctx, tx, id, constants.ProductSaleStatuses.Cancelled)
+	default:
+		return models.ProductSale{}, constants.ErrSaleOver
+	}
+	if err !=nil {
+		return models.ProductSale{}, err
+	}
+	if err = tx.Commit(); err !=nil {
+		return models.ProductSale{}, err
+	}
+	return r.retrieveSale(id)
+}
+
+// running sales are ended before new ones are started, so a sale can start the moment the one before it ends
+func (r *PriceRepository) ApplyScheduledSales() (int64, int64, error){
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	ending, err := r.retrieveDueSales(ctx, `Status = ? AND EndsAt <= NOW()`, constants.ProductSaleStatuses.Active)
+	if err !=nil {
+		return 0, 0, err
+	}
+	var started, ended int64
+	for _, id := range ending {
+		err = r.inTx(ctx, func(tx *sql.Tx) error {
+			return endSale(ctx, tx, id, constants.ProductSaleStatuses.Ended)
+		})
+		if err !=nil {
+			log.Println("unable to end sale", id, err)
+			continue
+		}
+		ended++
+	}
+	starting, err := r.retrieveDueSales(ctx, `Status = ? AND StartsAt <= NOW()`, constants.ProductSaleStatuses.Scheduled)
+	if err !=nil {
+		return started, ended, err
+	}
+	for _, id := range starting {
+		err = r.inTx(ctx, func(tx *sql.Tx) error {
+			return startSale(ctx, tx, id)
+		})
+		if err !=nil {
+			log.Println("unable to start sale", id, err)
+			continue
+		}
+		started++
+	}
+	return started, ended, nil
+}
+
+// private
+func (r *PriceRepository) retrieveSale(id string) (models.ProductSale, error){
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	sale, err := scanSale(r.db.QueryRowContext(ctx, `SELECT `+saleColumns+` FROM ProductSale WHERE ID = ?`, id))
+	if err == sql.ErrNoRows {
+		return sale, constants.ErrSaleNotFound
+	}
+	return sale, err
+}
+
+func (r *PriceRepository) retrieveDueSales(ctx context.Context, where string, args ...interface{}) ([]string, error){
+	ids := []string{}
+	rows, err := r.db.QueryContext(ctx, `SELECT ID FROM ProductSale WHERE `+where+` ORDER BY ID`, args...)
+	if err !=nil {
+		return ids, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		id := ""
+		if err = rows.Scan(&id); err !=nil {
+			return ids, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (r *PriceRepository) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err !=nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err = fn(tx); err !=nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// startSale puts the product on sale, a sale that was missed altogether or whose product was deleted is ended without touching the price.
+// The sale is skipped if it was cancelled in the meantime
+func startSale(ctx context.Context, tx *sql.Tx, id string) error {
+	productID, salePrice, status, missed := "", 0, "", false
+	err := tx.QueryRowContext(ctx, `SELECT ProductID, SalePrice, Status, EndsAt <= NOW() FROM ProductSale WHERE ID = ? FOR UPDATE`, id).Scan(&productID, &salePrice, &status, &missed)
+	if err !=nil || status != constants.ProductSaleStatuses.Scheduled {
+		return err
+	}
+	price, deleted := 0, false
+	var wasPrice sql.NullInt64
+	if err = tx.QueryRowContext(ctx, `SELECT Price, WasPrice, DeletedAt IS NOT NULL FROM Product WHERE ID = ? FOR UPDATE`, productID).Scan(&price, &wasPrice, &deleted); err !=nil {
+		return err
+	}
+	if missed || deleted {
+		_, err = tx.ExecContext(ctx, `UPDATE ProductSale SET Status = ? WHERE ID = ?`, constants.ProductSaleStatuses.Ended, id)
+		return err
+	}
+	regularPrice := price
+	if wasPrice.Valid {
+		regularPrice = int(wasPrice.Int64)
+	}
+	if _, err = tx.ExecContext(ctx, `UPDATE Product SET Price = ?, WasPrice = ? WHERE ID = ?`, salePrice, regularPrice, productID); err !=nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, `UPDATE ProductSale SET Status = ?, RegularPrice = ? WHERE ID = ?`, constants.ProductSaleStatuses.Active, regularPrice, id); err !=nil {
+		return err
+	}
+	return recordPriceChange(ctx, tx, productID, id, price, salePrice, constants.PriceChangeReasons.SaleStart)
+}
+
+// endSale puts the product back to its regular price, which the seller may have changed while the sale was running
+func endSale(ctx context.Context, tx *sql.Tx, id string, status string) error {
+	productID, current := "", ""
+	err := tx.QueryRowContext(ctx, `SELECT ProductID, Status FROM ProductSale WHERE ID = ? FOR UPDATE`, id).Scan(&productID, &current)
+	if err !=nil || current != constants.ProductSaleStatuses.Active {
+		return err
+	}
+	price := 0
+	var wasPrice sql.NullInt64
+	if err = tx.QueryRowContext(ctx, `SELECT Price, WasPrice FROM Product WHERE ID = ? FOR UPDATE`, productID).Scan(&price, &wasPrice); err !=nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, `UPDATE ProductSale SET Status = ? WHERE ID = ?`, status, id); err !=nil {
+		return err
+	}
+	if !wasPrice.Valid {
+		return nil
+	}
+	if _, err = tx.ExecContext(ctx, `UPDATE Product SET Price = WasPrice, WasPrice = NULL WHERE ID = ?`, productID); err !=nil {
+		return err
+	}
+	return recordPriceChange(ctx, tx, productID, id, price, int(wasPrice.Int64), constants.PriceChangeReasons.SaleEnd)
+}
+
+func recordPriceChange(ctx context.Context, tx *sql.Tx, productID string, saleID string, oldPrice int, newPrice int, reason string) error {
+	_, err := tx.ExecContext(ctx, `INSERT INTO ProductPriceChange (ID, ProductID, SaleID, OldPrice, NewPrice, Reason) VALUES (?, ?, NULLIF(?, ''), ?, ?, ?)`,
+		utils.NewID(), productID, saleID, oldPrice, newPrice, reason)
+	return err
+}
+
+type productOwnerQueryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// deleted products and products of other sellers are not found
+func checkProductOwner(ctx context.Context, q productOwnerQueryer, productID string, sellerID string) error {
+	owner := ""
+	err := q.QueryRowContext(ctx, `SELECT OwnerID FROM Product WHERE ID = ? AND DeletedAt IS NULL`, productID).Scan(&owner)
+	if err == sql.ErrNoRows || (err == nil && owner != sellerID) {
+		return constants.ErrProductNotFound
+	}
+	return err
+}
+
+type saleScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSale(row saleScanner, extra ...interface{}) (models.ProductSale, error){
+	sale := models.ProductSale{}
+	var regularPrice sql.NullInt64
+	dest := []interface{}{&sale.ID, &sale.ProductID, &sale.SalePrice, &regularPrice, &sale.StartsAt, &sale.EndsAt, &sale.Status, &sale.CreatedAt, &sale.UpdatedAt}
+	if err := row.Scan(append(dest, extra...)...); err !=nil {
+		return sale, err
+	}
+	if regularPrice.Valid {
+		price := int(regularPrice.Int64)
+		sale.RegularPrice = &price
+	}
+	return sale, nil
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/types"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestEndSale(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT ProductID, Status FROM ProductSale WHERE ID = \\? FOR UPDATE").WithArgs("sale-1").
+		WillReturnRows(sqlmock.NewRows([]string{"ProductID", "Status"}).AddRow("product-1", constants.ProductSaleStatuses.Active))
+	// the seller raised the regular price from 1000 to 1200 while the sale was running
+	mock.ExpectQuery("SELECT Price, WasPrice FROM Product WHERE ID = \\? FOR UPDATE").WithArgs("product-1").
+		WillReturnRows(sqlmock.NewRows([]string{"Price", "WasPrice"}).AddRow(800, 1200))
+	mock.ExpectExec("UPDATE ProductSale SET Status = \\?").WithArgs(constants.ProductSaleStatuses.Ended, "sale-1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE Product SET Price = WasPrice, WasPrice = NULL").WithArgs("product-1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO ProductPriceChange").WithArgs(sqlmock.AnyArg(), "product-1", "sale-1", 800, 1200, constants.PriceChangeReasons.SaleEnd).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = endSale(context.Background(), tx, "sale-1", constants.ProductSaleStatuses.Ended); err != nil {
+		t.Fatal(err)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestScheduleSale_PriceNotLower(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// on sale at 800 with a regular price of 1000, a second sale is measured against 1000
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT OwnerID, Price, WasPrice FROM Product").WithArgs("product-1").
+		WillReturnRows(sqlmock.NewRows([]string{"OwnerID", "Price", "WasPrice"}).AddRow("seller-1", 800, 1000))
+	mock.ExpectRollback()
+
+	input := types.ScheduleSaleInput{SalePrice: 1000, StartsAt: time.Now().Add(time.Hour * 24), EndsAt: time.Now().Add(time.Hour * 48)}
+	_, err = NewPriceRepository(db).ScheduleSale("product-1", "seller-1", input)
+	if err != constants.ErrSalePriceNotLower {
+		t.Errorf("error = %v, want %v", err, constants.ErrSalePriceNotLower)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestUpdateProduct_RecordsRegularPriceChangeDuringSale(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	// the product is on sale for 800, down from 1000
+	mock.ExpectQuery("SELECT Price, WasPrice, Quantity FROM Product WHERE ID = \\? AND OwnerID = \\?").WithArgs("product-1", "seller-1").
+		WillReturnRows(sqlmock.NewRows([]string{"Price", "WasPrice", "Quantity"}).AddRow(800, 1000, 4))
+	mock.ExpectExec("UPDATE Product SET Name = \\?").WithArgs("Kettle", "", 800, int64(1200), 4, 0, "category-1", "product-1").WillReturnResult(sqlmock.NewResult(0, 1))
+	// customers still pay 800, only the price the product goes back to changed
+	mock.ExpectExec("INSERT INTO ProductPriceChange").WithArgs(sqlmock.AnyArg(), "product-1", "", 1000, 1200, constants.PriceChangeReasons.RegularPrice).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectPrepare("FROM Product WHERE ID = \\? AND DeletedAt IS NULL").ExpectQuery().WithArgs("product-1").WillReturnError(constants.ErrProductNotFound)
+
+	_, err = NewProductRepository(db).UpdateProduct("product-1", "seller-1", types.AddProductInput{Name: "Kettle", Price: 1200, Quantity: 4, CategoryID: "category-1"})
+	if err != constants.ErrProductNotFound {
+		t.Errorf("error = %v, want %v", err, constants.ErrProductNotFound)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -21,42 +21,66 @@ func NewProductRepository(db *sql.DB) *ProductRepository {
 	}
 }
 
-// update product
-func (c *ProductRepository) UpdateProduct(id string, input types.AddProductInput) (models.Product, error){
-	db := c.db
-	// prepare query
-	query := "UPDATE Product SET Name = ?, Description = ?, Price = ?, Quantity = ?, CategoryID = ? WHERE ID = ?"
+const productColumns = `ID, Name, Description, Price, WasPrice, Quantity, ReorderThreshold, CategoryID, OwnerID, RatingAverage, RatingCount, OneStarCount, TwoStarCount, ThreeStarCount, FourStarCount, FiveStarCount, Status, PublishAt, UnpublishAt, DeletedAt, CreatedAt, UpdatedAt`
+
+// products shoppers can see and buy, the rest are only seen by their seller and in the orders they are part of
+const productForSaleCondition = `p.Status = 'active' AND p.DeletedAt IS NULL`
+
+// update product, a change of price is recorded in the price history and a change of quantity in the stock movements
+func (c *ProductRepository) UpdateProduct(id string, sellerId string, input types.AddProductInput) (models.Product, error){
 	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
 	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
 	defer cancel()
-	// prepare the statement
-	product := models.Product{}
-
-	stmt, err := db.PrepareContext(ctx, query)
+	tx, err := c.db.BeginTx(ctx, nil)
 	if err !=nil {
-		return product, err
+		return models.Product{}, err
 	}
-	defer stmt.Close() //close the statement after use
-	
-	res, err := stmt.ExecContext(ctx, input.Name, input.Description, input.Price, input.Quantity, input.CategoryID, id )
-	if err !=nil {
-		return product, err
+	defer tx.Rollback()
+	price, quantity := 0, 0
+	var wasPrice sql.NullInt64
+	// products of other sellers are not found
+	err = tx.QueryRowContext(ctx, `SELECT Price, WasPrice, Quantity FROM Product WHERE ID = ? AND OwnerID = ? AND DeletedAt IS NULL FOR UPDATE`, id, sellerId).Scan(&price, &wasPrice, &quantity)
+	if err == sql.ErrNoRows {
+		return models.Product{}, constants.ErrProductNotFound
 	}
-	_, err = res.RowsAffected()
 	if err !=nil {
-		return product, err
+		return models.Product{}, err
 	}
-	product, err = c.RetrieveProductByID( id)
-	if err !=nil {
-		return product, err
+	newPrice := input.Price
+	oldWasPrice := wasPrice.Int64
+	// while the product is on sale its price is the sale price, a different price is the one it goes back to when the sale ends
+	if wasPrice.Valid {
+		newPrice = price
+		if input.Price != price {
+			wasPrice.Int64 = int64(input.Price)
+		}
 	}
-
-	
-	return product, nil
-
+	query := "UPDATE Product SET Name = ?, Description = ?, Price = ?, WasPrice = ?, Quantity = ?, ReorderThreshold = ?, CategoryID = ? WHERE ID = ?"
+	if _, err = tx.ExecContext(ctx, query, input.Name, input.Description, newPrice, wasPrice, input.Quantity, input.ReorderThreshold, input.CategoryID, id); err !=nil {
+		return models.Product{}, err
+	}
+	if input.Quantity != quantity {
+		if err = recordStockMovement(ctx, tx, id, input.Quantity-quantity, constants.StockMovementReasons.Adjustment, constants.StockMovementActors.Seller, sellerId, ""); err !=nil {
+			return models.Product{}, err
+		}
+	}
+	if newPrice != price {
+		if err = recordPriceChange(ctx, tx, id, "", price, newPrice, constants.PriceChangeReasons.Manual); err !=nil {
+			return models.Product{}, err
+		}
+	}
+	if wasPrice.Valid && wasPrice.Int64 != oldWasPrice {
+		if err = recordPriceChange(ctx, tx, id, "", int(oldWasPrice), int(wasPrice.Int64), constants.PriceChangeReasons.RegularPrice); err !=nil {
+			return models.Product{}, err
+		}
+	}
+	if err = tx.Commit(); err !=nil {
+		return models.Product{}, err
+	}
+	return c.RetrieveProductByID(id)
 }
 
-// add multiple products
+// add multiple products, the stock each of them comes with is recorded as imported
 func (c *ProductRepository) AddMultipleProducts(input []types.MultipleProductInput, sellerId string) ([]types.MultipleProductInput, error){
 	db := c.db
 	
@@ -64,9 +88,11 @@ func (c *ProductRepository) AddMultipleProducts(input []types.MultipleProductInp
 	query := `INSERT INTO Product (ID, Name, Description, Price, Quantity, CategoryID, OwnerID) VALUES`
 	var inserts []string
 	var params []interface{}
+	ids := []string{}
 	for _, data := range input {
 		inserts = append(inserts, "(?, ?, ?, ?, ?, ?, ?)")
-		id, _:= utils.GenerateRandomID(10)
+		id := utils.NewID()
+		ids = append(ids, id)
 		params = append(params, id, data.Name, data.Description, data.Price, data.Quantity, data.CategoryID, sellerId)
 
 	}
@@ -75,19 +101,21 @@ func (c *ProductRepository) AddMultipleProducts(input []types.MultipleProductInp
 	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
 	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
 	defer cancel()
-	// prepare the statement
-	stmt, err := db.PrepareContext(ctx, query)
+	tx, err := db.BeginTx(ctx, nil)
 	if err !=nil {
 		return input, err
 	}
-	defer stmt.Close() //close the statement after use
+	defer tx.Rollback()
 	// execute the statement
-	res, err := stmt.ExecContext(ctx, params... )
-	if err !=nil {
+	if _, err = tx.ExecContext(ctx, query, params... ); err !=nil {
 		return input, err
 	}
-	_, err = res.RowsAffected()
-	if err !=nil {
+	for i, data := range input {
+		if err = recordStockMovement(ctx, tx, ids[i], data.Quantity, constants.StockMovementReasons.Import, constants.StockMovementActors.Seller, sellerId, ""); err !=nil {
+			return input, err
+		}
+	}
+	if err = tx.Commit(); err !=nil {
 		return input, err
 	}
 	// ensure input have
@@ -100,17 +128,17 @@ func (c *ProductRepository) RetrieveProducts(input types.RetrievProductsInput, s
 	db := c.db
 	// prepare query
 	query := `
-    SELECT p.*,
+    SELECT ` + prefixColumns("p", productColumns) + `,
 			c.ID AS category_id,
 			c.Name AS category_name,
 			c.Description AS category_description,
 			c.CreatedAt AS category_created_at,
 			c.UpdatedAt AS category_updated_at,
 		
-           (SELECT COUNT(*) FROM Product WHERE OwnerID = ?) AS total_products
+           (SELECT COUNT(*) FROM Product WHERE OwnerID = ? AND DeletedAt IS NULL AND (? = '' OR Status = ?)) AS total_products
     FROM Product p
 	JOIN Category c ON p.CategoryID = c.ID
-	WHERE p.OwnerID = ? AND  p.ID > ? 
+	WHERE p.OwnerID = ? AND p.DeletedAt IS NULL AND (? = '' OR p.Status = ?) AND  p.ID > ? 
     ORDER BY p.ID ASC
     LIMIT ?
 	`
@@ -128,20 +156,20 @@ func (c *ProductRepository) RetrieveProducts(input types.RetrievProductsInput, s
 	defer stmt.Close() //close the statement after use
 	// execute the statement
 	products := []models.Product{}
-	rows, err := stmt.QueryContext(ctx, sellerId, sellerId, utils.Ternary(input.Pagination.NextCursor == "", "", input.Pagination.NextCursor), utils.Ternary(input.Pagination.PageSize == 0, constants.DefaultPageSize, input.Pagination.PageSize))
+	rows, err := stmt.QueryContext(ctx, sellerId, input.Status, input.Status, sellerId, input.Status, input.Status, utils.Ternary(input.Pagination.NextCursor == "", "", input.Pagination.NextCursor), utils.Ternary(input.Pagination.PageSize == 0, constants.DefaultPageSize, input.Pagination.PageSize))
 	if err !=nil {
 		return output, err
 	}
 	defer rows.Close()
 	total := 0
 	for rows.Next() {
-		product := models.Product{}
-		product.Category = &models.Category{}
-		product.Seller = &models.Seller{}
-		err = rows.Scan(&product.ID, &product.Name, &product.Description, &product.Price, &product.Quantity, &product.CategoryID, &product.SellerID, &product.CreatedAt, &product.UpdatedAt, &product.Category.ID, &product.Category.Name, &product.Category.Description, &product.Category.CreatedAt, &product.Category.UpdatedAt, &total)
+		category := &models.Category{}
+		product, err := scanProduct(rows, &category.ID, &category.Name, &category.Description, &category.CreatedAt, &category.UpdatedAt, &total)
 		if err !=nil {
 			return output, err
 		}
+		product.Category = category
+		product.Seller = &models.Seller{}
 		products = append(products, product)
 	}
 	lastItemId := ""
@@ -160,11 +188,11 @@ func (c *ProductRepository) RetrieveProducts(input types.RetrievProductsInput, s
 	return output, nil
 
 }
-// retrieve product by id
+// retrieve product by id, whatever its status as long as it hasn't been deleted
 func (c *ProductRepository) RetrieveProductByID(id string) (models.Product, error){
 	db := c.db
 	// prepare query
-	query := `SELECT * FROM Product WHERE ID = ?`
+	query := `SELECT ` + productColumns + ` FROM Product WHERE ID = ? AND DeletedAt IS NULL`
 	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
 	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
 	defer cancel()
@@ -177,7 +205,10 @@ func (c *ProductRepository) RetrieveProductByID(id string) (models.Product, erro
 	defer stmt.Close() //close the statement after use
 	// execute the statement
 	
-	 err = stmt.QueryRowContext(ctx,  id).Scan(&product.ID, &product.Name, &product.Description, &product.Price, &product.Quantity, &product.CategoryID, &product.SellerID, &product.CreatedAt, &product.UpdatedAt)
+	 product, err = scanProduct(stmt.QueryRowContext(ctx,  id))
+	 if err == sql.ErrNoRows {
+		return product, constants.ErrProductNotFound
+	}
 	 if err !=nil {
 		return product, err
 	}
@@ -185,58 +216,116 @@ func (c *ProductRepository) RetrieveProductByID(id string) (models.Product, erro
 	return product, nil
 
 }
-// Delete Product
-func (c *ProductRepository)DeleteProduct(id string) (models.Product, error){
-	db := c.db
-	// prepare query
-	query := "DELETE FROM Product WHERE ID = ?"
+// Delete Product, the row is kept as orders point to it but it is taken off sale and out of carts and wishlists for good
+func (c *ProductRepository)DeleteProduct(id string, sellerId string) (models.Product, error){
 	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
 	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
 	defer cancel()
-	// prepare the statement
-	stmt, err := db.PrepareContext(ctx, query)
-	product := models.Product{}
+	product, err := c.RetrieveProductByID(id)
 	if err !=nil {
 		return product, err
 	}
-	
-	defer stmt.Close() //close the statement after use
-	product, err = c.RetrieveProductByID(id)
+	tx, err := c.db.BeginTx(ctx, nil)
 	if err !=nil {
 		return product, err
 	}
-	// execute the statement
-	_, err = stmt.ExecContext(ctx, id)
+	defer tx.Rollback()
+	statements := []string{
+		`UPDATE Product SET Status = ?, PublishAt = NULL, UnpublishAt = NULL, DeletedAt = NOW() WHERE ID = ? AND OwnerID = ? AND DeletedAt IS NULL`,
+		`DELETE FROM CartItem WHERE ProductID = ?`,
+		`DELETE FROM SavedForLaterItem WHERE ProductID = ?`,
+		`DELETE FROM WishlistItem WHERE ProductID = ?`,
+	}
+	// only the seller's own product is taken off sale, and nothing else is touched when it isn't theirs
+	res, err := tx.ExecContext(ctx, statements[0], constants.ProductStatuses.Archived, id, sellerId)
 	if err !=nil {
 		return product, err
 	}
-	
-	
+	count, err := res.RowsAffected()
+	if err !=nil {
+		return product, err
+	}
+	if count == 0 {
+		return models.Product{}, constants.ErrProductNotFound
+	}
+	for _, statement := range statements[1:] {
+		if _, err = tx.ExecContext(ctx, statement, id); err !=nil {
+			return product, err
+		}
+	}
+	if err = tx.Commit(); err !=nil {
+		return product, err
+	}
 	return product, nil
 }
-// Add product
+
+// sets the status of one of the seller's products, with the times it should be published or unpublished at. A time that is left out is cleared
+func (c *ProductRepository) UpdateProductStatus(id string, sellerId string, input types.UpdateProductStatusInput) (models.Product, error){
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	product, err := c.RetrieveProductByID(id)
+	if err !=nil {
+		return product, err
+	}
+	if product.SellerID != sellerId {
+		return models.Product{}, constants.ErrProductNotFound
+	}
+	_, err = c.db.ExecContext(ctx, `UPDATE Product SET Status = ?, PublishAt = ?, UnpublishAt = ? WHERE ID = ? AND DeletedAt IS NULL`, input.Status, input.PublishAt, input.UnpublishAt, id)
+	if err !=nil {
+		return product, err
+	}
+	return c.RetrieveProductByID(id)
+}
+
+// publishes the drafts and archives the active products whose time has come, a product due both ends up archived
+func (c *ProductRepository) PublishScheduledProducts() (int64, int64, error){
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	res, err := c.db.ExecContext(ctx, `UPDATE Product SET Status = ?, PublishAt = NULL WHERE Status = ? AND PublishAt <= NOW() AND DeletedAt IS NULL`, constants.ProductStatuses.Active, constants.ProductStatuses.Draft)
+	if err !=nil {
+		return 0, 0, err
+	}
+	published, err := res.RowsAffected()
+	if err !=nil {
+		return 0, 0, err
+	}
+	res, err = c.db.ExecContext(ctx, `UPDATE Product SET Status = ?, UnpublishAt = NULL WHERE Status = ? AND UnpublishAt <= NOW() AND DeletedAt IS NULL`, constants.ProductStatuses.Archived, constants.ProductStatuses.Active)
+	if err !=nil {
+		return published, 0, err
+	}
+	unpublished, err := res.RowsAffected()
+	return published, unpublished, err
+}
+// Add product, the stock it comes with is the first of its stock movements
 func (c *ProductRepository) AddProduct(inp types.AddProductInput, sellerId string) (models.Product, error) {
 	db := c.db
 	// prepare query
-	query := `INSERT INTO Product (ID, Name, Description, Price, Quantity, CategoryID, OwnerID) VALUES (?, ?, ?, ?, ?, ?, ?)`
+	query := `INSERT INTO Product (ID, Name, Description, Price, Quantity, ReorderThreshold, CategoryID, OwnerID, Status) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
 	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
 	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
 	defer cancel()
-	// prepare the statement
-	stmt, err := db.PrepareContext(ctx, query)
 	product := models.Product{}
+	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
 		return product, err
 	}
-	defer stmt.Close() //close the statement after use
+	defer tx.Rollback()
 	// execute the statement
-	id, _ := utils.GenerateRandomID(10)
-	res, err := stmt.ExecContext(ctx, id, inp.Name, inp.Description, inp.Price, inp.Quantity, inp.CategoryID, sellerId)
-	if err != nil {
+	id := utils.NewID()
+	// products go on sale straight away unless they are saved as a draft
+	status := inp.Status
+	if status == "" {
+		status = constants.ProductStatuses.Active
+	}
+	if _, err = tx.ExecContext(ctx, query, id, inp.Name, inp.Description, inp.Price, inp.Quantity, inp.ReorderThreshold, inp.CategoryID, sellerId, status); err != nil {
 		return product, err
 	}
-	_, err = res.RowsAffected()
-	if err != nil {
+	if err = recordStockMovement(ctx, tx, id, inp.Quantity, constants.StockMovementReasons.Initial, constants.StockMovementActors.Seller, sellerId, ""); err != nil {
+		return product, err
+	}
+	if err = tx.Commit(); err != nil {
 		return product, err
 	}
 	product.ID = id
@@ -244,14 +333,45 @@ func (c *ProductRepository) AddProduct(inp types.AddProductInput, sellerId strin
 	product.Description = inp.Description
 	product.Price = inp.Price
 	product.Quantity = inp.Quantity
+	product.ReorderThreshold = inp.ReorderThreshold
 	product.CategoryID = inp.CategoryID
 	product.SellerID = sellerId
+	product.Status = status
 	return product, nil
 
 }
 
+type productScanner interface {
+	Scan(dest ...interface{}) error
+}
 
-
-
-
-
+func scanProduct(row productScanner, extra ...interface{}) (models.Product, error){
+	product := models.Product{}
+	var description sql.NullString
+	var wasPrice sql.NullInt64
+	var publishAt, unpublishAt, deletedAt sql.NullTime
+	stars := make([]int, 5)
+	dest := []interface{}{&product.ID, &product.Name, &description, &product.Price, &wasPrice, &product.Quantity, &product.ReorderThreshold, &product.CategoryID, &product.SellerID, &product.Rating.Average, &product.Rating.Count, &stars[0], &stars[1], &stars[2], &stars[3], &stars[4], &product.Status, &publishAt, &unpublishAt, &deletedAt, &product.CreatedAt, &product.UpdatedAt}
+	if err := row.Scan(append(dest, extra...)...); err !=nil {
+		return product, err
+	}
+	product.Description = description.String
+	if wasPrice.Valid {
+		price := int(wasPrice.Int64)
+		product.WasPrice = &price
+	}
+	if publishAt.Valid {
+		product.PublishAt = &publishAt.Time
+	}
+	if unpublishAt.Valid {
+		product.UnpublishAt = &unpublishAt.Time
+	}
+	if deletedAt.Valid {
+		product.DeletedAt = &deletedAt.Time
+	}
+	product.Rating.Distribution = map[int]int{}
+	for i, count := range stars {
+		product.Rating.Distribution[i+1] = count
+	}
+	return product, nil
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/types"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestDeleteProduct_KeepsRow(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	columns := []string{"ID", "Name", "Description", "Price", "WasPrice", "Quantity", "ReorderThreshold", "CategoryID", "OwnerID", "RatingAverage", "RatingCount", "OneStarCount", "TwoStarCount", "ThreeStarCount", "FourStarCount", "FiveStarCount", "Status", "PublishAt", "UnpublishAt", "DeletedAt", "CreatedAt", "UpdatedAt"}
+	mock.ExpectPrepare("FROM Product WHERE ID = \\? AND DeletedAt IS NULL").ExpectQuery().WithArgs("product-1").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow("product-1", "Kettle", nil, 5000, nil, 3, 0, "category-1", "seller-1", 0, 0, 0, 0, 0, 0, 0, constants.ProductStatuses.Active, nil, nil, nil, time.Now(), time.Now()))
+	// sold products are referenced by order items, so the row is never deleted
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE Product SET Status = \\?, PublishAt = NULL, UnpublishAt = NULL, DeletedAt = NOW\\(\\)").WithArgs(constants.ProductStatuses.Archived, "product-1", "seller-1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM CartItem WHERE ProductID = \\?").WithArgs("product-1").WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("DELETE FROM SavedForLaterItem WHERE ProductID = \\?").WithArgs("product-1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("DELETE FROM WishlistItem WHERE ProductID = \\?").WithArgs("product-1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if _, err = NewProductRepository(db).DeleteProduct("product-1", "seller-1"); err != nil {
+		t.Fatal(err)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDeleteProduct_OtherSellersProduct(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	columns := []string{"ID", "Name", "Description", "Price", "WasPrice", "Quantity", "ReorderThreshold", "CategoryID", "OwnerID", "RatingAverage", "RatingCount", "OneStarCount", "TwoStarCount", "ThreeStarCount", "FourStarCount", "FiveStarCount", "Status", "PublishAt", "UnpublishAt", "DeletedAt", "CreatedAt", "UpdatedAt"}
+	mock.ExpectPrepare("FROM Product WHERE ID = \\? AND DeletedAt IS NULL").ExpectQuery().WithArgs("product-1").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow("product-1", "Kettle", nil, 5000, nil, 3, 0, "category-1", "seller-1", 0, 0, 0, 0, 0, 0, 0, constants.ProductStatuses.Active, nil, nil, nil, time.Now(), time.Now()))
+	// the product isn't archived and no one's cart or wishlist is touched
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE Product SET Status = \\?").WithArgs(constants.ProductStatuses.Archived, "product-1", "seller-2").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	if _, err = NewProductRepository(db).DeleteProduct("product-1", "seller-2"); err != constants.ErrProductNotFound {
+		t.Errorf("error = %v, want %v", err, constants.ErrProductNotFound)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPublishScheduledProducts(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("UPDATE Product SET Status = \\?, PublishAt = NULL WHERE Status = \\? AND PublishAt <= NOW\\(\\)").WithArgs(constants.ProductStatuses.Active, constants.ProductStatuses.Draft).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("UPDATE Product SET Status = \\?, UnpublishAt = NULL WHERE Status = \\? AND UnpublishAt <= NOW\\(\\)").WithArgs(constants.ProductStatuses.Archived, constants.ProductStatuses.Active).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	published, unpublished, err := NewProductRepository(db).PublishScheduledProducts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if published != 2 || unpublished != 1 {
+		t.Errorf("published, unpublished = %d, %d, want 2, 1", published, unpublished)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestUpdateProduct_OtherSellersProduct(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT Price, WasPrice, Quantity FROM Product WHERE ID = \\? AND OwnerID = \\?").WithArgs("product-1", "seller-2").
+		WillReturnRows(sqlmock.NewRows([]string{"Price", "WasPrice", "Quantity"}))
+	mock.ExpectRollback()
+
+	_, err = NewProductRepository(db).UpdateProduct("product-1", "seller-2", types.AddProductInput{Name: "Kettle", Price: 1, Quantity: 0, CategoryID: "category-1"})
+	if err != constants.ErrProductNotFound {
+		t.Errorf("error = %v, want %v", err, constants.ErrProductNotFound)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
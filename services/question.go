@@ -0,0 +1,339 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/models"
+	"github.com/kaasikodes/e-commerce-go/types"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+type QuestionRepository struct {
+	db *sql.DB
+}
+
+func NewQuestionRepository(db *sql.DB) *QuestionRepository {
+	return &QuestionRepository{
+		db: db,
+	}
+}
+
+const questionColumns = `q.ID, q.ProductID, q.CustomerID, COALESCE(u.Name, ''), u.ID, u.Email, q.Question, q.Status, COALESCE(q.ModerationReason, ''), q.CreatedAt, q.UpdatedAt`
+
+const questionTables = `ProductQuestion q
+	JOIN Product p ON p.ID = q.ProductID
+	JOIN Customer c ON c.ID = q.CustomerID
+	JOIN User u ON u.ID = c.UserID`
+
+// sellers answer under their shop name when they have set up a storefront
+const answerColumns = `a.ID, a.QuestionID, a.UserID, COALESCE(IF(a.AuthorRole = 'seller', sf.ShopName, NULL), u.Name, ''), a.AuthorRole, a.Answer, a.Status, COALESCE(a.ModerationReason, ''), a.CreatedAt, a.UpdatedAt`
+
+const answerTables = `ProductAnswer a
+	JOIN User u ON u.ID = a.UserID
+	LEFT JOIN Seller s ON s.UserID = u.ID
+	LEFT JOIN SellerStorefront sf ON sf.SellerID = s.ID`
+
+func (r *QuestionRepository) AskQuestion(productID string, customerID string, input types.AskQuestionInput) (models.ProductQuestion, error){
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	exists := 0
+	// only products that are for sale can be asked about
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM Product p WHERE p.ID = ? AND `+productForSaleCondition, productID).Scan(&exists); err !=nil {
+		return models.ProductQuestion{}, err
+	}
+	if exists == 0 {
+		return models.ProductQuestion{}, constants.ErrProductNotFound
+	}
+	id := utils.NewID()
+	_, err := r.db.ExecContext(ctx, `INSERT INTO ProductQuestion (ID, ProductID, CustomerID, Question, Status) VALUES (?, ?, ?, ?, ?)`, id, productID, customerID, input.Question, constants.QuestionStatuses.Published)
+	if err !=nil {
+		return models.ProductQuestion{}, err
+	}
+	return r.RetrieveQuestion(id)
+}
+
+func (r *QuestionRepository) AnswerQuestion(questionID string, user models.User, input types.AnswerQuestionInput) (models.ProductAnswer, error){
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err !=nil {
+		return models.ProductAnswer{}, err
+	}
+	defer tx.Rollback()
+	productID, sellerID, status := "", "", ""
+	err = tx.QueryRowContext(ctx, `SELECT q.ProductID, p.OwnerID, q.Status FROM ProductQuestion q JOIN Product p ON p.ID = q.ProductID WHERE q.ID = ?`, questionID).Scan(&productID, &sellerID, &status)
+	if err == sql.ErrNoRows || status == constants.QuestionStatuses.Hidden {
+		return models.ProductAnswer{}, constants.ErrQuestionNotFound
+	}
+	if err !=nil {
+		return models.ProductAnswer{}, err
+	}
+	role := ""
+	if user.Seller != nil && user.Seller.ID == sellerID {
+		role = constants.AnswerAuthorRoles.Seller
+	} else if user.Customer != nil {
+		bought, err := hasBoughtProduct(ctx, tx, user.Customer.ID, productID)
+		if err !=nil {
+			return models.ProductAnswer{}, err
+		}
+		if bought {
+			role = constants.AnswerAuthorRoles.VerifiedBuyer
+		}
+	}
+	if role == "" {
+		return models.ProductAnswer{}, constants.ErrNotAllowedToAnswer
+	}
+	id := utils.NewID()
+	_, err = tx.ExecContext(ctx, `INSERT INTO ProductAnswer (ID, QuestionID, UserID, AuthorRole, Answer, Status) VALUES (?, ?, ?, ?, ?, ?)`, id, questionID, user.ID, role, input.Answer, constants.QuestionStatuses.Published)
+	if err !=nil {
+		return models.ProductAnswer{}, err
+	}
+	if err = tx.Commit(); err !=nil {
+		return models.ProductAnswer{}, err
+	}
+	return r.retrieveAnswer(id)
+}
+
+// the answers of the question that are shown come with it
+func (r *QuestionRepository) RetrieveQuestion(id string) (models.ProductQuestion, error){
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	question, err := scanQuestion(r.db.QueryRowContext(ctx, `SELECT `+questionColumns+` FROM `+questionTables+` WHERE q.ID = ?`, id))
+	if err == sql.ErrNoRows {
+		return question, constants.ErrQuestionNotFound
+	}
+	if err !=nil {
+		return question, err
+	}
+	question.Answers, err = r.retrieveQuestionAnswers(ctx, id, false)
+	return question, err
+}
+
+func (r *QuestionRepository) RetrieveQuestions(input types.RetrieveQuestionsInput) (types.PaginatedDataOutput, error){
+	db := r.db
+	// prepare query, an empty product or seller matches every one of them
+	filter := `(? = '' OR q.ProductID = ?) AND (? = '' OR p.OwnerID = ?) AND ((? = '' AND q.Status != ?) OR q.Status = ?)`
+	// the cursor is the id of the last question of the previous page, ids are time ordered so the newest questions have the largest ids
+	query := `SELECT ` + questionColumns + `,
+		(SELECT COUNT(*) FROM ` + questionTables + ` WHERE ` + filter + `) AS total
+		FROM ` + questionTables + `
+		WHERE ` + filter + ` AND (? = '' OR q.ID < ?)
+		ORDER BY q.ID DESC
+		LIMIT ?`
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	// prepare the statement
+	output := types.PaginatedDataOutput{}
+	stmt, err := db.PrepareContext(ctx, query)
+	if err !=nil {
+		return output, err
+	}
+	defer stmt.Close() //close the statement after use
+	// execute the statement
+	filterArgs := []interface{}{input.ProductID, input.ProductID, input.SellerID, input.SellerID, input.Status, constants.QuestionStatuses.Hidden, input.Status}
+	args := append(append(append([]interface{}{}, filterArgs...), filterArgs...), input.Pagination.NextCursor, input.Pagination.NextCursor, utils.Ternary(input.Pagination.PageSize == 0, constants.DefaultPageSize, input.Pagination.PageSize))
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err !=nil {
+		return output, err
+	}
+	questions := []models.ProductQuestion{}
+	total := 0
+	for rows.Next() {
+		question, err := scanQuestion(rows, &total)
+		if err !=nil {
+			rows.Close()
+			return output, err
+		}
+		questions = append(questions, question)
+	}
+	rows.Close()
+	if err = rows.Err(); err !=nil {
+		return output, err
+	}
+	// admins looking at questions of a status see every answer, so they can moderate them too
+	for i := range questions {
+		if questions[i].Answers, err = r.retrieveQuestionAnswers(ctx, questions[i].ID, input.Status != ""); err !=nil {
+			return output, err
+		}
+	}
+	lastItemId := ""
+	// select last item in the list
+	if len(questions) > 0 {
+		lastItemId = questions[len(questions)-1].ID
+	}
+
+	output = types.PaginatedDataOutput{
+		Data: questions,
+		NextCursor: lastItemId,
+		HasMore:    len(questions) < total,
+		Total:      total,
+	}
+	return output, nil
+}
+
+func (r *QuestionRepository) RetrieveAnswers(input types.RetrieveAnswersInput) (types.PaginatedDataOutput, error){
+	db := r.db
+	// prepare query, an empty status matches the answers that are shown
+	filter := `((? = '' AND a.Status != ?) OR a.Status = ?)`
+	query := `SELECT ` + answerColumns + `,
+		(SELECT COUNT(*) FROM ProductAnswer a WHERE ` + filter + `) AS total
+		FROM ` + answerTables + `
+		WHERE ` + filter + ` AND (? = '' OR a.ID < ?)
+		ORDER BY a.ID DESC
+		LIMIT ?`
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	// prepare the statement
+	output := types.PaginatedDataOutput{}
+	stmt, err := db.PrepareContext(ctx, query)
+	if err !=nil {
+		return output, err
+	}
+	defer stmt.Close() //close the statement after use
+	// execute the statement
+	filterArgs := []interface{}{input.Status, constants.QuestionStatuses.Hidden, input.Status}
+	args := append(append(append([]interface{}{}, filterArgs...), filterArgs...), input.Pagination.NextCursor, input.Pagination.NextCursor, utils.Ternary(input.Pagination.PageSize == 0, constants.DefaultPageSize, input.Pagination.PageSize))
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err !=nil {
+		return output, err
+	}
+	defer rows.Close()
+	answers := []models.ProductAnswer{}
+	total := 0
+	for rows.Next() {
+		answer, err := scanAnswer(rows, &total)
+		if err !=nil {
+			return output, err
+		}
+		answers = append(answers, answer)
+	}
+	if err = rows.Err(); err !=nil {
+		return output, err
+	}
+	lastItemId := ""
+	// select last item in the list
+	if len(answers) > 0 {
+		lastItemId = answers[len(answers)-1].ID
+	}
+
+	output = types.PaginatedDataOutput{
+		Data: answers,
+		NextCursor: lastItemId,
+		HasMore:    len(answers) < total,
+		Total:      total,
+	}
+	return output, nil
+}
+
+func (r *QuestionRepository) ReportQuestion(id string) error{
+	return r.report("ProductQuestion", id, constants.ErrQuestionNotFound)
+}
+
+func (r *QuestionRepository) ReportAnswer(id string) error{
+	return r.report("ProductAnswer", id, constants.ErrAnswerNotFound)
+}
+
+func (r *QuestionRepository) ModerateQuestion(id string, input types.ModerateQuestionInput) (models.ProductQuestion, error){
+	if err := r.moderate("ProductQuestion", id, input, constants.ErrQuestionNotFound); err !=nil {
+		return models.ProductQuestion{}, err
+	}
+	return r.RetrieveQuestion(id)
+}
+
+func (r *QuestionRepository) ModerateAnswer(id string, input types.ModerateQuestionInput) (models.ProductAnswer, error){
+	if err := r.moderate("ProductAnswer", id, input, constants.ErrAnswerNotFound); err !=nil {
+		return models.ProductAnswer{}, err
+	}
+	return r.retrieveAnswer(id)
+}
+
+// private
+func (r *QuestionRepository) retrieveAnswer(id string) (models.ProductAnswer, error){
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	answer, err := scanAnswer(r.db.QueryRowContext(ctx, `SELECT `+answerColumns+` FROM `+answerTables+` WHERE a.ID = ?`, id))
+	if err == sql.ErrNoRows {
+		return answer, constants.ErrAnswerNotFound
+	}
+	return answer, err
+}
+
+// the seller's answers come first, then the others in the order they were given
+func (r *QuestionRepository) retrieveQuestionAnswers(ctx context.Context, questionID string, includeHidden bool) ([]models.ProductAnswer, error){
+	query := `SELECT ` + answerColumns + ` FROM ` + answerTables + `
+		WHERE a.QuestionID = ? AND (? OR a.Status != ?)
+		ORDER BY a.AuthorRole = ? DESC, a.ID ASC`
+	answers := []models.ProductAnswer{}
+	rows, err := r.db.QueryContext(ctx, query, questionID, includeHidden, constants.QuestionStatuses.Hidden, constants.AnswerAuthorRoles.Seller)
+	if err !=nil {
+		return answers, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		answer, err := scanAnswer(rows)
+		if err !=nil {
+			return answers, err
+		}
+		answers = append(answers, answer)
+	}
+	return answers, rows.Err()
+}
+
+// reports of something that has already been flagged or moderated change nothing, table is never user input
+func (r *QuestionRepository) report(table string, id string, notFound error) error{
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	status := ""
+	err := r.db.QueryRowContext(ctx, `SELECT Status FROM `+table+` WHERE ID = ?`, id).Scan(&status)
+	if err == sql.ErrNoRows || status == constants.QuestionStatuses.Hidden {
+		return notFound
+	}
+	if err !=nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, `UPDATE `+table+` SET Status = ? WHERE ID = ? AND Status = ?`, constants.QuestionStatuses.Flagged, id, constants.QuestionStatuses.Published)
+	return err
+}
+
+// table is never user input
+func (r *QuestionRepository) moderate(table string, id string, input types.ModerateQuestionInput, notFound error) error{
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	exists := 0
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM `+table+` WHERE ID = ?`, id).Scan(&exists); err !=nil {
+		return err
+	}
+	if exists == 0 {
+		return notFound
+	}
+	_, err := r.db.ExecContext(ctx, `UPDATE `+table+` SET Status = ?, ModerationReason = NULLIF(?, '') WHERE ID = ?`, input.Status, input.Reason, id)
+	return err
+}
+
+type questionScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanQuestion(row questionScanner, extra ...interface{}) (models.ProductQuestion, error){
+	question := models.ProductQuestion{Answers: []models.ProductAnswer{}}
+	dest := []interface{}{&question.ID, &question.ProductID, &question.CustomerID, &question.AskerName, &question.AskerUserID, &question.AskerEmail, &question.Question, &question.Status, &question.ModerationReason, &question.CreatedAt, &question.UpdatedAt}
+	err := row.Scan(append(dest, extra...)...)
+	return question, err
+}
+
+func scanAnswer(row questionScanner, extra ...interface{}) (models.ProductAnswer, error){
+	answer := models.ProductAnswer{}
+	dest := []interface{}{&answer.ID, &answer.QuestionID, &answer.UserID, &answer.AuthorName, &answer.AuthorRole, &answer.Answer, &answer.Status, &answer.ModerationReason, &answer.CreatedAt, &answer.UpdatedAt}
+	err := row.Scan(append(dest, extra...)...)
+	return answer, err
+}
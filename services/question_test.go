@@ -0,0 +1,56 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/models"
+	"github.com/kaasikodes/e-commerce-go/types"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestAnswerQuestion_NotAllowed(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// a customer who has not bought the product, and is not its seller
+	user := models.User{ID: "user-1", Customer: &models.Customer{ID: "customer-1"}}
+	mock.ExpectBegin()
+	mock.ExpectQuery("FROM ProductQuestion q JOIN Product p").WithArgs("question-1").
+		WillReturnRows(sqlmock.NewRows([]string{"ProductID", "OwnerID", "Status"}).AddRow("product-1", "seller-1", constants.QuestionStatuses.Published))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM OrderItem").WithArgs("customer-1", "product-1", constants.SubOrderStatuses.Cancelled).WillReturnRows(sqlmock.NewRows([]string{"COUNT"}).AddRow(0))
+	mock.ExpectRollback()
+
+	_, err = NewQuestionRepository(db).AnswerQuestion("question-1", user, types.AnswerQuestionInput{Answer: "It fits"})
+	if err != constants.ErrNotAllowedToAnswer {
+		t.Errorf("error = %v, want %v", err, constants.ErrNotAllowedToAnswer)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAnswerQuestion_HiddenQuestion(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	user := models.User{ID: "user-2", Seller: &models.Seller{ID: "seller-1"}}
+	mock.ExpectBegin()
+	mock.ExpectQuery("FROM ProductQuestion q JOIN Product p").WithArgs("question-1").
+		WillReturnRows(sqlmock.NewRows([]string{"ProductID", "OwnerID", "Status"}).AddRow("product-1", "seller-1", constants.QuestionStatuses.Hidden))
+	mock.ExpectRollback()
+
+	_, err = NewQuestionRepository(db).AnswerQuestion("question-1", user, types.AnswerQuestionInput{Answer: "It fits"})
+	if err != constants.ErrQuestionNotFound {
+		t.Errorf("error = %v, want %v", err, constants.ErrQuestionNotFound)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,225 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"math"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/models"
+	"github.com/kaasikodes/e-commerce-go/types"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+type RefundRepository struct {
+	db *sql.DB
+}
+
+func NewRefundRepository(db *sql.DB) *RefundRepository {
+	return &RefundRepository{
+		db: db,
+	}
+}
+
+func (r *RefundRepository) RetrieveRefunds(input types.RetrieveRefundsInput) (types.PaginatedDataOutput, error){
+	db := r.db
+	// prepare query, an empty status matches every status
+	query := `SELECT ` + refundColumns + `,
+		(SELECT COUNT(*) FROM Refund WHERE (? = '' OR Status = ?)) AS total
+		FROM Refund
+		WHERE (? = '' OR Status = ?) AND ID > ?
+		ORDER BY ID ASC
+		LIMIT ?`
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	// prepare the statement
+	output := types.PaginatedDataOutput{}
+	stmt, err := db.PrepareContext(ctx, query)
+	if err !=nil {
+		return output, err
+	}
+	defer stmt.Close() //close the statement after use
+	// execute the statement
+	rows, err := stmt.QueryContext(ctx, input.Status, input.Status, input.Status, input.Status, input.Pagination.NextCursor, utils.Ternary(input.Pagination.PageSize == 0, constants.DefaultPageSize, input.Pagination.PageSize))
+	if err !=nil {
+		return output, err
+	}
+	defer rows.Close()
+	refunds := []models.Refund{}
+	total := 0
+	for rows.Next() {
+		refund, err := scanRefund(rows, &total)
+		if err !=nil {
+			return output, err
+		}
+		refunds = append(refunds, refund)
+	}
+	if err = rows.Err(); err !=nil {
+		return output, err
+	}
+	lastItemId := ""
+	// select last item in the list
+	if len(refunds) > 0 {
+		lastItemId = refunds[len(refunds)-1].ID
+	}
+
+	output = types.PaginatedDataOutput{
+		Data: refunds,
+		NextCursor: lastItemId,
+		HasMore:    len(refunds) < total,
+		Total:      total,
+	}
+	return output, nil
+}
+
+func (r *RefundRepository) RetryRefund(id string) (models.Refund, error){
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	refund, err := scanRefund(r.db.QueryRowContext(ctx, `SELECT `+refundColumns+` FROM Refund WHERE ID = ?`, id))
+	if err == sql.ErrNoRows {
+		return refund, constants.ErrRefundNotFound
+	}
+	if err !=nil {
+		return refund, err
+	}
+	res, err := r.db.ExecContext(ctx, `UPDATE Refund SET Status = ?, FailureReason = NULL WHERE ID = ? AND Status = ?`, constants.RefundStatuses.Pending, id, constants.RefundStatuses.Failed)
+	if err !=nil {
+		return refund, err
+	}
+	count, err := res.RowsAffected()
+	if err !=nil {
+		return refund, err
+	}
+	if count == 0 {
+		return refund, constants.ErrRefundNotFailed
+	}
+	refund.Status, refund.FailureReason = constants.RefundStatuses.Pending, ""
+	return refund, nil
+}
+
+func (r *RefundRepository) ClaimPendingRefunds() ([]models.Refund, error){
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err !=nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	rows, err := tx.QueryContext(ctx, `SELECT `+refundColumns+` FROM Refund WHERE Status = ? ORDER BY CreatedAt ASC FOR UPDATE`, constants.RefundStatuses.Pending)
+	if err !=nil {
+		return nil, err
+	}
+	refunds := []models.Refund{}
+	for rows.Next() {
+		refund, err := scanRefund(rows)
+		if err !=nil {
+			rows.Close()
+			return nil, err
+		}
+		refunds = append(refunds, refund)
+	}
+	rows.Close()
+	if err = rows.Err(); err !=nil {
+		return nil, err
+	}
+	for i := range refunds {
+		if _, err = tx.ExecContext(ctx, `UPDATE Refund SET Status = ? WHERE ID = ?`, constants.RefundStatuses.Processing, refunds[i].ID); err !=nil {
+			return nil, err
+		}
+		refunds[i].Status = constants.RefundStatuses.Processing
+	}
+	return refunds, tx.Commit()
+}
+
+// complete a refund that was sent to the payment gateway, it failed when there is a failure reason
+func (r *RefundRepository) CompleteRefund(id string, gatewayReference string, failureReason string) error{
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	status := constants.RefundStatuses.Processed
+	if failureReason != "" {
+		status = constants.RefundStatuses.Failed
+	}
+	_, err := r.db.ExecContext(ctx, `UPDATE Refund SET Status = ?, GatewayReference = NULLIF(?, ''), FailureReason = NULLIF(?, '') WHERE ID = ? AND Status = ?`,
+		status, gatewayReference, failureReason, id, constants.RefundStatuses.Processing)
+	return err
+}
+
+func (r *RefundRepository) RefundCancelledPayment(paymentID string) error{
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err !=nil {
+		return err
+	}
+	defer tx.Rollback()
+	var orderID, status string
+	var paid bool
+	var total float64
+	query := "SELECT o.ID, o.Status, o.TotalAmount, p.Paid FROM Payment p JOIN `Order` o ON o.ID = p.OrderID WHERE p.ID = ? FOR UPDATE"
+	err = tx.QueryRowContext(ctx, query, paymentID).Scan(&orderID, &status, &total, &paid)
+	if err == sql.ErrNoRows || (err == nil && (!paid || status != constants.OrderStatuses.Cancelled)) {
+		return nil
+	}
+	if err !=nil {
+		return err
+	}
+	// whatever was cancelled after the payment came in has been refunded already
+	var refunded int64
+	if err = tx.QueryRowContext(ctx, `SELECT COALESCE(SUM(Amount), 0) FROM Refund WHERE PaymentID = ?`, paymentID).Scan(&refunded); err !=nil {
+		return err
+	}
+	if _, err = recordRefund(ctx, tx, paymentID, orderID, "", "", int64(math.Round(total * 100)) - refunded); err !=nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// private
+func (r *RefundRepository) retrieveOrderRefunds(orderID string) ([]models.Refund, error){
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	rows, err := r.db.QueryContext(ctx, `SELECT `+refundColumns+` FROM Refund WHERE OrderID = ? ORDER BY CreatedAt ASC`, orderID)
+	if err !=nil {
+		return nil, err
+	}
+	defer rows.Close()
+	refunds := []models.Refund{}
+	for rows.Next() {
+		refund, err := scanRefund(rows)
+		if err !=nil {
+			return nil, err
+		}
+		refunds = append(refunds, refund)
+	}
+	return refunds, rows.Err()
+}
+
+// recordRefund records a refund in tx for the payment gateway to send and returns its id, nothing is recorded for an amount of zero
+func recordRefund(ctx context.Context, tx *sql.Tx, paymentID string, orderID string, subOrderID string, returnID string, amount int64) (string, error) {
+	if amount <= 0 {
+		return "", nil
+	}
+	id := utils.NewID()
+	_, err := tx.ExecContext(ctx, `INSERT INTO Refund (ID, PaymentID, OrderID, SubOrderID, ReturnID, Amount, Status) VALUES (?, ?, ?, NULLIF(?, ''), NULLIF(?, ''), ?, ?)`,
+		id, paymentID, orderID, subOrderID, returnID, amount, constants.RefundStatuses.Pending)
+	return id, err
+}
+
+const refundColumns = `ID, PaymentID, OrderID, COALESCE(SubOrderID, ''), COALESCE(ReturnID, ''), Amount, Status, COALESCE(GatewayReference, ''), COALESCE(FailureReason, ''), CreatedAt, UpdatedAt`
+
+type refundScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanRefund(row refundScanner, extra ...interface{}) (models.Refund, error) {
+	refund := models.Refund{}
+	dest := []interface{}{&refund.ID, &refund.PaymentID, &refund.OrderID, &refund.SubOrderID, &refund.ReturnID, &refund.Amount, &refund.Status, &refund.GatewayReference, &refund.FailureReason, &refund.CreatedAt, &refund.UpdatedAt}
+	err := row.Scan(append(dest, extra...)...)
+	return refund, err
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/kaasikodes/e-commerce-go/models"
+)
+
+// PaystackRefundGateway refunds payments through paystack, back to however the customer paid
+type PaystackRefundGateway struct {
+	paystackClient
+}
+
+func NewPaystackRefundGateway(secretKey string, httpClient *http.Client) *PaystackRefundGateway {
+	return &PaystackRefundGateway{
+		paystackClient: newPaystackClient(secretKey, httpClient),
+	}
+}
+
+// Refund refunds part of a payment, the payment id is the reference of its paystack transaction
+func (g *PaystackRefundGateway) Refund(refund models.Refund) (string, error) {
+	res, err := g.post("/refund", map[string]interface{}{
+		"transaction": refund.PaymentID,
+		"amount":      refund.Amount,
+	})
+	if err != nil {
+		return "", err
+	}
+	reference := strconv.FormatInt(res.Data.ID, 10)
+	if res.Data.Status == "failed" {
+		return reference, fmt.Errorf("paystack refund %s", res.Data.Status)
+	}
+	return reference, nil
+}
@@ -0,0 +1,459 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"time"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/models"
+	"github.com/kaasikodes/e-commerce-go/types"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+type ReturnRepository struct {
+	db *sql.DB
+}
+
+func NewReturnRepository(db *sql.DB) *ReturnRepository {
+	return &ReturnRepository{
+		db: db,
+	}
+}
+
+func (r *ReturnRepository) CreateReturn(customerID string, input types.CreateReturnInput) (models.ReturnRequest, error){
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err !=nil {
+		return models.ReturnRequest{}, err
+	}
+	defer tx.Rollback()
+	// lock the item so two returns can't both take what is left of it, it was delivered when its last shipment was
+	query := "SELECT oi.OrderID, oi.ProductID, oi.Quantity, s.ID, s.SellerID, s.Status, p.Paid, " +
+		"COALESCE((SELECT MAX(sh.DeliveredAt) FROM Shipment sh WHERE sh.SubOrderID = s.ID), s.UpdatedAt) " +
+		"FROM OrderItem oi JOIN `Order` o ON o.ID = oi.OrderID JOIN Product pr ON pr.ID = oi.ProductID " +
+		"JOIN SubOrder s ON s.OrderID = oi.OrderID AND s.SellerID = pr.OwnerID JOIN Payment p ON p.OrderID = oi.OrderID " +
+		"WHERE oi.ID = ? AND o.CustomerID = ? FOR UPDATE"
+	ret := models.ReturnRequest{OrderItemID: input.OrderItemID, CustomerID: customerID}
+	var ordered int
+	var status string
+	var paid bool
+	var deliveredAt time.Time
+	err = tx.QueryRowContext(ctx, query, input.OrderItemID, customerID).Scan(&ret.OrderID, &ret.ProductID, &ordered, &ret.SubOrderID, &ret.SellerID, &status, &paid, &deliveredAt)
+	if err == sql.ErrNoRows {
+		return ret, constants.ErrOrderItemNotFound
+	}
+	if err !=nil {
+		return ret, err
+	}
+	if status != constants.SubOrderStatuses.Delivered || !paid || time.Since(deliveredAt) > constants.ReturnWindow {
+		return ret, constants.ErrNotReturnable
+	}
+	// rejected returns don't count, the item can be returned again
+	returned := 0
+	query = `SELECT COALESCE(SUM(Quantity), 0) FROM ReturnRequest WHERE OrderItemID = ? AND Status != ?`
+	if err = tx.QueryRowContext(ctx, query, input.OrderItemID, constants.ReturnStatuses.Rejected).Scan(&returned); err !=nil {
+		return ret, err
+	}
+	if returned + input.Quantity > ordered {
+		return ret, constants.ErrReturnQuantityExceeded
+	}
+	ret.ID = utils.NewID()
+	query = `INSERT INTO ReturnRequest (ID, OrderID, OrderItemID, SubOrderID, CustomerID, SellerID, ProductID, Quantity, Reason, Details, PreferredResolution, Status)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''), ?, ?)`
+	_, err = tx.ExecContext(ctx, query, ret.ID, ret.OrderID, ret.OrderItemID, ret.SubOrderID, ret.CustomerID, ret.SellerID, ret.ProductID,
+		input.Quantity, input.Reason, input.Details, input.PreferredResolution, constants.ReturnStatuses.Requested)
+	if err !=nil {
+		return ret, err
+	}
+	if err = tx.Commit(); err !=nil {
+		return ret, err
+	}
+
+	return r.RetrieveReturn(ret.ID)
+}
+
+func (r *ReturnRepository) AddReturnPhoto(id string, customerID string, input types.AddReturnPhotoInput) (models.ReturnPhoto, error){
+	photo := models.ReturnPhoto{}
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err !=nil {
+		return photo, err
+	}
+	defer tx.Rollback()
+	status := ""
+	err = tx.QueryRowContext(ctx, `SELECT Status FROM ReturnRequest WHERE ID = ? AND CustomerID = ? FOR UPDATE`, id, customerID).Scan(&status)
+	if err == sql.ErrNoRows {
+		return photo, constants.ErrReturnNotFound
+	}
+	if err !=nil {
+		return photo, err
+	}
+	if status != constants.ReturnStatuses.Requested {
+		return photo, constants.ErrInvalidReturnStatus
+	}
+	photos := 0
+	if err = tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM ReturnPhoto WHERE ReturnID = ?`, id).Scan(&photos); err !=nil {
+		return photo, err
+	}
+	if photos >= constants.MaxReturnPhotos {
+		return photo, constants.ErrTooManyReturnPhotos
+	}
+	photoID := utils.NewID()
+	if _, err = tx.ExecContext(ctx, `INSERT INTO ReturnPhoto (ID, ReturnID, FileUrl, ContentType) VALUES (?, ?, ?, ?)`, photoID, id, input.FileUrl, input.ContentType); err !=nil {
+		return photo, err
+	}
+	if err = tx.Commit(); err !=nil {
+		return photo, err
+	}
+
+	photo = models.ReturnPhoto{ID: photoID, ReturnID: id, FileUrl: input.FileUrl, ContentType: input.ContentType, CreatedAt: time.Now()}
+	return photo, nil
+}
+
+func (r *ReturnRepository) RetrieveReturn(id string) (models.ReturnRequest, error){
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	ret, err := scanReturn(r.db.QueryRowContext(ctx, `SELECT `+returnColumns+` FROM ReturnRequest WHERE ID = ?`, id))
+	if err == sql.ErrNoRows {
+		return ret, constants.ErrReturnNotFound
+	}
+	if err !=nil {
+		return ret, err
+	}
+	ret.Photos, err = r.retrieveReturnPhotos(ctx, id)
+	return ret, err
+}
+
+func (r *ReturnRepository) RetrieveReturnPhoto(returnID string, id string) (models.ReturnPhoto, error){
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	photo := models.ReturnPhoto{}
+	query := `SELECT ID, ReturnID, FileUrl, ContentType, CreatedAt FROM ReturnPhoto WHERE ReturnID = ? AND ID = ?`
+	err := r.db.QueryRowContext(ctx, query, returnID, id).Scan(&photo.ID, &photo.ReturnID, &photo.FileUrl, &photo.ContentType, &photo.CreatedAt)
+	if err == sql.ErrNoRows {
+		return photo, constants.ErrReturnPhotoNotFound
+	}
+	return photo, err
+}
+
+func (r *ReturnRepository) RetrieveReturns(input types.RetrieveReturnsInput) (types.PaginatedDataOutput, error){
+	db := r.db
+	// prepare query, an empty status, customer or seller matches every one of them
+	filter := `(? = '' OR Status = ?) AND (? = '' OR CustomerID = ?) AND (? = '' OR SellerID = ?)`
+	query := `SELECT ` + returnColumns + `,
+		(SELECT COUNT(*) FROM ReturnRequest WHERE ` + filter + `) AS total
+		FROM ReturnRequest
+		WHERE ` + filter + ` AND ID > ?
+		ORDER BY ID ASC
+		LIMIT ?`
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	// prepare the statement
+	output := types.PaginatedDataOutput{}
+	stmt, err := db.PrepareContext(ctx, query)
+	if err !=nil {
+		return output, err
+	}
+	defer stmt.Close() //close the statement after use
+	// execute the statement
+	filterArgs := []interface{}{input.Status, input.Status, input.CustomerID, input.CustomerID, input.SellerID, input.SellerID}
+	args := append(append(append([]interface{}{}, filterArgs...), filterArgs...), input.Pagination.NextCursor, utils.Ternary(input.Pagination.PageSize == 0, constants.DefaultPageSize, input.Pagination.PageSize))
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err !=nil {
+		return output, err
+	}
+	defer rows.Close()
+	returns := []models.ReturnRequest{}
+	total := 0
+	for rows.Next() {
+		ret, err := scanReturn(rows, &total)
+		if err !=nil {
+			return output, err
+		}
+		returns = append(returns, ret)
+	}
+	if err = rows.Err(); err !=nil {
+		return output, err
+	}
+	lastItemId := ""
+	// select last item in the list
+	if len(returns) > 0 {
+		lastItemId = returns[len(returns)-1].ID
+	}
+
+	output = types.PaginatedDataOutput{
+		Data: returns,
+		NextCursor: lastItemId,
+		HasMore:    len(returns) < total,
+		Total:      total,
+	}
+	return output, nil
+}
+
+func (r *ReturnRepository) ApproveReturn(id string, sellerID string, input types.ApproveReturnInput) (models.ReturnRequest, error){
+	query := `UPDATE ReturnRequest SET Status = ?, Instructions = ?, LabelUrl = NULLIF(?, ''), ApprovedAt = NOW() WHERE ID = ?`
+	return r.moveReturn(id, sellerID, constants.ReturnStatuses.Requested, func(ctx context.Context, tx *sql.Tx, ret models.ReturnRequest) error {
+		_, err := tx.ExecContext(ctx, query, constants.ReturnStatuses.Approved, input.Instructions, input.LabelUrl, id)
+		return err
+	})
+}
+
+func (r *ReturnRepository) RejectReturn(id string, sellerID string, input types.RejectReturnInput) (models.ReturnRequest, error){
+	query := `UPDATE ReturnRequest SET Status = ?, RejectionReason = ? WHERE ID = ?`
+	return r.moveReturn(id, sellerID, constants.ReturnStatuses.Requested, func(ctx context.Context, tx *sql.Tx, ret models.ReturnRequest) error {
+		_, err := tx.ExecContext(ctx, query, constants.ReturnStatuses.Rejected, input.Reason, id)
+		return err
+	})
+}
+
+func (r *ReturnRepository) ReceiveReturn(id string, sellerID string, input types.ReceiveReturnInput) (models.ReturnRequest, error){
+	return r.moveReturn(id, sellerID, constants.ReturnStatuses.Approved, func(ctx context.Context, tx *sql.Tx, ret models.ReturnRequest) error {
+		if input.Outcome == "rejected" {
+			query := `UPDATE ReturnRequest SET Status = ?, InspectionNotes = ?, RejectionReason = ?, ReceivedAt = NOW() WHERE ID = ?`
+			_, err := tx.ExecContext(ctx, query, constants.ReturnStatuses.Rejected, input.Notes, input.Notes, id)
+			return err
+		}
+		if input.Restock {
+			if _, err := tx.ExecContext(ctx, `UPDATE Product SET Quantity = Quantity + ? WHERE ID = ?`, ret.Quantity, ret.ProductID); err !=nil {
+				return err
+			}
+			if err := recordStockMovement(ctx, tx, ret.ProductID, ret.Quantity, constants.StockMovementReasons.Return, constants.StockMovementActors.Seller, ret.SellerID, ret.ID); err !=nil {
+				return err
+			}
+		}
+		query := `UPDATE ReturnRequest SET Status = ?, InspectionNotes = NULLIF(?, ''), Restocked = ?, ReceivedAt = NOW() WHERE ID = ?`
+		_, err := tx.ExecContext(ctx, query, constants.ReturnStatuses.Received, input.Notes, input.Restock, id)
+		return err
+	})
+}
+
+func (r *ReturnRepository) ResolveReturn(id string, sellerID string, input types.ResolveReturnInput) (models.ReturnRequest, error){
+	return r.moveReturn(id, sellerID, constants.ReturnStatuses.Received, func(ctx context.Context, tx *sql.Tx, ret models.ReturnRequest) error {
+		var amount int64
+		var refundID, replacementOrderID string
+		var err error
+		switch input.Resolution {
+		case constants.ReturnResolutions.Replacement:
+			replacementOrderID, err = createReplacementOrder(ctx, tx, ret)
+		case constants.ReturnResolutions.Refund:
+			if amount, err = returnAmount(ctx, tx, ret); err !=nil {
+				return err
+			}
+			if err = reverseReturnedSale(ctx, tx, ret.OrderItemID, ret.ID, amount, constants.LedgerAccounts.GatewayClearing, "Refund of returned item"); err !=nil {
+				return err
+			}
+			paymentID := ""
+			if err = tx.QueryRowContext(ctx, `SELECT ID FROM Payment WHERE OrderID = ?`, ret.OrderID).Scan(&paymentID); err !=nil {
+				return err
+			}
+			if refundID, err = recordRefund(ctx, tx, paymentID, ret.OrderID, ret.SubOrderID, ret.ID, amount); err !=nil {
+				return err
+			}
+			err = issueCreditNote(ctx, tx, ret.SubOrderID, refundID, ret.ID, "Refund of returned item", amount)
+		case constants.ReturnResolutions.StoreCredit:
+			if amount, err = returnAmount(ctx, tx, ret); err !=nil {
+				return err
+			}
+			if err = reverseReturnedSale(ctx, tx, ret.OrderItemID, ret.ID, amount, constants.LedgerAccounts.CustomerStoreCredit, "Store credit for returned item"); err !=nil {
+				return err
+			}
+			creditID := utils.NewID()
+			_, err = tx.ExecContext(ctx, `INSERT INTO StoreCredit (ID, CustomerID, ReturnID, Amount, Description) VALUES (?, ?, ?, ?, ?)`,
+				creditID, ret.CustomerID, ret.ID, amount, "Returned item of order "+ret.OrderID)
+			if err !=nil {
+				return err
+			}
+			err = issueCreditNote(ctx, tx, ret.SubOrderID, "", ret.ID, "Store credit for returned item", amount)
+		}
+		if err !=nil {
+			return err
+		}
+		query := `UPDATE ReturnRequest SET Status = ?, Resolution = ?, Amount = ?, RefundID = NULLIF(?, ''), ReplacementOrderID = NULLIF(?, ''), ResolvedAt = NOW() WHERE ID = ?`
+		_, err = tx.ExecContext(ctx, query, constants.ReturnStatuses.Resolved, input.Resolution, amount, refundID, replacementOrderID, id)
+		return err
+	})
+}
+
+func (r *ReturnRepository) RetrieveStoreCredit(customerID string) (models.StoreCredit, error){
+	credit := models.StoreCredit{Entries: []models.StoreCreditEntry{}}
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	query := `SELECT ID, CustomerID, COALESCE(ReturnID, ''), Amount, Description, CreatedAt FROM StoreCredit WHERE CustomerID = ? ORDER BY CreatedAt ASC`
+	rows, err := r.db.QueryContext(ctx, query, customerID)
+	if err !=nil {
+		return credit, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		entry := models.StoreCreditEntry{}
+		if err = rows.Scan(&entry.ID, &entry.CustomerID, &entry.ReturnID, &entry.Amount, &entry.Description, &entry.CreatedAt); err !=nil {
+			return credit, err
+		}
+		credit.Balance += entry.Amount
+		credit.Entries = append(credit.Entries, entry)
+	}
+	return credit, rows.Err()
+}
+
+// private
+// moveReturn locks a return of the seller and, when it has the from status, moves it on with update
+func (r *ReturnRepository) moveReturn(id string, sellerID string, from string, update func(ctx context.Context, tx *sql.Tx, ret models.ReturnRequest) error) (models.ReturnRequest, error){
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err !=nil {
+		return models.ReturnRequest{}, err
+	}
+	defer tx.Rollback()
+	ret, err := scanReturn(tx.QueryRowContext(ctx, `SELECT `+returnColumns+` FROM ReturnRequest WHERE ID = ? AND SellerID = ? FOR UPDATE`, id, sellerID))
+	if err == sql.ErrNoRows {
+		return ret, constants.ErrReturnNotFound
+	}
+	if err !=nil {
+		return ret, err
+	}
+	if ret.Status != from {
+		return ret, constants.ErrInvalidReturnStatus
+	}
+	if err = update(ctx, tx, ret); err !=nil {
+		return ret, err
+	}
+	if err = tx.Commit(); err !=nil {
+		return ret, err
+	}
+
+	return r.RetrieveReturn(id)
+}
+
+func (r *ReturnRepository) retrieveReturnPhotos(ctx context.Context, returnID string) ([]models.ReturnPhoto, error){
+	photos := []models.ReturnPhoto{}
+	rows, err := r.db.QueryContext(ctx, `SELECT ID, ReturnID, FileUrl, ContentType, CreatedAt FROM ReturnPhoto WHERE ReturnID = ? ORDER BY CreatedAt`, returnID)
+	if err !=nil {
+		return photos, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		photo := models.ReturnPhoto{}
+		if err = rows.Scan(&photo.ID, &photo.ReturnID, &photo.FileUrl, &photo.ContentType, &photo.CreatedAt); err !=nil {
+			return photos, err
+		}
+		photos = append(photos, photo)
+	}
+	return photos, rows.Err()
+}
+
+// retrieve the returns of an order, without their photos
+func (r *ReturnRepository) retrieveOrderReturns(orderID string) ([]models.ReturnRequest, error){
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	rows, err := r.db.QueryContext(ctx, `SELECT `+returnColumns+` FROM ReturnRequest WHERE OrderID = ? ORDER BY CreatedAt ASC`, orderID)
+	if err !=nil {
+		return nil, err
+	}
+	defer rows.Close()
+	returns := []models.ReturnRequest{}
+	for rows.Next() {
+		ret, err := scanReturn(rows)
+		if err !=nil {
+			return nil, err
+		}
+		returns = append(returns, ret)
+	}
+	return returns, rows.Err()
+}
+
+// returnAmount is what the customer paid for the returned units of the item, in kobo
+func returnAmount(ctx context.Context, tx *sql.Tx, ret models.ReturnRequest) (int64, error) {
+	var ordered int64
+	var totalPrice float64
+	if err := tx.QueryRowContext(ctx, `SELECT Quantity, TotalPrice FROM OrderItem WHERE ID = ?`, ret.OrderItemID).Scan(&ordered, &totalPrice); err != nil {
+		return 0, err
+	}
+	if ordered == 0 {
+		return 0, nil
+	}
+	return int64(math.Round(totalPrice * 100)) * int64(ret.Quantity) / ordered, nil
+}
+
+// createReplacementOrder places an order in tx for the returned units of the item, paid for by the return and delivered where the original order was.
+// The seller fulfils it like any other order
+func createReplacementOrder(ctx context.Context, tx *sql.Tx, ret models.ReturnRequest) (string, error) {
+	res, err := tx.ExecContext(ctx, `UPDATE Product SET Quantity = Quantity - ? WHERE ID = ? AND Quantity >= ?`, ret.Quantity, ret.ProductID, ret.Quantity)
+	if err != nil {
+		return "", err
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return "", err
+	}
+	if count == 0 {
+		return "", constants.ErrReplacementOutOfStock
+	}
+	if err = recordStockMovement(ctx, tx, ret.ProductID, -ret.Quantity, constants.StockMovementReasons.Replacement, constants.StockMovementActors.Seller, ret.SellerID, ret.ID); err != nil {
+		return "", err
+	}
+	addressID := ""
+	if err = tx.QueryRowContext(ctx, "SELECT DeliveryAddressID FROM `Order` WHERE ID = ?", ret.OrderID).Scan(&addressID); err != nil {
+		return "", err
+	}
+	orderNumber, err := nextOrderNumber(ctx, tx)
+	if err != nil {
+		return "", err
+	}
+	orderID := utils.NewID()
+	orderItemID := utils.NewID()
+	subOrderID := utils.NewID()
+	paymentID := utils.NewID()
+	if _, err = tx.ExecContext(ctx, "INSERT INTO `Order` (ID, Number, CustomerID, TotalAmount, DeliveryAddressID) VALUES (?, ?, ?, 0, ?)", orderID, orderNumber, ret.CustomerID, addressID); err != nil {
+		return "", err
+	}
+	if _, err = tx.ExecContext(ctx, `INSERT INTO OrderItem (ID, OrderID, ProductID, Quantity, TotalPrice) VALUES (?, ?, ?, ?, 0)`, orderItemID, orderID, ret.ProductID, ret.Quantity); err != nil {
+		return "", err
+	}
+	if _, err = tx.ExecContext(ctx, `INSERT INTO SubOrder (ID, OrderID, SellerID, Status, Subtotal) VALUES (?, ?, ?, ?, 0)`, subOrderID, orderID, ret.SellerID, constants.SubOrderStatuses.Pending); err != nil {
+		return "", err
+	}
+	if _, err = tx.ExecContext(ctx, `INSERT INTO Payment (ID, OrderID, Amount, Paid, Method) VALUES (?, ?, 0, true, ?)`, paymentID, orderID, constants.ReplacementPaymentMethod); err != nil {
+		return "", err
+	}
+	return orderID, nil
+}
+
+const returnColumns = `ID, OrderID, OrderItemID, SubOrderID, CustomerID, SellerID, ProductID, Quantity, Reason, COALESCE(Details, ''), PreferredResolution, Status,
+	COALESCE(Instructions, ''), COALESCE(LabelUrl, ''), COALESCE(RejectionReason, ''), COALESCE(InspectionNotes, ''), Restocked, COALESCE(Resolution, ''), Amount,
+	COALESCE(RefundID, ''), COALESCE(ReplacementOrderID, ''), ApprovedAt, ReceivedAt, ResolvedAt, CreatedAt, UpdatedAt`
+
+type returnScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanReturn(row returnScanner, extra ...interface{}) (models.ReturnRequest, error) {
+	ret := models.ReturnRequest{}
+	var approvedAt, receivedAt, resolvedAt sql.NullTime
+	dest := []interface{}{&ret.ID, &ret.OrderID, &ret.OrderItemID, &ret.SubOrderID, &ret.CustomerID, &ret.SellerID, &ret.ProductID, &ret.Quantity, &ret.Reason, &ret.Details, &ret.PreferredResolution, &ret.Status,
+		&ret.Instructions, &ret.LabelUrl, &ret.RejectionReason, &ret.InspectionNotes, &ret.Restocked, &ret.Resolution, &ret.Amount,
+		&ret.RefundID, &ret.ReplacementOrderID, &approvedAt, &receivedAt, &resolvedAt, &ret.CreatedAt, &ret.UpdatedAt}
+	err := row.Scan(append(dest, extra...)...)
+	if approvedAt.Valid {
+		ret.ApprovedAt = &approvedAt.Time
+	}
+	if receivedAt.Valid {
+		ret.ReceivedAt = &receivedAt.Time
+	}
+	if resolvedAt.Valid {
+		ret.ResolvedAt = &resolvedAt.Time
+	}
+	return ret, err
+}
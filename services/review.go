@@ -0,0 +1,348 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"time"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/models"
+	"github.com/kaasikodes/e-commerce-go/types"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+type ReviewRepository struct {
+	db *sql.DB
+}
+
+func NewReviewRepository(db *sql.DB) *ReviewRepository {
+	return &ReviewRepository{
+		db: db,
+	}
+}
+
+const reviewColumns = `r.ID, r.ProductID, r.CustomerID, COALESCE(u.Name, ''), r.Rating, COALESCE(r.Title, ''), COALESCE(r.Body, ''), r.Status, COALESCE(r.ModerationReason, ''),
+	r.HelpfulCount, COALESCE(r.SellerResponse, ''), r.SellerRespondedAt, r.CreatedAt, r.UpdatedAt`
+
+const reviewTables = `ProductReview r
+	JOIN Product p ON p.ID = r.ProductID
+	JOIN Customer c ON c.ID = r.CustomerID
+	JOIN User u ON u.ID = c.UserID`
+
+func (r *ReviewRepository) CreateReview(productID string, customerID string, input types.SaveReviewInput) (models.ProductReview, error){
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err !=nil {
+		return models.ProductReview{}, err
+	}
+	defer tx.Rollback()
+	if err = lockReviewedProduct(ctx, tx, productID); err !=nil {
+		return models.ProductReview{}, err
+	}
+	bought, err := hasBoughtProduct(ctx, tx, customerID, productID)
+	if err !=nil {
+		return models.ProductReview{}, err
+	}
+	if !bought {
+		return models.ProductReview{}, constants.ErrNotVerifiedBuyer
+	}
+	id := utils.NewID()
+	_, err = tx.ExecContext(ctx, `INSERT INTO ProductReview (ID, ProductID, CustomerID, Rating, Title, Body, Status) VALUES (?, ?, ?, ?, NULLIF(?, ''), NULLIF(?, ''), ?)`,
+		id, productID, customerID, input.Rating, input.Title, input.Body, constants.ReviewStatuses.Published)
+	if isDuplicateKeyError(err) {
+		return models.ProductReview{}, constants.ErrAlreadyReviewed
+	}
+	if err !=nil {
+		return models.ProductReview{}, err
+	}
+	if err = updateProductRating(ctx, tx, productID); err !=nil {
+		return models.ProductReview{}, err
+	}
+	if err = tx.Commit(); err !=nil {
+		return models.ProductReview{}, err
+	}
+	return r.RetrieveReview(id)
+}
+
+func (r *ReviewRepository) UpdateReview(id string, customerID string, input types.SaveReviewInput) (models.ProductReview, error){
+	err := r.changeReview(id, customerID, func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `UPDATE ProductReview SET Rating = ?, Title = NULLIF(?, ''), Body = NULLIF(?, '') WHERE ID = ?`, input.Rating, input.Title, input.Body, id)
+		return err
+	})
+	if err !=nil {
+		return models.ProductReview{}, err
+	}
+	return r.RetrieveReview(id)
+}
+
+func (r *ReviewRepository) DeleteReview(id string, customerID string) error{
+	return r.changeReview(id, customerID, func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `DELETE FROM ProductReview WHERE ID = ?`, id)
+		return err
+	})
+}
+
+func (r *ReviewRepository) RetrieveReview(id string) (models.ProductReview, error){
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	review, err := scanReview(r.db.QueryRowContext(ctx, `SELECT `+reviewColumns+` FROM `+reviewTables+` WHERE r.ID = ?`, id))
+	if err == sql.ErrNoRows {
+		return review, constants.ErrReviewNotFound
+	}
+	return review, err
+}
+
+func (r *ReviewRepository) RetrieveReviews(input types.RetrieveReviewsInput) (types.PaginatedDataOutput, error){
+	db := r.db
+	// prepare query, an empty product or seller matches every one of them
+	filter := `(? = '' OR r.ProductID = ?) AND (? = '' OR p.OwnerID = ?) AND ((? = '' AND r.Status != ?) OR r.Status = ?)`
+	// the cursor is the id of the last review of the previous page, ids are time ordered so the newest reviews have the largest ids
+	cursor := `(? = '' OR r.ID < ?)`
+	order := `r.ID DESC`
+	if input.Sort == constants.ReviewSorts.Helpful {
+		cursor = `(? = '' OR (r.HelpfulCount, r.ID) < (SELECT HelpfulCount, ID FROM ProductReview WHERE ID = ?))`
+		order = `r.HelpfulCount DESC, r.ID DESC`
+	}
+	query := `SELECT ` + reviewColumns + `,
+		(SELECT COUNT(*) FROM ` + reviewTables + ` WHERE ` + filter + `) AS total
+		FROM ` + reviewTables + `
+		WHERE ` + filter + ` AND ` + cursor + `
+		ORDER BY ` + order + `
+		LIMIT ?`
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	// prepare the statement
+	output := types.PaginatedDataOutput{}
+	stmt, err := db.PrepareContext(ctx, query)
+	if err !=nil {
+		return output, err
+	}
+	defer stmt.Close() //close the statement after use
+	// execute the statement
+	filterArgs := []interface{}{input.ProductID, input.ProductID, input.SellerID, input.SellerID, input.Status, constants.ReviewStatuses.Hidden, input.Status}
+	args := append(append(append([]interface{}{}, filterArgs...), filterArgs...), input.Pagination.NextCursor, input.Pagination.NextCursor, utils.Ternary(input.Pagination.PageSize == 0, constants.DefaultPageSize, input.Pagination.PageSize))
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err !=nil {
+		return output, err
+	}
+	defer rows.Close()
+	reviews := []models.ProductReview{}
+	total := 0
+	for rows.Next() {
+		review, err := scanReview(rows, &total)
+		if err !=nil {
+			return output, err
+		}
+		reviews = append(reviews, review)
+	}
+	if err = rows.Err(); err !=nil {
+		return output, err
+	}
+	lastItemId := ""
+	// select last item in the list
+	if len(reviews) > 0 {
+		lastItemId = reviews[len(reviews)-1].ID
+	}
+
+	output = types.PaginatedDataOutput{
+		Data: reviews,
+		NextCursor: lastItemId,
+		HasMore:    len(reviews) < total,
+		Total:      total,
+	}
+	return output, nil
+}
+
+func (r *ReviewRepository) VoteReviewHelpful(id string, userID string) (models.ProductReview, error){
+	err := r.changeHelpfulVote(id, userID, `INSERT IGNORE INTO ProductReviewVote (ReviewID, UserID) VALUES (?, ?)`, `UPDATE ProductReview SET HelpfulCount = HelpfulCount + 1, UpdatedAt = UpdatedAt WHERE ID = ?`)
+	if err !=nil {
+		return models.ProductReview{}, err
+	}
+	return r.RetrieveReview(id)
+}
+
+func (r *ReviewRepository) RemoveReviewHelpfulVote(id string, userID string) (models.ProductReview, error){
+	err := r.changeHelpfulVote(id, userID, `DELETE FROM ProductReviewVote WHERE ReviewID = ? AND UserID = ?`, `UPDATE ProductReview SET HelpfulCount = GREATEST(HelpfulCount - 1, 0), UpdatedAt = UpdatedAt WHERE ID = ?`)
+	if err !=nil {
+		return models.ProductReview{}, err
+	}
+	return r.RetrieveReview(id)
+}
+
+// reports of a review that has already been flagged or moderated change nothing
+func (r *ReviewRepository) ReportReview(id string) error{
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	status := ""
+	err := r.db.QueryRowContext(ctx, `SELECT Status FROM ProductReview WHERE ID = ?`, id).Scan(&status)
+	if err == sql.ErrNoRows || status == constants.ReviewStatuses.Hidden {
+		return constants.ErrReviewNotFound
+	}
+	if err !=nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, `UPDATE ProductReview SET Status = ? WHERE ID = ? AND Status = ?`, constants.ReviewStatuses.Flagged, id, constants.ReviewStatuses.Published)
+	return err
+}
+
+// responding again replaces the seller's response
+func (r *ReviewRepository) RespondToReview(id string, sellerID string, input types.RespondToReviewInput) (models.ProductReview, error){
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	exists := 0
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM ProductReview r JOIN Product p ON p.ID = r.ProductID WHERE r.ID = ? AND p.OwnerID = ? AND r.Status != ?`, id, sellerID, constants.ReviewStatuses.Hidden).Scan(&exists)
+	if err !=nil {
+		return models.ProductReview{}, err
+	}
+	if exists == 0 {
+		return models.ProductReview{}, constants.ErrReviewNotFound
+	}
+	if _, err = r.db.ExecContext(ctx, `UPDATE ProductReview SET SellerResponse = ?, SellerRespondedAt = ? WHERE ID = ?`, input.Response, time.Now(), id); err !=nil {
+		return models.ProductReview{}, err
+	}
+	return r.RetrieveReview(id)
+}
+
+func (r *ReviewRepository) ModerateReview(id string, input types.ModerateReviewInput) (models.ProductReview, error){
+	err := r.changeReview(id, "", func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `UPDATE ProductReview SET Status = ?, ModerationReason = NULLIF(?, '') WHERE ID = ?`, input.Status, input.Reason, id)
+		return err
+	})
+	if err !=nil {
+		return models.ProductReview{}, err
+	}
+	return r.RetrieveReview(id)
+}
+
+// private
+// changeReview makes a change to a review of the customer, or any review when customerID is empty, and brings the rating of its product up to date with it
+func (r *ReviewRepository) changeReview(id string, customerID string, change func(ctx context.Context, tx *sql.Tx) error) error{
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err !=nil {
+		return err
+	}
+	defer tx.Rollback()
+	productID := ""
+	err = tx.QueryRowContext(ctx, `SELECT ProductID FROM ProductReview WHERE ID = ? AND (? = '' OR CustomerID = ?)`, id, customerID, customerID).Scan(&productID)
+	if err == sql.ErrNoRows {
+		return constants.ErrReviewNotFound
+	}
+	if err !=nil {
+		return err
+	}
+	if err = lockReviewedProduct(ctx, tx, productID); err !=nil {
+		return err
+	}
+	if err = change(ctx, tx); err !=nil {
+		return err
+	}
+	if err = updateProductRating(ctx, tx, productID); err !=nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// votes are only counted for reviews that are shown, and not by their author
+func (r *ReviewRepository) changeHelpfulVote(id string, userID string, voteQuery string, countQuery string) error{
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err !=nil {
+		return err
+	}
+	defer tx.Rollback()
+	authorID, status := "", ""
+	err = tx.QueryRowContext(ctx, `SELECT c.UserID, r.Status FROM ProductReview r JOIN Customer c ON c.ID = r.CustomerID WHERE r.ID = ?`, id).Scan(&authorID, &status)
+	if err == sql.ErrNoRows || status == constants.ReviewStatuses.Hidden {
+		return constants.ErrReviewNotFound
+	}
+	if err !=nil {
+		return err
+	}
+	if authorID == userID {
+		return constants.ErrOwnReviewVote
+	}
+	res, err := tx.ExecContext(ctx, voteQuery, id, userID)
+	if err !=nil {
+		return err
+	}
+	count, err := res.RowsAffected()
+	if err !=nil {
+		return err
+	}
+	// voting twice, or taking back a vote that wasn't made, leaves the count as it is
+	if count > 0 {
+		if _, err = tx.ExecContext(ctx, countQuery, id); err !=nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// hasBoughtProduct reports whether the customer is a verified buyer of the product, it has to have been paid for and not cancelled since
+func hasBoughtProduct(ctx context.Context, tx *sql.Tx, customerID string, productID string) (bool, error) {
+	query := "SELECT COUNT(*) FROM OrderItem oi " +
+		"JOIN `Order` o ON o.ID = oi.OrderID " +
+		`JOIN Payment pa ON pa.OrderID = o.ID
+		JOIN Product p ON p.ID = oi.ProductID
+		LEFT JOIN SubOrder so ON so.OrderID = o.ID AND so.SellerID = p.OwnerID
+		WHERE o.CustomerID = ? AND oi.ProductID = ? AND pa.Paid = true AND (so.Status IS NULL OR so.Status != ?)`
+	bought := 0
+	err := tx.QueryRowContext(ctx, query, customerID, productID, constants.SubOrderStatuses.Cancelled).Scan(&bought)
+	return bought > 0, err
+}
+
+// lockReviewedProduct makes the review changes of a product wait for each other, so its rating is worked out from every review
+func lockReviewedProduct(ctx context.Context, tx *sql.Tx, productID string) error {
+	id := ""
+	err := tx.QueryRowContext(ctx, `SELECT ID FROM Product WHERE ID = ? FOR UPDATE`, productID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return constants.ErrProductNotFound
+	}
+	return err
+}
+
+// updateProductRating works the rating of the product out again from its reviews that are shown
+func updateProductRating(ctx context.Context, tx *sql.Tx, productID string) error {
+	rating := models.ProductRating{}
+	stars := make([]int, 5)
+	query := `SELECT COUNT(*), COALESCE(AVG(Rating), 0), COALESCE(SUM(Rating = 1), 0), COALESCE(SUM(Rating = 2), 0), COALESCE(SUM(Rating = 3), 0), COALESCE(SUM(Rating = 4), 0), COALESCE(SUM(Rating = 5), 0)
+		FROM ProductReview WHERE ProductID = ? AND Status != ? LOCK IN SHARE MODE`
+	err := tx.QueryRowContext(ctx, query, productID, constants.ReviewStatuses.Hidden).Scan(&rating.Count, &rating.Average, &stars[0], &stars[1], &stars[2], &stars[3], &stars[4])
+	if err != nil {
+		return err
+	}
+	// a new rating isn't a change to the product, so its UpdatedAt is kept
+	_, err = tx.ExecContext(ctx, `UPDATE Product SET RatingAverage = ?, RatingCount = ?, OneStarCount = ?, TwoStarCount = ?, ThreeStarCount = ?, FourStarCount = ?, FiveStarCount = ?, UpdatedAt = UpdatedAt WHERE ID = ?`,
+		math.Round(rating.Average*100)/100, rating.Count, stars[0], stars[1], stars[2], stars[3], stars[4], productID)
+	return err
+}
+
+type reviewScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanReview(row reviewScanner, extra ...interface{}) (models.ProductReview, error){
+	review := models.ProductReview{}
+	var respondedAt sql.NullTime
+	dest := []interface{}{&review.ID, &review.ProductID, &review.CustomerID, &review.AuthorName, &review.Rating, &review.Title, &review.Body, &review.Status, &review.ModerationReason,
+		&review.HelpfulCount, &review.SellerResponse, &respondedAt, &review.CreatedAt, &review.UpdatedAt}
+	if err := row.Scan(append(dest, extra...)...); err !=nil {
+		return review, err
+	}
+	if respondedAt.Valid {
+		review.SellerRespondedAt = &respondedAt.Time
+	}
+	return review, nil
+}
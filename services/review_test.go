@@ -0,0 +1,56 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/types"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestUpdateProductRating(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	// three reviews of 5, 4 and 4 stars
+	mock.ExpectQuery("FROM ProductReview WHERE ProductID = \\? AND Status != \\? LOCK IN SHARE MODE").WithArgs("product-1", constants.ReviewStatuses.Hidden).
+		WillReturnRows(sqlmock.NewRows([]string{"Count", "Average", "One", "Two", "Three", "Four", "Five"}).AddRow(3, "4.3333", 0, 0, 0, 2, 1))
+	mock.ExpectExec("UPDATE Product SET RatingAverage").WithArgs(4.33, 3, 0, 0, 0, 2, 1, "product-1").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = updateProductRating(context.Background(), tx, "product-1"); err != nil {
+		t.Fatal(err)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCreateReview_NotVerifiedBuyer(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT ID FROM Product").WithArgs("product-1").WillReturnRows(sqlmock.NewRows([]string{"ID"}).AddRow("product-1"))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM OrderItem").WithArgs("customer-1", "product-1", constants.SubOrderStatuses.Cancelled).WillReturnRows(sqlmock.NewRows([]string{"COUNT"}).AddRow(0))
+	mock.ExpectRollback()
+
+	_, err = NewReviewRepository(db).CreateReview("product-1", "customer-1", types.SaveReviewInput{Rating: 5})
+	if err != constants.ErrNotVerifiedBuyer {
+		t.Errorf("error = %v, want %v", err, constants.ErrNotVerifiedBuyer)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
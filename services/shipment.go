@@ -0,0 +1,268 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/models"
+	"github.com/kaasikodes/e-commerce-go/types"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+type ShipmentRepository struct {
+	db *sql.DB
+}
+
+func NewShipmentRepository(db *sql.DB) *ShipmentRepository {
+	return &ShipmentRepository{
+		db: db,
+	}
+}
+
+// ship items of a paid sub-order, an item can be spread over several shipments
+func (r *ShipmentRepository) CreateShipment(subOrderId string, sellerId string, input types.CreateShipmentInput) (models.Shipment, error) {
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Shipment{}, err
+	}
+	defer tx.Rollback()
+	// lock the sub-order so two shipments can't both ship what is left of an item
+	var status, orderId string
+	query := `SELECT s.Status, s.OrderID FROM SubOrder s JOIN Payment p ON p.OrderID = s.OrderID WHERE s.ID = ? AND s.SellerID = ? AND p.Paid = true FOR UPDATE`
+	err = tx.QueryRowContext(ctx, query, subOrderId, sellerId).Scan(&status, &orderId)
+	if err == sql.ErrNoRows {
+		return models.Shipment{}, constants.ErrSubOrderNotFound
+	}
+	if err != nil {
+		return models.Shipment{}, err
+	}
+	if status == constants.SubOrderStatuses.Delivered || status == constants.SubOrderStatuses.Cancelled {
+		return models.Shipment{}, constants.ErrSubOrderNotShippable
+	}
+	remaining, err := remainingToShip(ctx, tx, orderId, sellerId)
+	if err != nil {
+		return models.Shipment{}, err
+	}
+	// the same item listed twice is shipped once, with the quantities added up
+	quantities := map[string]int{}
+	orderItemIds := []string{}
+	for _, item := range input.Items {
+		if _, ok := remaining[item.OrderItemID]; !ok {
+			return models.Shipment{}, constants.ErrShipmentItemNotInOrder
+		}
+		if _, ok := quantities[item.OrderItemID]; !ok {
+			orderItemIds = append(orderItemIds, item.OrderItemID)
+		}
+		quantities[item.OrderItemID] += item.Quantity
+	}
+	for orderItemId, quantity := range quantities {
+		if quantity > remaining[orderItemId] {
+			return models.Shipment{}, constants.ErrShipmentQuantityExceeded
+		}
+		remaining[orderItemId] -= quantity
+	}
+
+	id := utils.NewID()
+	query = `INSERT INTO Shipment (ID, SubOrderID, Carrier, TrackingNumber, TrackingUrl, Status) VALUES (?, ?, ?, ?, ?, ?)`
+	if _, err = tx.ExecContext(ctx, query, id, subOrderId, input.Carrier, input.TrackingNumber, input.TrackingUrl, constants.ShipmentStatuses.Shipped); err != nil {
+		return models.Shipment{}, err
+	}
+	for _, orderItemId := range orderItemIds {
+		if _, err = tx.ExecContext(ctx, `INSERT INTO ShipmentItem (ShipmentID, OrderItemID, Quantity) VALUES (?, ?, ?)`, id, orderItemId, quantities[orderItemId]); err != nil {
+			return models.Shipment{}, err
+		}
+	}
+	// the sub-order is shipped once nothing is left to ship, it never moves back a status
+	next := constants.SubOrderStatuses.Shipped
+	for _, quantity := range remaining {
+		if quantity > 0 {
+			next = constants.SubOrderStatuses.Processing
+			break
+		}
+	}
+	if status == constants.SubOrderStatuses.Pending || (status == constants.SubOrderStatuses.Processing && next == constants.SubOrderStatuses.Shipped) {
+		if _, err = tx.ExecContext(ctx, `UPDATE SubOrder SET Status = ? WHERE ID = ?`, next, subOrderId); err != nil {
+			return models.Shipment{}, err
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return models.Shipment{}, err
+	}
+
+	return r.retrieveShipment(id)
+}
+
+// mark a shipment as delivered
+func (r *ShipmentRepository) MarkShipmentDelivered(id string, sellerId string) (models.Shipment, error) {
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Shipment{}, err
+	}
+	defer tx.Rollback()
+	// lock the shipment and its sub-order
+	var status, subOrderId, orderId string
+	query := `SELECT sh.Status, s.ID, s.OrderID FROM Shipment sh JOIN SubOrder s ON s.ID = sh.SubOrderID WHERE sh.ID = ? AND s.SellerID = ? FOR UPDATE`
+	err = tx.QueryRowContext(ctx, query, id, sellerId).Scan(&status, &subOrderId, &orderId)
+	if err == sql.ErrNoRows {
+		return models.Shipment{}, constants.ErrShipmentNotFound
+	}
+	if err != nil {
+		return models.Shipment{}, err
+	}
+	if status == constants.ShipmentStatuses.Delivered {
+		return models.Shipment{}, constants.ErrShipmentAlreadyDelivered
+	}
+	if _, err = tx.ExecContext(ctx, `UPDATE Shipment SET Status = ?, DeliveredAt = NOW() WHERE ID = ?`, constants.ShipmentStatuses.Delivered, id); err != nil {
+		return models.Shipment{}, err
+	}
+	// the sub-order is delivered once all of it has been shipped and every shipment has arrived
+	remaining, err := remainingToShip(ctx, tx, orderId, sellerId)
+	if err != nil {
+		return models.Shipment{}, err
+	}
+	delivered := true
+	for _, quantity := range remaining {
+		if quantity > 0 {
+			delivered = false
+			break
+		}
+	}
+	if delivered {
+		undelivered := 0
+		query = `SELECT COUNT(*) FROM Shipment WHERE SubOrderID = ? AND Status != ?`
+		if err = tx.QueryRowContext(ctx, query, subOrderId, constants.ShipmentStatuses.Delivered).Scan(&undelivered); err != nil {
+			return models.Shipment{}, err
+		}
+		delivered = undelivered == 0
+	}
+	if delivered {
+		if _, err = tx.ExecContext(ctx, `UPDATE SubOrder SET Status = ? WHERE ID = ?`, constants.SubOrderStatuses.Delivered, subOrderId); err != nil {
+			return models.Shipment{}, err
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return models.Shipment{}, err
+	}
+
+	return r.retrieveShipment(id)
+}
+
+// retrieve the shipments of a sub-order with the items in each
+func (r *ShipmentRepository) RetrieveSubOrderShipments(subOrderId string) ([]models.Shipment, error) {
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	rows, err := r.db.QueryContext(ctx, `SELECT `+shipmentColumns+` FROM Shipment sh WHERE sh.SubOrderID = ? ORDER BY sh.ShippedAt ASC`, subOrderId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	shipments := []models.Shipment{}
+	for rows.Next() {
+		shipment, err := scanShipment(rows)
+		if err != nil {
+			return nil, err
+		}
+		shipments = append(shipments, shipment)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	items, err := r.retrieveShipmentItems(ctx, `sh.SubOrderID = ?`, subOrderId)
+	if err != nil {
+		return nil, err
+	}
+	for i := range shipments {
+		shipments[i].Items = items[shipments[i].ID]
+	}
+	return shipments, nil
+}
+
+// private
+func (r *ShipmentRepository) retrieveShipment(id string) (models.Shipment, error) {
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	shipment, err := scanShipment(r.db.QueryRowContext(ctx, `SELECT `+shipmentColumns+` FROM Shipment sh WHERE sh.ID = ?`, id))
+	if err == sql.ErrNoRows {
+		return shipment, constants.ErrShipmentNotFound
+	}
+	if err != nil {
+		return shipment, err
+	}
+	items, err := r.retrieveShipmentItems(ctx, `sh.ID = ?`, id)
+	shipment.Items = items[id]
+	return shipment, err
+}
+
+// retrieve shipment items grouped by shipment, for the shipments matching the condition
+func (r *ShipmentRepository) retrieveShipmentItems(ctx context.Context, condition string, args ...interface{}) (map[string][]models.ShipmentItem, error) {
+	query := `
+	SELECT si.ShipmentID, si.OrderItemID, oi.ProductID, si.Quantity
+	FROM ShipmentItem si
+	JOIN Shipment sh ON sh.ID = si.ShipmentID
+	JOIN OrderItem oi ON oi.ID = si.OrderItemID
+	WHERE ` + condition
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := map[string][]models.ShipmentItem{}
+	for rows.Next() {
+		var shipmentId string
+		item := models.ShipmentItem{}
+		if err = rows.Scan(&shipmentId, &item.OrderItemID, &item.ProductID, &item.Quantity); err != nil {
+			return nil, err
+		}
+		items[shipmentId] = append(items[shipmentId], item)
+	}
+	return items, rows.Err()
+}
+
+// how much of each of the seller's items in an order is yet to be shipped, keyed by order item
+func remainingToShip(ctx context.Context, tx *sql.Tx, orderId string, sellerId string) (map[string]int, error) {
+	query := `
+	SELECT oi.ID, oi.Quantity - COALESCE((SELECT SUM(si.Quantity) FROM ShipmentItem si WHERE si.OrderItemID = oi.ID), 0)
+	FROM OrderItem oi
+	JOIN Product p ON p.ID = oi.ProductID
+	WHERE oi.OrderID = ? AND p.OwnerID = ?`
+	rows, err := tx.QueryContext(ctx, query, orderId, sellerId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	remaining := map[string]int{}
+	for rows.Next() {
+		var orderItemId string
+		var quantity int
+		if err = rows.Scan(&orderItemId, &quantity); err != nil {
+			return nil, err
+		}
+		remaining[orderItemId] = quantity
+	}
+	return remaining, rows.Err()
+}
+
+const shipmentColumns = `sh.ID, sh.SubOrderID, sh.Carrier, sh.TrackingNumber, COALESCE(sh.TrackingUrl, ''), sh.Status, sh.ShippedAt, sh.DeliveredAt, sh.CreatedAt, sh.UpdatedAt`
+
+type shipmentScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanShipment(row shipmentScanner) (models.Shipment, error) {
+	shipment := models.Shipment{}
+	var deliveredAt sql.NullTime
+	err := row.Scan(&shipment.ID, &shipment.SubOrderID, &shipment.Carrier, &shipment.TrackingNumber, &shipment.TrackingUrl, &shipment.Status, &shipment.ShippedAt, &deliveredAt, &shipment.CreatedAt, &shipment.UpdatedAt)
+	if deliveredAt.Valid {
+		shipment.DeliveredAt = &deliveredAt.Time
+	}
+	return shipment, err
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/models"
+	"github.com/kaasikodes/e-commerce-go/types"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+type StockRepository struct {
+	db *sql.DB
+}
+
+func NewStockRepository(db *sql.DB) *StockRepository {
+	return &StockRepository{
+		db: db,
+	}
+}
+
+const stockMovementColumns = `ID, ProductID, QuantityChange, QuantityAfter, Reason, Actor, COALESCE(ActorID, ''), COALESCE(Reference, ''), CreatedAt`
+
+// products that are running low, archived and deleted products are left out as they can't be sold anyway
+const lowStockCondition = `p.ReorderThreshold > 0 AND p.Quantity <= p.ReorderThreshold AND p.Status != 'archived' AND p.DeletedAt IS NULL`
+
+func (r *StockRepository) RetrieveStockMovements(productID string, sellerID string, pagination types.Pagination) (types.PaginatedDataOutput, error){
+	db := r.db
+	// the cursor is the id of the last movement of the previous page, ids are time ordered so the newest movements have the largest ids
+	query := `SELECT ` + stockMovementColumns + `,
+		(SELECT COUNT(*) FROM StockMovement WHERE ProductID = ?) AS total
+		FROM StockMovement
+		WHERE ProductID = ? AND (? = '' OR ID < ?)
+		ORDER BY ID DESC
+		LIMIT ?`
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	output := types.PaginatedDataOutput{}
+	if err := checkProductOwner(ctx, db, productID, sellerID); err !=nil {
+		return output, err
+	}
+	rows, err := db.QueryContext(ctx, query, productID, productID, pagination.NextCursor, pagination.NextCursor, utils.Ternary(pagination.PageSize == 0, constants.DefaultPageSize, pagination.PageSize))
+	if err !=nil {
+		return output, err
+	}
+	defer rows.Close()
+	movements := []models.StockMovement{}
+	total := 0
+	for rows.Next() {
+		movement, err := scanStockMovement(rows, &total)
+		if err !=nil {
+			return output, err
+		}
+		movements = append(movements, movement)
+	}
+	if err = rows.Err(); err !=nil {
+		return output, err
+	}
+	lastItemId := ""
+	// select last item in the list
+	if len(movements) > 0 {
+		lastItemId = movements[len(movements)-1].ID
+	}
+
+	output = types.PaginatedDataOutput{
+		Data: movements,
+		NextCursor: lastItemId,
+		HasMore:    len(movements) < total,
+		Total:      total,
+	}
+	return output, nil
+}
+
+func (r *StockRepository) RetrieveLowStockDigests() ([]models.LowStockDigest, error){
+	query := `SELECT s.ID, u.Email, p.ID, p.Name, p.Quantity, p.ReorderThreshold
+	FROM Product p
+	JOIN Seller s ON s.ID = p.OwnerID
+	JOIN User u ON u.ID = s.UserID
+	LEFT JOIN LowStockDigest d ON d.SellerID = s.ID
+	WHERE ` + lowStockCondition + ` AND (d.SentAt IS NULL OR d.SentAt <= ?)
+	ORDER BY s.ID, p.Quantity, p.ID`
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	digests := []models.LowStockDigest{}
+	rows, err := r.db.QueryContext(ctx, query, time.Now().Add(-constants.LowStockDigestPeriod))
+	if err !=nil {
+		return digests, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var sellerID, email string
+		product := models.LowStockProduct{}
+		if err = rows.Scan(&sellerID, &email, &product.ProductID, &product.Name, &product.Quantity, &product.ReorderThreshold); err !=nil {
+			return digests, err
+		}
+		// the products of a seller come one after the other
+		if len(digests) == 0 || digests[len(digests)-1].SellerID != sellerID {
+			digests = append(digests, models.LowStockDigest{SellerID: sellerID, SellerEmail: email})
+		}
+		digests[len(digests)-1].Products = append(digests[len(digests)-1].Products, product)
+	}
+	return digests, rows.Err()
+}
+
+func (r *StockRepository) MarkLowStockDigestSent(sellerID string) error{
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	_, err := r.db.ExecContext(ctx, `INSERT INTO LowStockDigest (SellerID, SentAt) VALUES (?, NOW()) ON DUPLICATE KEY UPDATE SentAt = VALUES(SentAt)`, sellerID)
+	return err
+}
+
+// private
+type stockMovementExecer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// recordStockMovement is called once the quantity of the product has been changed by change, so the quantity it is left with can be read back.
+// Within a transaction that is the quantity the change left it with
+func recordStockMovement(ctx context.Context, e stockMovementExecer, productID string, change int, reason string, actor string, actorID string, reference string) error {
+	query := `INSERT INTO StockMovement (ID, ProductID, QuantityChange, QuantityAfter, Reason, Actor, ActorID, Reference)
+		SELECT ?, ID, ?, Quantity, ?, ?, NULLIF(?, ''), NULLIF(?, '') FROM Product WHERE ID = ?`
+	_, err := e.ExecContext(ctx, query, utils.NewID(), change, reason, actor, actorID, reference, productID)
+	return err
+}
+
+type stockMovementScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanStockMovement(row stockMovementScanner, extra ...interface{}) (models.StockMovement, error){
+	movement := models.StockMovement{}
+	dest := []interface{}{&movement.ID, &movement.ProductID, &movement.Change, &movement.QuantityAfter, &movement.Reason, &movement.Actor, &movement.ActorID, &movement.Reference, &movement.CreatedAt}
+	err := row.Scan(append(dest, extra...)...)
+	return movement, err
+}
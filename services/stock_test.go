@@ -0,0 +1,67 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/types"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestUpdateProduct_RecordsStockAdjustment(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT Price, WasPrice, Quantity FROM Product WHERE ID = \\? AND OwnerID = \\? AND DeletedAt IS NULL FOR UPDATE").WithArgs("product-1", "seller-1").
+		WillReturnRows(sqlmock.NewRows([]string{"Price", "WasPrice", "Quantity"}).AddRow(500, nil, 10))
+	mock.ExpectExec("UPDATE Product SET Name = \\?").WithArgs("Kettle", "", 500, nil, 4, 2, "category-1", "product-1").WillReturnResult(sqlmock.NewResult(0, 1))
+	// the quantity went from 10 to 4, the price didn't change
+	mock.ExpectExec("INSERT INTO StockMovement").
+		WithArgs(sqlmock.AnyArg(), -6, constants.StockMovementReasons.Adjustment, constants.StockMovementActors.Seller, "seller-1", "", "product-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectPrepare("FROM Product WHERE ID = \\? AND DeletedAt IS NULL").ExpectQuery().WithArgs("product-1").WillReturnError(constants.ErrProductNotFound)
+
+	_, err = NewProductRepository(db).UpdateProduct("product-1", "seller-1", types.AddProductInput{Name: "Kettle", Price: 500, Quantity: 4, ReorderThreshold: 2, CategoryID: "category-1"})
+	if err != constants.ErrProductNotFound {
+		t.Errorf("error = %v, want %v", err, constants.ErrProductNotFound)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRetrieveLowStockDigests_GroupsBySeller(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT s.ID, u.Email, p.ID, p.Name, p.Quantity, p.ReorderThreshold").WithArgs(sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"SellerID", "Email", "ProductID", "Name", "Quantity", "ReorderThreshold"}).
+			AddRow("seller-1", "shop@example.com", "product-1", "Kettle", 0, 5).
+			AddRow("seller-1", "shop@example.com", "product-2", "Mug", 3, 3).
+			AddRow("seller-2", "store@example.com", "product-3", "Plate", 1, 10))
+
+	digests, err := NewStockRepository(db).RetrieveLowStockDigests()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(digests) != 2 {
+		t.Fatalf("digests = %d, want 2", len(digests))
+	}
+	if digests[0].SellerEmail != "shop@example.com" || len(digests[0].Products) != 2 {
+		t.Errorf("first digest = %s with %d products, want shop@example.com with 2", digests[0].SellerEmail, len(digests[0].Products))
+	}
+	if digests[1].SellerID != "seller-2" || len(digests[1].Products) != 1 {
+		t.Errorf("second digest = %s with %d products, want seller-2 with 1", digests[1].SellerID, len(digests[1].Products))
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
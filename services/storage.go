@@ -0,0 +1,72 @@
+package services
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalFileStorage keeps uploads on the server's disk, they are served under /<root>/
+type LocalFileStorage struct {
+	root    string
+	baseUrl string
+}
+
+func NewLocalFileStorage(root string, baseUrl string) *LocalFileStorage {
+	return &LocalFileStorage{
+		root:    root,
+		baseUrl: strings.TrimSuffix(baseUrl, "/"),
+	}
+}
+
+func (s *LocalFileStorage) Save(dir string, name string, content io.Reader) (string, error) {
+	if err := os.MkdirAll(filepath.Join(s.root, dir), 0755); err != nil {
+		return "", err
+	}
+	// only the base of the name is used so a name can't point outside the directory
+	path := filepath.Join(s.root, dir, filepath.Base(name))
+	file, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	if _, err = io.Copy(file, content); err != nil {
+		os.Remove(path)
+		return "", err
+	}
+	return s.baseUrl + "/" + filepath.ToSlash(path), nil
+}
+
+func (s *LocalFileStorage) Open(url string) (io.ReadCloser, error) {
+	path, ok := s.pathFromUrl(url)
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return os.Open(path)
+}
+
+func (s *LocalFileStorage) Delete(url string) error {
+	path, ok := s.pathFromUrl(url)
+	if !ok {
+		return nil
+	}
+	err := os.Remove(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// pathFromUrl returns the path on disk of a url the storage handed out
+func (s *LocalFileStorage) pathFromUrl(url string) (string, bool) {
+	prefix := s.baseUrl + "/" + filepath.ToSlash(s.root) + "/"
+	if !strings.HasPrefix(url, prefix) {
+		return "", false
+	}
+	rel := filepath.Clean(filepath.FromSlash(strings.TrimPrefix(url, prefix)))
+	if strings.HasPrefix(rel, "..") {
+		return "", false
+	}
+	return filepath.Join(s.root, rel), true
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalFileStorage_SaveAndDelete(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "uploads")
+	storage := NewLocalFileStorage(root, "http://localhost:8000/")
+
+	// a name with a path in it must still land in the directory
+	url, err := storage.Save("avatars", "../../escape.png", strings.NewReader("image"))
+	if err != nil {
+		t.Fatalf("unexpected error saving file: %v", err)
+	}
+	path := filepath.Join(root, "avatars", "escape.png")
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("expected file to be saved at %s: %v", path, err)
+	}
+	if !strings.HasPrefix(url, "http://localhost:8000/") || !strings.HasSuffix(url, "/avatars/escape.png") {
+		t.Errorf("unexpected url: %s", url)
+	}
+
+	if err := storage.Delete("https://example.com/avatar.png"); err != nil {
+		t.Errorf("urls from elsewhere should be ignored, got %v", err)
+	}
+	if err := storage.Delete(url); err != nil {
+		t.Fatalf("unexpected error deleting file: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected file to be deleted")
+	}
+}
@@ -0,0 +1,258 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/models"
+	"github.com/kaasikodes/e-commerce-go/types"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+type StorefrontRepository struct {
+	db *sql.DB
+}
+
+func NewStorefrontRepository(db *sql.DB) *StorefrontRepository {
+	return &StorefrontRepository{
+		db: db,
+	}
+}
+
+const storefrontColumns = `SellerID, ShopName, Slug, LogoUrl, BannerUrl, Description, ReturnPolicy, ShippingPolicy, ContactEmail, ContactPhone, CreatedAt, UpdatedAt`
+
+func (r *StorefrontRepository) RetrieveStorefront(sellerID string) (models.Storefront, error){
+	db := r.db
+	// prepare query
+	query := `SELECT ` + storefrontColumns + ` FROM SellerStorefront WHERE SellerID = ?`
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	// prepare the statement
+	stmt, err := db.PrepareContext(ctx, query)
+	if err !=nil {
+		return models.Storefront{}, err
+	}
+	defer stmt.Close() //close the statement after use
+	// execute the statement
+	storefront, err := scanStorefront(stmt.QueryRowContext(ctx, sellerID))
+	if err == sql.ErrNoRows {
+		return storefront, constants.ErrStorefrontNotFound
+	}
+	return storefront, err
+}
+
+func (r *StorefrontRepository) SaveStorefront(sellerID string, input types.SaveStorefrontInput) (models.Storefront, error){
+	db := r.db
+	slug := slugify(input.Slug)
+	if input.Slug == "" {
+		slug = slugify(input.ShopName)
+	}
+	if len(slug) < 3 || len(slug) > 60 {
+		return models.Storefront{}, constants.ErrInvalidShopSlug
+	}
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	taken := 0
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM SellerStorefront WHERE Slug = ? AND SellerID != ?`, slug, sellerID).Scan(&taken); err !=nil {
+		return models.Storefront{}, err
+	}
+	if taken > 0 {
+		return models.Storefront{}, constants.ErrShopSlugTaken
+	}
+	query := `INSERT INTO SellerStorefront (SellerID, ShopName, Slug, Description, ReturnPolicy, ShippingPolicy, ContactEmail, ContactPhone)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE ShopName = VALUES(ShopName), Slug = VALUES(Slug), Description = VALUES(Description), ReturnPolicy = VALUES(ReturnPolicy),
+		ShippingPolicy = VALUES(ShippingPolicy), ContactEmail = VALUES(ContactEmail), ContactPhone = VALUES(ContactPhone)`
+	// prepare the statement
+	stmt, err := db.PrepareContext(ctx, query)
+	if err !=nil {
+		return models.Storefront{}, err
+	}
+	defer stmt.Close() //close the statement after use
+	// execute the statement
+	_, err = stmt.ExecContext(ctx, sellerID, input.ShopName, slug, input.Description, input.ReturnPolicy, input.ShippingPolicy, input.ContactEmail, input.ContactPhone)
+	// the unique index catches a slug taken by another seller in the meantime
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+		return models.Storefront{}, constants.ErrShopSlugTaken
+	}
+	if err !=nil {
+		return models.Storefront{}, err
+	}
+
+	return r.RetrieveStorefront(sellerID)
+}
+
+func (r *StorefrontRepository) UpdateStorefrontLogo(sellerID string, logoUrl string) (models.Storefront, error){
+	return r.updateStorefrontImage(sellerID, "LogoUrl", logoUrl)
+}
+
+func (r *StorefrontRepository) UpdateStorefrontBanner(sellerID string, bannerUrl string) (models.Storefront, error){
+	return r.updateStorefrontImage(sellerID, "BannerUrl", bannerUrl)
+}
+
+func (r *StorefrontRepository) RetrieveShop(slug string, input types.RetrieveShopInput) (types.Shop, error){
+	db := r.db
+	shop := types.Shop{}
+	// prepare query
+	query := `SELECT ` + prefixColumns("sf", storefrontColumns) + `, s.CreatedAt
+		FROM SellerStorefront sf
+		JOIN Seller s ON s.ID = sf.SellerID
+		JOIN SellerOnboarding so ON so.SellerID = sf.SellerID
+		WHERE sf.Slug = ? AND so.Status = ?`
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	// prepare the statement
+	stmt, err := db.PrepareContext(ctx, query)
+	if err !=nil {
+		return shop, err
+	}
+	defer stmt.Close() //close the statement after use
+	// execute the statement
+	shop.Storefront, err = scanStorefront(stmt.QueryRowContext(ctx, slug, constants.SellerOnboardingStatuses.Approved), &shop.Stats.MemberSince)
+	if err == sql.ErrNoRows {
+		return shop, constants.ErrShopNotFound
+	}
+	if err !=nil {
+		return shop, err
+	}
+	sellerID := shop.Storefront.SellerID
+	if err = db.QueryRowContext(ctx, `SELECT COUNT(*) FROM Product p WHERE p.OwnerID = ? AND p.Quantity > 0 AND `+productForSaleCondition, sellerID).Scan(&shop.Stats.ActiveProducts); err !=nil {
+		return shop, err
+	}
+	unitsSoldQuery := `SELECT COALESCE(SUM(oi.Quantity), 0)
+		FROM OrderItem oi
+		JOIN Product p ON p.ID = oi.ProductID
+		JOIN Payment pa ON pa.OrderID = oi.OrderID
+		LEFT JOIN SubOrder so ON so.OrderID = oi.OrderID AND so.SellerID = p.OwnerID
+		WHERE p.OwnerID = ? AND pa.Paid = true AND (so.Status IS NULL OR so.Status != ?)`
+	if err = db.QueryRowContext(ctx, unitsSoldQuery, sellerID, constants.SubOrderStatuses.Cancelled).Scan(&shop.Stats.UnitsSold); err !=nil {
+		return shop, err
+	}
+	// the shop is rated by the reviews of all its products that are shown
+	ratingQuery := `SELECT COALESCE(AVG(r.Rating), 0), COUNT(*) FROM ProductReview r JOIN Product p ON p.ID = r.ProductID WHERE p.OwnerID = ? AND r.Status != ?`
+	if err = db.QueryRowContext(ctx, ratingQuery, sellerID, constants.ReviewStatuses.Hidden).Scan(&shop.Rating.Average, &shop.Rating.Count); err !=nil {
+		return shop, err
+	}
+	shop.Rating.Average = math.Round(shop.Rating.Average*100) / 100
+	shop.Products, err = r.retrieveShopProducts(ctx, sellerID, input.Pagination)
+
+	return shop, err
+}
+
+// only products that are for sale and in stock are shown on the shop page
+func (r *StorefrontRepository) retrieveShopProducts(ctx context.Context, sellerID string, pagination types.Pagination) (types.PaginatedDataOutput, error){
+	output := types.PaginatedDataOutput{}
+	query := `SELECT ` + prefixColumns("p", productColumns) + `,
+			(SELECT COUNT(*) FROM Product p WHERE p.OwnerID = ? AND p.Quantity > 0 AND ` + productForSaleCondition + `) AS total_products
+		FROM Product p
+		WHERE p.OwnerID = ? AND p.Quantity > 0 AND ` + productForSaleCondition + ` AND p.ID > ?
+		ORDER BY p.ID ASC
+		LIMIT ?`
+	rows, err := r.db.QueryContext(ctx, query, sellerID, sellerID, pagination.NextCursor, utils.Ternary(pagination.PageSize == 0, constants.DefaultPageSize, pagination.PageSize))
+	if err !=nil {
+		return output, err
+	}
+	defer rows.Close()
+	products := []models.Product{}
+	total := 0
+	for rows.Next() {
+		product, err := scanProduct(rows, &total)
+		if err !=nil {
+			return output, err
+		}
+		products = append(products, product)
+	}
+	if err = rows.Err(); err !=nil {
+		return output, err
+	}
+	lastItemId := ""
+	// select last item in the list
+	if len(products) > 0 {
+		lastItemId = products[len(products)-1].ID
+	}
+
+	output = types.PaginatedDataOutput{
+		Data: products,
+		NextCursor: lastItemId,
+		HasMore:    len(products) < total,
+		Total:      total,
+	}
+	return output, nil
+}
+
+func (r *StorefrontRepository) updateStorefrontImage(sellerID string, column string, url string) (models.Storefront, error){
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	// column is never user input
+	res, err := r.db.ExecContext(ctx, `UPDATE SellerStorefront SET `+column+` = ? WHERE SellerID = ?`, url, sellerID)
+	if err !=nil {
+		return models.Storefront{}, err
+	}
+	count, err := res.RowsAffected()
+	if err !=nil {
+		return models.Storefront{}, err
+	}
+	if count == 0 {
+		return models.Storefront{}, constants.ErrStorefrontNotFound
+	}
+
+	return r.RetrieveStorefront(sellerID)
+}
+
+type storefrontScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanStorefront(row storefrontScanner, extra ...interface{}) (models.Storefront, error){
+	storefront := models.Storefront{}
+	var logoUrl, bannerUrl, description, returnPolicy, shippingPolicy, contactEmail, contactPhone sql.NullString
+	dest := []interface{}{&storefront.SellerID, &storefront.ShopName, &storefront.Slug, &logoUrl, &bannerUrl, &description, &returnPolicy, &shippingPolicy, &contactEmail, &contactPhone, &storefront.CreatedAt, &storefront.UpdatedAt}
+	if err := row.Scan(append(dest, extra...)...); err !=nil {
+		return storefront, err
+	}
+	storefront.LogoUrl = logoUrl.String
+	storefront.BannerUrl = bannerUrl.String
+	storefront.Description = description.String
+	storefront.ReturnPolicy = returnPolicy.String
+	storefront.ShippingPolicy = shippingPolicy.String
+	storefront.ContactEmail = contactEmail.String
+	storefront.ContactPhone = contactPhone.String
+	return storefront, nil
+}
+
+// prefixColumns qualifies a comma separated list of columns with a table alias
+func prefixColumns(alias string, columns string) string {
+	parts := strings.Split(columns, ",")
+	for i, column := range parts {
+		parts[i] = alias + "." + strings.TrimSpace(column)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// slugify lower cases the text and joins its letters and numbers with dashes, "Ada's Fabrics & More" becomes "ada-s-fabrics-more"
+func slugify(text string) string {
+	var b strings.Builder
+	dash := false
+	for _, c := range strings.ToLower(strings.TrimSpace(text)) {
+		if (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') {
+			b.WriteRune(c)
+			dash = false
+			continue
+		}
+		if !dash && b.Len() > 0 {
+			b.WriteRune('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
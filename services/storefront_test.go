@@ -0,0 +1,17 @@
+package services
+
+import "testing"
+
+func TestSlugify(t *testing.T) {
+	cases := map[string]string{
+		"Ada's Fabrics & More": "ada-s-fabrics-more",
+		"  Lagos   Gadgets  ":  "lagos-gadgets",
+		"shop-123":             "shop-123",
+		"!!!":                  "",
+	}
+	for text, want := range cases {
+		if got := slugify(text); got != want {
+			t.Errorf("slugify(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/models"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+type AuthThrottleRepository struct {
+	db *sql.DB
+}
+
+func NewAuthThrottleRepository(db *sql.DB) *AuthThrottleRepository {
+	return &AuthThrottleRepository{
+		db: db,
+	}
+}
+
+func (r *AuthThrottleRepository) RetrieveThrottle(key string) (models.AuthThrottle, error){
+	db := r.db
+	// prepare query
+	query := "SELECT ID, `Key`, FailedAttempts, LastFailedAt, LockedUntil, LockoutNotified FROM AuthThrottle WHERE `Key` = ?"
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	// prepare the statement
+	throttle := models.AuthThrottle{}
+	stmt, err := db.PrepareContext(ctx, query)
+	if err !=nil {
+		return throttle, err
+	}
+	defer stmt.Close() //close the statement after use
+	// execute the statement
+	var lockedUntil sql.NullTime
+	err = stmt.QueryRowContext(ctx, key).Scan(&throttle.ID, &throttle.Key, &throttle.FailedAttempts, &throttle.LastFailedAt, &lockedUntil, &throttle.LockoutNotified)
+	if err !=nil {
+		return throttle, err
+	}
+	throttle.LockedUntil = lockedUntil.Time
+
+	return throttle, nil
+
+}
+
+// RecordFailedAttempt bumps the failure count for key and works out how long the key is blocked for:
+// nothing for the first policy.FreeAttempts failures, an exponential backoff after that and a full lockout once policy.MaxAttempts is reached.
+// The row is locked while the count is bumped so failures arriving together are all counted
+func (r *AuthThrottleRepository) RecordFailedAttempt(key string, policy constants.AuthThrottlePolicy) (models.AuthThrottle, error){
+	throttle := models.AuthThrottle{}
+	db := r.db
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	tx, err := db.BeginTx(ctx, nil)
+	if err !=nil {
+		return throttle, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	// make sure there is a row to lock, an existing row is left as it is
+	_, err = tx.ExecContext(ctx, "INSERT INTO AuthThrottle (ID, `Key`, FailedAttempts, LastFailedAt) VALUES (?, ?, 0, ?) ON DUPLICATE KEY UPDATE ID = ID", utils.NewID(), key, now)
+	if err !=nil {
+		return throttle, err
+	}
+	var lockedUntil sql.NullTime
+	err = tx.QueryRowContext(ctx, "SELECT ID, `Key`, FailedAttempts, LastFailedAt, LockedUntil, LockoutNotified FROM AuthThrottle WHERE `Key` = ? FOR UPDATE", key).Scan(&throttle.ID, &throttle.Key, &throttle.FailedAttempts, &throttle.LastFailedAt, &lockedUntil, &throttle.LockoutNotified)
+	if err !=nil {
+		return throttle, err
+	}
+
+	// failures outside the window are forgotten, and so is the lockout they led to
+	if now.Sub(throttle.LastFailedAt) > policy.Window {
+		throttle.FailedAttempts = 0
+		throttle.LockoutNotified = false
+	}
+	throttle.FailedAttempts++
+	throttle.LastFailedAt = now
+	throttle.LockedUntil = time.Time{}
+	if throttle.FailedAttempts >= policy.MaxAttempts {
+		throttle.LockedUntil = now.Add(policy.LockoutDuration)
+		// the owner is told once per lockout, not on every failure made while locked
+		throttle.NotifyLockout = !throttle.LockoutNotified
+		throttle.LockoutNotified = true
+	} else if throttle.FailedAttempts > policy.FreeAttempts {
+		throttle.LockedUntil = now.Add(backoffDelay(throttle.FailedAttempts-policy.FreeAttempts, policy))
+	}
+
+	lockedUntil = sql.NullTime{Time: throttle.LockedUntil, Valid: !throttle.LockedUntil.IsZero()}
+	_, err = tx.ExecContext(ctx, "UPDATE AuthThrottle SET FailedAttempts = ?, LastFailedAt = ?, LockedUntil = ?, LockoutNotified = ? WHERE ID = ?", throttle.FailedAttempts, throttle.LastFailedAt, lockedUntil, throttle.LockoutNotified, throttle.ID)
+	if err !=nil {
+		return throttle, err
+	}
+	if err = tx.Commit(); err !=nil {
+		return throttle, err
+	}
+
+	return throttle, nil
+}
+
+func (r *AuthThrottleRepository) ClearThrottle(key string) ( error){
+	db := r.db
+	// prepare query
+	query := "DELETE FROM AuthThrottle WHERE `Key` = ?"
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	// prepare the statement
+	stmt, err := db.PrepareContext(ctx, query)
+	if err !=nil {
+		return  err
+	}
+	defer stmt.Close() //close the statement after use
+
+	// execute the statement
+	_, err = stmt.ExecContext(ctx, key)
+	if err !=nil {
+		return  err
+	}
+
+	return  nil
+}
+
+// backoffDelay doubles the policy's base delay for every failure past the free ones, capped at the policy's max delay
+func backoffDelay(excessFailures int, policy constants.AuthThrottlePolicy) time.Duration {
+	delay := policy.BaseDelay
+	for i := 1; i < excessFailures; i++ {
+		delay *= 2
+		if delay >= policy.MaxDelay {
+			return policy.MaxDelay
+		}
+	}
+	return delay
+}
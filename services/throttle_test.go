@@ -0,0 +1,142 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+var testThrottlePolicy = constants.AuthThrottlePolicy{
+	FreeAttempts:    3,
+	MaxAttempts:     10,
+	BaseDelay:       2 * time.Second,
+	MaxDelay:        10 * time.Second,
+	LockoutDuration: 30 * time.Minute,
+	Window:          time.Hour,
+}
+
+func expectLockedThrottle(mock sqlmock.Sqlmock, failedAttempts int, lastFailedAt time.Time, lockoutNotified bool) {
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO AuthThrottle .* ON DUPLICATE KEY UPDATE ID = ID").WithArgs(sqlmock.AnyArg(), "account:ada", sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("FROM AuthThrottle WHERE `Key` = \\? FOR UPDATE").WithArgs("account:ada").
+		WillReturnRows(sqlmock.NewRows([]string{"ID", "Key", "FailedAttempts", "LastFailedAt", "LockedUntil", "LockoutNotified"}).
+			AddRow("throttle-1", "account:ada", failedAttempts, lastFailedAt, nil, lockoutNotified))
+}
+
+func TestBackoffDelay(t *testing.T) {
+	want := []time.Duration{2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+	for i, delay := range want {
+		if got := backoffDelay(i+1, testThrottlePolicy); got != delay {
+			t.Errorf("backoffDelay(%d) = %v, want %v", i+1, got, delay)
+		}
+	}
+}
+
+func TestRecordFailedAttempt_Schedule(t *testing.T) {
+	cases := []struct {
+		name        string
+		previous    int
+		wantBlocked time.Duration
+	}{
+		{"free attempt", 2, 0},
+		{"first backoff", 3, 2 * time.Second},
+		{"doubled backoff", 4, 4 * time.Second},
+		{"capped backoff", 8, 10 * time.Second},
+		{"lockout", 9, 30 * time.Minute},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			expectLockedThrottle(mock, c.previous, time.Now().Add(-time.Minute), false)
+			mock.ExpectExec("UPDATE AuthThrottle SET FailedAttempts = \\?").WithArgs(c.previous+1, sqlmock.AnyArg(), sqlmock.AnyArg(), c.previous+1 >= testThrottlePolicy.MaxAttempts, "throttle-1").WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+
+			throttle, err := NewAuthThrottleRepository(db).RecordFailedAttempt("account:ada", testThrottlePolicy)
+			if err != nil {
+				t.Fatalf("error = %v, want nil", err)
+			}
+			if throttle.FailedAttempts != c.previous+1 {
+				t.Errorf("failed attempts = %d, want %d", throttle.FailedAttempts, c.previous+1)
+			}
+			blocked := time.Duration(0)
+			if !throttle.LockedUntil.IsZero() {
+				blocked = throttle.LockedUntil.Sub(throttle.LastFailedAt)
+			}
+			if blocked != c.wantBlocked {
+				t.Errorf("blocked for = %v, want %v", blocked, c.wantBlocked)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestRecordFailedAttempt_NotifiesLockoutOnce(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// the failure that reaches the limit asks for the email
+	expectLockedThrottle(mock, 9, time.Now().Add(-time.Minute), false)
+	mock.ExpectExec("UPDATE AuthThrottle SET FailedAttempts = \\?").WithArgs(10, sqlmock.AnyArg(), sqlmock.AnyArg(), true, "throttle-1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	// failures made while locked do not
+	expectLockedThrottle(mock, 10, time.Now().Add(-time.Minute), true)
+	mock.ExpectExec("UPDATE AuthThrottle SET FailedAttempts = \\?").WithArgs(11, sqlmock.AnyArg(), sqlmock.AnyArg(), true, "throttle-1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	repo := NewAuthThrottleRepository(db)
+	throttle, err := repo.RecordFailedAttempt("account:ada", testThrottlePolicy)
+	if err != nil {
+		t.Fatalf("error = %v, want nil", err)
+	}
+	if !throttle.NotifyLockout {
+		t.Errorf("notify lockout = %v, want %v", throttle.NotifyLockout, true)
+	}
+	throttle, err = repo.RecordFailedAttempt("account:ada", testThrottlePolicy)
+	if err != nil {
+		t.Fatalf("error = %v, want nil", err)
+	}
+	if throttle.NotifyLockout {
+		t.Errorf("notify lockout = %v, want %v", throttle.NotifyLockout, false)
+	}
+	if throttle.LockedUntil.Sub(throttle.LastFailedAt) != testThrottlePolicy.LockoutDuration {
+		t.Errorf("blocked for = %v, want %v", throttle.LockedUntil.Sub(throttle.LastFailedAt), testThrottlePolicy.LockoutDuration)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRecordFailedAttempt_ForgetsFailuresOutsideWindow(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	expectLockedThrottle(mock, 10, time.Now().Add(-2*time.Hour), true)
+	mock.ExpectExec("UPDATE AuthThrottle SET FailedAttempts = \\?").WithArgs(1, sqlmock.AnyArg(), nil, false, "throttle-1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	throttle, err := NewAuthThrottleRepository(db).RecordFailedAttempt("account:ada", testThrottlePolicy)
+	if err != nil {
+		t.Fatalf("error = %v, want nil", err)
+	}
+	if throttle.FailedAttempts != 1 || !throttle.LockedUntil.IsZero() || throttle.LockoutNotified {
+		t.Errorf("throttle = %+v, want one failure, no block and no lockout notified", throttle)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -3,6 +3,8 @@ package services
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"time"
 
 	"github.com/kaasikodes/e-commerce-go/constants"
 	"github.com/kaasikodes/e-commerce-go/models"
@@ -20,168 +22,296 @@ func NewTokenRepository(db *sql.DB) *TokenRepository {
 	}
 }
 
+// token tables
+const (
+	verificationTokenTable = "VerificationToken"
+	passwordResetTokenTable = "PasswordResetToken"
+	magicLinkTokenTable = "MagicLinkToken"
+	emailChangeTokenTable = "EmailChangeToken"
+)
+
 
 func (r *TokenRepository) CreateVerificationToken(input types.CreateTokenInput) (models.VerificationToken, error){
+	token, err := r.createToken(verificationTokenTable, input.Email, constants.VerificationTokenTTL)
+	return models.VerificationToken(token), err
+}
+func (r *TokenRepository) DeleteVerificationToken(email string) ( error){
+	return r.deleteTokens(verificationTokenTable, email)
+}
+func (r *TokenRepository) CreatePasswordResetToken(input types.CreateTokenInput) (models.PasswordResetToken, error){
+	token, err := r.createToken(passwordResetTokenTable, input.Email, constants.PasswordResetTokenTTL)
+	return models.PasswordResetToken(token), err
+}
+func (r *TokenRepository) DeletePasswordResetToken(email string) ( error){
+	return r.deleteTokens(passwordResetTokenTable, email)
+}
+func (r *TokenRepository) ConsumeVerificationToken(email string, token string) ( error){
+	return r.consumeToken(verificationTokenTable, email, token)
+}
+func (r *TokenRepository) ConsumePasswordResetToken(email string, token string) ( error){
+	return r.consumeToken(passwordResetTokenTable, email, token)
+}
+func (r *TokenRepository) CreateMagicLinkToken(input types.CreateMagicLinkTokenInput) (models.MagicLinkToken, error){
 	db := r.db
+	token := models.MagicLinkToken{}
+	// limit how many unused tokens an email can have at a time
+	outstanding, err := r.countOutstandingTokens(magicLinkTokenTable, input.Email)
+	if err !=nil {
+		return token, err
+	}
+	if outstanding >= constants.MaxOutstandingTokensPerEmail {
+		return token, constants.ErrTooManyOutstandingTokens
+	}
 	// prepare query
-	query := `INSERT INTO VerificationToken (ID, Token, Email, ExpiresAt) VALUES (?, ?, ?,?)`
+	query := `INSERT INTO MagicLinkToken (ID, TokenHash, DeviceHash, Email, ExpiresAt) VALUES (?, ?, ?, ?, ?)`
 	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
 	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
 	defer cancel()
 	// prepare the statement
 	stmt, err := db.PrepareContext(ctx, query)
-	token := models.VerificationToken{}
 	if err !=nil {
 		return token, err
 	}
 	defer stmt.Close() //close the statement after use
 	// execute the statement
-	id, _ := utils.GenerateRandomID(10)
-	tokenVal, _ := utils.GenerateRandomID(14)
-	res, err := stmt.ExecContext(ctx, id, tokenVal, input.Email, constants.VerificationTokenExpiresAt)
+	id := utils.NewID()
+	tokenVal, err := utils.GenerateSecureToken()
 	if err !=nil {
 		return token, err
 	}
-	_, err = res.RowsAffected()
+	now := time.Now()
+	expiresAt := now.Add(constants.MagicLinkTokenTTL)
+	tokenHash := utils.HashToken(tokenVal)
+	deviceHash := utils.HashToken(input.DeviceToken)
+	_, err = stmt.ExecContext(ctx, id, tokenHash, deviceHash, input.Email, expiresAt)
 	if err !=nil {
 		return token, err
 	}
 
-	token.Email = input.Email
-	token.ID = id
-	token.Token = tokenVal
-	token.ExpiresAt = constants.VerificationTokenExpiresAt
-
+	token = models.MagicLinkToken{ID: id, Email: input.Email, Token: tokenVal, TokenHash: tokenHash, DeviceHash: deviceHash, CreatedAt: now, ExpiresAt: expiresAt}
 	return token, nil
 }
-func (r *TokenRepository) DeleteVerificationToken(email string) ( error){
-	db := r.db
-	// prepare query
-	query := "DELETE FROM VerificationToken WHERE Email = ?"
+func (r *TokenRepository) ConsumeMagicLinkToken(email string, tokenVal string, deviceToken string) ( error){
 	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
 	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
 	defer cancel()
-	// prepare the statement
-	stmt, err := db.PrepareContext(ctx, query)
+	// the link only works on the device it was requested from, and is deleted as it is checked so it can only be used once
+	res, err := r.db.ExecContext(ctx, "DELETE FROM MagicLinkToken WHERE Email = ? AND TokenHash = ? AND DeviceHash = ? AND ExpiresAt > ?", email, utils.HashToken(tokenVal), utils.HashToken(deviceToken), time.Now())
 	if err !=nil {
-		return  err
+		return err
 	}
-	
-	
-	defer stmt.Close() //close the statement after use
-
-	// execute the statement
-	_, err = stmt.ExecContext(ctx, email)
+	count, err := res.RowsAffected()
 	if err !=nil {
-		return  err
+		return err
 	}
-	
-	
-	return  nil
+	if count == 0 {
+		return constants.ErrInvalidOrExpiredToken
+	}
+
+	return r.deleteTokens(magicLinkTokenTable, email)
 }
-func (r *TokenRepository) CreatePasswordResetToken(input types.CreateTokenInput) (models.PasswordResetToken, error){
+func (r *TokenRepository) DeleteMagicLinkToken(email string) ( error){
+	return r.deleteTokens(magicLinkTokenTable, email)
+}
+func (r *TokenRepository) CreateEmailChangeToken(input types.CreateEmailChangeTokenInput) (models.EmailChangeToken, error){
 	db := r.db
+	token := models.EmailChangeToken{}
+	if err := r.DeleteEmailChangeToken(input.UserID); err !=nil {
+		return token, err
+	}
 	// prepare query
-	query := `INSERT INTO PasswordResetToken (ID, Token, Email, ExpiresAt) VALUES (?, ?, ?, ?)`
+	query := `INSERT INTO EmailChangeToken (ID, TokenHash, UserID, NewEmail, ExpiresAt) VALUES (?, ?, ?, ?, ?)`
 	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
 	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
 	defer cancel()
 	// prepare the statement
 	stmt, err := db.PrepareContext(ctx, query)
-	token := models.PasswordResetToken{}
 	if err !=nil {
 		return token, err
 	}
 	defer stmt.Close() //close the statement after use
 	// execute the statement
-	id, _ := utils.GenerateRandomID(10)
-	tokenVal, _ := utils.GenerateRandomID(14)
-	res, err := stmt.ExecContext(ctx, id, tokenVal, input.Email, constants.VerificationTokenExpiresAt)
+	id := utils.NewID()
+	tokenVal, err := utils.GenerateSecureToken()
 	if err !=nil {
 		return token, err
 	}
-	_, err = res.RowsAffected()
+	now := time.Now()
+	expiresAt := now.Add(constants.EmailChangeTokenTTL)
+	tokenHash := utils.HashToken(tokenVal)
+	_, err = stmt.ExecContext(ctx, id, tokenHash, input.UserID, input.NewEmail, expiresAt)
 	if err !=nil {
 		return token, err
 	}
 
-	token.Email = input.Email
-	token.ID = id
-	token.Token = tokenVal
-	token.ExpiresAt = constants.VerificationTokenExpiresAt
-
+	token = models.EmailChangeToken{ID: id, UserID: input.UserID, NewEmail: input.NewEmail, Token: tokenVal, TokenHash: tokenHash, CreatedAt: now, ExpiresAt: expiresAt}
 	return token, nil
 }
-func (r *TokenRepository) DeletePasswordResetToken(email string) ( error){
-	db := r.db
-	// prepare query
-	query := "DELETE FROM PasswordResetToken WHERE Email = ?"
+func (r *TokenRepository) ConsumeEmailChangeToken(newEmail string, tokenVal string) (models.EmailChangeToken, error){
 	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
 	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
 	defer cancel()
-	// prepare the statement
-	stmt, err := db.PrepareContext(ctx, query)
+	token := models.EmailChangeToken{}
+	tokenHash := utils.HashToken(tokenVal)
+	now := time.Now()
+	err := r.db.QueryRowContext(ctx, `SELECT ID, UserID, NewEmail, TokenHash, CreatedAt, ExpiresAt FROM EmailChangeToken WHERE NewEmail = ? AND TokenHash = ? AND ExpiresAt > ?`, newEmail, tokenHash, now).
+		Scan(&token.ID, &token.UserID, &token.NewEmail, &token.TokenHash, &token.CreatedAt, &token.ExpiresAt)
+	if err == sql.ErrNoRows {
+		return token, constants.ErrInvalidOrExpiredToken
+	}
 	if err !=nil {
-		return  err
+		return token, err
 	}
-	
-	
-	defer stmt.Close() //close the statement after use
-
-	// execute the statement
-	_, err = stmt.ExecContext(ctx, email)
+	// the token is only used by whoever manages to delete it, so the same link confirmed twice at once only changes the email once
+	res, err := r.db.ExecContext(ctx, `DELETE FROM EmailChangeToken WHERE NewEmail = ? AND TokenHash = ? AND ExpiresAt > ?`, newEmail, tokenHash, now)
 	if err !=nil {
-		return  err
+		return token, err
 	}
-	
-	
-	return  nil
+	count, err := res.RowsAffected()
+	if err !=nil {
+		return token, err
+	}
+	if count == 0 {
+		return models.EmailChangeToken{}, constants.ErrInvalidOrExpiredToken
+	}
+
+	return token, nil
 }
-func (r *TokenRepository) RetrieveVerificationToken(email string) (models.VerificationToken, error){
+func (r *TokenRepository) DeleteEmailChangeToken(userID string) ( error){
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	_, err := r.db.ExecContext(ctx, "DELETE FROM EmailChangeToken WHERE UserID = ?", userID)
+	return err
+}
+
+// DeleteExpiredTokens purges every expired token and returns how many were removed
+func (r *TokenRepository) DeleteExpiredTokens() (int64, error){
+	var total int64
+	for _, table := range []string{verificationTokenTable, passwordResetTokenTable, magicLinkTokenTable, emailChangeTokenTable} {
+		// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+		ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+		res, err := r.db.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE ExpiresAt <= ?", table), time.Now())
+		cancel()
+		if err != nil {
+			return total, err
+		}
+		count, err := res.RowsAffected()
+		if err != nil {
+			return total, err
+		}
+		total += count
+	}
+	return total, nil
+}
+
+// private
+// create a token, only its hash is stored and the plain value is only ever returned here
+func (r *TokenRepository) createToken(table string, email string, ttl time.Duration) (models.VerificationToken, error){
 	db := r.db
+	token := models.VerificationToken{}
+	// limit how many unused tokens an email can have at a time
+	outstanding, err := r.countOutstandingTokens(table, email)
+	if err !=nil {
+		return token, err
+	}
+	if outstanding >= constants.MaxOutstandingTokensPerEmail {
+		return token, constants.ErrTooManyOutstandingTokens
+	}
 	// prepare query
-	query := `SELECT ID,  Email, Token, CreatedAt, ExpiresAt FROM VerificationToken WHERE Email = ?`
+	query := fmt.Sprintf(`INSERT INTO %s (ID, TokenHash, Email, ExpiresAt) VALUES (?, ?, ?, ?)`, table)
 	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
 	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
 	defer cancel()
 	// prepare the statement
-	token := models.VerificationToken{}
 	stmt, err := db.PrepareContext(ctx, query)
 	if err !=nil {
 		return token, err
 	}
 	defer stmt.Close() //close the statement after use
 	// execute the statement
-	
-	 err = stmt.QueryRowContext(ctx,  email).Scan(&token.ID, &token.Email, &token.Token, &token.CreatedAt, &token.ExpiresAt)
-	 if err !=nil {
+	id := utils.NewID()
+	tokenVal, err := utils.GenerateSecureToken()
+	if err !=nil {
+		return token, err
+	}
+	// expiry is worked out per token, at the time it is created
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	tokenHash := utils.HashToken(tokenVal)
+	res, err := stmt.ExecContext(ctx, id, tokenHash, email, expiresAt)
+	if err !=nil {
 		return token, err
 	}
-	
+	_, err = res.RowsAffected()
+	if err !=nil {
+		return token, err
+	}
+
+	token.Email = email
+	token.ID = id
+	token.Token = tokenVal
+	token.TokenHash = tokenHash
+	token.CreatedAt = now
+	token.ExpiresAt = expiresAt
+
 	return token, nil
+}
+func (r *TokenRepository) countOutstandingTokens(table string, email string) (int, error){
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	count := 0
+	err := r.db.QueryRowContext(ctx, fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE Email = ? AND ExpiresAt > ?", table), email, time.Now()).Scan(&count)
+	return count, err
+}
+// consume the unexpired token of an email that matches the plain token. The token is deleted as it is checked,
+// so when the same token is used twice at once only one of them finds it
+func (r *TokenRepository) consumeToken(table string, email string, tokenVal string) ( error){
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	// matching the hash in the query is what replaces comparing each of the email's hashes with utils.CompareTokenHash.
+	// The time the database takes to compare can only give away how much of the stored sha256 hash was guessed, never the plain token,
+	// and the plain token can't be worked out from its hash, so the comparison doesn't need to be constant time.
+	// Only the deleting query can match the token, which is what makes it single use
+	res, err := r.db.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE Email = ? AND TokenHash = ? AND ExpiresAt > ?", table), email, utils.HashToken(tokenVal), time.Now())
+	if err !=nil {
+		return err
+	}
+	count, err := res.RowsAffected()
+	if err !=nil {
+		return err
+	}
+	if count == 0 {
+		return constants.ErrInvalidOrExpiredToken
+	}
 
+	return r.deleteTokens(table, email)
 }
-func (r *TokenRepository) RetrievePasswordResetToken(email string) (models.PasswordResetToken, error){
+// tokens are single use, so once one is used every token of the email is removed
+func (r *TokenRepository) deleteTokens(table string, email string) ( error){
 	db := r.db
 	// prepare query
-	query := `SELECT ID,  Email, Token, CreatedAt, ExpiresAt FROM PasswordResetToken WHERE Email = ?`
+	query := fmt.Sprintf("DELETE FROM %s WHERE Email = ?", table)
 	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
 	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
 	defer cancel()
 	// prepare the statement
-	token := models.PasswordResetToken{}
 	stmt, err := db.PrepareContext(ctx, query)
 	if err !=nil {
-		return token, err
+		return  err
 	}
+
+
 	defer stmt.Close() //close the statement after use
+
 	// execute the statement
-	
-	 err = stmt.QueryRowContext(ctx,  email).Scan(&token.ID, &token.Email, &token.Token, &token.CreatedAt, &token.ExpiresAt)
-	 if err !=nil {
-		return token, err
+	_, err = stmt.ExecContext(ctx, email)
+	if err !=nil {
+		return  err
 	}
-	
-	return token, nil
 
+
+	return  nil
 }
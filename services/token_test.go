@@ -0,0 +1,193 @@
+package services
+
+import (
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/types"
+	"github.com/kaasikodes/e-commerce-go/utils"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+// capturedArg matches any argument and keeps it so the test can look at it afterwards
+type capturedArg struct {
+	value driver.Value
+}
+
+func (a *capturedArg) Match(v driver.Value) bool {
+	a.value = v
+	return true
+}
+
+func TestCreateVerificationToken_StoresHashWithExpiry(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	storedHash := &capturedArg{}
+	storedExpiry := &capturedArg{}
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM VerificationToken").WithArgs("ada@example.com", sqlmock.AnyArg()).WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(0))
+	mock.ExpectPrepare("INSERT INTO VerificationToken").ExpectExec().WithArgs(sqlmock.AnyArg(), storedHash, "ada@example.com", storedExpiry).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	before := time.Now()
+	token, err := NewTokenRepository(db).CreateVerificationToken(types.CreateTokenInput{Email: "ada@example.com"})
+	if err != nil {
+		t.Fatalf("error = %v, want nil", err)
+	}
+	if storedHash.value == token.Token || storedHash.value != utils.HashToken(token.Token) {
+		t.Errorf("stored token = %v, want the hash %v", storedHash.value, utils.HashToken(token.Token))
+	}
+	expiresAt, _ := storedExpiry.value.(time.Time)
+	if expiresAt.Before(before.Add(constants.VerificationTokenTTL)) || expiresAt.After(time.Now().Add(constants.VerificationTokenTTL)) {
+		t.Errorf("expires at = %v, want %v after creation", expiresAt, constants.VerificationTokenTTL)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCreateVerificationToken_TooManyOutstanding(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM VerificationToken").WithArgs("ada@example.com", sqlmock.AnyArg()).WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(constants.MaxOutstandingTokensPerEmail))
+
+	_, err = NewTokenRepository(db).CreateVerificationToken(types.CreateTokenInput{Email: "ada@example.com"})
+	if err != constants.ErrTooManyOutstandingTokens {
+		t.Errorf("error = %v, want %v", err, constants.ErrTooManyOutstandingTokens)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestConsumePasswordResetToken(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// the token is looked up by its hash and only while unexpired, and the email's other tokens go with it
+	mock.ExpectExec("DELETE FROM PasswordResetToken WHERE Email = \\? AND TokenHash = \\? AND ExpiresAt > \\?").WithArgs("ada@example.com", utils.HashToken("plain-token"), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectPrepare("DELETE FROM PasswordResetToken WHERE Email = \\?").ExpectExec().WithArgs("ada@example.com").WillReturnResult(sqlmock.NewResult(0, 2))
+
+	if err := NewTokenRepository(db).ConsumePasswordResetToken("ada@example.com", "plain-token"); err != nil {
+		t.Errorf("error = %v, want nil", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestConsumePasswordResetToken_ExpiredOrUsed(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// an expired token, a wrong one and one that has just been used all delete nothing
+	mock.ExpectExec("DELETE FROM PasswordResetToken WHERE Email = \\? AND TokenHash = \\? AND ExpiresAt > \\?").WithArgs("ada@example.com", utils.HashToken("plain-token"), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = NewTokenRepository(db).ConsumePasswordResetToken("ada@example.com", "plain-token")
+	if err != constants.ErrInvalidOrExpiredToken {
+		t.Errorf("error = %v, want %v", err, constants.ErrInvalidOrExpiredToken)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestConsumeMagicLinkToken(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("DELETE FROM MagicLinkToken WHERE Email = \\? AND TokenHash = \\? AND DeviceHash = \\? AND ExpiresAt > \\?").
+		WithArgs("ada@example.com", utils.HashToken("plain-token"), utils.HashToken("device-token"), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectPrepare("DELETE FROM MagicLinkToken WHERE Email = \\?").ExpectExec().WithArgs("ada@example.com").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if err := NewTokenRepository(db).ConsumeMagicLinkToken("ada@example.com", "plain-token", "device-token"); err != nil {
+		t.Errorf("error = %v, want nil", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestConsumeMagicLinkToken_OtherDeviceOrUsed(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("DELETE FROM MagicLinkToken WHERE Email = \\? AND TokenHash = \\? AND DeviceHash = \\? AND ExpiresAt > \\?").
+		WithArgs("ada@example.com", utils.HashToken("plain-token"), utils.HashToken("other-device"), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = NewTokenRepository(db).ConsumeMagicLinkToken("ada@example.com", "plain-token", "other-device")
+	if err != constants.ErrInvalidOrExpiredToken {
+		t.Errorf("error = %v, want %v", err, constants.ErrInvalidOrExpiredToken)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func expectEmailChangeToken(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery("FROM EmailChangeToken WHERE NewEmail = \\? AND TokenHash = \\? AND ExpiresAt > \\?").WithArgs("new@example.com", utils.HashToken("plain-token"), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"ID", "UserID", "NewEmail", "TokenHash", "CreatedAt", "ExpiresAt"}).
+			AddRow("token-1", "user-1", "new@example.com", utils.HashToken("plain-token"), time.Now(), time.Now().Add(time.Hour)))
+}
+
+func TestConsumeEmailChangeToken(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	expectEmailChangeToken(mock)
+	mock.ExpectExec("DELETE FROM EmailChangeToken WHERE NewEmail = \\? AND TokenHash = \\? AND ExpiresAt > \\?").WithArgs("new@example.com", utils.HashToken("plain-token"), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	token, err := NewTokenRepository(db).ConsumeEmailChangeToken("new@example.com", "plain-token")
+	if err != nil {
+		t.Fatalf("error = %v, want nil", err)
+	}
+	if token.UserID != "user-1" {
+		t.Errorf("user id = %v, want %v", token.UserID, "user-1")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestConsumeEmailChangeToken_UsedInTheMeantime(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// another confirmation deleted the token between it being found and being deleted
+	expectEmailChangeToken(mock)
+	mock.ExpectExec("DELETE FROM EmailChangeToken WHERE NewEmail = \\? AND TokenHash = \\? AND ExpiresAt > \\?").WithArgs("new@example.com", utils.HashToken("plain-token"), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	_, err = NewTokenRepository(db).ConsumeEmailChangeToken("new@example.com", "plain-token")
+	if err != constants.ErrInvalidOrExpiredToken {
+		t.Errorf("error = %v, want %v", err, constants.ErrInvalidOrExpiredToken)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
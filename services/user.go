@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"database/sql"
+	"slices"
 	"strings"
 	"time"
 
@@ -45,7 +46,7 @@ func (r *UserRepository) AddUser(input types.AddUserInput) (models.User, error){
 	}
 	defer stmt.Close() //close the statement after use
 	// execute the statement
-	id, _ := utils.GenerateRandomID(10)
+	id := utils.NewID()
 	res, err := stmt.ExecContext(ctx, id, input.Name, input.Email, input.Password, input.Image, strings.Join(input.UserRoles, ","))
 	if err !=nil {
 		return user, err
@@ -58,14 +59,14 @@ func (r *UserRepository) AddUser(input types.AddUserInput) (models.User, error){
 	user = models.User{ID:id, Name: input.Name, Email: input.Email, Image: input.Image}
 	// create the customer and seller records based on the user roles specified
 	if(strings.Contains(strings.Join(input.UserRoles, ","), "customer")) {
-		customer, err := r.createCustomerProfile(id)
+		customer, err := r.createCustomerProfile(db, id)
 		if err !=nil {
 			return user, err
 		}
 		user.Customer = &customer
 	}
 	if(strings.Contains(strings.Join(input.UserRoles, ","), "seller")) {
-		seller, err := r.createSellerProfile(id)
+		seller, err := r.createSellerProfile(db, id)
 		if err !=nil {
 			return user, err
 		}
@@ -74,8 +75,12 @@ func (r *UserRepository) AddUser(input types.AddUserInput) (models.User, error){
 
 	return user, nil
 }
-func (r *UserRepository) createCustomerProfile(userId string) (models.Customer, error){
-	db := r.db
+type profilePreparer interface {
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+// the profile is created with db, which is the transaction when the user row is locked by it
+func (r *UserRepository) createCustomerProfile(db profilePreparer, userId string) (models.Customer, error){
 	// prepare query
 	query := `INSERT INTO Customer (ID,UserID) VALUES (?,?)`
 	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
@@ -89,7 +94,7 @@ func (r *UserRepository) createCustomerProfile(userId string) (models.Customer,
 	}
 	defer stmt.Close() //close the statement after use
 	// execute the statement
-	id, _ := utils.GenerateRandomID(10)
+	id := utils.NewID()
 	res, err := stmt.ExecContext(ctx, id, userId)
 	if err !=nil {
 		return customer, err
@@ -103,8 +108,7 @@ func (r *UserRepository) createCustomerProfile(userId string) (models.Customer,
 
 	return models.Customer{ID:id, UserID: userId, }, nil
 }
-func (r *UserRepository) createSellerProfile(userId string) (models.Seller, error){
-	db := r.db
+func (r *UserRepository) createSellerProfile(db profilePreparer, userId string) (models.Seller, error){
 	// prepare query
 	query := `INSERT INTO Seller (ID,UserID) VALUES (?,?)`
 	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
@@ -118,7 +122,7 @@ func (r *UserRepository) createSellerProfile(userId string) (models.Seller, erro
 	}
 	defer stmt.Close() //close the statement after use
 	// execute the statement
-	id, _ := utils.GenerateRandomID(10)
+	id := utils.NewID()
 	res, err := stmt.ExecContext(ctx, id, userId)
 	if err !=nil {
 		return seller, err
@@ -166,6 +170,38 @@ func (r *UserRepository) VerifyUser(email string) (models.User, error){
 	
 	return user, nil
 }
+// ClaimUnverifiedUser hands an account whose email was never verified to whoever has just proved they own the email.
+// Whoever signed up with it may not be the owner, so the password they chose is replaced and the sign ins they had are ended
+func (r *UserRepository) ClaimUnverifiedUser(id string, password string) (models.User, error){
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err !=nil {
+		return models.User{}, err
+	}
+	defer tx.Rollback()
+	now := time.Now()
+	query := `UPDATE User SET Password = ?, RefreshToken = NULL, AccessToken = NULL, EmailVerified = TRUE, EmailVerifiedAt = ? WHERE ID = ? AND EmailVerified = FALSE`
+	res, err := tx.ExecContext(ctx, query, password, now, id)
+	if err !=nil {
+		return models.User{}, err
+	}
+	claimed, err := res.RowsAffected()
+	if err !=nil {
+		return models.User{}, err
+	}
+	// verified in the meantime by the owner of the email, the account is theirs already
+	if claimed > 0 {
+		if _, err = tx.ExecContext(ctx, `UPDATE APIKey SET RevokedAt = ? WHERE UserID = ? AND RevokedAt IS NULL`, now, id); err !=nil {
+			return models.User{}, err
+		}
+	}
+	if err = tx.Commit(); err !=nil {
+		return models.User{}, err
+	}
+	return r.RetrieveUserByID(id)
+}
 func (r *UserRepository) UpdateUserPassword(id string, data types.UpdateUserPwdInput) (models.User, error){
 
 	db := r.db
@@ -204,7 +240,7 @@ func (r *UserRepository) UpdateUserProfile(id string, data types.UpdateUserProfi
 	db := r.db
 	
 	// prepare query
-	query := "UPDATE User SET Name = ?, Email = ?, Image = ? WHERE ID = ?"
+	query := "UPDATE User SET Name = ? WHERE ID = ?"
 	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
 	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
 	defer cancel()
@@ -217,7 +253,7 @@ func (r *UserRepository) UpdateUserProfile(id string, data types.UpdateUserProfi
 	}
 	defer stmt.Close() //close the statement after use
 	
-	res, err := stmt.ExecContext(ctx,  data.Name, data.Email, data.Image, id )
+	res, err := stmt.ExecContext(ctx,  data.Name, id )
 	if err !=nil {
 		return user, err
 	}
@@ -234,6 +270,73 @@ func (r *UserRepository) UpdateUserProfile(id string, data types.UpdateUserProfi
 	return user, nil
 }
 
+func (r *UserRepository) UpdateUserEmail(id string, email string) (models.User, error){
+	return r.updateUserColumns(id, "Email = ?, EmailVerified = ?, EmailVerifiedAt = ?", email, true, time.Now())
+}
+
+func (r *UserRepository) UpdateUserImage(id string, image string) (models.User, error){
+	return r.updateUserColumns(id, "Image = ?", image)
+}
+
+func (r *UserRepository) AddUserRole(id string, role string) (models.User, error){
+	db := r.db
+	user := models.User{}
+	profileTables := map[string]string{"customer": "Customer", "seller": "Seller"}
+	profileTable, ok := profileTables[role]
+	if !ok {
+		return user, constants.ErrInvalidUserRole
+	}
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	// the user row is locked so two requests for the same role can't both create a profile
+	tx, err := db.BeginTx(ctx, nil)
+	if err !=nil {
+		return user, err
+	}
+	defer tx.Rollback()
+	var roles string
+	if err = tx.QueryRowContext(ctx, `SELECT Roles FROM User WHERE ID = ? FOR UPDATE`, id).Scan(&roles); err !=nil {
+		return user, err
+	}
+	// the profile is what matters, the roles column is only brought in line with it
+	profiles := 0
+	if err = tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM " + profileTable + " WHERE UserID = ?", id).Scan(&profiles); err !=nil {
+		return user, err
+	}
+	roleList := []string{}
+	for _, r := range strings.Split(roles, ",") {
+		if r != "" {
+			roleList = append(roleList, r)
+		}
+	}
+	hasRole := slices.Contains(roleList, role)
+	if profiles > 0 && hasRole {
+		return user, constants.ErrUserRoleAlreadyAdded
+	}
+	if profiles == 0 {
+		if role == "customer" {
+			_, err = r.createCustomerProfile(tx, id)
+		} else {
+			_, err = r.createSellerProfile(tx, id)
+		}
+		if err !=nil {
+			return user, err
+		}
+	}
+	if !hasRole {
+		roleList = append(roleList, role)
+	}
+	if _, err = tx.ExecContext(ctx, `UPDATE User SET Roles = ? WHERE ID = ?`, strings.Join(roleList, ","), id); err !=nil {
+		return user, err
+	}
+	if err = tx.Commit(); err !=nil {
+		return user, err
+	}
+
+	return r.RetrieveUserByID(id)
+}
+
 func (r *UserRepository) RetrieveSellers(input types.RetrievUsersInput) (types.PaginatedDataOutput, error) {
     db := r.db
     // Prepare query
@@ -436,56 +539,19 @@ func (r *UserRepository) RetrieveUsers(input types.RetrievUsersInput) (types.Pag
 }
 
 func (r *UserRepository) RetrieveUserByID(id string) (models.User, error){
-	db := r.db
-	// prepare query
-	query := `SELECT
-				u.ID AS user_id,
-				u.Name AS user_name,
-				u.Email AS user_email,
-				u.Image AS user_image,
-				u.Password AS user_password,
-				u.EmailVerified AS user_email_verified,
-				u.CreatedAt AS user_created_at,
-				u.UpdatedAt AS user_updated_at,
-				c.ID AS customer_id,
-				c.UserID AS customer_user_id,
-				c.CreatedAt AS customer_created_at,
-				c.UpdatedAt AS customer_updated_at,
-				s.ID AS seller_id,
-				s.UserID AS seller_user_id,
-				s.CreatedAt AS seller_created_at,
-				s.UpdatedAt AS seller_updated_at
-			FROM
-				User u
-			JOIN
-				Customer c ON u.ID = c.UserID
-			JOIN
-				Seller s ON u.ID = s.UserID
-			WHERE
-				u.ID = ?
-	`
-	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
-	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
-	defer cancel()
-	// prepare the statement
-	user := models.User{}
-	user.Customer = &models.Customer{}
-	user.Seller = &models.Seller{}
-	stmt, err := db.PrepareContext(ctx, query)
-	if err !=nil {
-		return user, err
-	}
-	defer stmt.Close() //close the statement after use
-	// execute the statement
-	
-	 err = stmt.QueryRowContext(ctx,  id).Scan(&user.ID, &user.Name, &user.Email, &user.Image, &user.Password, &user.EmailVerified, &user.CreatedAt, &user.UpdatedAt, &user.Customer.ID, &user.Customer.UserID, &user.Customer.CreatedAt, &user.Customer.UpdatedAt, &user.Seller.ID, &user.Seller.UserID, &user.Seller.CreatedAt, &user.Seller.UpdatedAt)
-	 if err !=nil {
-		return user, err
-	}
-	return user, nil
-
+	return r.retrieveUser("u.ID = ?", id)
 }
 func (r *UserRepository) RetrieveUserByEmail(email string) (models.User, error){
+	return r.retrieveUser("u.Email = ?", email)
+}
+func (r *UserRepository) RetrieveUserBySellerID(sellerId string) (models.User, error){
+	return r.retrieveUser("s.ID = ?", sellerId)
+}
+func (r *UserRepository) RetrieveUserByCustomerID(customerId string) (models.User, error){
+	return r.retrieveUser("c.ID = ?", customerId)
+}
+// retrieve a single user matching the where clause, along with the customer and seller profiles the user has
+func (r *UserRepository) retrieveUser(where string, arg interface{}) (models.User, error){
 	db := r.db
 	// prepare query
 	query := `SELECT
@@ -504,34 +570,51 @@ func (r *UserRepository) RetrieveUserByEmail(email string) (models.User, error){
 				s.ID AS seller_id,
 				s.UserID AS seller_user_id,
 				s.CreatedAt AS seller_created_at,
-				s.UpdatedAt AS seller_updated_at
+				s.UpdatedAt AS seller_updated_at,
+				u.Roles AS user_roles
 			FROM
 				User u
-			JOIN
+			LEFT JOIN
 				Customer c ON u.ID = c.UserID
-			JOIN
+			LEFT JOIN
 				Seller s ON u.ID = s.UserID
 			WHERE
-				u.Email = ?
-	`
+				` + where
 	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
 	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
 	defer cancel()
 	// prepare the statement
 	user := models.User{}
-	user.Customer = &models.Customer{}
-	user.Seller = &models.Seller{}
 	stmt, err := db.PrepareContext(ctx, query)
 	if err !=nil {
 		return user, err
 	}
 	defer stmt.Close() //close the statement after use
 	// execute the statement
-	
-	 err = stmt.QueryRowContext(ctx,  email).Scan(&user.ID, &user.Name, &user.Email, &user.Image, &user.Password, &user.EmailVerified, &user.CreatedAt, &user.UpdatedAt, &user.Customer.ID, &user.Customer.UserID, &user.Customer.CreatedAt, &user.Customer.UpdatedAt, &user.Seller.ID, &user.Seller.UserID, &user.Seller.CreatedAt, &user.Seller.UpdatedAt)
-	 if err !=nil {
+	// a user only has the profiles of the roles they registered with, so either join can be empty
+	var image sql.NullString
+	var emailVerified sql.NullBool
+	var customerID, customerUserID, sellerID, sellerUserID sql.NullString
+	var customerCreatedAt, customerUpdatedAt, sellerCreatedAt, sellerUpdatedAt sql.NullTime
+	var roles string
+	err = stmt.QueryRowContext(ctx, arg).Scan(&user.ID, &user.Name, &user.Email, &image, &user.Password, &emailVerified, &user.CreatedAt, &user.UpdatedAt, &customerID, &customerUserID, &customerCreatedAt, &customerUpdatedAt, &sellerID, &sellerUserID, &sellerCreatedAt, &sellerUpdatedAt, &roles)
+	if err !=nil {
 		return user, err
 	}
+	user.Roles = []string{}
+	for _, role := range strings.Split(roles, ",") {
+		if role != "" {
+			user.Roles = append(user.Roles, role)
+		}
+	}
+	user.Image = image.String
+	user.EmailVerified = emailVerified.Bool
+	if customerID.Valid {
+		user.Customer = &models.Customer{ID: customerID.String, UserID: customerUserID.String, CreatedAt: customerCreatedAt.Time, UpdatedAt: customerUpdatedAt.Time}
+	}
+	if sellerID.Valid {
+		user.Seller = &models.Seller{ID: sellerID.String, UserID: sellerUserID.String, CreatedAt: sellerCreatedAt.Time, UpdatedAt: sellerUpdatedAt.Time}
+	}
 	return user, nil
 
 }
@@ -573,7 +656,7 @@ func (r *UserRepository) AddMultipleUsers(input []types.MultipleUserInput) ([]ty
 	var params []interface{}
 	for _, data := range input {
 		inserts = append(inserts, "(?, ?, ?, ?, ?)")
-		id, _:= utils.GenerateRandomID(10)
+		id := utils.NewID()
 		params = append(params, id, data.Name, data.Email, data.Password, strings.Join(data.UserRoles, ","))
 
 	}
@@ -600,4 +683,29 @@ func (r *UserRepository) AddMultipleUsers(input []types.MultipleUserInput) ([]ty
 	// ensure input have
 	return input, nil
 
-}
\ No newline at end of file
+}
+
+// private
+// updateUserColumns runs an update with the set clause passed in on a single user and returns the updated user
+func (r *UserRepository) updateUserColumns(id string, set string, args ...interface{}) (models.User, error){
+	db := r.db
+	// prepare query
+	query := "UPDATE User SET " + set + " WHERE ID = ?"
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	// prepare the statement
+	user := models.User{}
+	stmt, err := db.PrepareContext(ctx, query)
+	if err !=nil {
+		return user, err
+	}
+	defer stmt.Close() //close the statement after use
+	// execute the statement
+	_, err = stmt.ExecContext(ctx, append(args, id)...)
+	if err !=nil {
+		return user, err
+	}
+
+	return r.RetrieveUserByID(id)
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func expectRetrieveUser(mock sqlmock.Sqlmock, customerID interface{}, sellerID interface{}, roles string) {
+	columns := []string{"user_id", "user_name", "user_email", "user_image", "user_password", "user_email_verified", "user_created_at", "user_updated_at",
+		"customer_id", "customer_user_id", "customer_created_at", "customer_updated_at", "seller_id", "seller_user_id", "seller_created_at", "seller_updated_at", "user_roles"}
+	var customerUserID, sellerUserID interface{}
+	if customerID != nil {
+		customerUserID = "user-1"
+	}
+	if sellerID != nil {
+		sellerUserID = "user-1"
+	}
+	mock.ExpectPrepare("FROM\\s+User u").ExpectQuery().WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow("user-1", "Ada", "ada@example.com", nil, "hash", true, time.Now(), time.Now(),
+			customerID, customerUserID, nil, nil, sellerID, sellerUserID, nil, nil, roles))
+}
+
+func TestAddUserRole_Customer(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT Roles FROM User WHERE ID = \\? FOR UPDATE").WithArgs("user-1").WillReturnRows(sqlmock.NewRows([]string{"Roles"}).AddRow("seller"))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM Customer WHERE UserID = \\?").WithArgs("user-1").WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(0))
+	// the profile is created in the transaction that holds the lock on the user
+	mock.ExpectPrepare("INSERT INTO Customer").ExpectExec().WithArgs(sqlmock.AnyArg(), "user-1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE User SET Roles = \\?").WithArgs("seller,customer", "user-1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectRetrieveUser(mock, "customer-1", "seller-1", "seller,customer")
+
+	user, err := NewUserRepository(db).AddUserRole("user-1", "customer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Customer == nil {
+		t.Error("the user has no customer profile")
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAddUserRole_Seller(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT Roles FROM User WHERE ID = \\? FOR UPDATE").WithArgs("user-1").WillReturnRows(sqlmock.NewRows([]string{"Roles"}).AddRow("customer"))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM Seller WHERE UserID = \\?").WithArgs("user-1").WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(0))
+	mock.ExpectPrepare("INSERT INTO Seller").ExpectExec().WithArgs(sqlmock.AnyArg(), "user-1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE User SET Roles = \\?").WithArgs("customer,seller", "user-1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectRetrieveUser(mock, "customer-1", "seller-1", "customer,seller")
+
+	user, err := NewUserRepository(db).AddUserRole("user-1", "seller")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Seller == nil {
+		t.Error("the user has no seller profile")
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAddUserRole_AlreadyAdded(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT Roles FROM User WHERE ID = \\? FOR UPDATE").WithArgs("user-1").WillReturnRows(sqlmock.NewRows([]string{"Roles"}).AddRow("customer,seller"))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM Seller WHERE UserID = \\?").WithArgs("user-1").WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(1))
+	mock.ExpectRollback()
+
+	_, err = NewUserRepository(db).AddUserRole("user-1", "seller")
+	if err != constants.ErrUserRoleAlreadyAdded {
+		t.Errorf("error = %v, want %v", err, constants.ErrUserRoleAlreadyAdded)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestClaimUnverifiedUser_ReplacesPasswordAndRevokesKeys(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE User SET Password = \\?, RefreshToken = NULL, AccessToken = NULL, EmailVerified = TRUE").
+		WithArgs("new-hash", sqlmock.AnyArg(), "user-1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE APIKey SET RevokedAt = \\?").WithArgs(sqlmock.AnyArg(), "user-1").WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+	expectRetrieveUser(mock, "customer-1", nil, "customer")
+
+	if _, err = NewUserRepository(db).ClaimUnverifiedUser("user-1", "new-hash"); err != nil {
+		t.Fatal(err)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestClaimUnverifiedUser_AlreadyVerified(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// the owner verified the email first, so their password and keys are left alone
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE User SET Password = \\?").WithArgs("new-hash", sqlmock.AnyArg(), "user-1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	expectRetrieveUser(mock, "customer-1", nil, "customer")
+
+	if _, err = NewUserRepository(db).ClaimUnverifiedUser("user-1", "new-hash"); err != nil {
+		t.Fatal(err)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,343 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/models"
+	"github.com/kaasikodes/e-commerce-go/types"
+	"github.com/kaasikodes/e-commerce-go/utils"
+)
+
+type WishlistRepository struct {
+	db *sql.DB
+}
+
+func NewWishlistRepository(db *sql.DB) *WishlistRepository {
+	return &WishlistRepository{
+		db: db,
+	}
+}
+
+const wishlistColumns = `ID, CustomerID, Name, COALESCE(ShareToken, ''), CreatedAt, UpdatedAt`
+
+// a product that is not for sale counts as out of stock, so it being put back on sale is a restock
+const wishlistProductStock = `IF(` + productForSaleCondition + `, p.Quantity, 0)`
+
+// an item is due an alert when its product came back in stock or got cheaper since the customer last heard about it
+const wishlistAlertCondition = `((wi.NotifyWhenInStock AND wi.LastSeenQuantity <= 0 AND ` + wishlistProductStock + ` > 0) OR (wi.NotifyOnPriceDrop AND p.Price < wi.LastSeenPrice AND ` + productForSaleCondition + `))`
+
+func (r *WishlistRepository) RetrieveWishlists(customerID string) ([]models.Wishlist, error){
+	db := r.db
+	// prepare query
+	query := `SELECT ` + wishlistColumns + ` FROM Wishlist WHERE CustomerID = ? ORDER BY CreatedAt, ID`
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	wishlists := []models.Wishlist{}
+	rows, err := db.QueryContext(ctx, query, customerID)
+	if err !=nil {
+		return wishlists, err
+	}
+	for rows.Next() {
+		wishlist, err := scanWishlist(rows)
+		if err !=nil {
+			rows.Close()
+			return wishlists, err
+		}
+		wishlists = append(wishlists, wishlist)
+	}
+	rows.Close()
+	if err = rows.Err(); err !=nil {
+		return wishlists, err
+	}
+	for i := range wishlists {
+		if wishlists[i].Items, err = r.retrieveWishlistItems(ctx, wishlists[i].ID); err !=nil {
+			return wishlists, err
+		}
+	}
+	return wishlists, nil
+}
+
+func (r *WishlistRepository) RetrieveWishlist(id string, customerID string) (models.Wishlist, error){
+	return r.retrieveWishlist(`ID = ? AND CustomerID = ?`, id, customerID)
+}
+
+func (r *WishlistRepository) CreateWishlist(customerID string, input types.SaveWishlistInput) (models.Wishlist, error){
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	count := 0
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM Wishlist WHERE CustomerID = ?`, customerID).Scan(&count); err !=nil {
+		return models.Wishlist{}, err
+	}
+	if count >= constants.MaxWishlistsPerCustomer {
+		return models.Wishlist{}, constants.ErrTooManyWishlists
+	}
+	id := utils.NewID()
+	_, err := r.db.ExecContext(ctx, `INSERT INTO Wishlist (ID, CustomerID, Name) VALUES (?, ?, ?)`, id, customerID, input.Name)
+	if isDuplicateKeyError(err) {
+		return models.Wishlist{}, constants.ErrWishlistNameTaken
+	}
+	if err !=nil {
+		return models.Wishlist{}, err
+	}
+	return r.RetrieveWishlist(id, customerID)
+}
+
+func (r *WishlistRepository) RenameWishlist(id string, customerID string, input types.SaveWishlistInput) (models.Wishlist, error){
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	_, err := r.db.ExecContext(ctx, `UPDATE Wishlist SET Name = ? WHERE ID = ? AND CustomerID = ?`, input.Name, id, customerID)
+	if isDuplicateKeyError(err) {
+		return models.Wishlist{}, constants.ErrWishlistNameTaken
+	}
+	if err !=nil {
+		return models.Wishlist{}, err
+	}
+	// a rename to the same name changes no rows, so whether the wishlist exists is left to the retrieval
+	return r.RetrieveWishlist(id, customerID)
+}
+
+// the items of the wishlist go with it
+func (r *WishlistRepository) DeleteWishlist(id string, customerID string) error{
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	res, err := r.db.ExecContext(ctx, `DELETE FROM Wishlist WHERE ID = ? AND CustomerID = ?`, id, customerID)
+	if err !=nil {
+		return err
+	}
+	count, err := res.RowsAffected()
+	if err !=nil {
+		return err
+	}
+	if count == 0 {
+		return constants.ErrWishlistNotFound
+	}
+	return nil
+}
+
+func (r *WishlistRepository) AddWishlistItem(wishlistID string, customerID string, input types.AddWishlistItemInput) (models.Wishlist, error){
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	if err := r.checkWishlistOwner(ctx, wishlistID, customerID); err !=nil {
+		return models.Wishlist{}, err
+	}
+	price, quantity := 0, 0
+	err := r.db.QueryRowContext(ctx, `SELECT p.Price, p.Quantity FROM Product p WHERE p.ID = ? AND `+productForSaleCondition, input.ProductID).Scan(&price, &quantity)
+	if err == sql.ErrNoRows {
+		return models.Wishlist{}, constants.ErrProductNotFound
+	}
+	if err !=nil {
+		return models.Wishlist{}, err
+	}
+	// alerts are measured from what the product is like now
+	query := `INSERT INTO WishlistItem (ID, WishlistID, ProductID, NotifyWhenInStock, NotifyOnPriceDrop, LastSeenPrice, LastSeenQuantity) VALUES (?, ?, ?, ?, ?, ?, ?)
+	ON DUPLICATE KEY UPDATE NotifyWhenInStock = VALUES(NotifyWhenInStock), NotifyOnPriceDrop = VALUES(NotifyOnPriceDrop), LastSeenPrice = VALUES(LastSeenPrice), LastSeenQuantity = VALUES(LastSeenQuantity)`
+	_, err = r.db.ExecContext(ctx, query, utils.NewID(), wishlistID, input.ProductID, input.NotifyWhenInStock, input.NotifyOnPriceDrop, price, quantity)
+	if err !=nil {
+		return models.Wishlist{}, err
+	}
+	return r.RetrieveWishlist(wishlistID, customerID)
+}
+
+func (r *WishlistRepository) UpdateWishlistItem(wishlistID string, productID string, customerID string, input types.UpdateWishlistItemInput) (models.Wishlist, error){
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	if err := r.checkWishlistOwner(ctx, wishlistID, customerID); err !=nil {
+		return models.Wishlist{}, err
+	}
+	// turning an alert on starts it from what the product is like now, not from when the product was added
+	query := `UPDATE WishlistItem wi JOIN Product p ON p.ID = wi.ProductID
+	SET wi.LastSeenPrice = IF((? AND NOT wi.NotifyWhenInStock) OR (? AND NOT wi.NotifyOnPriceDrop), p.Price, wi.LastSeenPrice),
+	wi.LastSeenQuantity = IF((? AND NOT wi.NotifyWhenInStock) OR (? AND NOT wi.NotifyOnPriceDrop), ` + wishlistProductStock + `, wi.LastSeenQuantity),
+	wi.NotifyWhenInStock = ?, wi.NotifyOnPriceDrop = ?
+	WHERE wi.WishlistID = ? AND wi.ProductID = ?`
+	exists := 0
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM WishlistItem WHERE WishlistID = ? AND ProductID = ?`, wishlistID, productID).Scan(&exists); err !=nil {
+		return models.Wishlist{}, err
+	}
+	if exists == 0 {
+		return models.Wishlist{}, constants.ErrWishlistItemNotFound
+	}
+	_, err := r.db.ExecContext(ctx, query, input.NotifyWhenInStock, input.NotifyOnPriceDrop, input.NotifyWhenInStock, input.NotifyOnPriceDrop, input.NotifyWhenInStock, input.NotifyOnPriceDrop, wishlistID, productID)
+	if err !=nil {
+		return models.Wishlist{}, err
+	}
+	return r.RetrieveWishlist(wishlistID, customerID)
+}
+
+func (r *WishlistRepository) RemoveWishlistItem(wishlistID string, productID string, customerID string) (models.Wishlist, error){
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	if err := r.checkWishlistOwner(ctx, wishlistID, customerID); err !=nil {
+		return models.Wishlist{}, err
+	}
+	res, err := r.db.ExecContext(ctx, `DELETE FROM WishlistItem WHERE WishlistID = ? AND ProductID = ?`, wishlistID, productID)
+	if err !=nil {
+		return models.Wishlist{}, err
+	}
+	count, err := res.RowsAffected()
+	if err !=nil {
+		return models.Wishlist{}, err
+	}
+	if count == 0 {
+		return models.Wishlist{}, constants.ErrWishlistItemNotFound
+	}
+	return r.RetrieveWishlist(wishlistID, customerID)
+}
+
+func (r *WishlistRepository) ShareWishlist(id string, customerID string) (models.Wishlist, error){
+	token, err := utils.GenerateSecureToken()
+	if err !=nil {
+		return models.Wishlist{}, err
+	}
+	return r.updateShareToken(id, customerID, token)
+}
+
+func (r *WishlistRepository) UnshareWishlist(id string, customerID string) (models.Wishlist, error){
+	return r.updateShareToken(id, customerID, "")
+}
+
+// shared wishlists are shown to anyone with the link, so who they belong to is left out
+func (r *WishlistRepository) RetrieveSharedWishlist(shareToken string) (models.Wishlist, error){
+	if shareToken == "" {
+		return models.Wishlist{}, constants.ErrWishlistNotFound
+	}
+	wishlist, err := r.retrieveWishlist(`ShareToken = ?`, shareToken)
+	wishlist.CustomerID = ""
+	wishlist.ShareToken = ""
+	return wishlist, err
+}
+
+func (r *WishlistRepository) RetrieveWishlistAlerts() ([]models.WishlistAlert, error){
+	db := r.db
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	// products that sold out or got dearer are remembered that way, so the next restock or price drop is measured from there
+	_, err := db.ExecContext(ctx, `UPDATE WishlistItem wi JOIN Product p ON p.ID = wi.ProductID
+	SET wi.LastSeenPrice = p.Price, wi.LastSeenQuantity = `+wishlistProductStock+`
+	WHERE (wi.LastSeenPrice != p.Price OR wi.LastSeenQuantity != `+wishlistProductStock+`) AND NOT `+wishlistAlertCondition)
+	if err !=nil {
+		return nil, err
+	}
+	query := `SELECT wi.ID, u.Email, w.Name, p.ID, p.Name, wi.LastSeenQuantity <= 0 AND ` + wishlistProductStock + ` > 0, wi.LastSeenPrice, p.Price, ` + wishlistProductStock + `
+	FROM WishlistItem wi
+	JOIN Wishlist w ON w.ID = wi.WishlistID
+	JOIN Customer c ON c.ID = w.CustomerID
+	JOIN User u ON u.ID = c.UserID
+	JOIN Product p ON p.ID = wi.ProductID
+	WHERE ` + wishlistAlertCondition + `
+	ORDER BY u.Email, wi.ID`
+	alerts := []models.WishlistAlert{}
+	rows, err := db.QueryContext(ctx, query)
+	if err !=nil {
+		return alerts, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		alert := models.WishlistAlert{}
+		if err = rows.Scan(&alert.ItemID, &alert.CustomerEmail, &alert.WishlistName, &alert.ProductID, &alert.ProductName, &alert.Restocked, &alert.PreviousPrice, &alert.Price, &alert.Quantity); err !=nil {
+			return alerts, err
+		}
+		alerts = append(alerts, alert)
+	}
+	return alerts, rows.Err()
+}
+
+func (r *WishlistRepository) MarkWishlistAlertSent(alert models.WishlistAlert) error{
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	_, err := r.db.ExecContext(ctx, `UPDATE WishlistItem SET LastSeenPrice = ?, LastSeenQuantity = ? WHERE ID = ?`, alert.Price, alert.Quantity, alert.ItemID)
+	return err
+}
+
+// private
+func (r *WishlistRepository) retrieveWishlist(where string, args ...interface{}) (models.Wishlist, error){
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	wishlist, err := scanWishlist(r.db.QueryRowContext(ctx, `SELECT `+wishlistColumns+` FROM Wishlist WHERE `+where, args...))
+	if err == sql.ErrNoRows {
+		return wishlist, constants.ErrWishlistNotFound
+	}
+	if err !=nil {
+		return wishlist, err
+	}
+	wishlist.Items, err = r.retrieveWishlistItems(ctx, wishlist.ID)
+	return wishlist, err
+}
+
+func (r *WishlistRepository) retrieveWishlistItems(ctx context.Context, wishlistID string) ([]models.WishlistItem, error){
+	query := `SELECT ` + prefixColumns("p", productColumns) + `, wi.ID, wi.WishlistID, wi.ProductID, wi.NotifyWhenInStock, wi.NotifyOnPriceDrop, wi.LastSeenPrice, wi.LastSeenQuantity, wi.CreatedAt, wi.UpdatedAt
+	FROM WishlistItem wi
+	JOIN Product p ON p.ID = wi.ProductID
+	WHERE wi.WishlistID = ?
+	ORDER BY wi.CreatedAt DESC, wi.ID DESC`
+	items := []models.WishlistItem{}
+	rows, err := r.db.QueryContext(ctx, query, wishlistID)
+	if err !=nil {
+		return items, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		item := models.WishlistItem{}
+		item.Product, err = scanProduct(rows, &item.ID, &item.WishlistID, &item.ProductID, &item.NotifyWhenInStock, &item.NotifyOnPriceDrop, &item.LastSeenPrice, &item.LastSeenQuantity, &item.CreatedAt, &item.UpdatedAt)
+		if err !=nil {
+			return items, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func (r *WishlistRepository) checkWishlistOwner(ctx context.Context, id string, customerID string) error{
+	exists := 0
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM Wishlist WHERE ID = ? AND CustomerID = ?`, id, customerID).Scan(&exists); err !=nil {
+		return err
+	}
+	if exists == 0 {
+		return constants.ErrWishlistNotFound
+	}
+	return nil
+}
+
+func (r *WishlistRepository) updateShareToken(id string, customerID string, token string) (models.Wishlist, error){
+	// create a context as a responsible developer (to handle network error) that does not wish to waste time when something doesb't work
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultContextTimeOut)
+	defer cancel()
+	if err := r.checkWishlistOwner(ctx, id, customerID); err !=nil {
+		return models.Wishlist{}, err
+	}
+	if _, err := r.db.ExecContext(ctx, `UPDATE Wishlist SET ShareToken = NULLIF(?, '') WHERE ID = ? AND CustomerID = ?`, token, id, customerID); err !=nil {
+		return models.Wishlist{}, err
+	}
+	return r.RetrieveWishlist(id, customerID)
+}
+
+type wishlistScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanWishlist(row wishlistScanner, extra ...interface{}) (models.Wishlist, error){
+	wishlist := models.Wishlist{Items: []models.WishlistItem{}}
+	dest := []interface{}{&wishlist.ID, &wishlist.CustomerID, &wishlist.Name, &wishlist.ShareToken, &wishlist.CreatedAt, &wishlist.UpdatedAt}
+	err := row.Scan(append(dest, extra...)...)
+	return wishlist, err
+}
+
+// isDuplicateKeyError reports whether err is mysql refusing a row that breaks a unique index
+func isDuplicateKeyError(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1062
+}
@@ -0,0 +1,32 @@
+package services
+
+import (
+	"testing"
+
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestRetrieveWishlistAlerts(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// the items that aren't due an alert are brought up to date first, so a sell out is remembered
+	mock.ExpectExec("UPDATE WishlistItem wi JOIN Product p").WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectQuery("FROM WishlistItem wi").WillReturnRows(sqlmock.NewRows([]string{"ID", "Email", "Wishlist", "ProductID", "Product", "Restocked", "LastSeenPrice", "Price", "Quantity"}).
+		AddRow("item-1", "ada@example.com", "Birthday", "product-1", "Kettle", true, 500, 500, 4).
+		AddRow("item-2", "ada@example.com", "Kitchen", "product-2", "Mug", false, 120, 100, 9))
+
+	alerts, err := NewWishlistRepository(db).RetrieveWishlistAlerts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(alerts) != 2 || !alerts[0].Restocked || alerts[1].Restocked || alerts[1].PreviousPrice != 120 || alerts[1].Price != 100 {
+		t.Errorf("alerts = %+v, want the kettle restocked and the mug down from 120 to 100", alerts)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,40 @@
+package types
+
+import (
+	"time"
+
+	"github.com/kaasikodes/e-commerce-go/models"
+)
+
+// AccountDataExport is everything held about a user, each field is written to its own file in the export archive
+type AccountDataExport struct {
+	Profile   models.User           `json:"profile"`
+	Addresses []models.Address      `json:"addresses"`
+	Orders    []models.Order        `json:"orders"`
+	Payments  []models.Payment      `json:"payments"`
+	Returns   []models.ReturnRequest `json:"returns"`
+	StoreCredit *models.StoreCredit `json:"storeCredit"`
+	Wishlists []models.Wishlist   `json:"wishlists"`
+	Reviews   []models.ProductReview `json:"reviews"`
+	Questions []models.ProductQuestion `json:"questions"`
+	Products  []models.Product      `json:"products"`
+	SellerOnboarding *models.SellerOnboarding `json:"sellerOnboarding"`
+	Storefront *models.Storefront `json:"storefront"`
+	AuditLogs []models.UserAuditLog `json:"auditLogs"`
+}
+
+// AccountFiles are the urls of the files uploaded for an account, public ones are in the public file storage and private ones in the private storage
+type AccountFiles struct {
+	Public  []string
+	Private []string
+}
+
+type AccountRepository interface {
+	RetrieveAccountData(user models.User) (AccountDataExport, error)
+	ScheduleAccountDeletion(userID string, scheduledFor time.Time) (models.AccountDeletionRequest, error)
+	CancelAccountDeletion(userID string) error
+	RetrieveDueAccountDeletions() ([]models.AccountDeletionRequest, error)
+	// AnonymiseUser removes the personal data of a user, orders, payments and products are kept for the financial records.
+	// The files of the account are returned so they can be removed once the data is gone
+	AnonymiseUser(userID string) (AccountFiles, error)
+}
@@ -0,0 +1,17 @@
+package types
+
+import "github.com/kaasikodes/e-commerce-go/models"
+
+type CreateAPIKeyInput struct {
+	Name          string   `json:"name" validate:"required,min=3,max=50"`
+	Scopes        []string `json:"scopes" validate:"required,min=1,dive,oneof=products:read products:write orders:read"`
+	ExpiresInDays int      `json:"expiresInDays" validate:"omitempty,min=1,max=365"` // keys without an expiry last until they are revoked
+}
+
+type APIKeyRepository interface {
+	CreateAPIKey(userID string, input CreateAPIKeyInput) (models.APIKey, error)
+	RetrieveAPIKeys(userID string) ([]models.APIKey, error)
+	RetrieveAPIKeyByKey(key string) (models.APIKey, error)
+	RevokeAPIKey(userID string, id string) (models.APIKey, error)
+	TouchAPIKey(id string) error
+}
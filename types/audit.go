@@ -0,0 +1,18 @@
+package types
+
+import "github.com/kaasikodes/e-commerce-go/models"
+
+type AddUserAuditLogInput struct {
+	UserID    string
+	Action    string
+	OldValue  string
+	NewValue  string
+	IPAddress string
+	UserAgent string
+}
+
+type UserAuditLogRepository interface {
+	AddUserAuditLog(input AddUserAuditLogInput) (models.UserAuditLog, error)
+	// RetrieveUserAuditLogs returns the logs of a user, newest first
+	RetrieveUserAuditLogs(userID string) ([]models.UserAuditLog, error)
+}
@@ -11,6 +11,12 @@ type CartItemInput struct {
 type SaveCartInput struct {
 	Items []CartItemInput `json:"items" validate:"required"`
 }
+// ReorderOutput is the cart after the items of a past order were added to it, with the items that weren't added as they were ordered
+type ReorderOutput struct {
+	Cart        models.Cart          `json:"cart"`
+	Unavailable []models.ReorderItem `json:"unavailable"` // out of stock, or not enough of it
+	Repriced    []models.ReorderItem `json:"repriced"`    // added, but the price has changed since
+}
 type CartCheckoutInput struct {
 	DeliveryAddress AddressInput `json:"deliveryAddress" validate:"required"`
 }
@@ -21,5 +27,13 @@ type CartRepository interface {
 	RetrieveCart(customerId string) (models.Cart, error)
 	CheckoutCart(customerId string, userEmail string) (models.Order, error)
 	VerifyPayment(reference string) (VerifyPaystackTransactionResponse, error)
+	// Reorder adds what is still available of a past order of the customer to their cart
+	Reorder(orderId string, customerId string) (ReorderOutput, error)
+	RetrieveSavedItems(customerId string) ([]models.SavedItem, error)
+	// SaveForLater moves a product out of the cart into the saved for later list
+	SaveForLater(productId string, customerId string) (models.Cart, error)
+	// MoveToCart moves a saved product back into the cart
+	MoveToCart(productId string, customerId string) (models.Cart, error)
+	DeleteSavedItem(productId string, customerId string) error
 }
 
@@ -0,0 +1,12 @@
+package types
+
+import "github.com/kaasikodes/e-commerce-go/models"
+
+type IdempotencyKeyRepository interface {
+	// ClaimIdempotencyKey saves the key of a request that is about to be handled.
+	// When the key is already in use nothing is saved, and the request that used it is returned with claimed false
+	ClaimIdempotencyKey(key models.IdempotencyKey) (saved models.IdempotencyKey, claimed bool, err error)
+	// CompleteIdempotencyKey saves the response to replay for the key
+	CompleteIdempotencyKey(userID string, key string, status int, contentType string, body []byte) error
+	DeleteExpiredIdempotencyKeys() (int64, error)
+}
@@ -0,0 +1,47 @@
+package types
+
+import "github.com/kaasikodes/e-commerce-go/models"
+
+type OIDCCallbackInput struct {
+	Code  string `json:"code" validate:"required"`
+	State string `json:"state" validate:"required"`
+}
+
+type AddUserIdentityInput struct {
+	UserID   string
+	Provider string
+	Subject  string
+	Email    string
+}
+
+type OIDCTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	IDToken     string `json:"id_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// OIDCClaims are the id token claims used to sign a user in
+type OIDCClaims struct {
+	Subject       string `json:"sub"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Name          string `json:"name"`
+	Picture       string `json:"picture"`
+	Nonce         string `json:"nonce"`
+}
+
+// OIDCClient talks to a single OpenID Connect provider
+type OIDCClient interface {
+	AuthCodeURL(state, nonce, codeChallenge string) (string, error)
+	Exchange(code, codeVerifier string) (OIDCTokenResponse, error)
+	VerifyIDToken(rawIDToken, nonce string) (OIDCClaims, error)
+}
+
+type UserIdentityRepository interface {
+	AddUserIdentity(input AddUserIdentityInput) (models.UserIdentity, error)
+	RetrieveUserIdentity(provider, subject string) (models.UserIdentity, error)
+	CreateOIDCLoginState(state models.OIDCLoginState) error
+	// ConsumeOIDCLoginState returns an unexpired login state and removes it, so that it can only be used once
+	ConsumeOIDCLoginState(state string) (models.OIDCLoginState, error)
+}
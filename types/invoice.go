@@ -0,0 +1,10 @@
+package types
+
+import "github.com/kaasikodes/e-commerce-go/models"
+
+type InvoiceRepository interface {
+	// IssueOrderInvoices issues an invoice for each seller's part of a paid order that doesn't have one yet, and returns all of the order's invoices with their credit notes
+	IssueOrderInvoices(orderID string) ([]models.Invoice, error)
+	// RetrieveSellerInvoice returns the invoice of a seller's sub-order, issuing it if the order has been paid for
+	RetrieveSellerInvoice(subOrderID string, sellerID string) (models.Invoice, error)
+}
@@ -0,0 +1,25 @@
+package types
+
+import (
+	"time"
+
+	"github.com/kaasikodes/e-commerce-go/models"
+)
+
+type SaveCommissionRateInput struct {
+	Scope   string `json:"scope" validate:"required,oneof=category seller global"`
+	ScopeID string `json:"scopeId" validate:"required_unless=Scope global"` // the global rate has none
+	RateBps int    `json:"rateBps" validate:"min=0,max=10000"`
+}
+
+type LedgerRepository interface {
+	RetrieveCommissionRates() ([]models.CommissionRate, error)
+	SaveCommissionRate(input SaveCommissionRateInput) (models.CommissionRate, error)
+	DeleteCommissionRate(scope string, scopeID string) error
+	// PostPaymentSales posts each item of a paid order to its seller's pending balance less commission, items already posted are skipped
+	PostPaymentSales(paymentID string) error
+	// ReleaseHeldFunds moves sales posted before the time from pending to available, returning how many were released
+	ReleaseHeldFunds(postedBefore time.Time) (int, error)
+	RetrieveSellerBalance(sellerID string) (models.SellerBalance, error)
+	RetrieveSellerStatement(sellerID string, from time.Time, to time.Time) (models.SellerStatement, error)
+}
@@ -0,0 +1,43 @@
+package types
+
+import "github.com/kaasikodes/e-commerce-go/models"
+
+type SaveSellerOnboardingInput struct {
+	BusinessName       string `json:"businessName" validate:"required,min=2,max=100"`
+	BusinessType       string `json:"businessType" validate:"required,oneof=individual company"`
+	RegistrationNumber string `json:"registrationNumber" validate:"required_if=BusinessType company,max=50"`
+	BusinessAddress    string `json:"businessAddress" validate:"required,max=255"`
+	PhoneNumber        string `json:"phoneNumber" validate:"required,e164"`
+	BankName           string `json:"bankName" validate:"required,max=100"`
+	BankCode           string `json:"bankCode" validate:"required,numeric,max=10"`
+	BankAccountName    string `json:"bankAccountName" validate:"required,max=100"`
+	BankAccountNumber  string `json:"bankAccountNumber" validate:"required,numeric,len=10"`
+}
+
+type AddSellerDocumentInput struct {
+	Type        string
+	FileUrl     string
+	ContentType string
+}
+
+type ReviewSellerOnboardingInput struct {
+	Status  string `json:"status" validate:"required,oneof=approved rejected"`
+	Comment string `json:"comment" validate:"max=500"`
+}
+
+type RetrieveSellerOnboardingsInput struct {
+	Pagination Pagination
+	Status     string
+}
+
+type SellerOnboardingRepository interface {
+	// RetrieveSellerOnboarding returns a draft with no details for sellers that haven't started onboarding
+	RetrieveSellerOnboarding(sellerID string) (models.SellerOnboarding, error)
+	// SaveSellerOnboarding moves a rejected onboarding back to draft
+	SaveSellerOnboarding(sellerID string, input SaveSellerOnboardingInput) (models.SellerOnboarding, error)
+	AddSellerDocument(sellerID string, input AddSellerDocumentInput) (models.SellerDocument, error)
+	RetrieveSellerDocument(sellerID string, id string) (models.SellerDocument, error)
+	SubmitSellerOnboarding(sellerID string) (models.SellerOnboarding, error)
+	RetrieveSellerOnboardings(input RetrieveSellerOnboardingsInput) (PaginatedDataOutput, error)
+	ReviewSellerOnboarding(sellerID string, reviewerID string, input ReviewSellerOnboardingInput) (models.SellerOnboarding, error)
+}
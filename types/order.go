@@ -1,6 +1,10 @@
 package types
 
-import "github.com/kaasikodes/e-commerce-go/models"
+import (
+	"time"
+
+	"github.com/kaasikodes/e-commerce-go/models"
+)
 
 type RetrievOrdersInput struct {
 	Pagination Pagination
@@ -16,6 +20,8 @@ type PaginatedOrdersDataOutput struct {
 
 type OrderItemInput struct {
 	ProductId string `json:"productId" validate:"required"`
+	SellerId string `json:"sellerId" validate:"required"`
+	UnitPrice int `json:"unitPrice" validate:"min=0"`
 	TotalPrice float64 `json:"totalPrice" validate:"required min=0"`
 	Quantity int `json:"quantity" validate:"required min=1"`
 }
@@ -23,11 +29,39 @@ type OrderItemInput struct {
 type CreateOrderInput struct {
 	TotalAmount float64 `json:"totalAmount" validate:"required min=0"`
 	OrderItems  []OrderItemInput
+	Payment     CreatePaymentInput // saved with the order, so there is never an order without a payment
+}
+type RetrieveSellerOrdersInput struct {
+	Pagination Pagination
+	Status     string
+}
+
+type UpdateSubOrderStatusInput struct {
+	Status string `json:"status" validate:"required,oneof=processing shipped delivered"`
 }
+
+type CancelOrderInput struct {
+	Reason string `json:"reason" validate:"max=255"`
+}
+
+type CancelSubOrderInput struct {
+	Reason string `json:"reason" validate:"required,max=255"`
+}
+
 type OrderRepository interface {
-	CreateOrder(data CreateOrderInput, customerId,addressId string) ( orderId string, error error)
+	// CreateOrder also creates a sub-order for each seller whose products are in the order, and the order's payment
+	CreateOrder(data CreateOrderInput, customerId,addressId string) ( orderId string, orderNumber string, error error)
 	RetrieveOrder(id string) (models.Order, error)
 	RetrieveOrders(input RetrievOrdersInput, customerId string) (PaginatedOrdersDataOutput, error)
-	DeleteOrder(id string) ( error)
+	// CancelOrder cancels the whole order of a customer, giving the stock back and refunding the payment if it was paid
+	CancelOrder(id string, customerId string, input CancelOrderInput) (models.Order, error)
+	// CancelUnpaidOrders cancels orders that have not been paid for since placedBefore
+	CancelUnpaidOrders(placedBefore time.Time) (int, error)
+	// sellers only see the sub-orders of orders that have been paid for
+	RetrieveSellerOrders(input RetrieveSellerOrdersInput, sellerId string) (PaginatedDataOutput, error)
+	RetrieveSellerOrder(id string, sellerId string) (models.SubOrder, error)
+	UpdateSubOrderStatus(id string, sellerId string, input UpdateSubOrderStatusInput) (models.SubOrder, error)
+	// CancelSubOrder cancels the seller's part of an order, refunding what the customer paid for it
+	CancelSubOrder(id string, sellerId string, input CancelSubOrderInput) (models.SubOrder, error)
 	
 }
\ No newline at end of file
@@ -41,6 +41,9 @@ type PaymentRepository interface {
 // retrieve payments
 	CreatePayment(data CreatePaymentInput, orderId string) ( error)
 	UpdatePayment(data UpdatePaymentInput, reference string) ( error)
+	// MarkPaymentPaid only marks an unpaid payment, it reports false when the payment was already paid
+	MarkPaymentPaid(reference string, paidAt time.Time) (bool, error)
+	RetrievePaymentCustomerEmail(reference string) (string, error)
 	RetrievePayment(id string) (models.Payment, error)
 	RetrievePayments(input  RetrievePaymentsInput, customerId string) (PaginatedPaymentsDataOutput, error)
 	
@@ -0,0 +1,39 @@
+package types
+
+import "github.com/kaasikodes/e-commerce-go/models"
+
+type RequestPayoutInput struct {
+	Amount int64 `json:"amount" validate:"required,min=1"` // in kobo
+}
+
+type RetrievePayoutsInput struct {
+	Pagination Pagination
+	Status     string
+}
+
+type CreatePayoutBatchInput struct {
+	Method string `json:"method" validate:"required,oneof=gateway manual"`
+}
+
+type CompletePayoutInput struct {
+	Status           string `json:"status" validate:"required,oneof=paid failed"`
+	GatewayReference string `json:"gatewayReference" validate:"max=100"`
+	FailureReason    string `json:"failureReason" validate:"required_if=Status failed,max=255"`
+}
+
+type PayoutRepository interface {
+	// RequestPayout moves the amount from the seller's available balance until the payout is paid or fails
+	RequestPayout(sellerID string, input RequestPayoutInput) (models.Payout, error)
+	RetrieveSellerPayouts(sellerID string, input RetrievePayoutsInput) (PaginatedDataOutput, error)
+	RetrievePayouts(input RetrievePayoutsInput) (PaginatedDataOutput, error)
+	// CreatePayoutBatch moves every requested payout into a new batch for processing
+	CreatePayoutBatch(createdBy string, input CreatePayoutBatchInput) (models.PayoutBatch, error)
+	RetrievePayoutBatch(id string) (models.PayoutBatch, error)
+	// CompletePayout gives the money back to the seller's available balance when the payout failed
+	CompletePayout(id string, input CompletePayoutInput) (models.Payout, error)
+}
+
+// PayoutGateway sends money to a seller's bank account
+type PayoutGateway interface {
+	Transfer(payout models.Payout) (reference string, err error)
+}
@@ -0,0 +1,24 @@
+package types
+
+import (
+	"time"
+
+	"github.com/kaasikodes/e-commerce-go/models"
+)
+
+type ScheduleSaleInput struct {
+	SalePrice int       `json:"salePrice" validate:"required,min=1"`
+	StartsAt  time.Time `json:"startsAt" validate:"required"` // a time that has passed starts the sale straight away
+	EndsAt    time.Time `json:"endsAt" validate:"required"`
+}
+
+type PriceRepository interface {
+	// RetrievePriceHistory lists the price changes of one of the seller's products, the newest first
+	RetrievePriceHistory(productID string, sellerID string, pagination Pagination) (PaginatedDataOutput, error)
+	RetrieveSales(productID string, sellerID string) ([]models.ProductSale, error)
+	ScheduleSale(productID string, sellerID string, input ScheduleSaleInput) (models.ProductSale, error)
+	// CancelSale calls off a sale that hasn't started, or ends a running one early
+	CancelSale(id string, productID string, sellerID string) (models.ProductSale, error)
+	// ApplyScheduledSales starts and ends the sales whose time has come, returning how many of each
+	ApplyScheduledSales() (int64, int64, error)
+}
@@ -1,16 +1,29 @@
 package types
 
-import "github.com/kaasikodes/e-commerce-go/models"
+import (
+	"time"
+
+	"github.com/kaasikodes/e-commerce-go/models"
+)
 
 type AddProductInput struct {
 	Name string `json:"name" validate:"required,min=3,max=35"`
 	Description string `json:"description" validate:"omitempty,min=3,max=100"`
 	Price int `json:"price" validate:"required"`
 	Quantity int `json:"quantity" validate:"required"`
+	ReorderThreshold int `json:"reorderThreshold" validate:"min=0"`
 	CategoryID string `json:"categoryId" validate:"required"`
+	Status string `json:"status" validate:"omitempty,oneof=draft active"` // only used when adding, products are active unless saved as a draft
 }
 type RetrievProductsInput struct {
 	Pagination Pagination
+	Status string
+}
+
+type UpdateProductStatusInput struct {
+	Status string `json:"status" validate:"required,oneof=draft active archived"`
+	PublishAt *time.Time `json:"publishAt"`
+	UnpublishAt *time.Time `json:"unpublishAt"`
 }
 
 type MultipleProductInput struct {
@@ -23,10 +36,15 @@ type MultipleProductInput struct {
 }
 type ProductRepository interface {
 	AddProduct(input AddProductInput, sellerId string) (models.Product, error)
-	UpdateProduct(id string, input AddProductInput) (models.Product, error)
+	// UpdateProduct records a change of quantity as an adjustment by the seller
+	UpdateProduct(id string, sellerId string, input AddProductInput) (models.Product, error)
 	AddMultipleProducts(input []MultipleProductInput, sellerId string) ([]MultipleProductInput, error)
 	RetrieveProducts(input RetrievProductsInput, sellerId string) (PaginatedDataOutput, error)
 	RetrieveProductByID(id string) (models.Product, error)
-	DeleteProduct(id string) (models.Product, error)
+	// DeleteProduct takes the seller's product off sale for good, it is kept for the orders it is part of
+	DeleteProduct(id string, sellerId string) (models.Product, error)
+	UpdateProductStatus(id string, sellerId string, input UpdateProductStatusInput) (models.Product, error)
+	// PublishScheduledProducts returns how many products were published and unpublished
+	PublishScheduledProducts() (int64, int64, error)
 }
 
@@ -0,0 +1,44 @@
+package types
+
+import "github.com/kaasikodes/e-commerce-go/models"
+
+type AskQuestionInput struct {
+	Question string `json:"question" validate:"required,min=5,max=500"`
+}
+
+type AnswerQuestionInput struct {
+	Answer string `json:"answer" validate:"required,min=1,max=1000"`
+}
+
+type RetrieveQuestionsInput struct {
+	Pagination Pagination
+	ProductID  string
+	SellerID   string
+	// an empty status lists the questions that are shown with the product, with their answers that are shown
+	Status string
+}
+
+type RetrieveAnswersInput struct {
+	Pagination Pagination
+	Status     string
+}
+
+type ModerateQuestionInput struct {
+	Status string `json:"status" validate:"required,oneof=published flagged hidden"`
+	Reason string `json:"reason" validate:"required_if=Status hidden,max=255"`
+}
+
+type QuestionRepository interface {
+	AskQuestion(productID string, customerID string, input AskQuestionInput) (models.ProductQuestion, error)
+	// AnswerQuestion lets the seller of the product or a customer who has bought it answer a question that is shown
+	AnswerQuestion(questionID string, user models.User, input AnswerQuestionInput) (models.ProductAnswer, error)
+	RetrieveQuestion(id string) (models.ProductQuestion, error)
+	// RetrieveQuestions lists questions with the newest first
+	RetrieveQuestions(input RetrieveQuestionsInput) (PaginatedDataOutput, error)
+	RetrieveAnswers(input RetrieveAnswersInput) (PaginatedDataOutput, error)
+	// ReportQuestion and ReportAnswer flag a published question or answer for an admin to look at
+	ReportQuestion(id string) error
+	ReportAnswer(id string) error
+	ModerateQuestion(id string, input ModerateQuestionInput) (models.ProductQuestion, error)
+	ModerateAnswer(id string, input ModerateQuestionInput) (models.ProductAnswer, error)
+}
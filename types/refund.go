@@ -0,0 +1,23 @@
+package types
+
+import "github.com/kaasikodes/e-commerce-go/models"
+
+type RetrieveRefundsInput struct {
+	Pagination Pagination
+	Status     string
+}
+
+type RefundRepository interface {
+	RetrieveRefunds(input RetrieveRefundsInput) (PaginatedDataOutput, error)
+	// RetryRefund puts a failed refund back in line to be sent to the payment gateway
+	RetryRefund(id string) (models.Refund, error)
+	// ClaimPendingRefunds marks pending refunds as processing and returns them, so each is only sent once
+	ClaimPendingRefunds() ([]models.Refund, error)
+	CompleteRefund(id string, gatewayReference string, failureReason string) error
+	// RefundCancelledPayment refunds a payment that came in after its order was cancelled
+	RefundCancelledPayment(paymentID string) error
+}
+
+type RefundGateway interface {
+	Refund(refund models.Refund) (reference string, err error)
+}
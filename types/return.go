@@ -0,0 +1,60 @@
+package types
+
+import "github.com/kaasikodes/e-commerce-go/models"
+
+type CreateReturnInput struct {
+	OrderItemID         string `json:"orderItemId" validate:"required"`
+	Quantity            int    `json:"quantity" validate:"required,min=1"`
+	Reason              string `json:"reason" validate:"required,oneof=damaged defective wrong_item not_as_described no_longer_needed other"`
+	Details             string `json:"details" validate:"required_if=Reason other,max=1000"`
+	PreferredResolution string `json:"preferredResolution" validate:"required,oneof=refund replacement store_credit"`
+}
+
+type AddReturnPhotoInput struct {
+	FileUrl     string
+	ContentType string
+}
+
+type RetrieveReturnsInput struct {
+	Pagination Pagination
+	Status     string
+	CustomerID string
+	SellerID   string
+}
+
+type ApproveReturnInput struct {
+	Instructions string `json:"instructions" validate:"required,max=1000"`
+	LabelUrl     string `json:"labelUrl" validate:"omitempty,url,max=255"`
+}
+
+type RejectReturnInput struct {
+	Reason string `json:"reason" validate:"required,max=255"`
+}
+
+type ReceiveReturnInput struct {
+	Outcome string `json:"outcome" validate:"required,oneof=accepted rejected"`
+	Notes   string `json:"notes" validate:"required_if=Outcome rejected,max=1000"`
+	Restock bool   `json:"restock"` // put the item back into stock, only when it was accepted
+}
+
+type ResolveReturnInput struct {
+	Resolution string `json:"resolution" validate:"required,oneof=refund replacement store_credit"`
+}
+
+type ReturnRepository interface {
+	// CreateReturn opens a return for an item of a delivered order, within the return window
+	CreateReturn(customerID string, input CreateReturnInput) (models.ReturnRequest, error)
+	// AddReturnPhoto adds a photo to a return the customer has opened, until the seller has looked at it
+	AddReturnPhoto(id string, customerID string, input AddReturnPhotoInput) (models.ReturnPhoto, error)
+	RetrieveReturn(id string) (models.ReturnRequest, error)
+	RetrieveReturnPhoto(returnID string, id string) (models.ReturnPhoto, error)
+	// RetrieveReturns lists the returns of a customer or a seller
+	RetrieveReturns(input RetrieveReturnsInput) (PaginatedDataOutput, error)
+	ApproveReturn(id string, sellerID string, input ApproveReturnInput) (models.ReturnRequest, error)
+	RejectReturn(id string, sellerID string, input RejectReturnInput) (models.ReturnRequest, error)
+	// ReceiveReturn records the inspection of a returned item, an item that fails inspection rejects the return
+	ReceiveReturn(id string, sellerID string, input ReceiveReturnInput) (models.ReturnRequest, error)
+	// ResolveReturn refunds the payment, gives store credit or sends a replacement for a received return
+	ResolveReturn(id string, sellerID string, input ResolveReturnInput) (models.ReturnRequest, error)
+	RetrieveStoreCredit(customerID string) (models.StoreCredit, error)
+}
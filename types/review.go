@@ -0,0 +1,44 @@
+package types
+
+import "github.com/kaasikodes/e-commerce-go/models"
+
+type SaveReviewInput struct {
+	Rating int    `json:"rating" validate:"required,min=1,max=5"`
+	Title  string `json:"title" validate:"max=120"`
+	Body   string `json:"body" validate:"max=2000"`
+}
+
+type RetrieveReviewsInput struct {
+	Pagination Pagination
+	Sort       string // newest or helpful, newest when left out
+	ProductID  string
+	SellerID   string
+	CustomerID string
+	// an empty status lists the reviews that are shown on the product page, hidden ones are only listed when asked for
+	Status string
+}
+
+type RespondToReviewInput struct {
+	Response string `json:"response" validate:"required,max=1000"`
+}
+
+type ModerateReviewInput struct {
+	Status string `json:"status" validate:"required,oneof=published flagged hidden"`
+	Reason string `json:"reason" validate:"required_if=Status hidden,max=255"`
+}
+
+type ReviewRepository interface {
+	// CreateReview only lets customers with a paid order of the product review it, once
+	CreateReview(productID string, customerID string, input SaveReviewInput) (models.ProductReview, error)
+	UpdateReview(id string, customerID string, input SaveReviewInput) (models.ProductReview, error)
+	DeleteReview(id string, customerID string) error
+	RetrieveReview(id string) (models.ProductReview, error)
+	RetrieveReviews(input RetrieveReviewsInput) (PaginatedDataOutput, error)
+	// VoteReviewHelpful counts a user's vote once, voting again does nothing
+	VoteReviewHelpful(id string, userID string) (models.ProductReview, error)
+	RemoveReviewHelpfulVote(id string, userID string) (models.ProductReview, error)
+	// ReportReview flags a published review for an admin to look at
+	ReportReview(id string) error
+	RespondToReview(id string, sellerID string, input RespondToReviewInput) (models.ProductReview, error)
+	ModerateReview(id string, input ModerateReviewInput) (models.ProductReview, error)
+}
@@ -0,0 +1,23 @@
+package types
+
+import "github.com/kaasikodes/e-commerce-go/models"
+
+type ShipmentItemInput struct {
+	OrderItemID string `json:"orderItemId" validate:"required"`
+	Quantity    int    `json:"quantity" validate:"required,min=1"`
+}
+
+type CreateShipmentInput struct {
+	Carrier        string              `json:"carrier" validate:"required,max=100"`
+	TrackingNumber string              `json:"trackingNumber" validate:"required,max=100"`
+	TrackingUrl    string              `json:"trackingUrl" validate:"omitempty,url,max=255"`
+	Items          []ShipmentItemInput `json:"items" validate:"required,min=1,dive"`
+}
+
+type ShipmentRepository interface {
+	// CreateShipment ships part or all of what is left of a sub-order, moving the sub-order to processing or shipped
+	CreateShipment(subOrderId string, sellerId string, input CreateShipmentInput) (models.Shipment, error)
+	// MarkShipmentDelivered moves the sub-order to delivered once all of it has been shipped and delivered
+	MarkShipmentDelivered(id string, sellerId string) (models.Shipment, error)
+	RetrieveSubOrderShipments(subOrderId string) ([]models.Shipment, error)
+}
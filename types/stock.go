@@ -0,0 +1,13 @@
+package types
+
+import (
+	"github.com/kaasikodes/e-commerce-go/models"
+)
+
+type StockRepository interface {
+	// RetrieveStockMovements lists the stock movements of one of the seller's products, the newest first
+	RetrieveStockMovements(productID string, sellerID string, pagination Pagination) (PaginatedDataOutput, error)
+	// RetrieveLowStockDigests finds the sellers with products at or below their reorder threshold who haven't had a digest in the last period
+	RetrieveLowStockDigests() ([]models.LowStockDigest, error)
+	MarkLowStockDigestSent(sellerID string) error
+}
@@ -0,0 +1,11 @@
+package types
+
+import "io"
+
+// FileStorage stores uploaded files and returns the public url they can be fetched from
+type FileStorage interface {
+	Save(dir string, name string, content io.Reader) (string, error)
+	Open(url string) (io.ReadCloser, error)
+	// Delete removes a file by its url, urls that the storage didn't hand out are ignored
+	Delete(url string) error
+}
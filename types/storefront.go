@@ -0,0 +1,35 @@
+package types
+
+import "github.com/kaasikodes/e-commerce-go/models"
+
+type SaveStorefrontInput struct {
+	ShopName       string `json:"shopName" validate:"required,min=2,max=60"`
+	// worked out from the shop name when left out
+	Slug           string `json:"slug" validate:"omitempty,max=60"`
+	Description    string `json:"description" validate:"max=1000"`
+	ReturnPolicy   string `json:"returnPolicy" validate:"max=2000"`
+	ShippingPolicy string `json:"shippingPolicy" validate:"max=2000"`
+	ContactEmail   string `json:"contactEmail" validate:"omitempty,email"`
+	ContactPhone   string `json:"contactPhone" validate:"omitempty,e164"`
+}
+
+type RetrieveShopInput struct {
+	Pagination Pagination
+}
+
+// Shop is the public shop page of a seller, products holds a page of the seller's active products
+type Shop struct {
+	Storefront models.Storefront   `json:"storefront"`
+	Rating     models.ShopRating   `json:"rating"`
+	Stats      models.ShopStats    `json:"stats"`
+	Products   PaginatedDataOutput `json:"products"`
+}
+
+type StorefrontRepository interface {
+	RetrieveStorefront(sellerID string) (models.Storefront, error)
+	SaveStorefront(sellerID string, input SaveStorefrontInput) (models.Storefront, error)
+	UpdateStorefrontLogo(sellerID string, logoUrl string) (models.Storefront, error)
+	UpdateStorefrontBanner(sellerID string, bannerUrl string) (models.Storefront, error)
+	// RetrieveShop only finds the shops of approved sellers
+	RetrieveShop(slug string, input RetrieveShopInput) (Shop, error)
+}
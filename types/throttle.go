@@ -0,0 +1,12 @@
+package types
+
+import (
+	"github.com/kaasikodes/e-commerce-go/constants"
+	"github.com/kaasikodes/e-commerce-go/models"
+)
+
+type AuthThrottleRepository interface {
+	RetrieveThrottle(key string) (models.AuthThrottle, error)
+	RecordFailedAttempt(key string, policy constants.AuthThrottlePolicy) (models.AuthThrottle, error)
+	ClearThrottle(key string) error
+}
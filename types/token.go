@@ -11,11 +11,43 @@ type VerifyTokenInput struct {
 	Email     string   `json:"email" validate:"required,email"`
 	Token  string   `json:"token" validate:"required"`
 }
+type MagicLinkInput struct {
+	Email     string   `json:"email" validate:"required,email"`
+}
+type VerifyMagicLinkInput struct {
+	Email     string   `json:"email" validate:"required,email"`
+	Token  string   `json:"token" validate:"required"`
+	DeviceToken  string   `json:"deviceToken" validate:"required"`
+}
+type CreateMagicLinkTokenInput struct {
+	Email string
+	DeviceToken string
+}
+type CreateEmailChangeTokenInput struct {
+	UserID string
+	NewEmail string
+}
+type ConfirmEmailChangeInput struct {
+	Email     string   `json:"email" validate:"required,email"` // the new email
+	Token  string   `json:"token" validate:"required"`
+}
 type TokenRepository interface {
 	CreateVerificationToken(input CreateTokenInput) (models.VerificationToken, error)
 	DeleteVerificationToken(email string) ( error)
 	CreatePasswordResetToken(input CreateTokenInput) (models.PasswordResetToken, error)
 	DeletePasswordResetToken(email string) ( error)
-	RetrieveVerificationToken(email string) (models.VerificationToken, error)
-	RetrievePasswordResetToken(email string) (models.PasswordResetToken, error)
-}
\ No newline at end of file
+	// the consume methods use up the unexpired token of the email that matches the plain token passed in, along with the email's other tokens.
+	// constants.ErrInvalidOrExpiredToken is returned when there is no such token, including when it has just been used
+	ConsumeVerificationToken(email string, token string) ( error)
+	ConsumePasswordResetToken(email string, token string) ( error)
+	CreateMagicLinkToken(input CreateMagicLinkTokenInput) (models.MagicLinkToken, error)
+	// ConsumeMagicLinkToken also requires the device token of the device the link was requested from
+	ConsumeMagicLinkToken(email string, token string, deviceToken string) ( error)
+	DeleteMagicLinkToken(email string) ( error)
+	// CreateEmailChangeToken replaces any pending email change of the user, only the latest one can be confirmed
+	CreateEmailChangeToken(input CreateEmailChangeTokenInput) (models.EmailChangeToken, error)
+	// ConsumeEmailChangeToken uses up the unexpired token for the new email, constants.ErrInvalidOrExpiredToken is returned when there is no such token
+	ConsumeEmailChangeToken(newEmail string, token string) (models.EmailChangeToken, error)
+	DeleteEmailChangeToken(userID string) ( error)
+	DeleteExpiredTokens() (int64, error)
+}
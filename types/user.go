@@ -26,10 +26,12 @@ type UpdateUserPwdInput struct {
 	Password string `validate:"min=6,max=12"`
 }
 
+// the email and image are changed through the email change flow and avatar upload
 type UpdateUserProfileInput struct{
 	Name string `json:"name" validate:"required,min=3,max=35"`
+}
+type ChangeEmailInput struct {
 	Email string `json:"email" validate:"required,email"`
-	Image string `json:"image" validate:"nonempty,url"`
 }
 type RetrievUsersInput struct {
 	Pagination Pagination
@@ -47,12 +49,21 @@ type UserRepository interface {
 	AddUser(input AddUserInput) (models.User, error)
 	UpdateUserPassword(id string, data UpdateUserPwdInput) (models.User, error)
 	VerifyUser(email string) (models.User, error)
+	// ClaimUnverifiedUser verifies an account for the owner of its email, replacing the password it was signed up with and ending its sign ins
+	ClaimUnverifiedUser(id string, password string) (models.User, error)
 	UpdateUserProfile(id string, data UpdateUserProfileInput) (models.User, error)
+	// UpdateUserEmail swaps the email of a user, the new email is marked as verified so it must only be called once the user has proved they own it
+	UpdateUserEmail(id string, email string) (models.User, error)
+	UpdateUserImage(id string, image string) (models.User, error)
+	// AddUserRole gives an existing user the customer or seller role, creating the profile that goes with it
+	AddUserRole(id string, role string) (models.User, error)
 	RetrieveUsers(input RetrievUsersInput) (PaginatedDataOutput, error)
 	RetrieveCustomers(input RetrievUsersInput) (PaginatedDataOutput, error)
 	RetrieveSellers(input RetrievUsersInput) (PaginatedDataOutput, error)
 	RetrieveUserByEmail(email string) (models.User, error)
 	RetrieveUserByID(id string) (models.User, error)
+	RetrieveUserBySellerID(sellerId string) (models.User, error)
+	RetrieveUserByCustomerID(customerId string) (models.User, error)
 	DeleteUser(id string) (models.User, error)
 	AddMultipleUsers(input []MultipleUserInput) ([]MultipleUserInput, error)
 }
\ No newline at end of file
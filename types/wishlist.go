@@ -0,0 +1,38 @@
+package types
+
+import "github.com/kaasikodes/e-commerce-go/models"
+
+type SaveWishlistInput struct {
+	Name string `json:"name" validate:"required,min=1,max=60"`
+}
+
+type AddWishlistItemInput struct {
+	ProductID         string `json:"productId" validate:"required"`
+	NotifyWhenInStock bool   `json:"notifyWhenInStock"`
+	NotifyOnPriceDrop bool   `json:"notifyOnPriceDrop"`
+}
+
+type UpdateWishlistItemInput struct {
+	NotifyWhenInStock bool `json:"notifyWhenInStock"`
+	NotifyOnPriceDrop bool `json:"notifyOnPriceDrop"`
+}
+
+type WishlistRepository interface {
+	RetrieveWishlists(customerID string) ([]models.Wishlist, error)
+	RetrieveWishlist(id string, customerID string) (models.Wishlist, error)
+	CreateWishlist(customerID string, input SaveWishlistInput) (models.Wishlist, error)
+	RenameWishlist(id string, customerID string, input SaveWishlistInput) (models.Wishlist, error)
+	DeleteWishlist(id string, customerID string) error
+	// AddWishlistItem adds a product to the wishlist, adding it again updates its alerts
+	AddWishlistItem(wishlistID string, customerID string, input AddWishlistItemInput) (models.Wishlist, error)
+	UpdateWishlistItem(wishlistID string, productID string, customerID string, input UpdateWishlistItemInput) (models.Wishlist, error)
+	RemoveWishlistItem(wishlistID string, productID string, customerID string) (models.Wishlist, error)
+	// ShareWishlist gives the wishlist a new share token, links with the old token stop working
+	ShareWishlist(id string, customerID string) (models.Wishlist, error)
+	UnshareWishlist(id string, customerID string) (models.Wishlist, error)
+	RetrieveSharedWishlist(shareToken string) (models.Wishlist, error)
+	// RetrieveWishlistAlerts finds the opted in items whose product was restocked or got cheaper, the other items are brought up to date with their product
+	RetrieveWishlistAlerts() ([]models.WishlistAlert, error)
+	// MarkWishlistAlertSent records the price and quantity the customer was told about
+	MarkWishlistAlertSent(alert models.WishlistAlert) error
+}
@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// GenerateSecureToken returns a random, url safe token made up of 32 random bytes
+func GenerateSecureToken() (string, error) {
+	randomBytes := make([]byte, 32)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(randomBytes), nil
+}
+
+// HashToken returns the hex encoded sha256 hash of a token, tokens are random enough that a slow hash isn't needed.
+// Tokens are looked up by this hash in the database, which is safe to do as knowing the hash doesn't help work out the token
+func HashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// CompareTokenHash reports whether token hashes to hash, in constant time.
+// It is for hashes found by something other than the hash itself, like API keys found by their prefix
+func CompareTokenHash(token string, hash string) bool {
+	return subtle.ConstantTimeCompare([]byte(HashToken(token)), []byte(hash)) == 1
+}
+
+// PKCECodeChallenge returns the S256 pkce code challenge of a code verifier
+func PKCECodeChallenge(codeVerifier string) string {
+	hash := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
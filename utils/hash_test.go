@@ -0,0 +1,17 @@
+package utils
+
+import "testing"
+
+func TestHashToken(t *testing.T) {
+	// the sha256 hash of "abc", so a hash made here matches the one stored in the database
+	want := "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"
+	if got := HashToken("abc"); got != want {
+		t.Errorf("HashToken = %v, want %v", got, want)
+	}
+	if !CompareTokenHash("abc", want) {
+		t.Error("the token doesn't match its own hash")
+	}
+	if CompareTokenHash("abd", want) || CompareTokenHash("abc", want[:63]) {
+		t.Error("a different token or hash matched")
+	}
+}
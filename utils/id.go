@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"crypto/rand"
+	"sync"
+	"time"
+)
+
+// crockford's base32, it leaves out I, L, O and U so ids can be read out loud without mistakes
+const idAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+var idGenerator = struct {
+	sync.Mutex
+	lastMs  uint64
+	lastRnd [10]byte
+}{}
+
+// NewID returns a new primary key, a ULID: 48 bits of unix milliseconds followed by 80 random bits, as 26 characters.
+// Ids sort in the order they were made, so cursor pagination on ID returns rows oldest first.
+// Within the same millisecond the random part is incremented rather than drawn again, so ids made by one process never collide
+func NewID() string {
+	idGenerator.Lock()
+	defer idGenerator.Unlock()
+	ms := uint64(time.Now().UnixMilli())
+	if ms <= idGenerator.lastMs {
+		// the clock hasn't moved (or went back), carry on from the last id
+		ms = idGenerator.lastMs
+		for i := len(idGenerator.lastRnd) - 1; i >= 0; i-- {
+			idGenerator.lastRnd[i]++
+			if idGenerator.lastRnd[i] != 0 {
+				break
+			}
+			if i == 0 {
+				// the random part overflowed, move on to the next millisecond
+				ms++
+			}
+		}
+	} else {
+		if _, err := rand.Read(idGenerator.lastRnd[:]); err != nil {
+			panic(err)
+		}
+	}
+	idGenerator.lastMs = ms
+
+	// 128 bits, 2 bits of padding at the front make 26 groups of 5
+	var id [16]byte
+	for i := 0; i < 6; i++ {
+		id[i] = byte(ms >> (40 - 8 * i))
+	}
+	copy(id[6:], idGenerator.lastRnd[:])
+	out := make([]byte, 26)
+	for i := 25; i >= 0; i-- {
+		out[i] = idAlphabet[id[15] & 31]
+		// shift the whole id right by 5 bits
+		for j := 15; j >= 0; j-- {
+			id[j] >>= 5
+			if j > 0 {
+				id[j] |= id[j-1] << 3
+			}
+		}
+	}
+	return string(out)
+}
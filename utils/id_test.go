@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewIDIsOrderedAndUnique(t *testing.T) {
+	seen := map[string]bool{}
+	last := ""
+	for i := 0; i < 10000; i++ {
+		id := NewID()
+		if len(id) != 26 || strings.Trim(id, idAlphabet) != "" {
+			t.Fatalf("id %q is not a ulid", id)
+		}
+		if seen[id] {
+			t.Fatalf("id %q was made twice", id)
+		}
+		if id <= last {
+			t.Fatalf("id %q sorts before %q made earlier", id, last)
+		}
+		seen[id] = true
+		last = id
+	}
+	// the first 10 characters are the time
+	before := NewID()
+	time.Sleep(2 * time.Millisecond)
+	if after := NewID(); after[:10] <= before[:10] {
+		t.Fatalf("time part of %q is not after %q", after, before)
+	}
+}
@@ -1,6 +1,20 @@
 package utils
 
-import "net/smtp"
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"mime/multipart"
+	"net/smtp"
+	"net/textproto"
+)
+
+// MailAttachment is a file sent along with an email
+type MailAttachment struct {
+	Filename    string
+	ContentType string
+	Content     []byte
+}
 
 func SendMail(to []string, subject string, body string) error {
 	err := smtp.SendMail("smtp.mailtrap.io:2525", smtp.PlainAuth("", "6c53d765680ca4", "83175273732073", "smtp.mailtrap.io"), "hello@caasimedia.com", to, []byte("Subject: "+subject+"\r\n\r\n"+body))
@@ -10,4 +24,43 @@ func SendMail(to []string, subject string, body string) error {
 	}
 	
 	return nil
-}
\ No newline at end of file
+}
+
+// SendMailWithAttachments sends the body as plain text with the attachments after it
+func SendMailWithAttachments(to []string, subject string, body string, attachments []MailAttachment) error {
+	message := &bytes.Buffer{}
+	writer := multipart.NewWriter(message)
+	fmt.Fprintf(message, "Subject: %s\r\nMIME-Version: 1.0\r\nContent-Type: multipart/mixed; boundary=%s\r\n\r\n", subject, writer.Boundary())
+	part, err := writer.CreatePart(textproto.MIMEHeader{"Content-Type": {"text/plain; charset=utf-8"}})
+	if err != nil {
+		return err
+	}
+	if _, err = part.Write([]byte(body)); err != nil {
+		return err
+	}
+	for _, attachment := range attachments {
+		part, err = writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {attachment.ContentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {fmt.Sprintf(`attachment; filename="%s"`, attachment.Filename)},
+		})
+		if err != nil {
+			return err
+		}
+		// base64 lines can't be longer than 76 characters
+		encoded := base64.StdEncoding.EncodeToString(attachment.Content)
+		for len(encoded) > 76 {
+			if _, err = part.Write([]byte(encoded[:76] + "\r\n")); err != nil {
+				return err
+			}
+			encoded = encoded[76:]
+		}
+		if _, err = part.Write([]byte(encoded + "\r\n")); err != nil {
+			return err
+		}
+	}
+	if err = writer.Close(); err != nil {
+		return err
+	}
+	return smtp.SendMail("smtp.mailtrap.io:2525", smtp.PlainAuth("", "6c53d765680ca4", "83175273732073", "smtp.mailtrap.io"), "hello@caasimedia.com", to, message.Bytes())
+}
@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+)
+
+// PDF is a minimal writer for text documents on A4 pages, using the standard helvetica fonts so nothing has to be embedded.
+// Positions are in points from the top left corner of the page
+type PDF struct {
+	pages []*bytes.Buffer
+}
+
+const (
+	PDFPageWidth  = 595.28
+	PDFPageHeight = 841.89
+)
+
+func NewPDF() *PDF {
+	return &PDF{}
+}
+
+// AddPage starts a new page, everything drawn after it goes on that page
+func (p *PDF) AddPage() {
+	p.pages = append(p.pages, &bytes.Buffer{})
+}
+
+func (p *PDF) Text(x float64, y float64, size float64, bold bool, text string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(p.page(), "BT /%s %.2f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, PDFPageHeight - y, pdfEscape(text))
+}
+
+func (p *PDF) Line(x1 float64, y1 float64, x2 float64, y2 float64) {
+	fmt.Fprintf(p.page(), "%.2f w %.2f %.2f m %.2f %.2f l S\n", 0.5, x1, PDFPageHeight - y1, x2, PDFPageHeight - y2)
+}
+
+// Bytes writes out the document
+func (p *PDF) Bytes() ([]byte, error) {
+	if len(p.pages) == 0 {
+		p.AddPage()
+	}
+	out := &bytes.Buffer{}
+	offsets := []int{}
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+	out.WriteString("%PDF-1.4\n")
+	// the catalog, page tree and fonts come first, each page is followed by its content stream
+	kids := []string{}
+	for i := range p.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", 5 + i * 2))
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(p.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, page := range p.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>", PDFPageWidth, PDFPageHeight, 6 + i * 2))
+		compressed := &bytes.Buffer{}
+		writer := zlib.NewWriter(compressed)
+		if _, err := writer.Write(page.Bytes()); err != nil {
+			return nil, err
+		}
+		if err := writer.Close(); err != nil {
+			return nil, err
+		}
+		object(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", compressed.Len(), compressed.String()))
+	}
+	xref := out.Len()
+	fmt.Fprintf(out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets) + 1)
+	for _, offset := range offsets {
+		fmt.Fprintf(out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets) + 1, xref)
+	return out.Bytes(), nil
+}
+
+// private
+func (p *PDF) page() *bytes.Buffer {
+	if len(p.pages) == 0 {
+		p.AddPage()
+	}
+	return p.pages[len(p.pages)-1]
+}
+
+// pdfEscape escapes text for a pdf string, characters the standard fonts can't show are replaced with a question mark
+func pdfEscape(text string) string {
+	escaped := strings.Builder{}
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			escaped.WriteByte('\\')
+			escaped.WriteRune(r)
+		case r < 32 || r > 255:
+			escaped.WriteByte('?')
+		case r > 126:
+			fmt.Fprintf(&escaped, "\\%03o", r)
+		default:
+			escaped.WriteRune(r)
+		}
+	}
+	return escaped.String()
+}